@@ -0,0 +1,380 @@
+package gocql
+
+import (
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// dseAuthenticator is the class of the DSE authenticator, which negotiates the SASL mechanism: the client
+// sends the name of the mechanism and the server replies with a challenge of the name followed by -START.
+const dseAuthenticator = "com.datastax.bdp.cassandra.auth.DseAuthenticator"
+
+// negotiateMechanism returns the initial response of a client authenticating with mechanism.
+// If the server negotiates the mechanism, the response is the name of the mechanism and next is
+// called with the challenge starting the mechanism; otherwise next is called right away.
+func negotiateMechanism(class []byte, mechanism string, next func() ([]byte, Authenticator, error)) ([]byte, Authenticator, error) {
+	if string(class) != dseAuthenticator {
+		return next()
+	}
+	return []byte(mechanism), mechanismStart{mechanism: mechanism, next: next}, nil
+}
+
+type mechanismStart struct {
+	mechanism string
+	next      func() ([]byte, Authenticator, error)
+}
+
+func (m mechanismStart) Challenge(req []byte) ([]byte, Authenticator, error) {
+	if string(req) != m.mechanism+"-START" {
+		return nil, nil, fmt.Errorf("gocql: unexpected challenge %q, expected the start of %s authentication", req, m.mechanism)
+	}
+	return m.next()
+}
+
+func (m mechanismStart) Success(data []byte) error {
+	return fmt.Errorf("gocql: authentication succeeded before %s authentication started", m.mechanism)
+}
+
+// PlainAuthenticator authenticates with the SASL PLAIN mechanism. Unlike PasswordAuthenticator it can
+// log in as another role, with the permissions of the role, if the authenticated user is allowed to
+// do so, e.g. with GRANT PROXY.LOGIN in DSE.
+type PlainAuthenticator struct {
+	Username string
+	Password string
+	// AuthorizationID is the role to log in as, empty to log in as Username.
+	AuthorizationID string
+	// Setting this to nil or empty will allow authenticating with any authenticator
+	// provided by the server.
+	AllowedAuthenticators []string
+}
+
+func (p PlainAuthenticator) Challenge(req []byte) ([]byte, Authenticator, error) {
+	if !approve(string(req), p.AllowedAuthenticators) {
+		return nil, nil, fmt.Errorf("unexpected authenticator %q", req)
+	}
+	return negotiateMechanism(req, "PLAIN", func() ([]byte, Authenticator, error) {
+		// authzid NUL authcid NUL passwd, RFC 4616
+		resp := make([]byte, 0, len(p.AuthorizationID)+len(p.Username)+len(p.Password)+2)
+		resp = append(resp, p.AuthorizationID...)
+		resp = append(resp, 0)
+		resp = append(resp, p.Username...)
+		resp = append(resp, 0)
+		resp = append(resp, p.Password...)
+		return resp, nil, nil
+	})
+}
+
+func (p PlainAuthenticator) Success(data []byte) error {
+	return nil
+}
+
+// ScramSHA256Authenticator authenticates with the SASL SCRAM-SHA-256 mechanism, RFC 7677, which doesn't
+// send the password to the server and verifies that the server knows the credentials of the user.
+// Channel binding is not supported.
+type ScramSHA256Authenticator struct {
+	Username string
+	Password string
+	// AuthorizationID is the role to log in as, empty to log in as Username.
+	AuthorizationID string
+	// Setting this to nil or empty will allow authenticating with any authenticator
+	// provided by the server.
+	AllowedAuthenticators []string
+
+	// nonce returns the nonce of the client, tests set it to use known values.
+	nonce func() (string, error)
+}
+
+func (a ScramSHA256Authenticator) Challenge(req []byte) ([]byte, Authenticator, error) {
+	if !approve(string(req), a.AllowedAuthenticators) {
+		return nil, nil, fmt.Errorf("unexpected authenticator %q", req)
+	}
+	return negotiateMechanism(req, "SCRAM-SHA-256", func() ([]byte, Authenticator, error) {
+		nonce := scramNonce
+		if a.nonce != nil {
+			nonce = a.nonce
+		}
+		clientNonce, err := nonce()
+		if err != nil {
+			return nil, nil, err
+		}
+		s := &scramSession{password: a.Password, clientNonce: clientNonce}
+		s.gs2Header = "n,,"
+		if a.AuthorizationID != "" {
+			s.gs2Header = "n,a=" + scramEscape(a.AuthorizationID) + ","
+		}
+		s.clientFirstBare = "n=" + scramEscape(a.Username) + ",r=" + clientNonce
+		return []byte(s.gs2Header + s.clientFirstBare), s, nil
+	})
+}
+
+func (a ScramSHA256Authenticator) Success(data []byte) error {
+	return nil
+}
+
+func scramNonce() (string, error) {
+	b := make([]byte, 18)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("gocql: unable to generate SCRAM nonce: %w", err)
+	}
+	return base64.RawStdEncoding.EncodeToString(b), nil
+}
+
+// scramEscape escapes a SCRAM username, RFC 5802 section 5.1.
+func scramEscape(s string) string {
+	return strings.NewReplacer("=", "=3D", ",", "=2C").Replace(s)
+}
+
+// scramSession is the state of a single SCRAM exchange.
+type scramSession struct {
+	password        string
+	clientNonce     string
+	gs2Header       string
+	clientFirstBare string
+
+	// serverSignature is the signature expected in the final message of the server, nil until the
+	// final message of the client is sent.
+	serverSignature []byte
+	verified        bool
+}
+
+func (s *scramSession) Challenge(req []byte) ([]byte, Authenticator, error) {
+	if s.serverSignature == nil {
+		resp, err := s.clientFinal(string(req))
+		if err != nil {
+			return nil, nil, err
+		}
+		return resp, s, nil
+	}
+	// some servers send the final message as a challenge and succeed with an empty token
+	if err := s.verify(req); err != nil {
+		return nil, nil, err
+	}
+	return nil, s, nil
+}
+
+func (s *scramSession) Success(data []byte) error {
+	if s.serverSignature == nil {
+		return errors.New("gocql: SCRAM authentication succeeded before the client proof was sent")
+	}
+	if len(data) == 0 && s.verified {
+		return nil
+	}
+	return s.verify(data)
+}
+
+// clientFinal returns the final message of the client in response to the first message of the server.
+func (s *scramSession) clientFinal(serverFirst string) ([]byte, error) {
+	attrs, err := scramAttributes(serverFirst)
+	if err != nil {
+		return nil, err
+	}
+	nonce := attrs["r"]
+	if !strings.HasPrefix(nonce, s.clientNonce) || len(nonce) == len(s.clientNonce) {
+		return nil, errors.New("gocql: SCRAM server nonce doesn't extend the client nonce")
+	}
+	salt, err := base64.StdEncoding.DecodeString(attrs["s"])
+	if err != nil || len(salt) == 0 {
+		return nil, fmt.Errorf("gocql: invalid SCRAM salt %q", attrs["s"])
+	}
+	iterations, err := strconv.Atoi(attrs["i"])
+	if err != nil || iterations < 1 {
+		return nil, fmt.Errorf("gocql: invalid SCRAM iteration count %q", attrs["i"])
+	}
+
+	saltedPassword, err := pbkdf2.Key(sha256.New, s.password, salt, iterations, sha256.Size)
+	if err != nil {
+		return nil, fmt.Errorf("gocql: unable to derive SCRAM key: %w", err)
+	}
+	clientKey := scramHMAC(saltedPassword, "Client Key")
+	storedKey := sha256.Sum256(clientKey)
+
+	clientFinalWithoutProof := "c=" + base64.StdEncoding.EncodeToString([]byte(s.gs2Header)) + ",r=" + nonce
+	authMessage := s.clientFirstBare + "," + serverFirst + "," + clientFinalWithoutProof
+
+	proof := scramHMAC(storedKey[:], authMessage)
+	for i := range proof {
+		proof[i] ^= clientKey[i]
+	}
+	s.serverSignature = scramHMAC(scramHMAC(saltedPassword, "Server Key"), authMessage)
+	return []byte(clientFinalWithoutProof + ",p=" + base64.StdEncoding.EncodeToString(proof)), nil
+}
+
+// verify checks the signature in the final message of the server.
+func (s *scramSession) verify(serverFinal []byte) error {
+	attrs, err := scramAttributes(string(serverFinal))
+	if err != nil {
+		return err
+	}
+	signature, err := base64.StdEncoding.DecodeString(attrs["v"])
+	if err != nil || subtle.ConstantTimeCompare(signature, s.serverSignature) != 1 {
+		return errors.New("gocql: invalid SCRAM server signature")
+	}
+	s.verified = true
+	return nil
+}
+
+// scramAttributes parses a SCRAM message of comma separated key=value attributes.
+func scramAttributes(msg string) (map[string]string, error) {
+	attrs := make(map[string]string)
+	for _, attr := range strings.Split(msg, ",") {
+		if len(attr) < 2 || attr[1] != '=' {
+			return nil, fmt.Errorf("gocql: invalid SCRAM message %q", msg)
+		}
+		attrs[attr[:1]] = attr[2:]
+	}
+	if e, ok := attrs["e"]; ok {
+		return nil, fmt.Errorf("gocql: SCRAM authentication failed: %s", e)
+	}
+	return attrs, nil
+}
+
+func scramHMAC(key []byte, msg string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(msg))
+	return mac.Sum(nil)
+}
+
+// GSSAPIContext is a GSS-API security context of the client, provided by a Kerberos library,
+// see GSSAPIAuthenticator.
+type GSSAPIContext interface {
+	// InitSecContext processes the token of the server, nil at the start, and returns the token to send
+	// to the server and whether the context is established.
+	InitSecContext(token []byte) (resp []byte, established bool, err error)
+	// Wrap protects a message with the established context, without encryption.
+	Wrap(msg []byte) ([]byte, error)
+	// Unwrap verifies a message protected by the server and returns its payload.
+	Unwrap(msg []byte) ([]byte, error)
+}
+
+// GSSAPIAuthenticator authenticates with the SASL GSSAPI mechanism, RFC 4752, usually with Kerberos.
+// gocql doesn't implement Kerberos, NewContext creates the security context with a Kerberos library.
+// No security layer is negotiated, use TLS to protect the connections.
+type GSSAPIAuthenticator struct {
+	// NewContext returns a new security context for the service of the cluster,
+	// e.g. for the principal cassandra/<hostname>@REALM. It is called for every connection.
+	NewContext func() (GSSAPIContext, error)
+	// AuthorizationID is the role to log in as, empty to log in as the authenticated principal.
+	AuthorizationID string
+	// Setting this to nil or empty will allow authenticating with any authenticator
+	// provided by the server.
+	AllowedAuthenticators []string
+}
+
+func (a GSSAPIAuthenticator) Challenge(req []byte) ([]byte, Authenticator, error) {
+	if !approve(string(req), a.AllowedAuthenticators) {
+		return nil, nil, fmt.Errorf("unexpected authenticator %q", req)
+	}
+	if a.NewContext == nil {
+		return nil, nil, errors.New("gocql: GSSAPIAuthenticator.NewContext is not set")
+	}
+	return negotiateMechanism(req, "GSSAPI", func() ([]byte, Authenticator, error) {
+		ctx, err := a.NewContext()
+		if err != nil {
+			return nil, nil, err
+		}
+		s := &gssapiSession{ctx: ctx, authzID: a.AuthorizationID}
+		resp, err := s.init(nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		return resp, s, nil
+	})
+}
+
+func (a GSSAPIAuthenticator) Success(data []byte) error {
+	return nil
+}
+
+// gssapiSession is the state of a single GSSAPI exchange.
+type gssapiSession struct {
+	ctx         GSSAPIContext
+	authzID     string
+	established bool
+}
+
+func (s *gssapiSession) init(token []byte) ([]byte, error) {
+	resp, established, err := s.ctx.InitSecContext(token)
+	if err != nil {
+		return nil, fmt.Errorf("gocql: GSSAPI authentication failed: %w", err)
+	}
+	s.established = established
+	return resp, nil
+}
+
+func (s *gssapiSession) Challenge(req []byte) ([]byte, Authenticator, error) {
+	if !s.established {
+		resp, err := s.init(req)
+		if err != nil {
+			return nil, nil, err
+		}
+		return resp, s, nil
+	}
+
+	// security layer negotiation, RFC 4752 section 3.1: the server offers the layers it supports
+	// and the maximum message size, the client selects no security layer
+	offer, err := s.ctx.Unwrap(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("gocql: GSSAPI authentication failed: %w", err)
+	}
+	if len(offer) != 4 {
+		return nil, nil, fmt.Errorf("gocql: invalid GSSAPI security layer offer of %d bytes", len(offer))
+	}
+	if offer[0]&gssapiNoSecurityLayer == 0 {
+		return nil, nil, errors.New("gocql: server requires a GSSAPI security layer, which is not supported")
+	}
+	resp, err := s.ctx.Wrap(append([]byte{gssapiNoSecurityLayer, 0, 0, 0}, s.authzID...))
+	if err != nil {
+		return nil, nil, fmt.Errorf("gocql: GSSAPI authentication failed: %w", err)
+	}
+	return resp, nil, nil
+}
+
+func (s *gssapiSession) Success(data []byte) error {
+	if !s.established {
+		if _, err := s.init(data); err != nil {
+			return err
+		}
+		if !s.established {
+			return errors.New("gocql: authentication succeeded before the GSSAPI context was established")
+		}
+	}
+	return nil
+}
+
+const gssapiNoSecurityLayer = 0x01
+
+// Credentials are the credentials of a user, see CredentialsAuthProvider.
+type Credentials struct {
+	Username string
+	Password string
+	// AuthorizationID is the role to log in as, empty to log in as Username.
+	AuthorizationID string
+}
+
+// CredentialsAuthProvider returns an AuthProvider, see ClusterConfig.AuthProvider, which calls fn for every
+// new connection and authenticates it with the returned credentials using PlainAuthenticator. It allows
+// rotating the credentials, e.g. reading them from a secret store, without recreating the session;
+// established connections are not affected. allowedAuthenticators are the allowed authenticator class
+// names, any authenticator is allowed if none are given.
+func CredentialsAuthProvider(fn func(host *HostInfo) (Credentials, error), allowedAuthenticators ...string) func(h *HostInfo) (Authenticator, error) {
+	return func(h *HostInfo) (Authenticator, error) {
+		creds, err := fn(h)
+		if err != nil {
+			return nil, fmt.Errorf("gocql: unable to get credentials for %s: %w", h.ConnectAddressAndPort(), err)
+		}
+		return PlainAuthenticator{
+			Username:              creds.Username,
+			Password:              creds.Password,
+			AuthorizationID:       creds.AuthorizationID,
+			AllowedAuthenticators: allowedAuthenticators,
+		}, nil
+	}
+}
@@ -0,0 +1,456 @@
+//go:build unit
+// +build unit
+
+package gocql_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+func newAuthTestCluster(t *testing.T, auth *gocqltest.Authenticator) *gocqltest.Cluster {
+	t.Helper()
+	c, err := gocqltest.NewCluster(gocqltest.Config{Authenticator: auth})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(c.Close)
+	return c
+}
+
+func createAuthTestSession(c *gocqltest.Cluster, configure func(cfg *gocql.ClusterConfig)) (*gocql.Session, error) {
+	cfg := c.ClusterConfig()
+	cfg.Timeout = time.Second
+	cfg.ConnectTimeout = time.Second
+	configure(cfg)
+	return cfg.CreateSession()
+}
+
+func TestPasswordHandshake(t *testing.T) {
+	c := newAuthTestCluster(t, gocqltest.PasswordAuthenticator(map[string]string{"alice": "secret"}))
+
+	session, err := createAuthTestSession(c, func(cfg *gocql.ClusterConfig) {
+		cfg.Authenticator = gocql.PlainAuthenticator{Username: "alice", Password: "secret"}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+	if err := session.Query(`SELECT v FROM ks.t`).Exec(); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = createAuthTestSession(c, func(cfg *gocql.ClusterConfig) {
+		cfg.Authenticator = gocql.PlainAuthenticator{Username: "alice", Password: "wrong"}
+	})
+	if err == nil || !strings.Contains(err.Error(), "password are incorrect") {
+		t.Fatalf("expected an authentication error, got %v", err)
+	}
+}
+
+func TestProxyLoginHandshake(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		logins []string
+	)
+	c := newAuthTestCluster(t, &gocqltest.Authenticator{
+		Class: "com.datastax.bdp.cassandra.auth.DseAuthenticator",
+		NewExchange: func() gocqltest.AuthExchange {
+			var started bool
+			return func(token []byte) ([]byte, bool, error) {
+				if !started {
+					if string(token) != "PLAIN" {
+						return nil, false, errors.New("unsupported mechanism " + string(token))
+					}
+					started = true
+					return []byte("PLAIN-START"), false, nil
+				}
+				authzID, user, password, err := gocqltest.ParsePlain(token)
+				if err != nil {
+					return nil, false, err
+				}
+				if user != "alice" || password != "secret" {
+					return nil, false, errors.New("bad credentials")
+				}
+				mu.Lock()
+				logins = append(logins, authzID)
+				mu.Unlock()
+				return nil, true, nil
+			}
+		},
+	})
+
+	session, err := createAuthTestSession(c, func(cfg *gocql.ClusterConfig) {
+		cfg.Authenticator = gocql.PlainAuthenticator{Username: "alice", Password: "secret", AuthorizationID: "bob"}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(logins) == 0 {
+		t.Fatal("expected the connections to be authenticated")
+	}
+	for _, authzID := range logins {
+		if authzID != "bob" {
+			t.Fatalf("expected to log in as bob, got %q", authzID)
+		}
+	}
+}
+
+// scramExchange is the server side of SCRAM-SHA-256 for a user with the password.
+func scramExchange(user, password string) gocqltest.AuthExchange {
+	const iterations = 4096
+	salt := []byte("gocqltest-salt")
+	var clientFirstBare, serverFirst, nonce string
+	return func(token []byte) ([]byte, bool, error) {
+		msg := string(token)
+		if serverFirst == "" {
+			if !strings.HasPrefix(msg, "n,,") {
+				return nil, false, errors.New("unexpected gs2 header")
+			}
+			clientFirstBare = strings.TrimPrefix(msg, "n,,")
+			attrs := strings.Split(clientFirstBare, ",")
+			if len(attrs) != 2 || attrs[0] != "n="+user || !strings.HasPrefix(attrs[1], "r=") {
+				return nil, false, errors.New("unexpected client-first message " + msg)
+			}
+			nonce = strings.TrimPrefix(attrs[1], "r=") + "server-nonce"
+			serverFirst = "r=" + nonce + ",s=" + base64.StdEncoding.EncodeToString(salt) + ",i=4096"
+			return []byte(serverFirst), false, nil
+		}
+
+		i := strings.LastIndex(msg, ",p=")
+		if i < 0 || msg[:i] != "c=biws,r="+nonce {
+			return nil, false, errors.New("unexpected client-final message " + msg)
+		}
+		proof, err := base64.StdEncoding.DecodeString(msg[i+3:])
+		if err != nil || len(proof) != sha256.Size {
+			return nil, false, errors.New("invalid proof")
+		}
+		authMessage := clientFirstBare + "," + serverFirst + "," + msg[:i]
+		mac := func(key []byte, s string) []byte {
+			h := hmac.New(sha256.New, key)
+			h.Write([]byte(s))
+			return h.Sum(nil)
+		}
+		salted, err := pbkdf2.Key(sha256.New, password, salt, iterations, sha256.Size)
+		if err != nil {
+			return nil, false, err
+		}
+		storedKey := sha256.Sum256(mac(salted, "Client Key"))
+		signature := mac(storedKey[:], authMessage)
+		for j := range signature {
+			signature[j] ^= proof[j]
+		}
+		if clientKey := sha256.Sum256(signature); !hmac.Equal(clientKey[:], storedKey[:]) {
+			return nil, false, errors.New("invalid proof")
+		}
+		return []byte("v=" + base64.StdEncoding.EncodeToString(mac(mac(salted, "Server Key"), authMessage))), true, nil
+	}
+}
+
+func TestScramSHA256Handshake(t *testing.T) {
+	c := newAuthTestCluster(t, &gocqltest.Authenticator{
+		NewExchange: func() gocqltest.AuthExchange { return scramExchange("alice", "secret") },
+	})
+
+	session, err := createAuthTestSession(c, func(cfg *gocql.ClusterConfig) {
+		cfg.Authenticator = gocql.ScramSHA256Authenticator{Username: "alice", Password: "secret"}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+	if err := session.Query(`SELECT v FROM ks.t`).Exec(); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = createAuthTestSession(c, func(cfg *gocql.ClusterConfig) {
+		cfg.Authenticator = gocql.ScramSHA256Authenticator{Username: "alice", Password: "wrong"}
+	})
+	if err == nil || !strings.Contains(err.Error(), "invalid proof") {
+		t.Fatalf("expected an authentication error, got %v", err)
+	}
+}
+
+func TestCredentialsAuthProvider(t *testing.T) {
+	var (
+		mu            sync.Mutex
+		password      = "first"
+		calls         int
+		authenticated int
+		rotatedLogins int
+	)
+	c := newAuthTestCluster(t, &gocqltest.Authenticator{
+		NewExchange: func() gocqltest.AuthExchange {
+			return func(token []byte) ([]byte, bool, error) {
+				_, user, got, err := gocqltest.ParsePlain(token)
+				if err != nil {
+					return nil, false, err
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				if user != "alice" || got != password {
+					return nil, false, errors.New("bad credentials")
+				}
+				authenticated++
+				if password == "second" {
+					rotatedLogins++
+				}
+				return nil, true, nil
+			}
+		},
+	})
+
+	session, err := createAuthTestSession(c, func(cfg *gocql.ClusterConfig) {
+		cfg.AuthProvider = gocql.CredentialsAuthProvider(func(host *gocql.HostInfo) (gocql.Credentials, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			calls++
+			return gocql.Credentials{Username: "alice", Password: password}, nil
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	mu.Lock()
+	fetched, connections := calls, authenticated
+	// rotate the password, the new connections use the new one
+	password = "second"
+	mu.Unlock()
+	if fetched == 0 || fetched != connections {
+		t.Fatalf("expected the credentials to be fetched for every connection, got %d calls for %d connections", fetched, connections)
+	}
+
+	for _, node := range c.Nodes() {
+		node.DropConnections()
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		rotated := rotatedLogins
+		mu.Unlock()
+		if rotated > 0 && session.Query(`SELECT v FROM ks.t`).Exec() == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the session didn't reconnect with the rotated credentials")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRotateCredentials(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		passwords = map[string]bool{"first": true}
+		current   = "first"
+		logins    = map[string]int{}
+	)
+	c := newAuthTestCluster(t, &gocqltest.Authenticator{
+		NewExchange: func() gocqltest.AuthExchange {
+			return func(token []byte) ([]byte, bool, error) {
+				_, _, password, err := gocqltest.ParsePlain(token)
+				if err != nil {
+					return nil, false, err
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				if !passwords[password] {
+					return nil, false, errors.New("bad credentials")
+				}
+				logins[password]++
+				return nil, true, nil
+			}
+		},
+	})
+
+	session, err := createAuthTestSession(c, func(cfg *gocql.ClusterConfig) {
+		cfg.NumConns = 2
+		cfg.ReconnectionPolicy = &gocql.ConstantReconnectionPolicy{MaxRetries: 1, Interval: time.Millisecond}
+		cfg.PoolConfig.RateLimiter = &gocql.RateLimiterConfig{MaxConcurrency: 10}
+		cfg.AuthProvider = gocql.CredentialsAuthProvider(func(host *gocql.HostInfo) (gocql.Credentials, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			return gocql.Credentials{Username: "alice", Password: current}, nil
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	admitted := func() int64 {
+		t.Helper()
+		var n int64
+		session.IterateHostPools(func(info gocql.HostPoolInfo) bool {
+			for _, state := range info.(gocql.HostPoolRateLimiterInfo).RateLimiterState() {
+				n += state.Admitted
+			}
+			return true
+		})
+		return n
+	}
+
+	node := c.Nodes()[0]
+	waitConnections := func(expected int) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for node.Connections() != expected {
+			if time.Now().After(deadline) {
+				t.Fatalf("expected %d connections, got %d", expected, node.Connections())
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	// the control connection and the pool
+	waitConnections(3)
+
+	if err := session.Query(`SELECT v FROM ks.t`).Exec(); err != nil {
+		t.Fatal(err)
+	}
+	before := admitted()
+
+	// the server accepts both passwords while the clients are rotated
+	mu.Lock()
+	passwords["second"] = true
+	current = "second"
+	mu.Unlock()
+
+	if err := session.RotateCredentials(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	waitConnections(3)
+	mu.Lock()
+	rotated := logins["second"]
+	mu.Unlock()
+	if rotated != 3 {
+		t.Fatalf("expected all connections to log in with the rotated password, got %d logins", rotated)
+	}
+	if err := session.Query(`SELECT v FROM ks.t`).Exec(); err != nil {
+		t.Fatal(err)
+	}
+	// the rate limiter of the host is carried over to the new pool
+	if after := admitted(); before == 0 || after != before+1 {
+		t.Fatalf("expected the rate limiter state to be kept, admitted %d requests before rotation and %d after", before, after)
+	}
+
+	// the old connections are kept if the new credentials are rejected
+	mu.Lock()
+	current = "wrong"
+	mu.Unlock()
+	if err := session.RotateCredentials(context.Background()); err == nil {
+		t.Fatal("expected an error for rejected credentials")
+	}
+	if err := session.Query(`SELECT v FROM ks.t`).Exec(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRotateCredentialsHostFailure(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		current = "first"
+		// rejected is the address of the host which is given the wrong password
+		rejected string
+		logins   = map[string]int{}
+	)
+	c, err := gocqltest.NewCluster(gocqltest.Config{
+		Nodes: make([]gocqltest.NodeConfig, 3),
+		Authenticator: &gocqltest.Authenticator{
+			NewExchange: func() gocqltest.AuthExchange {
+				return func(token []byte) ([]byte, bool, error) {
+					_, _, password, err := gocqltest.ParsePlain(token)
+					if err != nil {
+						return nil, false, err
+					}
+					if password != "first" && password != "second" {
+						return nil, false, errors.New("bad credentials")
+					}
+					mu.Lock()
+					logins[password]++
+					mu.Unlock()
+					return nil, true, nil
+				}
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	session, err := createAuthTestSession(c, func(cfg *gocql.ClusterConfig) {
+		cfg.NumConns = 1
+		cfg.ReconnectionPolicy = &gocql.ConstantReconnectionPolicy{MaxRetries: 1, Interval: time.Millisecond}
+		cfg.AuthProvider = gocql.CredentialsAuthProvider(func(host *gocql.HostInfo) (gocql.Credentials, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if host.ConnectAddressAndPort() == rejected {
+				return gocql.Credentials{Username: "alice", Password: "wrong"}, nil
+			}
+			return gocql.Credentials{Username: "alice", Password: current}, nil
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	deadline := time.Now().Add(5 * time.Second)
+	connections := func() (total int) {
+		for _, node := range c.Nodes() {
+			total += node.Connections()
+		}
+		return total
+	}
+	// a connection per host and the control connection
+	for connections() != 4 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected 4 connections, got %d", connections())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// the first host fails to rotate, the others are still rotated
+	nodes := c.Nodes()
+	mu.Lock()
+	current = "second"
+	rejected = nodes[0].Address()
+	mu.Unlock()
+
+	err = session.RotateCredentials(context.Background())
+	if err == nil || !strings.Contains(err.Error(), nodes[0].Address()) {
+		t.Fatalf("expected an error naming %s, got %v", nodes[0].Address(), err)
+	}
+	for _, node := range nodes[1:] {
+		if strings.Contains(err.Error(), node.Address()) {
+			t.Fatalf("unexpected error of %s: %v", node.Address(), err)
+		}
+	}
+	mu.Lock()
+	rotated := logins["second"]
+	mu.Unlock()
+	// the pools of the two other hosts and the control connection, unless it's connected to the rejected host
+	if rotated < 2 {
+		t.Fatalf("expected the other hosts to be rotated, got %d logins with the rotated password", rotated)
+	}
+	if err := session.Query(`SELECT v FROM ks.t`).Exec(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,167 @@
+//go:build unit
+// +build unit
+
+package gocql
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestPlainAuthenticator(t *testing.T) {
+	auth := PlainAuthenticator{Username: "alice", Password: "secret", AuthorizationID: "bob"}
+
+	resp, next, err := auth.Challenge([]byte("org.apache.cassandra.auth.PasswordAuthenticator"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(resp) != "bob\x00alice\x00secret" || next != nil {
+		t.Fatalf("unexpected response %q", resp)
+	}
+
+	// DSE negotiates the mechanism first
+	resp, next, err = auth.Challenge([]byte(dseAuthenticator))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(resp) != "PLAIN" {
+		t.Fatalf("expected the mechanism, got %q", resp)
+	}
+	if _, _, err := next.Challenge([]byte("GSSAPI-START")); err == nil {
+		t.Fatal("expected an error for the start of another mechanism")
+	}
+	resp, _, err = next.Challenge([]byte("PLAIN-START"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(resp) != "bob\x00alice\x00secret" {
+		t.Fatalf("unexpected response %q", resp)
+	}
+
+	auth.AllowedAuthenticators = []string{dseAuthenticator}
+	if _, _, err := auth.Challenge([]byte("org.apache.cassandra.auth.PasswordAuthenticator")); err == nil {
+		t.Fatal("expected an error for an authenticator which is not allowed")
+	}
+}
+
+func TestScramSHA256Authenticator(t *testing.T) {
+	// the example of RFC 7677 section 3
+	auth := ScramSHA256Authenticator{
+		Username: "user",
+		Password: "pencil",
+		nonce:    func() (string, error) { return "rOprNGfwEbeRWgbNEkqO", nil },
+	}
+	resp, next, err := auth.Challenge([]byte("org.apache.cassandra.auth.PasswordAuthenticator"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(resp) != "n,,n=user,r=rOprNGfwEbeRWgbNEkqO" {
+		t.Fatalf("unexpected client-first message %q", resp)
+	}
+
+	resp, next, err = next.Challenge([]byte("r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "c=biws,r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,p=dHzbZapWIk4jUhN+Ute9ytag9zjfMHgsqmmiz7AndVQ="; string(resp) != expected {
+		t.Fatalf("expected client-final message %q, got %q", expected, resp)
+	}
+
+	if err := next.Success([]byte("v=AAAA")); err == nil {
+		t.Fatal("expected an error for a wrong server signature")
+	}
+	if err := next.Success([]byte("v=6rriTRBi23WpRR/wtup+mMhUZUn/dB5nLTJRsjl95G4=")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScramSHA256AuthenticatorErrors(t *testing.T) {
+	auth := ScramSHA256Authenticator{
+		Username:        "us,er",
+		Password:        "pencil",
+		AuthorizationID: "ro=le",
+		nonce:           func() (string, error) { return "abc", nil },
+	}
+	resp, next, err := auth.Challenge([]byte("org.apache.cassandra.auth.PasswordAuthenticator"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(resp) != "n,a=ro=3Dle,n=us=2Cer,r=abc" {
+		t.Fatalf("unexpected client-first message %q", resp)
+	}
+
+	for _, serverFirst := range []string{
+		"r=abc,s=c2FsdA==,i=4096",    // the nonce of the server is missing
+		"r=xyzdef,s=c2FsdA==,i=4096", // the nonce doesn't start with the client nonce
+		"r=abcdef,s=c2FsdA==,i=0",    // invalid iteration count
+		"r=abcdef,s=!,i=4096",        // invalid salt
+		"e=unknown-user",             // error of the server
+		"garbage",
+	} {
+		if _, _, err := next.Challenge([]byte(serverFirst)); err == nil {
+			t.Errorf("expected an error for %q", serverFirst)
+		}
+	}
+}
+
+type fakeGSSAPIContext struct {
+	steps   int
+	tokens  [][]byte
+	wrapped []byte
+}
+
+func (c *fakeGSSAPIContext) InitSecContext(token []byte) ([]byte, bool, error) {
+	c.tokens = append(c.tokens, token)
+	c.steps--
+	return []byte("token"), c.steps == 0, nil
+}
+
+func (c *fakeGSSAPIContext) Wrap(msg []byte) ([]byte, error) {
+	c.wrapped = msg
+	return append([]byte("wrapped:"), msg...), nil
+}
+
+func (c *fakeGSSAPIContext) Unwrap(msg []byte) ([]byte, error) {
+	if !bytes.HasPrefix(msg, []byte("wrapped:")) {
+		return nil, errors.New("invalid token")
+	}
+	return msg[len("wrapped:"):], nil
+}
+
+func TestGSSAPIAuthenticator(t *testing.T) {
+	ctx := &fakeGSSAPIContext{steps: 2}
+	auth := GSSAPIAuthenticator{
+		NewContext:      func() (GSSAPIContext, error) { return ctx, nil },
+		AuthorizationID: "bob",
+	}
+
+	resp, next, err := auth.Challenge([]byte(dseAuthenticator))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(resp) != "GSSAPI" {
+		t.Fatalf("expected the mechanism, got %q", resp)
+	}
+	if resp, next, err = next.Challenge([]byte("GSSAPI-START")); err != nil || string(resp) != "token" {
+		t.Fatalf("unexpected initial token %q: %v", resp, err)
+	}
+	if resp, next, err = next.Challenge([]byte("server token")); err != nil || string(resp) != "token" {
+		t.Fatalf("unexpected token %q: %v", resp, err)
+	}
+	if len(ctx.tokens) != 2 || ctx.tokens[0] != nil || string(ctx.tokens[1]) != "server token" {
+		t.Fatalf("unexpected server tokens %q", ctx.tokens)
+	}
+
+	// the context is established, the server offers no security layer and 64KiB messages
+	if _, _, err := next.Challenge([]byte("wrapped:\x02\x01\x00\x00")); err == nil {
+		t.Fatal("expected an error for a required security layer")
+	}
+	resp, next, err = next.Challenge([]byte("wrapped:\x01\x01\x00\x00"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(ctx.wrapped) != "\x01\x00\x00\x00bob" || string(resp) != "wrapped:\x01\x00\x00\x00bob" || next != nil {
+		t.Fatalf("unexpected security layer selection %q", resp)
+	}
+}
@@ -0,0 +1,137 @@
+package gocql
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// namedValues provides the values of bound variables by name.
+type namedValues interface {
+	value(name string) (interface{}, bool)
+}
+
+type structValues struct {
+	v      reflect.Value
+	fields *cqlFields
+}
+
+func (s structValues) value(name string) (interface{}, bool) {
+	f, ok := s.fields.lookup(name)
+	if !ok {
+		return nil, false
+	}
+
+	v := s.v.FieldByIndex(f.index)
+	if f.omitEmpty && v.IsZero() {
+		return UnsetValue, true
+	}
+	return v.Interface(), true
+}
+
+type mapValues map[string]interface{}
+
+func (m mapValues) value(name string) (interface{}, bool) {
+	v, ok := m[name]
+	return v, ok
+}
+
+// BindStruct binds the fields of the struct v, or a pointer to it, to the bound variables of the
+// query by name. The variables are resolved using the metadata of the prepared statement, so
+// the query has to be one which gets prepared (SELECT, INSERT, UPDATE, DELETE or BATCH).
+//
+// Fields are mapped to variables the same way as columns are mapped to fields by ScanAll.
+// Fields tagged with omitempty, e.g. `cql:"name,omitempty"`, are bound as UnsetValue when they
+// hold the zero value of their type, which requires protocol v4 or later. An error is returned
+// when the query is executed if a variable has no field.
+//
+//	type User struct {
+//		ID    gocql.UUID `cql:"id"`
+//		Name  string
+//		Email string `cql:"email_address,omitempty"`
+//	}
+//
+//	err := session.Query(`INSERT INTO users (id, name, email_address) VALUES (?, ?, ?)`).BindStruct(user).Exec()
+//
+// Named markers can be used as well, in which case the variables are named after the markers:
+//
+//	err := session.Query(`UPDATE users SET name = :name WHERE id = :id`).BindStruct(user).Exec()
+//
+// Binding values by name replaces values bound with Bind and vice versa.
+func (q *Query) BindStruct(v interface{}) *Query {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		q.bindNamed(nil)
+		q.binding = func(*QueryInfo) ([]interface{}, error) {
+			return nil, fmt.Errorf("gocql: BindStruct requires a struct or a pointer to a struct, got %T", v)
+		}
+		return q
+	}
+
+	return q.bindNamed(structValues{v: rv, fields: structFields(rv.Type())})
+}
+
+// BindMap binds the values of m to the bound variables of the query by name, see BindStruct.
+// Variables with no value in m result in an error when the query is executed, use UnsetValue
+// to leave a variable unset.
+func (q *Query) BindMap(m map[string]interface{}) *Query {
+	return q.bindNamed(mapValues(m))
+}
+
+func (q *Query) bindNamed(values namedValues) *Query {
+	q.values = nil
+	q.pageState = nil
+	q.namedValues = values
+	if values != nil {
+		q.binding = func(info *QueryInfo) ([]interface{}, error) {
+			return bindNamedValues(values, info.Args)
+		}
+	}
+	return q
+}
+
+// bindNamedValues returns the values of the bound variables args.
+func bindNamedValues(values namedValues, args []ColumnInfo) ([]interface{}, error) {
+	bound := make([]interface{}, len(args))
+	var missing []string
+	for i, arg := range args {
+		v, ok := values.value(arg.Name)
+		if !ok {
+			missing = append(missing, strconv.Quote(arg.Name))
+			continue
+		}
+		bound[i] = v
+	}
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("gocql: no values bound for variables %s", strings.Join(missing, ", "))
+	}
+	return bound, nil
+}
+
+// createNamedRoutingKey creates the routing key from values bound by name.
+func createNamedRoutingKey(codecs *CodecRegistry, routingKeyInfo *routingKeyInfo, values namedValues) ([]byte, error) {
+	if routingKeyInfo == nil {
+		return nil, nil
+	}
+
+	var n int
+	for _, i := range routingKeyInfo.indexes {
+		n = max(n, i+1)
+	}
+
+	bound := make([]interface{}, n)
+	for i, name := range routingKeyInfo.names {
+		v, ok := values.value(name)
+		if !ok {
+			// the error is reported once the query is executed
+			return nil, nil
+		}
+		bound[routingKeyInfo.indexes[i]] = v
+	}
+	return createRoutingKey(codecs, routingKeyInfo, bound)
+}
@@ -0,0 +1,126 @@
+//go:build unit
+// +build unit
+
+package gocql
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type bindTestKey struct {
+	ID     int    `cql:"id"`
+	Bucket string `cql:"bucket"`
+}
+
+type bindTestUser struct {
+	bindTestKey
+	Name    string
+	Email   string `cql:"email_address,omitempty"`
+	Ignored string `cql:"-"`
+}
+
+func bindTestArgs(names ...string) []ColumnInfo {
+	args := make([]ColumnInfo, len(names))
+	for i, name := range names {
+		args[i] = ColumnInfo{Name: name, TypeInfo: NativeType{proto: protoVersion4, typ: TypeVarchar}}
+	}
+	return args
+}
+
+func TestQueryBindStruct(t *testing.T) {
+	t.Parallel()
+
+	user := bindTestUser{bindTestKey: bindTestKey{ID: 1, Bucket: "b"}, Name: "alice", Ignored: "x"}
+	q := (&Query{}).BindStruct(&user)
+
+	values, err := q.binding(&QueryInfo{Args: bindTestArgs("name", "email_address", "id", "bucket")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []interface{}{"alice", UnsetValue, 1, "b"}
+	if !reflect.DeepEqual(values, expected) {
+		t.Fatalf("expected %v got %v", expected, values)
+	}
+
+	user.Email = "alice@example.com"
+	values, err = (&Query{}).BindStruct(user).binding(&QueryInfo{Args: bindTestArgs("email_address")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values[0] != "alice@example.com" {
+		t.Fatalf("expected the email to be bound, got %v", values[0])
+	}
+}
+
+func TestQueryBindMissingNames(t *testing.T) {
+	t.Parallel()
+
+	q := (&Query{}).BindStruct(bindTestUser{})
+	_, err := q.binding(&QueryInfo{Args: bindTestArgs("id", "ignored", "[limit]")})
+	if err == nil || !strings.Contains(err.Error(), `"ignored", "[limit]"`) {
+		t.Fatalf("expected an error naming the missing variables, got %v", err)
+	}
+
+	q = (&Query{}).BindMap(map[string]interface{}{"id": 1})
+	if _, err := q.binding(&QueryInfo{Args: bindTestArgs("id", "name")}); err == nil || !strings.Contains(err.Error(), `"name"`) {
+		t.Fatalf("expected an error naming the missing variable, got %v", err)
+	}
+
+	q = (&Query{}).BindStruct(42)
+	if _, err := q.binding(&QueryInfo{}); err == nil {
+		t.Fatal("expected an error binding a non struct value")
+	}
+}
+
+func TestQueryBindMap(t *testing.T) {
+	t.Parallel()
+
+	q := (&Query{}).BindMap(map[string]interface{}{"id": 1, "name": nil, "email_address": UnsetValue})
+	values, err := q.binding(&QueryInfo{Args: bindTestArgs("email_address", "id", "name")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []interface{}{UnsetValue, 1, nil}
+	if !reflect.DeepEqual(values, expected) {
+		t.Fatalf("expected %v got %v", expected, values)
+	}
+
+	// positional values replace the named ones
+	q.Bind(2)
+	if q.binding != nil || q.namedValues != nil || !reflect.DeepEqual(q.values, []interface{}{2}) {
+		t.Fatal("expected Bind to replace the values bound by name")
+	}
+}
+
+func TestCreateNamedRoutingKey(t *testing.T) {
+	t.Parallel()
+
+	info := &routingKeyInfo{
+		indexes: []int{2, 0},
+		types: []TypeInfo{
+			NativeType{proto: protoVersion4, typ: TypeInt},
+			NativeType{proto: protoVersion4, typ: TypeVarchar},
+		},
+		names: []string{"id", "bucket"},
+	}
+
+	user := bindTestUser{bindTestKey: bindTestKey{ID: 1, Bucket: "b"}}
+	key, err := createNamedRoutingKey(nil, info, structValues{v: reflect.ValueOf(user), fields: structFields(reflect.TypeOf(user))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := createRoutingKey(nil, info, []interface{}{"b", nil, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(key, expected) {
+		t.Fatalf("expected routing key %x got %x", expected, key)
+	}
+
+	if key, err := createNamedRoutingKey(nil, info, mapValues{"id": 1}); key != nil || err != nil {
+		t.Fatalf("expected no routing key when a value is missing, got %x, %v", key, err)
+	}
+}
@@ -0,0 +1,162 @@
+// Package cdc reads the changes of Scylla tables with CDC enabled.
+//
+// Scylla writes the changes of a table with CDC enabled to its log table, <table>_scylla_cdc_log,
+// partitioned by streams. The streams change over time, a set of streams which is used from a given
+// time on is a generation. The generations and their streams are published in the system_distributed
+// keyspace.
+//
+// A Reader discovers the generations, reads the log table of every stream in time windows and
+// passes the changes to a consumer:
+//
+//	reader, err := cdc.NewReader(session, cdc.ReaderConfig{
+//		Tables: []string{"ks.orders"},
+//		Consumer: func(ctx context.Context, change *cdc.Change) error {
+//			for _, row := range change.Delta {
+//				log.Println(row.Operation, row.Columns)
+//			}
+//			return nil
+//		},
+//		Progress: cdc.NewTableProgressStore(session, "ks.cdc_progress"),
+//	})
+//	...
+//	err = reader.Run(ctx)
+//
+// The progress of every stream is saved to a ProgressStore after every window, so a reader created
+// with the same store resumes where the previous one stopped. Changes are delivered at least once,
+// changes of a window whose progress was not saved are delivered again.
+package cdc
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/gocql/gocql"
+)
+
+const (
+	logTableSuffix = "_scylla_cdc_log"
+
+	// metaPrefix is the prefix of the columns of the log table which don't belong to the base table.
+	metaPrefix            = "cdc$"
+	colStreamID           = "cdc$stream_id"
+	colTime               = "cdc$time"
+	colBatchSeqNo         = "cdc$batch_seq_no"
+	colOperation          = "cdc$operation"
+	colTTL                = "cdc$ttl"
+	colEndOfBatch         = "cdc$end_of_batch"
+	deletedPrefix         = "cdc$deleted_"
+	deletedElementsPrefix = "cdc$deleted_elements_"
+)
+
+// OperationType is the operation of a row of the log table, the cdc$operation column.
+type OperationType int8
+
+const (
+	PreImage                  OperationType = 0
+	Update                    OperationType = 1
+	Insert                    OperationType = 2
+	RowDelete                 OperationType = 3
+	PartitionDelete           OperationType = 4
+	RangeDeleteStartInclusive OperationType = 5
+	RangeDeleteStartExclusive OperationType = 6
+	RangeDeleteEndInclusive   OperationType = 7
+	RangeDeleteEndExclusive   OperationType = 8
+	PostImage                 OperationType = 9
+)
+
+func (op OperationType) String() string {
+	switch op {
+	case PreImage:
+		return "PRE_IMAGE"
+	case Update:
+		return "UPDATE"
+	case Insert:
+		return "INSERT"
+	case RowDelete:
+		return "ROW_DELETE"
+	case PartitionDelete:
+		return "PARTITION_DELETE"
+	case RangeDeleteStartInclusive:
+		return "RANGE_DELETE_START_INCLUSIVE"
+	case RangeDeleteStartExclusive:
+		return "RANGE_DELETE_START_EXCLUSIVE"
+	case RangeDeleteEndInclusive:
+		return "RANGE_DELETE_END_INCLUSIVE"
+	case RangeDeleteEndExclusive:
+		return "RANGE_DELETE_END_EXCLUSIVE"
+	case PostImage:
+		return "POST_IMAGE"
+	default:
+		return fmt.Sprintf("UNKNOWN_OPERATION_%d", int8(op))
+	}
+}
+
+// StreamID identifies a stream of a CDC generation.
+type StreamID []byte
+
+func (id StreamID) String() string {
+	return hex.EncodeToString(id)
+}
+
+// Equal reports whether both ids are the same.
+func (id StreamID) Equal(other StreamID) bool {
+	return bytes.Equal(id, other)
+}
+
+// ChangeRow is a row of the log table.
+type ChangeRow struct {
+	// StreamID is the stream the row belongs to.
+	StreamID StreamID
+	// Time is the time of the change, rows of the same change share it.
+	Time gocql.UUID
+	// BatchSeqNo is the position of the row within its change.
+	BatchSeqNo int
+	// Operation is the operation of the row.
+	Operation OperationType
+	// TTL is the TTL of the change in seconds, zero if the change has no TTL.
+	TTL int64
+	// EndOfBatch is true for the last row of a change.
+	EndOfBatch bool
+	// Columns are the values of the columns of the base table which are not null in the row.
+	// A column of a delta row is null if the change didn't write it.
+	Columns map[string]interface{}
+	// Deleted are the columns of the base table the change set to null, the cdc$deleted_<column> columns.
+	Deleted map[string]bool
+	// DeletedElements are the elements the change removed from non-frozen collections and
+	// UDTs, the cdc$deleted_elements_<column> columns.
+	DeletedElements map[string]interface{}
+}
+
+// Change is a change of a single partition of a base table, the rows of the log table with the same
+// stream and time.
+type Change struct {
+	// Table is the base table, in the keyspace.table format.
+	Table string
+	// StreamID is the stream of the change.
+	StreamID StreamID
+	// Time is the time of the change.
+	Time gocql.UUID
+	// PreImage are the rows of the base table before the change, present if the table was created with preimage enabled.
+	PreImage []*ChangeRow
+	// Delta are the rows describing the change.
+	Delta []*ChangeRow
+	// PostImage are the rows of the base table after the change, present if the table was created with postimage enabled.
+	PostImage []*ChangeRow
+}
+
+// ConsumerFunc processes a change. Changes of a stream are passed in the order of their time, changes
+// of different streams may be passed concurrently. If it returns an error, the reader stops with it.
+type ConsumerFunc func(ctx context.Context, change *Change) error
+
+func (c *Change) add(row *ChangeRow) {
+	switch row.Operation {
+	case PreImage:
+		c.PreImage = append(c.PreImage, row)
+	case PostImage:
+		c.PostImage = append(c.PostImage, row)
+	default:
+		c.Delta = append(c.Delta, row)
+	}
+}
@@ -0,0 +1,138 @@
+package cdc
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+const (
+	stmtGenerationTimes   = `SELECT time FROM system_distributed.cdc_generation_timestamps WHERE key = 'timestamps'`
+	stmtGenerationStreams = `SELECT streams FROM system_distributed.cdc_streams_descriptions_v2 WHERE time = ?`
+
+	// the format used by Scylla before 4.4, a single row with all streams of a generation
+	stmtLegacyGenerationTimes   = `SELECT time FROM system_distributed.cdc_streams_descriptions`
+	stmtLegacyGenerationStreams = `SELECT streams FROM system_distributed.cdc_streams_descriptions WHERE time = ?`
+
+	// legacyGroupSize is the number of streams of the legacy format read by a single query.
+	legacyGroupSize = 64
+)
+
+// Generation is a CDC generation, the streams used by the log tables from its start time until
+// the start of the next generation.
+type Generation struct {
+	// Time is the start time of the generation.
+	Time time.Time
+	// Streams are the streams of the generation in groups, the streams of a group are read by
+	// a single query. The streams of a group belong to the same vnode, except for the legacy format
+	// which doesn't publish vnodes.
+	Streams [][]StreamID
+}
+
+// generationSource discovers the generations, it detects the format of the system_distributed tables
+// on the first use.
+type generationSource struct {
+	session     *gocql.Session
+	consistency gocql.Consistency
+	legacy      bool
+	detected    bool
+}
+
+// times returns the start times of all generations in ascending order.
+func (g *generationSource) times(ctx context.Context) ([]time.Time, error) {
+	if !g.detected {
+		times, err := g.queryTimes(ctx, stmtGenerationTimes)
+		if err == nil && len(times) > 0 {
+			g.detected = true
+			return times, nil
+		}
+		legacy, legacyErr := g.queryTimes(ctx, stmtLegacyGenerationTimes)
+		if legacyErr != nil {
+			if err != nil {
+				return nil, err
+			}
+			// the legacy table may not exist in clusters created with the new format
+			return times, nil
+		}
+		if len(legacy) == 0 {
+			return times, nil
+		}
+		g.legacy, g.detected = true, true
+		return legacy, nil
+	}
+	if g.legacy {
+		return g.queryTimes(ctx, stmtLegacyGenerationTimes)
+	}
+	return g.queryTimes(ctx, stmtGenerationTimes)
+}
+
+func (g *generationSource) queryTimes(ctx context.Context, stmt string) ([]time.Time, error) {
+	iter := g.session.Query(stmt).WithContext(ctx).Consistency(g.consistency).Iter()
+	var (
+		times []time.Time
+		t     time.Time
+	)
+	for iter.Scan(&t) {
+		times = append(times, t)
+	}
+	if err := iter.Close(); err != nil {
+		return nil, fmt.Errorf("cdc: failed to read generations: %w", err)
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	return times, nil
+}
+
+// generation returns the generation starting at t with its streams.
+func (g *generationSource) generation(ctx context.Context, t time.Time) (*Generation, error) {
+	stmt := stmtGenerationStreams
+	if g.legacy {
+		stmt = stmtLegacyGenerationStreams
+	}
+	iter := g.session.Query(stmt, t).WithContext(ctx).Consistency(g.consistency).Iter()
+	gen := &Generation{Time: t}
+	var streams [][]byte
+	for iter.Scan(&streams) {
+		group := make([]StreamID, len(streams))
+		for i, id := range streams {
+			group[i] = StreamID(id)
+		}
+		if g.legacy {
+			for len(group) > legacyGroupSize {
+				gen.Streams = append(gen.Streams, group[:legacyGroupSize])
+				group = group[legacyGroupSize:]
+			}
+		}
+		if len(group) > 0 {
+			gen.Streams = append(gen.Streams, group)
+		}
+		streams = nil
+	}
+	if err := iter.Close(); err != nil {
+		return nil, fmt.Errorf("cdc: failed to read streams of generation %v: %w", t, err)
+	}
+	if len(gen.Streams) == 0 {
+		return nil, fmt.Errorf("cdc: generation %v has no streams", t)
+	}
+	return gen, nil
+}
+
+// Generations returns the CDC generations of the cluster with their streams, in ascending order of their time.
+func Generations(ctx context.Context, session *gocql.Session) ([]*Generation, error) {
+	src := &generationSource{session: session, consistency: gocql.Quorum}
+	times, err := src.times(ctx)
+	if err != nil {
+		return nil, err
+	}
+	gens := make([]*Generation, 0, len(times))
+	for _, t := range times {
+		gen, err := src.generation(ctx, t)
+		if err != nil {
+			return nil, err
+		}
+		gens = append(gens, gen)
+	}
+	return gens, nil
+}
@@ -0,0 +1,148 @@
+package cdc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// ProgressStore saves the progress of a Reader, so a reader created with the same store resumes
+// where the previous one stopped. The progress is kept per table, a store can't be shared by readers
+// of the same table.
+type ProgressStore interface {
+	// GetGeneration returns the start time of the generation the changes of the table are read from,
+	// zero if it's not known.
+	GetGeneration(ctx context.Context, table string) (time.Time, error)
+	// SaveGeneration saves the generation the changes of the table are read from.
+	SaveGeneration(ctx context.Context, table string, gen time.Time) error
+	// GetProgress returns the time up to which the changes of the stream were consumed,
+	// zero if there is no progress of the stream.
+	GetProgress(ctx context.Context, table string, gen time.Time, stream StreamID) (time.Time, error)
+	// SaveProgress saves that the changes of the stream up to t, inclusive, were consumed.
+	SaveProgress(ctx context.Context, table string, gen time.Time, stream StreamID, t time.Time) error
+}
+
+type progressKey struct {
+	table  string
+	gen    int64
+	stream string
+}
+
+// MemoryProgressStore keeps the progress in memory, it's lost when the process exits.
+type MemoryProgressStore struct {
+	mu          sync.Mutex
+	generations map[string]time.Time
+	progress    map[progressKey]time.Time
+}
+
+// NewMemoryProgressStore returns an empty MemoryProgressStore.
+func NewMemoryProgressStore() *MemoryProgressStore {
+	return &MemoryProgressStore{
+		generations: make(map[string]time.Time),
+		progress:    make(map[progressKey]time.Time),
+	}
+}
+
+func (s *MemoryProgressStore) GetGeneration(_ context.Context, table string) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.generations[table], nil
+}
+
+func (s *MemoryProgressStore) SaveGeneration(_ context.Context, table string, gen time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.generations[table] = gen
+	return nil
+}
+
+func (s *MemoryProgressStore) GetProgress(_ context.Context, table string, gen time.Time, stream StreamID) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.progress[progressKey{table: table, gen: gen.UnixMilli(), stream: string(stream)}], nil
+}
+
+func (s *MemoryProgressStore) SaveProgress(_ context.Context, table string, gen time.Time, stream StreamID, t time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.progress[progressKey{table: table, gen: gen.UnixMilli(), stream: string(stream)}] = t
+	return nil
+}
+
+// TableProgressStore keeps the progress in a table, which is created by CreateTable.
+// The progress of a base table is kept in a single partition.
+type TableProgressStore struct {
+	session *gocql.Session
+	table   string
+	ttl     time.Duration
+}
+
+// NewTableProgressStore returns a store keeping the progress in the given table, in the keyspace.table format.
+func NewTableProgressStore(session *gocql.Session, table string) *TableProgressStore {
+	return &TableProgressStore{session: session, table: table}
+}
+
+// WithTTL sets the TTL of the saved progress of streams, it should be longer than the TTL of the log tables.
+// Zero, the default, keeps the progress forever.
+func (s *TableProgressStore) WithTTL(ttl time.Duration) *TableProgressStore {
+	s.ttl = ttl
+	return s
+}
+
+// CreateTable creates the table of the store if it doesn't exist.
+func (s *TableProgressStore) CreateTable(ctx context.Context) error {
+	stmt := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		table_name text,
+		generation timestamp,
+		stream_id blob,
+		last_time timestamp,
+		current_generation timestamp STATIC,
+		PRIMARY KEY (table_name, generation, stream_id)
+	)`, s.table)
+	if err := s.session.Query(stmt).WithContext(ctx).Exec(); err != nil {
+		return fmt.Errorf("cdc: failed to create progress table %s: %w", s.table, err)
+	}
+	return nil
+}
+
+func (s *TableProgressStore) GetGeneration(ctx context.Context, table string) (time.Time, error) {
+	var gen time.Time
+	err := s.session.Query(`SELECT current_generation FROM `+s.table+` WHERE table_name = ? LIMIT 1`, table).
+		WithContext(ctx).Scan(&gen)
+	if err != nil && !errors.Is(err, gocql.ErrNotFound) {
+		return time.Time{}, fmt.Errorf("cdc: failed to read generation of %s: %w", table, err)
+	}
+	return gen, nil
+}
+
+func (s *TableProgressStore) SaveGeneration(ctx context.Context, table string, gen time.Time) error {
+	err := s.session.Query(`UPDATE `+s.table+` SET current_generation = ? WHERE table_name = ?`, gen, table).
+		WithContext(ctx).Exec()
+	if err != nil {
+		return fmt.Errorf("cdc: failed to save generation of %s: %w", table, err)
+	}
+	return nil
+}
+
+func (s *TableProgressStore) GetProgress(ctx context.Context, table string, gen time.Time, stream StreamID) (time.Time, error) {
+	var t time.Time
+	err := s.session.Query(`SELECT last_time FROM `+s.table+` WHERE table_name = ? AND generation = ? AND stream_id = ?`,
+		table, gen, []byte(stream)).WithContext(ctx).Scan(&t)
+	if err != nil && !errors.Is(err, gocql.ErrNotFound) {
+		return time.Time{}, fmt.Errorf("cdc: failed to read progress of stream %v of %s: %w", stream, table, err)
+	}
+	return t, nil
+}
+
+func (s *TableProgressStore) SaveProgress(ctx context.Context, table string, gen time.Time, stream StreamID, t time.Time) error {
+	err := s.session.Query(`INSERT INTO `+s.table+` (table_name, generation, stream_id, last_time) VALUES (?, ?, ?, ?) USING TTL ?`,
+		table, gen, []byte(stream), t, int(s.ttl/time.Second)).WithContext(ctx).Exec()
+	if err != nil {
+		return fmt.Errorf("cdc: failed to save progress of stream %v of %s: %w", stream, table, err)
+	}
+	return nil
+}
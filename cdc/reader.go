@@ -0,0 +1,520 @@
+package cdc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// ReaderConfig configures a Reader.
+type ReaderConfig struct {
+	// Tables are the base tables with CDC enabled to read the changes of, in the keyspace.table format.
+	Tables []string
+	// Consumer processes the changes, it's required.
+	Consumer ConsumerFunc
+	// Progress saves the progress of the reader.
+	// Default: a new MemoryProgressStore
+	Progress ProgressStore
+	// Consistency is the consistency of the queries reading the generations and the log tables.
+	// Default: Quorum
+	Consistency gocql.Consistency
+	// StartTime is the time from which the changes of tables without saved progress are read.
+	// Zero means the start of the oldest generation.
+	StartTime time.Time
+	// EndTime is the time up to which the changes are read, Run returns once they are all consumed.
+	// Zero means that Run reads changes until its context is done.
+	EndTime time.Time
+	// WindowSize is the longest period of time whose changes of a stream group are read by a single query.
+	// Default: 30s
+	WindowSize time.Duration
+	// ConfidenceWindow is the period of time before now whose changes are not read yet, because
+	// changes with timestamps in it may still be written.
+	// Default: 30s
+	ConfidenceWindow time.Duration
+	// PollInterval is how long the reader waits for new changes after it has read all streams
+	// up to the confidence window. It also checks for new generations with this period.
+	// Default: 1s
+	PollInterval time.Duration
+	// Logger logs generation switches.
+	// Default: the standard logger of the log package
+	Logger gocql.StdLogger
+}
+
+// Validate checks the configuration.
+func (cfg *ReaderConfig) Validate() error {
+	if len(cfg.Tables) == 0 {
+		return errors.New("Tables should not be empty")
+	}
+	for _, table := range cfg.Tables {
+		if ks, name, ok := strings.Cut(table, "."); !ok || ks == "" || name == "" {
+			return fmt.Errorf("Tables should be in the keyspace.table format, got %q", table)
+		}
+	}
+	if cfg.Consumer == nil {
+		return errors.New("Consumer should be set")
+	}
+	if !cfg.EndTime.IsZero() && cfg.EndTime.Before(cfg.StartTime) {
+		return errors.New("EndTime should not be before StartTime")
+	}
+	if cfg.WindowSize < 0 {
+		return errors.New("WindowSize should be positive time.Duration or zero")
+	}
+	if cfg.ConfidenceWindow < 0 {
+		return errors.New("ConfidenceWindow should be positive time.Duration or zero")
+	}
+	if cfg.PollInterval < 0 {
+		return errors.New("PollInterval should be positive time.Duration or zero")
+	}
+	return nil
+}
+
+// Reader reads the changes of tables with CDC enabled.
+//
+// The streams of every table are read in groups, one query per group and window, the changes of
+// a window are passed to the consumer before the next window of the group is read. When all groups
+// of a table reach the start of the next generation, the reader switches to it. Tables are read
+// concurrently.
+type Reader struct {
+	session *gocql.Session
+	cfg     ReaderConfig
+}
+
+// NewReader returns a reader of the changes of cfg.Tables.
+func NewReader(session *gocql.Session, cfg ReaderConfig) (*Reader, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("cdc: invalid reader config: %w", err)
+	}
+	cfg.Tables = append([]string(nil), cfg.Tables...)
+	if cfg.Progress == nil {
+		cfg.Progress = NewMemoryProgressStore()
+	}
+	if cfg.Consistency == gocql.Any {
+		cfg.Consistency = gocql.Quorum
+	}
+	if cfg.WindowSize == 0 {
+		cfg.WindowSize = 30 * time.Second
+	}
+	if cfg.ConfidenceWindow == 0 {
+		cfg.ConfidenceWindow = 30 * time.Second
+	}
+	if cfg.PollInterval == 0 {
+		cfg.PollInterval = time.Second
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = log.Default()
+	}
+	cfg.StartTime = truncate(cfg.StartTime)
+	cfg.EndTime = truncate(cfg.EndTime)
+	return &Reader{session: session, cfg: cfg}, nil
+}
+
+// Run reads the changes until all changes up to EndTime are consumed, in which case it returns nil,
+// or until ctx is done. It returns the first error of the consumer, the progress store or a query.
+func (r *Reader) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errs := make(chan error, len(r.cfg.Tables))
+	for _, table := range r.cfg.Tables {
+		t := r.newTableReader(table)
+		go func() {
+			err := t.run(ctx)
+			if err != nil {
+				cancel()
+			}
+			errs <- err
+		}()
+	}
+
+	var first error
+	for range r.cfg.Tables {
+		err := <-errs
+		// the other tables fail with the cancellation caused by the first error
+		if err != nil && (first == nil || errors.Is(first, context.Canceled) && !errors.Is(err, context.Canceled)) {
+			first = err
+		}
+	}
+	return first
+}
+
+// tableReader reads the changes of a single table.
+type tableReader struct {
+	r     *Reader
+	table string
+	stmt  string
+	gens  *generationSource
+}
+
+func (r *Reader) newTableReader(table string) *tableReader {
+	ks, name, _ := strings.Cut(table, ".")
+	return &tableReader{
+		r:     r,
+		table: table,
+		stmt: fmt.Sprintf(`SELECT * FROM %s.%s WHERE "cdc$stream_id" IN ? AND "cdc$time" > maxTimeuuid(?) AND "cdc$time" <= maxTimeuuid(?) BYPASS CACHE`,
+			quote(ks), quote(name+logTableSuffix)),
+		gens: &generationSource{session: r.session, consistency: r.cfg.Consistency},
+	}
+}
+
+func (t *tableReader) run(ctx context.Context) error {
+	cfg := &t.r.cfg
+
+	var times []time.Time
+	for {
+		var err error
+		if times, err = t.gens.times(ctx); err != nil {
+			return err
+		}
+		if len(times) > 0 {
+			break
+		}
+		if !cfg.EndTime.IsZero() && time.Now().After(cfg.EndTime) {
+			// there are no changes to read
+			return nil
+		}
+		if err := sleep(ctx, cfg.PollInterval); err != nil {
+			return err
+		}
+	}
+
+	saved, err := cfg.Progress.GetGeneration(ctx, t.table)
+	if err != nil {
+		return err
+	}
+	i := t.startGeneration(times, saved)
+	for {
+		if !cfg.EndTime.IsZero() && times[i].After(cfg.EndTime) {
+			return nil
+		}
+		gen, err := t.gens.generation(ctx, times[i])
+		if err != nil {
+			return err
+		}
+		if !gen.Time.Equal(saved) {
+			if err := cfg.Progress.SaveGeneration(ctx, t.table, gen.Time); err != nil {
+				return err
+			}
+			saved = gen.Time
+		}
+
+		next, done, err := t.readGeneration(ctx, gen, times[i+1:])
+		if err != nil || done {
+			return err
+		}
+		cfg.Logger.Printf("cdc: %s switches from generation %v to %v", t.table, gen.Time, next[0])
+		times, i = next, 0
+	}
+}
+
+// startGeneration returns the index of the generation the reading starts with, the saved one if it's known,
+// otherwise the generation of StartTime.
+func (t *tableReader) startGeneration(times []time.Time, saved time.Time) int {
+	if !saved.IsZero() {
+		for i, gen := range times {
+			if gen.Equal(saved) {
+				return i
+			}
+		}
+	}
+	start := t.r.cfg.StartTime
+	if !saved.IsZero() && saved.After(start) {
+		start = saved
+	}
+	i := 0
+	for i+1 < len(times) && !times[i+1].After(start) {
+		i++
+	}
+	return i
+}
+
+// streamGroup is a group of streams read by a single query.
+type streamGroup struct {
+	streams []StreamID
+	ids     [][]byte
+	// progress is the saved progress of the streams, the changes up to it were consumed
+	progress map[string]time.Time
+	// from is the end of the last window read, the next window starts after it
+	from time.Time
+}
+
+// readGeneration reads the changes of gen. It returns the start times of the generations starting with the next one
+// once the changes of gen are consumed, or done if the changes up to EndTime are consumed.
+func (t *tableReader) readGeneration(ctx context.Context, gen *Generation, next []time.Time) ([]time.Time, bool, error) {
+	cfg := &t.r.cfg
+
+	// the changes of a generation are read from its start time, inclusive
+	start := gen.Time.Add(-time.Millisecond)
+	if !cfg.StartTime.IsZero() && cfg.StartTime.Add(-time.Millisecond).After(start) {
+		start = cfg.StartTime.Add(-time.Millisecond)
+	}
+	groups := make([]*streamGroup, 0, len(gen.Streams))
+	for _, streams := range gen.Streams {
+		g := &streamGroup{streams: streams, ids: make([][]byte, len(streams)), progress: make(map[string]time.Time, len(streams))}
+		for i, stream := range streams {
+			g.ids[i] = stream
+			progress, err := cfg.Progress.GetProgress(ctx, t.table, gen.Time, stream)
+			if err != nil {
+				return nil, false, err
+			}
+			if progress.Before(start) {
+				progress = start
+			}
+			g.progress[string(stream)] = progress
+			if i == 0 || progress.Before(g.from) {
+				g.from = progress
+			}
+		}
+		groups = append(groups, g)
+	}
+
+	for {
+		// bound is the end of the changes to read in this generation, inclusive
+		var bound time.Time
+		if len(next) > 0 {
+			bound = next[0].Add(-time.Millisecond)
+		}
+		done := false
+		if !cfg.EndTime.IsZero() && (bound.IsZero() || !cfg.EndTime.After(bound)) {
+			bound, done = cfg.EndTime, true
+		}
+		limit := truncate(time.Now().Add(-cfg.ConfidenceWindow))
+		if !bound.IsZero() && bound.Before(limit) {
+			limit = bound
+		}
+
+		read, finished := false, true
+		for _, g := range groups {
+			if g.from.Before(limit) {
+				to := g.from.Add(cfg.WindowSize)
+				if to.After(limit) {
+					to = limit
+				}
+				if err := t.readWindow(ctx, gen, g, to); err != nil {
+					return nil, false, err
+				}
+				read = true
+			}
+			if bound.IsZero() || g.from.Before(bound) {
+				finished = false
+			}
+		}
+		if finished {
+			return next, done, nil
+		}
+		if read {
+			continue
+		}
+
+		if err := sleep(ctx, cfg.PollInterval); err != nil {
+			return nil, false, err
+		}
+		if len(next) == 0 {
+			times, err := t.gens.times(ctx)
+			if err != nil {
+				return nil, false, err
+			}
+			for i, tm := range times {
+				if tm.After(gen.Time) {
+					next = times[i:]
+					break
+				}
+			}
+		}
+	}
+}
+
+// readWindow reads the changes of the group after g.from up to to, inclusive, passes them to the consumer
+// and saves the progress of the streams.
+func (t *tableReader) readWindow(ctx context.Context, gen *Generation, g *streamGroup, to time.Time) error {
+	cfg := &t.r.cfg
+	iter := t.r.session.Query(t.stmt, g.ids, g.from, to).WithContext(ctx).Consistency(cfg.Consistency).Iter()
+
+	var (
+		scanner = newRowScanner(iter.Columns())
+		change  *Change
+	)
+	deliver := func() error {
+		if change == nil {
+			return nil
+		}
+		c := change
+		change = nil
+		return cfg.Consumer(ctx, c)
+	}
+	for {
+		row, ok, err := scanner.scan(iter)
+		if err != nil {
+			iter.Close()
+			return err
+		}
+		if !ok {
+			break
+		}
+		if progress, ok := g.progress[string(row.StreamID)]; ok && !truncate(row.Time.Time()).After(progress) {
+			// consumed before the restart of the reader
+			continue
+		}
+		if change != nil && (!change.StreamID.Equal(row.StreamID) || change.Time != row.Time) {
+			if err := deliver(); err != nil {
+				iter.Close()
+				return err
+			}
+		}
+		if change == nil {
+			change = &Change{Table: t.table, StreamID: row.StreamID, Time: row.Time}
+		}
+		change.add(row)
+	}
+	if err := iter.Close(); err != nil {
+		return fmt.Errorf("cdc: failed to read changes of %s: %w", t.table, err)
+	}
+	if err := deliver(); err != nil {
+		return err
+	}
+
+	for _, stream := range g.streams {
+		if progress := g.progress[string(stream)]; progress.Before(to) {
+			if err := cfg.Progress.SaveProgress(ctx, t.table, gen.Time, stream, to); err != nil {
+				return err
+			}
+			g.progress[string(stream)] = to
+		}
+	}
+	g.from = to
+	return nil
+}
+
+// rowScanner scans the rows of a log table into ChangeRows.
+type rowScanner struct {
+	cols []gocql.ColumnInfo
+	// dest are the pointers to pointers the values are scanned into, null values are scanned as nil pointers.
+	// Tuples are scanned into a destination per element.
+	dest []reflect.Value
+	args []interface{}
+	err  error
+}
+
+func newRowScanner(cols []gocql.ColumnInfo) *rowScanner {
+	s := &rowScanner{cols: cols}
+	add := func(info gocql.TypeInfo) {
+		v, err := info.NewWithError()
+		if err != nil {
+			if s.err == nil {
+				s.err = fmt.Errorf("cdc: failed to scan the log table: %w", err)
+			}
+			return
+		}
+		dest := reflect.New(reflect.TypeOf(v))
+		s.dest = append(s.dest, dest)
+		s.args = append(s.args, dest.Interface())
+	}
+	for _, col := range cols {
+		if tuple, ok := col.TypeInfo.(gocql.TupleTypeInfo); ok {
+			for _, elem := range tuple.Elems {
+				add(elem)
+			}
+			continue
+		}
+		add(col.TypeInfo)
+	}
+	return s
+}
+
+// scan returns the next row, false if there are no more rows.
+func (s *rowScanner) scan(iter *gocql.Iter) (*ChangeRow, bool, error) {
+	if s.err != nil {
+		return nil, false, s.err
+	}
+	if !iter.Scan(s.args...) {
+		return nil, false, nil
+	}
+
+	row := &ChangeRow{Columns: make(map[string]interface{})}
+	i := 0
+	next := func() interface{} {
+		v := s.dest[i].Elem()
+		i++
+		if v.IsNil() {
+			return nil
+		}
+		return v.Elem().Interface()
+	}
+	for _, col := range s.cols {
+		var value interface{}
+		if tuple, ok := col.TypeInfo.(gocql.TupleTypeInfo); ok {
+			elems := make([]interface{}, len(tuple.Elems))
+			for j := range elems {
+				if elems[j] = next(); elems[j] != nil {
+					value = elems
+				}
+			}
+		} else {
+			value = next()
+		}
+		if value == nil {
+			continue
+		}
+
+		name := col.Name
+		switch {
+		case !strings.HasPrefix(name, metaPrefix):
+			row.Columns[name] = value
+		case name == colStreamID:
+			row.StreamID, _ = value.([]byte)
+		case name == colTime:
+			row.Time, _ = value.(gocql.UUID)
+		case name == colBatchSeqNo:
+			row.BatchSeqNo, _ = value.(int)
+		case name == colOperation:
+			op, _ := value.(int8)
+			row.Operation = OperationType(op)
+		case name == colTTL:
+			row.TTL, _ = value.(int64)
+		case name == colEndOfBatch:
+			row.EndOfBatch, _ = value.(bool)
+		case strings.HasPrefix(name, deletedElementsPrefix):
+			if row.DeletedElements == nil {
+				row.DeletedElements = make(map[string]interface{})
+			}
+			row.DeletedElements[strings.TrimPrefix(name, deletedElementsPrefix)] = value
+		case strings.HasPrefix(name, deletedPrefix):
+			if deleted, _ := value.(bool); deleted {
+				if row.Deleted == nil {
+					row.Deleted = make(map[string]bool)
+				}
+				row.Deleted[strings.TrimPrefix(name, deletedPrefix)] = true
+			}
+		}
+	}
+	return row, true, nil
+}
+
+// quote quotes an identifier, so its case is preserved.
+func quote(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// truncate truncates t to milliseconds, the precision of CQL timestamps.
+func truncate(t time.Time) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	return t.Truncate(time.Millisecond)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
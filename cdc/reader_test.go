@@ -0,0 +1,408 @@
+//go:build unit
+// +build unit
+
+package cdc
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+// fakeCDC serves the CDC generations and the log table of ks.t from a fake cluster.
+type fakeCDC struct {
+	mu          sync.Mutex
+	generations map[int64][][]byte
+	rows        []logRow
+}
+
+type logRow struct {
+	stream  []byte
+	time    gocql.UUID
+	seq     int
+	op      OperationType
+	v       interface{}
+	deleted bool
+}
+
+var logColumns = []gocqltest.Column{
+	gocqltest.Col("cdc$stream_id", gocql.TypeBlob),
+	gocqltest.Col("cdc$time", gocql.TypeTimeUUID),
+	gocqltest.Col("cdc$batch_seq_no", gocql.TypeInt),
+	gocqltest.Col("cdc$operation", gocql.TypeTinyInt),
+	gocqltest.Col("cdc$ttl", gocql.TypeBigInt),
+	gocqltest.Col("cdc$end_of_batch", gocql.TypeBoolean),
+	gocqltest.Col("id", gocql.TypeInt),
+	gocqltest.Col("v", gocql.TypeText),
+	gocqltest.Col("cdc$deleted_v", gocql.TypeBoolean),
+}
+
+func newFakeCDC(t *testing.T) (*fakeCDC, *gocql.Session) {
+	t.Helper()
+	c, err := gocqltest.NewCluster(gocqltest.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(c.Close)
+
+	f := &fakeCDC{generations: make(map[int64][][]byte)}
+	c.When(`FROM system_distributed\.cdc_generation_timestamps`).Handle(func(req *gocqltest.Request) gocqltest.Response {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		var rows [][]interface{}
+		for gen := range f.generations {
+			rows = append(rows, []interface{}{time.UnixMilli(gen)})
+		}
+		return gocqltest.Rows([]gocqltest.Column{gocqltest.Col("time", gocql.TypeTimestamp)}, rows...)
+	})
+	c.When(`FROM system_distributed\.cdc_streams_descriptions_v2`).
+		Params(gocqltest.Col("time", gocql.TypeTimestamp)).
+		Handle(func(req *gocqltest.Request) gocqltest.Response {
+			var gen time.Time
+			if err := req.Bind(0, &gen); err != nil {
+				return gocqltest.Error(gocql.ErrCodeInvalid, err.Error())
+			}
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			var rows [][]interface{}
+			for _, group := range f.generations[gen.UnixMilli()] {
+				rows = append(rows, []interface{}{[][]byte{group}})
+			}
+			return gocqltest.Rows([]gocqltest.Column{{Name: "streams", Type: gocqltest.SetOf(gocqltest.Native(gocql.TypeBlob))}}, rows...)
+		})
+	c.When(`FROM "ks"\."t_scylla_cdc_log"`).
+		Params(
+			gocqltest.Column{Name: "stream_ids", Type: gocqltest.ListOf(gocqltest.Native(gocql.TypeBlob))},
+			gocqltest.Col("from", gocql.TypeTimestamp),
+			gocqltest.Col("to", gocql.TypeTimestamp),
+		).
+		Handle(func(req *gocqltest.Request) gocqltest.Response {
+			var (
+				ids      [][]byte
+				from, to time.Time
+			)
+			if err := req.Bind(0, &ids); err != nil {
+				return gocqltest.Error(gocql.ErrCodeInvalid, err.Error())
+			}
+			if err := req.Bind(1, &from); err != nil {
+				return gocqltest.Error(gocql.ErrCodeInvalid, err.Error())
+			}
+			if err := req.Bind(2, &to); err != nil {
+				return gocqltest.Error(gocql.ErrCodeInvalid, err.Error())
+			}
+			return gocqltest.Rows(logColumns, f.logRows(ids, from, to)...)
+		})
+
+	cfg := c.ClusterConfig()
+	cfg.Timeout = time.Second
+	session, err := cfg.CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(session.Close)
+	return f, session
+}
+
+// addGeneration adds a generation with a single stream per group.
+func (f *fakeCDC) addGeneration(gen time.Time, streams ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, stream := range streams {
+		f.generations[gen.UnixMilli()] = append(f.generations[gen.UnixMilli()], []byte(stream))
+	}
+}
+
+func (f *fakeCDC) addRows(rows ...logRow) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rows = append(f.rows, rows...)
+}
+
+func (f *fakeCDC) logRows(ids [][]byte, from, to time.Time) [][]interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var rows []logRow
+	for _, row := range f.rows {
+		ms := row.time.Time().Truncate(time.Millisecond)
+		if !ms.After(from) || ms.After(to) {
+			continue
+		}
+		for _, id := range ids {
+			if string(id) == string(row.stream) {
+				rows = append(rows, row)
+			}
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if string(rows[i].stream) != string(rows[j].stream) {
+			return string(rows[i].stream) < string(rows[j].stream)
+		}
+		if rows[i].time != rows[j].time {
+			return rows[i].time.Timestamp() < rows[j].time.Timestamp()
+		}
+		return rows[i].seq < rows[j].seq
+	})
+	values := make([][]interface{}, len(rows))
+	for i, row := range rows {
+		var deleted interface{}
+		if row.deleted {
+			deleted = true
+		}
+		values[i] = []interface{}{row.stream, row.time, row.seq, int8(row.op), nil, nil, 1, row.v, deleted}
+	}
+	return values
+}
+
+type consumed struct {
+	mu      sync.Mutex
+	changes []*Change
+}
+
+func (c *consumed) consume(_ context.Context, change *Change) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.changes = append(c.changes, change)
+	return nil
+}
+
+func (c *consumed) get() []*Change {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*Change(nil), c.changes...)
+}
+
+func TestReader(t *testing.T) {
+	f, session := newFakeCDC(t)
+
+	now := time.Now().Truncate(time.Millisecond)
+	gen1, gen2 := now.Add(-10*time.Minute), now.Add(-5*time.Minute)
+	f.addGeneration(gen1, "s1", "s2")
+	f.addGeneration(gen2, "s3")
+
+	t1 := gocql.UUIDFromTime(gen1.Add(time.Second))
+	t2 := gocql.UUIDFromTime(gen1.Add(2 * time.Minute))
+	t3 := gocql.UUIDFromTime(gen2.Add(time.Second))
+	f.addRows(
+		logRow{stream: []byte("s1"), time: t1, seq: 0, op: PreImage, v: "a"},
+		logRow{stream: []byte("s1"), time: t1, seq: 1, op: Update, v: "b"},
+		logRow{stream: []byte("s1"), time: t1, seq: 2, op: PostImage, v: "b"},
+		logRow{stream: []byte("s2"), time: t2, seq: 0, op: Insert, v: "c"},
+		logRow{stream: []byte("s3"), time: t3, seq: 0, op: Update, deleted: true},
+	)
+
+	var got consumed
+	progress := NewMemoryProgressStore()
+	end := now.Add(-2 * time.Minute)
+	reader, err := NewReader(session, ReaderConfig{
+		Tables:           []string{"ks.t"},
+		Consumer:         got.consume,
+		Progress:         progress,
+		Consistency:      gocql.One,
+		EndTime:          end,
+		WindowSize:       time.Minute,
+		ConfidenceWindow: time.Millisecond,
+		PollInterval:     10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := reader.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	changes := got.get()
+	if len(changes) != 3 {
+		t.Fatalf("expected 3 changes, got %d", len(changes))
+	}
+	first := changes[0]
+	if first.Table != "ks.t" || string(first.StreamID) != "s1" || first.Time != t1 {
+		t.Fatalf("unexpected change: %+v", first)
+	}
+	if len(first.PreImage) != 1 || len(first.Delta) != 1 || len(first.PostImage) != 1 {
+		t.Fatalf("unexpected rows of the change: %d pre, %d delta, %d post", len(first.PreImage), len(first.Delta), len(first.PostImage))
+	}
+	if delta := first.Delta[0]; delta.Operation != Update || delta.BatchSeqNo != 1 || delta.Columns["v"] != "b" || delta.Columns["id"] != 1 {
+		t.Fatalf("unexpected delta row: %+v", delta)
+	}
+	if _, ok := first.Delta[0].Columns["cdc$ttl"]; ok {
+		t.Fatal("cdc columns should not be among the columns of the base table")
+	}
+	if string(changes[1].StreamID) != "s2" || changes[1].Delta[0].Operation != Insert {
+		t.Fatalf("unexpected change: %+v", changes[1])
+	}
+	last := changes[2].Delta[0]
+	if _, ok := last.Columns["v"]; ok || !last.Deleted["v"] {
+		t.Fatalf("expected v to be deleted, got %+v", last)
+	}
+
+	ctx := context.Background()
+	if gen, _ := progress.GetGeneration(ctx, "ks.t"); !gen.Equal(gen2) {
+		t.Fatalf("expected generation %v to be saved, got %v", gen2, gen)
+	}
+	if p, _ := progress.GetProgress(ctx, "ks.t", gen1, StreamID("s1")); !p.Equal(gen2.Add(-time.Millisecond)) {
+		t.Fatalf("unexpected progress of the first generation: %v", p)
+	}
+	if p, _ := progress.GetProgress(ctx, "ks.t", gen2, StreamID("s3")); !p.Equal(end) {
+		t.Fatalf("unexpected progress of the second generation: %v", p)
+	}
+
+	// a reader with the same progress store only reads the new changes
+	t4 := gocql.UUIDFromTime(now.Add(-90 * time.Second))
+	f.addRows(logRow{stream: []byte("s3"), time: t4, op: Insert, v: "d"})
+	var resumed consumed
+	reader, err = NewReader(session, ReaderConfig{
+		Tables:           []string{"ks.t"},
+		Consumer:         resumed.consume,
+		Progress:         progress,
+		EndTime:          now.Add(-time.Minute),
+		ConfidenceWindow: time.Millisecond,
+		PollInterval:     10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := reader.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if changes := resumed.get(); len(changes) != 1 || changes[0].Time != t4 {
+		t.Fatalf("expected only the new change, got %d changes", len(changes))
+	}
+}
+
+func TestReaderGenerationSwitch(t *testing.T) {
+	f, session := newFakeCDC(t)
+
+	now := time.Now().Truncate(time.Millisecond)
+	f.addGeneration(now.Add(-time.Minute), "s1")
+	f.addRows(logRow{stream: []byte("s1"), time: gocql.UUIDFromTime(now.Add(-time.Second)), op: Insert, v: "a"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var (
+		mu    sync.Mutex
+		count int
+	)
+	reader, err := NewReader(session, ReaderConfig{
+		Tables: []string{"ks.t"},
+		Consumer: func(_ context.Context, change *Change) error {
+			mu.Lock()
+			defer mu.Unlock()
+			count++
+			switch string(change.StreamID) {
+			case "s1":
+				// the next generation is published while the reader reads the current one
+				gen := time.Now().Add(100 * time.Millisecond).Truncate(time.Millisecond)
+				f.addGeneration(gen, "s2")
+				f.addRows(logRow{stream: []byte("s2"), time: gocql.UUIDFromTime(gen.Add(10 * time.Millisecond)), op: Insert, v: "b"})
+			case "s2":
+				cancel()
+			}
+			return nil
+		},
+		ConfidenceWindow: time.Millisecond,
+		PollInterval:     10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- reader.Run(ctx) }()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected the reader to be canceled, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the change of the next generation was not consumed")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if count != 2 {
+		t.Fatalf("expected 2 changes, got %d", count)
+	}
+}
+
+func TestReaderConsumerError(t *testing.T) {
+	f, session := newFakeCDC(t)
+
+	now := time.Now().Truncate(time.Millisecond)
+	f.addGeneration(now.Add(-time.Minute), "s1")
+	f.addRows(logRow{stream: []byte("s1"), time: gocql.UUIDFromTime(now.Add(-time.Second)), op: Insert})
+
+	errConsumer := errors.New("consumer failed")
+	progress := NewMemoryProgressStore()
+	reader, err := NewReader(session, ReaderConfig{
+		Tables:           []string{"ks.t"},
+		Consumer:         func(context.Context, *Change) error { return errConsumer },
+		Progress:         progress,
+		ConfidenceWindow: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := reader.Run(context.Background()); !errors.Is(err, errConsumer) {
+		t.Fatalf("expected the error of the consumer, got %v", err)
+	}
+	// the change is delivered again by the next reader
+	if p, _ := progress.GetProgress(context.Background(), "ks.t", now.Add(-time.Minute), StreamID("s1")); !p.Before(now.Add(-time.Second)) {
+		t.Fatalf("expected the progress to be before the failed change, got %v", p)
+	}
+}
+
+func TestLegacyGenerations(t *testing.T) {
+	c, err := gocqltest.NewCluster(gocqltest.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	gen := time.Now().Truncate(time.Millisecond)
+	streams := make([][]byte, 100)
+	for i := range streams {
+		streams[i] = []byte{byte(i)}
+	}
+	c.When(`FROM system_distributed\.cdc_streams_descriptions$`).
+		Respond(gocqltest.Rows([]gocqltest.Column{gocqltest.Col("time", gocql.TypeTimestamp)}, []interface{}{gen}))
+	c.When(`FROM system_distributed\.cdc_streams_descriptions WHERE`).
+		Params(gocqltest.Col("time", gocql.TypeTimestamp)).
+		Respond(gocqltest.Rows([]gocqltest.Column{{Name: "streams", Type: gocqltest.SetOf(gocqltest.Native(gocql.TypeBlob))}}, []interface{}{streams}))
+
+	session, err := c.ClusterConfig().CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	gens, err := Generations(context.Background(), session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gens) != 1 || !gens[0].Time.Equal(gen) {
+		t.Fatalf("unexpected generations: %+v", gens)
+	}
+	if groups := gens[0].Streams; len(groups) != 2 || len(groups[0]) != legacyGroupSize || len(groups[1]) != 100-legacyGroupSize {
+		t.Fatalf("unexpected stream groups: %d", len(groups))
+	}
+}
+
+func TestReaderConfigValidate(t *testing.T) {
+	consumer := func(context.Context, *Change) error { return nil }
+	for _, cfg := range []ReaderConfig{
+		{Consumer: consumer},
+		{Tables: []string{"t"}, Consumer: consumer},
+		{Tables: []string{"ks.t"}},
+		{Tables: []string{"ks.t"}, Consumer: consumer, WindowSize: -time.Second},
+		{Tables: []string{"ks.t"}, Consumer: consumer, StartTime: time.Now(), EndTime: time.Now().Add(-time.Hour)},
+	} {
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("expected %+v to be invalid", cfg)
+		}
+	}
+}
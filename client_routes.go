@@ -479,7 +479,7 @@ func (p *ClientRoutesHandler) Initialize(s *Session) error {
 	p.startReadingEvents()
 	err := p.updateHostPortMappingSync(connectionIDs, nil)
 	if err != nil {
-		p.log.Printf("error updating host ports: %v\n", err)
+		logEntry(p.log, LogLevelError, "gocql: error updating host ports", "err", err)
 	}
 	return nil
 }
@@ -594,11 +594,11 @@ func (p *ClientRoutesHandler) startReadingEvents() {
 			case *events.ClientRoutesChangedEvent:
 				if debug.Enabled {
 					if len(evt.ConnectionIDs) == 0 {
-						p.log.Printf("got CLIENT_ROUTES_CHANGE event with no connection IDs")
+						logEntry(p.log, LogLevelDebug, "gocql: got CLIENT_ROUTES_CHANGE event with no connection IDs")
 						continue
 					}
 					if len(evt.HostIDs) == 0 {
-						p.log.Printf("got CLIENT_ROUTES_CHANGE event with no host IDs")
+						logEntry(p.log, LogLevelDebug, "gocql: got CLIENT_ROUTES_CHANGE event with no host IDs")
 						continue
 					}
 				}
@@ -633,7 +633,7 @@ func (p *ClientRoutesHandler) startUpdateWorker() {
 			err := p.updateHostPortMapping(task.connectionIDs, task.hostIDs)
 			if err != nil {
 				if debug.Enabled {
-					p.log.Printf("failed to update host port mapping: %v", err)
+					logEntry(p.log, LogLevelDebug, "gocql: failed to update host port mapping", "err", err)
 				}
 			}
 			if task.result != nil {
@@ -654,7 +654,7 @@ func (p *ClientRoutesHandler) updateHostPortMapping(connectionIDs []string, host
 	updated.MergeWithUnresolved(unresolved)
 	err = p.resolveAndUpdateInPlace(updated)
 	if err != nil {
-		p.log.Printf("failed to resolve endpoints: %v", err)
+		logEntry(p.log, LogLevelWarn, "gocql: failed to resolve endpoints", "err", err)
 		// Despite an error it is better to save results, it should not corrupt existing and resolved records
 	}
 
@@ -668,7 +668,7 @@ func (p *ClientRoutesHandler) updateHostPortMapping(connectionIDs []string, host
 		current = p.resolvedEndpoints.Load()
 		updated.MergeWithResolved(current)
 	}
-	p.log.Printf("failed to update host port mapping due to collisions")
+	logEntry(p.log, LogLevelWarn, "gocql: failed to update host port mapping due to collisions")
 
 	return nil
 }
@@ -43,6 +43,7 @@ func (f *fakeControlConn) connect(hosts []*HostInfo) error                 { ret
 func (f *fakeControlConn) close()                                          {}
 func (f *fakeControlConn) getSession() *Session                            { return nil }
 func (f *fakeControlConn) reconnect() error                                { return nil }
+func (f *fakeControlConn) rotate() error                                   { return nil }
 
 type testHostInfo struct {
 	hostID string
@@ -50,6 +50,10 @@ type PoolConfig struct {
 	// It is not supported to use a single HostSelectionPolicy in multiple sessions
 	// (even if you close the old session before using in a new session).
 	HostSelectionPolicy HostSelectionPolicy
+	// RateLimiter, if set, enables client side admission control of requests per host and shard,
+	// see RateLimiterConfig.
+	// Default: nil
+	RateLimiter *RateLimiterConfig
 }
 
 func (p PoolConfig) buildPool(session *Session) *policyConnPool {
@@ -74,6 +78,8 @@ type ClusterConfig struct {
 	DNSResolver DNSResolver
 	// Logger for this ClusterConfig.
 	// If not specified, defaults to the gocql.defaultLogger.
+	// If the logger implements StructuredLogger, e.g. a logger returned by NewSlogLogger,
+	// the driver logs entries with levels and attributes to it.
 	Logger StdLogger
 	// HostDialer will be used to establish all connections for this Cluster.
 	// Unlike Dialer, HostDialer is responsible for setting up the entire connection, including the TLS session.
@@ -92,6 +98,17 @@ type ClusterConfig struct {
 	// QueryObserver will set the provided query observer on all queries created from this session.
 	// Use it to collect metrics / stats from queries by providing an implementation of QueryObserver.
 	QueryObserver QueryObserver
+	// ExecutionObserver will set the provided execution observer on all queries and batches created from this session.
+	// Unlike QueryObserver and BatchObserver it observes an execution as a whole, including its retries and
+	// speculative executions, which makes it suitable for tracing integrations.
+	ExecutionObserver ExecutionObserver
+	// MetricsExporter, if set, periodically receives a snapshot of the session metrics,
+	// the same snapshot is available on demand from Session.Metrics.
+	// Default: nil
+	MetricsExporter MetricsExporter
+	// MetricsExportInterval is the interval at which the session metrics are passed to MetricsExporter.
+	// Default: 10s
+	MetricsExportInterval time.Duration
 	// AddressTranslator will translate addresses found on peer discovery and/or
 	// node change events.
 	AddressTranslator AddressTranslator
@@ -102,6 +119,8 @@ type ClusterConfig struct {
 	// Compression algorithm.
 	// Default: nil
 	Compressor Compressor
+	// Authenticator authenticates the connections, e.g. PasswordAuthenticator, PlainAuthenticator,
+	// ScramSHA256Authenticator or GSSAPIAuthenticator.
 	// Default: nil
 	Authenticator Authenticator
 	actualSslOpts atomic.Value
@@ -111,6 +130,10 @@ type ClusterConfig struct {
 	// Default retry policy to use for queries.
 	// Default: SimpleRetryPolicy{NumRetries: 3}.
 	RetryPolicy RetryPolicy
+	// ExecutionProfiles are named sets of query defaults selected with Query.ExecutionProfile and
+	// Batch.ExecutionProfile, see ExecutionProfile.
+	// Default: nil
+	ExecutionProfiles map[string]*ExecutionProfile
 	// ConvictionPolicy decides whether to mark host as down based on the error and host info.
 	// Default: SimpleConvictionPolicy
 	ConvictionPolicy ConvictionPolicy
@@ -123,9 +146,18 @@ type ClusterConfig struct {
 	// SslOpts is ignored if HostDialer is set.
 	SslOpts *SslOptions
 	// An Authenticator factory. Can be used to create alternative authenticators.
+	// It's called for every new connection, see CredentialsAuthProvider.
 	// Default: nil
 	AuthProvider       func(h *HostInfo) (Authenticator, error)
 	ClientRoutesConfig *ClientRoutesConfig
+	// WarmStart, if set, enables the on-disk snapshot of the topology and the prepared statements
+	// which speeds up the start of the next session, see WarmStartConfig.
+	// Default: nil
+	WarmStart *WarmStartConfig
+	// Codecs converts between the CQL types and the Go types which can't implement Marshaler and Unmarshaler,
+	// see CodecRegistry. It's used for the values of queries, the routing keys and the results.
+	// Default: nil, only the built-in codecs are used
+	Codecs *CodecRegistry
 	// The version of the driver that is going to be reported to the server.
 	// Defaulted to current library version
 	DriverVersion string
@@ -168,6 +200,9 @@ type ClusterConfig struct {
 	// If it is 0 or unset (the default) then the driver will attempt to discover the
 	// highest supported protocol for the cluster. In clusters with nodes of different
 	// versions the protocol selected is not defined (ie, it can be any of the supported in the cluster)
+	//
+	// Protocol version 5 is never discovered automatically and has to be set explicitly. With version 5
+	// frames are exchanged in checksummed segments and only lz4 compression is supported.
 	ProtoVersion int
 	// Maximum number of inflight requests allowed per connection.
 	// Default: 32768 for CQL v3 and newer
@@ -431,21 +466,27 @@ func (cfg *ClusterConfig) ValidateAndInitSSL() error {
 	if cfg.SslOpts == nil {
 		return nil
 	}
-	actualTLSConfig, err := setupTLSConfig(cfg.SslOpts)
+	source, err := newTLSConfigSource(cfg.SslOpts, cfg.logger())
 	if err != nil {
 		return fmt.Errorf("failed to initialize ssl configuration: %s", err.Error())
 	}
 
-	cfg.actualSslOpts.Store(actualTLSConfig)
+	cfg.actualSslOpts.Store(source)
 	return nil
 }
 
 func (cfg *ClusterConfig) getActualTLSConfig() *tls.Config {
-	val, ok := cfg.actualSslOpts.Load().(*tls.Config)
-	if !ok {
+	source := cfg.getTLSConfigSource()
+	if source == nil {
 		return nil
 	}
-	return val.Clone()
+	return source.get().Clone()
+}
+
+// getTLSConfigSource returns the source of the TLS configs of new connections, nil if TLS is not used.
+func (cfg *ClusterConfig) getTLSConfigSource() *tlsConfigSource {
+	source, _ := cfg.actualSslOpts.Load().(*tlsConfigSource)
+	return source
 }
 
 type ClusterOption func(*ClusterConfig)
@@ -608,6 +649,20 @@ func (cfg *ClusterConfig) Validate() error {
 		return fmt.Errorf("MaxExcessShardConnectionsRate should be positive number or zero")
 	}
 
+	if err := cfg.PoolConfig.RateLimiter.Validate(); err != nil {
+		return fmt.Errorf("PoolConfig.RateLimiter is invalid: %v", err)
+	}
+
+	for name, p := range cfg.ExecutionProfiles {
+		if err := p.Validate(); err != nil {
+			return fmt.Errorf("ExecutionProfiles[%q] is invalid: %v", name, err)
+		}
+	}
+
+	if err := cfg.WarmStart.Validate(); err != nil {
+		return fmt.Errorf("WarmStart is invalid: %v", err)
+	}
+
 	if cfg.ClientRoutesConfig != nil {
 		if cfg.AddressTranslator != nil {
 			return fmt.Errorf("AddressTranslator and ClientRoutesConfig should not be set at the same time")
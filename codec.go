@@ -0,0 +1,258 @@
+package gocql
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"net/netip"
+	"reflect"
+	"sync"
+	"sync/atomic"
+
+	"gopkg.in/inf.v0"
+)
+
+// MarshalFunc encodes value, whose Go type is the type the codec is registered for, into the CQL type info.
+type MarshalFunc func(info TypeInfo, value interface{}) ([]byte, error)
+
+// UnmarshalFunc decodes data of the CQL type info into value, which is a pointer to the Go type
+// the codec is registered for. data is nil if the CQL value is null.
+type UnmarshalFunc func(info TypeInfo, data []byte, value interface{}) error
+
+type codecKey struct {
+	typ    Type
+	goType reflect.Type
+}
+
+type codec struct {
+	marshal   MarshalFunc
+	unmarshal UnmarshalFunc
+}
+
+// CodecRegistry maps pairs of a CQL type and a Go type to the functions which convert between them.
+// It's used for Go types which can't implement Marshaler and Unmarshaler, for example types of other packages.
+// The codecs are consulted before the Marshaler and Unmarshaler interfaces and the built-in conversions,
+// for the values of queries, the destinations of scans and the elements of collections, tuples and user-defined types.
+//
+// The registry returned by NewCodecRegistry contains the built-in codecs:
+//
+//	CQL type                   | Go type      | Note
+//	inet                       | netip.Addr   |
+//	varchar, ascii, text       | netip.Addr   | formatted with netip.Addr.String
+//	varchar, ascii, text       | netip.Prefix | formatted with netip.Prefix.String
+//	decimal                    | big.Float    | the precision of the value is kept, 64 bits if it's zero
+//	float, double              | big.Float    |
+//
+// A nil *CodecRegistry has only the built-in codecs.
+// Codecs can be registered while the registry is in use.
+type CodecRegistry struct {
+	mu     sync.Mutex
+	codecs atomic.Pointer[map[codecKey]codec]
+}
+
+// defaultCodecs is used by Marshal, Unmarshal and a nil *CodecRegistry.
+var defaultCodecs = NewCodecRegistry()
+
+// NewCodecRegistry returns a registry with the built-in codecs.
+func NewCodecRegistry() *CodecRegistry {
+	r := &CodecRegistry{}
+	registerBuiltinCodecs(r)
+	return r
+}
+
+// Register registers the codec of the CQL type typ and the Go type goType, replacing the previous one.
+// goType must not be a pointer type, the pointers to it are dereferenced by Marshal and passed to unmarshal.
+// Either marshal or unmarshal can be nil if the conversion is supported in one direction only.
+func (r *CodecRegistry) Register(typ Type, goType reflect.Type, marshal MarshalFunc, unmarshal UnmarshalFunc) {
+	if goType == nil || goType.Kind() == reflect.Ptr {
+		panic(fmt.Sprintf("gocql: can't register a codec of the Go type %v", goType))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	codecs := make(map[codecKey]codec)
+	if old := r.codecs.Load(); old != nil {
+		for k, v := range *old {
+			codecs[k] = v
+		}
+	}
+	codecs[codecKey{typ: typ, goType: goType}] = codec{marshal: marshal, unmarshal: unmarshal}
+	r.codecs.Store(&codecs)
+}
+
+// RegisterCodec registers the codec of the CQL types and the Go type T, see CodecRegistry.Register.
+func RegisterCodec[T any](r *CodecRegistry, marshal func(info TypeInfo, value T) ([]byte, error), unmarshal func(info TypeInfo, data []byte, value *T) error, types ...Type) {
+	var m MarshalFunc
+	if marshal != nil {
+		m = func(info TypeInfo, value interface{}) ([]byte, error) {
+			return marshal(info, value.(T))
+		}
+	}
+	var u UnmarshalFunc
+	if unmarshal != nil {
+		u = func(info TypeInfo, data []byte, value interface{}) error {
+			return unmarshal(info, data, value.(*T))
+		}
+	}
+	goType := reflect.TypeOf((*T)(nil)).Elem()
+	for _, typ := range types {
+		r.Register(typ, goType, m, u)
+	}
+}
+
+// Marshal is like the package Marshal, but it consults the codecs of the registry.
+func (r *CodecRegistry) Marshal(info TypeInfo, value interface{}) ([]byte, error) {
+	return marshalWith(r, info, value)
+}
+
+// Unmarshal is like the package Unmarshal, but it consults the codecs of the registry.
+func (r *CodecRegistry) Unmarshal(info TypeInfo, data []byte, value interface{}) error {
+	return unmarshalWith(r, info, data, value)
+}
+
+func (r *CodecRegistry) lookup(typ Type, goType reflect.Type) (codec, bool) {
+	if r == nil {
+		r = defaultCodecs
+	}
+	codecs := r.codecs.Load()
+	if codecs == nil || goType == nil {
+		return codec{}, false
+	}
+	c, ok := (*codecs)[codecKey{typ: typ, goType: goType}]
+	return c, ok
+}
+
+func (r *CodecRegistry) marshaler(info TypeInfo, value interface{}) MarshalFunc {
+	c, _ := r.lookup(info.Type(), reflect.TypeOf(value))
+	return c.marshal
+}
+
+func (r *CodecRegistry) unmarshaler(info TypeInfo, value interface{}) UnmarshalFunc {
+	t := reflect.TypeOf(value)
+	if t == nil || t.Kind() != reflect.Ptr {
+		return nil
+	}
+	c, _ := r.lookup(info.Type(), t.Elem())
+	return c.unmarshal
+}
+
+func registerBuiltinCodecs(r *CodecRegistry) {
+	RegisterCodec(r, marshalNetipAddr, unmarshalNetipAddr, TypeInet)
+	RegisterCodec(r, marshalNetipAddrText, unmarshalNetipAddrText, TypeVarchar, TypeAscii, TypeText)
+	RegisterCodec(r, marshalNetipPrefix, unmarshalNetipPrefix, TypeVarchar, TypeAscii, TypeText)
+	RegisterCodec(r, marshalBigFloatDecimal, unmarshalBigFloatDecimal, TypeDecimal)
+	RegisterCodec(r, marshalBigFloat, unmarshalBigFloat, TypeFloat, TypeDouble)
+}
+
+func marshalNetipAddr(info TypeInfo, value netip.Addr) ([]byte, error) {
+	if !value.IsValid() {
+		return nil, nil
+	}
+	return value.AsSlice(), nil
+}
+
+func unmarshalNetipAddr(info TypeInfo, data []byte, value *netip.Addr) error {
+	if len(data) == 0 {
+		*value = netip.Addr{}
+		return nil
+	}
+	addr, ok := netip.AddrFromSlice(data)
+	if !ok {
+		return unmarshalErrorf("unmarshal inet: invalid length %d", len(data))
+	}
+	*value = addr
+	return nil
+}
+
+func marshalNetipAddrText(info TypeInfo, value netip.Addr) ([]byte, error) {
+	if !value.IsValid() {
+		return nil, nil
+	}
+	return []byte(value.String()), nil
+}
+
+func unmarshalNetipAddrText(info TypeInfo, data []byte, value *netip.Addr) error {
+	if len(data) == 0 {
+		*value = netip.Addr{}
+		return nil
+	}
+	addr, err := netip.ParseAddr(string(data))
+	if err != nil {
+		return unmarshalErrorf("unmarshal %s into netip.Addr: %v", info, err)
+	}
+	*value = addr
+	return nil
+}
+
+func marshalNetipPrefix(info TypeInfo, value netip.Prefix) ([]byte, error) {
+	if !value.IsValid() {
+		return nil, nil
+	}
+	return []byte(value.String()), nil
+}
+
+func unmarshalNetipPrefix(info TypeInfo, data []byte, value *netip.Prefix) error {
+	if len(data) == 0 {
+		*value = netip.Prefix{}
+		return nil
+	}
+	prefix, err := netip.ParsePrefix(string(data))
+	if err != nil {
+		return unmarshalErrorf("unmarshal %s into netip.Prefix: %v", info, err)
+	}
+	*value = prefix
+	return nil
+}
+
+func marshalBigFloatDecimal(info TypeInfo, value big.Float) ([]byte, error) {
+	if value.IsInf() {
+		return nil, marshalErrorf("marshal %s: can't marshal infinity", info)
+	}
+	dec, ok := new(inf.Dec).SetString(value.Text('f', -1))
+	if !ok {
+		return nil, marshalErrorf("marshal %s: can't convert %s", info, value.Text('g', -1))
+	}
+	return marshalDecimal(dec)
+}
+
+func unmarshalBigFloatDecimal(info TypeInfo, data []byte, value *big.Float) error {
+	if data == nil {
+		value.SetInt64(0)
+		return nil
+	}
+	var dec inf.Dec
+	if err := unmarshalDecimal(data, &dec); err != nil {
+		return err
+	}
+	if _, ok := value.SetString(dec.String()); !ok {
+		return unmarshalErrorf("unmarshal %s: can't convert %s", info, dec.String())
+	}
+	return nil
+}
+
+func marshalBigFloat(info TypeInfo, value big.Float) ([]byte, error) {
+	if info.Type() == TypeFloat {
+		f, _ := value.Float32()
+		return marshalFloat(f)
+	}
+	f, _ := value.Float64()
+	return marshalDouble(f)
+}
+
+func unmarshalBigFloat(info TypeInfo, data []byte, value *big.Float) error {
+	var f float64
+	if info.Type() == TypeFloat {
+		var f32 float32
+		if err := unmarshalFloat(data, &f32); err != nil {
+			return err
+		}
+		f = float64(f32)
+	} else if err := unmarshalDouble(data, &f); err != nil {
+		return err
+	}
+	if math.IsNaN(f) {
+		return unmarshalErrorf("unmarshal %s: can't convert NaN into big.Float", info)
+	}
+	value.SetFloat64(f)
+	return nil
+}
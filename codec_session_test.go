@@ -0,0 +1,61 @@
+//go:build unit
+// +build unit
+
+package gocql_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+type celsius struct {
+	degrees int32
+}
+
+func TestSessionCodecs(t *testing.T) {
+	c, err := gocqltest.NewCluster(gocqltest.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	c.When(`SELECT temperature FROM ks\.readings WHERE id = \?`).
+		Params(gocqltest.Col("id", gocql.TypeInt)).
+		Handle(func(req *gocqltest.Request) gocqltest.Response {
+			var id int32
+			if err := req.Bind(0, &id); err != nil {
+				return gocqltest.Error(gocql.ErrCodeInvalid, err.Error())
+			}
+			return gocqltest.Rows([]gocqltest.Column{gocqltest.Col("temperature", gocql.TypeInt)}, []interface{}{id * 2})
+		})
+
+	codecs := gocql.NewCodecRegistry()
+	gocql.RegisterCodec(codecs,
+		func(info gocql.TypeInfo, value celsius) ([]byte, error) {
+			return gocql.Marshal(info, value.degrees)
+		},
+		func(info gocql.TypeInfo, data []byte, value *celsius) error {
+			return gocql.Unmarshal(info, data, &value.degrees)
+		},
+		gocql.TypeInt)
+
+	cfg := c.ClusterConfig()
+	cfg.Timeout = time.Second
+	cfg.Codecs = codecs
+	session, err := cfg.CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	var got celsius
+	if err := session.Query(`SELECT temperature FROM ks.readings WHERE id = ?`, celsius{degrees: 21}).Scan(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got.degrees != 42 {
+		t.Fatalf("expected 42 degrees, got %d", got.degrees)
+	}
+}
@@ -0,0 +1,185 @@
+//go:build unit
+// +build unit
+
+package gocql
+
+import (
+	"bytes"
+	"math/big"
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBuiltinCodecs(t *testing.T) {
+	native := func(typ Type) TypeInfo { return NativeType{proto: protoVersion4, typ: typ} }
+
+	addr := netip.MustParseAddr("192.168.1.1")
+	data, err := Marshal(native(TypeInet), addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, []byte{192, 168, 1, 1}) {
+		t.Fatalf("unexpected inet %v", data)
+	}
+	var gotAddr netip.Addr
+	if err := Unmarshal(native(TypeInet), data, &gotAddr); err != nil || gotAddr != addr {
+		t.Fatalf("got %v, %v", gotAddr, err)
+	}
+	if err := Unmarshal(native(TypeInet), nil, &gotAddr); err != nil || gotAddr.IsValid() {
+		t.Fatalf("null should unmarshal into the zero address, got %v, %v", gotAddr, err)
+	}
+
+	var nullable *netip.Addr
+	if err := Unmarshal(native(TypeInet), data, &nullable); err != nil || nullable == nil || *nullable != addr {
+		t.Fatalf("got %v, %v", nullable, err)
+	}
+	if data, err := Marshal(native(TypeInet), &addr); err != nil || len(data) != 4 {
+		t.Fatalf("got %v, %v", data, err)
+	}
+
+	prefix := netip.MustParsePrefix("2001:db8::/32")
+	data, err = Marshal(native(TypeText), prefix)
+	if err != nil || string(data) != "2001:db8::/32" {
+		t.Fatalf("got %q, %v", data, err)
+	}
+	var gotPrefix netip.Prefix
+	if err := Unmarshal(native(TypeText), data, &gotPrefix); err != nil || gotPrefix != prefix {
+		t.Fatalf("got %v, %v", gotPrefix, err)
+	}
+	if err := Unmarshal(native(TypeText), []byte("not a prefix"), &gotPrefix); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	f, _, err := big.ParseFloat("-12345.678", 10, 128, big.ToNearestEven)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err = Marshal(native(TypeDecimal), f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var gotFloat big.Float
+	if err := Unmarshal(native(TypeDecimal), data, &gotFloat); err != nil {
+		t.Fatal(err)
+	}
+	if got := gotFloat.Text('f', 3); got != "-12345.678" {
+		t.Fatalf("got %s", got)
+	}
+	if _, err := Marshal(native(TypeDecimal), new(big.Float).SetInf(false)); err == nil {
+		t.Fatal("expected an error for infinity")
+	}
+
+	data, err = Marshal(native(TypeDouble), big.NewFloat(1.5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Unmarshal(native(TypeDouble), data, &gotFloat); err != nil || gotFloat.Cmp(big.NewFloat(1.5)) != 0 {
+		t.Fatalf("got %v, %v", &gotFloat, err)
+	}
+}
+
+type celsius struct {
+	degrees int32
+}
+
+func TestCodecRegistry(t *testing.T) {
+	r := NewCodecRegistry()
+	RegisterCodec(r,
+		func(info TypeInfo, value celsius) ([]byte, error) {
+			return Marshal(info, value.degrees)
+		},
+		func(info TypeInfo, data []byte, value *celsius) error {
+			return Unmarshal(info, data, &value.degrees)
+		},
+		TypeInt)
+
+	intType := NativeType{proto: protoVersion4, typ: TypeInt}
+	if _, err := Marshal(intType, celsius{degrees: 1}); err == nil {
+		t.Fatal("the package Marshal should not use the codecs of the registry")
+	}
+
+	data, err := r.Marshal(intType, celsius{degrees: 21})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got celsius
+	if err := r.Unmarshal(intType, data, &got); err != nil || got.degrees != 21 {
+		t.Fatalf("got %v, %v", got, err)
+	}
+
+	// the codecs are used for the elements of collections, tuples and user-defined types
+	for _, tc := range []struct {
+		info  TypeInfo
+		value interface{}
+		dest  interface{}
+	}{
+		{
+			info: CollectionType{
+				NativeType: NativeType{proto: protoVersion4, typ: TypeList},
+				Elem:       intType,
+			},
+			value: []celsius{{1}, {2}},
+			dest:  &[]celsius{},
+		},
+		{
+			info: CollectionType{
+				NativeType: NativeType{proto: protoVersion4, typ: TypeMap},
+				Key:        NativeType{proto: protoVersion4, typ: TypeText},
+				Elem:       intType,
+			},
+			value: map[string]celsius{"a": {1}},
+			dest:  &map[string]celsius{},
+		},
+		{
+			info: TupleTypeInfo{
+				NativeType: NativeType{proto: protoVersion4, typ: TypeTuple},
+				Elems:      []TypeInfo{intType, NativeType{proto: protoVersion4, typ: TypeInet}},
+			},
+			value: struct {
+				A celsius
+				B netip.Addr
+			}{celsius{3}, netip.MustParseAddr("::1")},
+			dest: &struct {
+				A celsius
+				B netip.Addr
+			}{},
+		},
+		{
+			info: UDTTypeInfo{
+				NativeType: NativeType{proto: protoVersion4, typ: TypeUDT},
+				Name:       "reading",
+				Elements:   []UDTField{{Name: "temperature", Type: intType}},
+			},
+			value: struct {
+				Temperature celsius `cql:"temperature"`
+			}{celsius{4}},
+			dest: &struct {
+				Temperature celsius `cql:"temperature"`
+			}{},
+		},
+	} {
+		t.Run(tc.info.Type().String(), func(t *testing.T) {
+			data, err := r.Marshal(tc.info, tc.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := r.Unmarshal(tc.info, data, tc.dest); err != nil {
+				t.Fatal(err)
+			}
+			if got := reflect.ValueOf(tc.dest).Elem().Interface(); !reflect.DeepEqual(got, tc.value) {
+				t.Fatalf("got %+v, want %+v", got, tc.value)
+			}
+		})
+	}
+}
+
+func TestCodecRegistryRegisterPointer(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(r.(string), "can't register") {
+			t.Fatalf("expected a panic, got %v", r)
+		}
+	}()
+	NewCodecRegistry().Register(TypeInt, reflect.TypeOf(&celsius{}), nil, nil)
+}
@@ -0,0 +1,330 @@
+package gocql
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// ColumnVector is a column of the rows decoded by ColumnReader. Only the slice of the Go type
+// the CQL type of the column is decoded into is set:
+//
+//	CQL type                                | Field          | Note
+//	tinyint, smallint, int, bigint, counter | Ints           |
+//	time                                    | Ints           | nanoseconds since midnight
+//	timestamp                               | Ints           | milliseconds since Unix epoch
+//	float                                   | Float32s       |
+//	double                                  | Float64s       |
+//	boolean                                 | Bools          |
+//	ascii, varchar, text                    | Strings        |
+//	uuid, timeuuid                          | UUIDs          |
+//	vector<float, n>                        | Float32Vectors |
+//	other types                             | Bytes          | the CQL encoded values
+//
+// The values of the null rows are the zero values.
+type ColumnVector struct {
+	Info ColumnInfo
+	// Nulls reports which of the rows are null.
+	Nulls []bool
+
+	Ints           []int64
+	Float32s       []float32
+	Float64s       []float64
+	Bools          []bool
+	Strings        []string
+	UUIDs          []UUID
+	Float32Vectors [][]float32
+	Bytes          [][]byte
+}
+
+// ColumnReader decodes the rows of pages column by column into typed slices, without the
+// reflection and the allocations of Iter.Scan. The slices of the columns, including the
+// byte slices, are reused by the next call of Read, they must be copied to be retained.
+// The strings are immutable and can be retained, the strings of a column of a page share
+// a single allocation. A ColumnReader must not be used concurrently.
+//
+//	var r gocql.ColumnReader
+//	for page, err := range session.Query(`SELECT id, name FROM users`).Iter().Pages() {
+//		if err != nil {
+//			return err
+//		}
+//		if err := r.Read(page); err != nil {
+//			return err
+//		}
+//		ids, names := r.Columns()[0].Ints, r.Columns()[1].Strings
+//		...
+//	}
+type ColumnReader struct {
+	columns []ColumnVector
+	state   []columnState
+	numRows int
+}
+
+type columnKind uint8
+
+const (
+	columnBytes columnKind = iota
+	columnInt8
+	columnInt16
+	columnInt32
+	columnInt64
+	columnFloat32
+	columnFloat64
+	columnBool
+	columnString
+	columnUUID
+	columnFloat32Vector
+)
+
+// columnState holds the buffers of a column which are reused by the next Read.
+type columnState struct {
+	kind columnKind
+	dims int
+	// arena holds the strings and the byte slices, ends is the end offset of the value of each row
+	arena  []byte
+	ends   []int
+	floats []float32
+}
+
+func columnKindOf(info TypeInfo) (columnKind, int) {
+	switch info.Type() {
+	case TypeTinyInt:
+		return columnInt8, 0
+	case TypeSmallInt:
+		return columnInt16, 0
+	case TypeInt:
+		return columnInt32, 0
+	case TypeBigInt, TypeCounter, TypeTime, TypeTimestamp:
+		return columnInt64, 0
+	case TypeFloat:
+		return columnFloat32, 0
+	case TypeDouble:
+		return columnFloat64, 0
+	case TypeBoolean:
+		return columnBool, 0
+	case TypeAscii, TypeVarchar, TypeText:
+		return columnString, 0
+	case TypeUUID, TypeTimeUUID:
+		return columnUUID, 0
+	case TypeCustom:
+		if vector, ok := info.(VectorType); ok && vector.SubType.Type() == TypeFloat {
+			return columnFloat32Vector, vector.Dimensions
+		}
+	}
+	return columnBytes, 0
+}
+
+// NumRows returns the number of rows decoded by the last Read.
+func (r *ColumnReader) NumRows() int {
+	return r.numRows
+}
+
+// Columns returns the columns decoded by the last Read, in the order of the columns of the result.
+func (r *ColumnReader) Columns() []ColumnVector {
+	return r.columns
+}
+
+// Read decodes the remaining rows of the page, see Page.Rows. The next page is prefetched
+// if Query.Prefetch is set. An error is also recorded on the Iter of the page.
+func (r *ColumnReader) Read(p *Page) error {
+	it := p.iter
+	r.numRows = 0
+	if it.err != nil {
+		return it.err
+	}
+	if it.next != nil {
+		it.next.fetchAsync()
+	}
+
+	n := it.numRows - it.pos
+	r.reset(it.meta.columns, n)
+	for row := 0; row < n; row++ {
+		for c := range r.columns {
+			data, err := it.readColumn()
+			if err != nil {
+				it.err = err
+				return err
+			}
+			if err := r.decode(c, row, data); err != nil {
+				it.err = fmt.Errorf("gocql: can not decode column %q: %w", r.columns[c].Info.Name, err)
+				return it.err
+			}
+		}
+		it.pos++
+	}
+	r.finish(n)
+	r.numRows = n
+	return nil
+}
+
+// reset prepares the slices of the columns for n rows, reusing the buffers of the previous Read.
+func (r *ColumnReader) reset(columns []ColumnInfo, n int) {
+	if len(r.columns) != len(columns) {
+		r.columns = make([]ColumnVector, len(columns))
+		r.state = make([]columnState, len(columns))
+	}
+
+	for c, info := range columns {
+		col, state := &r.columns[c], &r.state[c]
+		kind, dims := columnKindOf(info.TypeInfo)
+		if kind != state.kind || dims != state.dims {
+			*col = ColumnVector{}
+			*state = columnState{kind: kind, dims: dims}
+		}
+		col.Info = info
+		col.Nulls = resize(col.Nulls, n)
+
+		switch kind {
+		case columnInt8, columnInt16, columnInt32, columnInt64:
+			col.Ints = resize(col.Ints, n)
+		case columnFloat32:
+			col.Float32s = resize(col.Float32s, n)
+		case columnFloat64:
+			col.Float64s = resize(col.Float64s, n)
+		case columnBool:
+			col.Bools = resize(col.Bools, n)
+		case columnString:
+			col.Strings = resize(col.Strings, n)
+			state.arena, state.ends = state.arena[:0], resize(state.ends, n)
+		case columnUUID:
+			col.UUIDs = resize(col.UUIDs, n)
+		case columnFloat32Vector:
+			col.Float32Vectors = resize(col.Float32Vectors, n)
+			state.floats = resize(state.floats, n*dims)
+		default:
+			col.Bytes = resize(col.Bytes, n)
+			state.arena, state.ends = state.arena[:0], resize(state.ends, n)
+		}
+	}
+}
+
+func resize[T any](s []T, n int) []T {
+	if cap(s) < n {
+		return make([]T, n)
+	}
+	return s[:n]
+}
+
+// decode decodes the value of the column c of the row. Empty values of the types which aren't
+// strings or bytes are null.
+func (r *ColumnReader) decode(c, row int, data []byte) error {
+	col, state := &r.columns[c], &r.state[c]
+	null := data == nil || len(data) == 0 && state.kind != columnString && state.kind != columnBytes
+	col.Nulls[row] = null
+
+	switch state.kind {
+	case columnInt8:
+		if null {
+			col.Ints[row] = 0
+		} else if len(data) != 1 {
+			return unmarshalErrorf("expected 1 byte, got %d", len(data))
+		} else {
+			col.Ints[row] = int64(int8(data[0]))
+		}
+	case columnInt16:
+		if null {
+			col.Ints[row] = 0
+		} else if len(data) != 2 {
+			return unmarshalErrorf("expected 2 bytes, got %d", len(data))
+		} else {
+			col.Ints[row] = int64(int16(binary.BigEndian.Uint16(data)))
+		}
+	case columnInt32:
+		if null {
+			col.Ints[row] = 0
+		} else if len(data) != 4 {
+			return unmarshalErrorf("expected 4 bytes, got %d", len(data))
+		} else {
+			col.Ints[row] = int64(int32(binary.BigEndian.Uint32(data)))
+		}
+	case columnInt64:
+		if null {
+			col.Ints[row] = 0
+		} else if len(data) != 8 {
+			return unmarshalErrorf("expected 8 bytes, got %d", len(data))
+		} else {
+			col.Ints[row] = int64(binary.BigEndian.Uint64(data))
+		}
+	case columnFloat32:
+		if null {
+			col.Float32s[row] = 0
+		} else if len(data) != 4 {
+			return unmarshalErrorf("expected 4 bytes, got %d", len(data))
+		} else {
+			col.Float32s[row] = math.Float32frombits(binary.BigEndian.Uint32(data))
+		}
+	case columnFloat64:
+		if null {
+			col.Float64s[row] = 0
+		} else if len(data) != 8 {
+			return unmarshalErrorf("expected 8 bytes, got %d", len(data))
+		} else {
+			col.Float64s[row] = math.Float64frombits(binary.BigEndian.Uint64(data))
+		}
+	case columnBool:
+		if null {
+			col.Bools[row] = false
+		} else if len(data) != 1 {
+			return unmarshalErrorf("expected 1 byte, got %d", len(data))
+		} else {
+			col.Bools[row] = data[0] != 0
+		}
+	case columnUUID:
+		if null {
+			col.UUIDs[row] = UUID{}
+		} else if len(data) != 16 {
+			return unmarshalErrorf("expected 16 bytes, got %d", len(data))
+		} else {
+			col.UUIDs[row] = UUID(data)
+		}
+	case columnFloat32Vector:
+		vector := state.floats[row*state.dims : (row+1)*state.dims : (row+1)*state.dims]
+		if null {
+			clear(vector)
+			col.Float32Vectors[row] = nil
+			return nil
+		}
+		if len(data) != 4*state.dims {
+			return unmarshalErrorf("expected %d bytes of a vector of %d dimensions, got %d", 4*state.dims, state.dims, len(data))
+		}
+		for i := range vector {
+			vector[i] = math.Float32frombits(binary.BigEndian.Uint32(data[4*i:]))
+		}
+		col.Float32Vectors[row] = vector
+	default:
+		// the strings and the byte slices point into the arena once all the rows are read
+		state.arena = append(state.arena, data...)
+		state.ends[row] = len(state.arena)
+	}
+	return nil
+}
+
+// finish points the strings and the byte slices of the n rows into the arenas of the columns.
+// The strings are substrings of a copy of the arena, they must not change with the next Read.
+func (r *ColumnReader) finish(n int) {
+	for c := range r.columns {
+		col, state := &r.columns[c], &r.state[c]
+		if state.kind != columnString && state.kind != columnBytes {
+			continue
+		}
+
+		var text string
+		if state.kind == columnString {
+			text = string(state.arena)
+		}
+		start := 0
+		for row := 0; row < n; row++ {
+			end := state.ends[row]
+			value := state.arena[start:end:end]
+
+			if state.kind == columnString {
+				col.Strings[row] = text[start:end]
+			} else if col.Nulls[row] {
+				col.Bytes[row] = nil
+			} else {
+				col.Bytes[row] = value
+			}
+			start = end
+		}
+	}
+}
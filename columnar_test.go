@@ -0,0 +1,127 @@
+//go:build unit
+// +build unit
+
+package gocql
+
+import (
+	"reflect"
+	"testing"
+)
+
+// cellFramer returns the cells of the rows in order.
+type cellFramer struct {
+	cells [][]byte
+}
+
+func (f *cellFramer) ReadBytesInternal() ([]byte, error) {
+	cell := f.cells[0]
+	f.cells = f.cells[1:]
+	return cell, nil
+}
+
+func (f *cellFramer) GetCustomPayload() map[string][]byte { return nil }
+func (f *cellFramer) GetHeaderWarnings() []string         { return nil }
+
+func TestColumnReader(t *testing.T) {
+	native := func(typ Type) TypeInfo { return NativeType{proto: protoVersion4, typ: typ} }
+	vector := VectorType{NativeType: NativeType{proto: protoVersion4, typ: TypeCustom}, SubType: native(TypeFloat), Dimensions: 2}
+	columns := []ColumnInfo{
+		{Name: "id", TypeInfo: native(TypeUUID)},
+		{Name: "n", TypeInfo: native(TypeSmallInt)},
+		{Name: "name", TypeInfo: native(TypeText)},
+		{Name: "score", TypeInfo: native(TypeDouble)},
+		{Name: "embedding", TypeInfo: vector},
+		{Name: "tags", TypeInfo: CollectionType{NativeType: NativeType{proto: protoVersion4, typ: TypeList}, Elem: native(TypeInt)}},
+	}
+	id := MustRandomUUID()
+	rows := [][]interface{}{
+		{id, int16(-3), "first", 1.5, []float32{1, 2}, []int{1}},
+		{nil, nil, nil, nil, nil, nil},
+		{id, int16(7), "", 0.0, []float32{3, 4}, []int{}},
+	}
+
+	var cells [][]byte
+	for _, row := range rows {
+		for i, v := range row {
+			data, err := Marshal(columns[i].TypeInfo, v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cells = append(cells, data)
+		}
+	}
+	newPage := func() *Page {
+		f := &cellFramer{cells: cells}
+		return &Page{iter: &Iter{framer: f, meta: resultMetadata{columns: columns, actualColCount: len(columns)}, numRows: len(rows)}}
+	}
+
+	var r ColumnReader
+	for i := 0; i < 2; i++ {
+		if err := r.Read(newPage()); err != nil {
+			t.Fatal(err)
+		}
+		if r.NumRows() != 3 {
+			t.Fatalf("expected 3 rows, got %d", r.NumRows())
+		}
+		cols := r.Columns()
+		for c, col := range cols {
+			if !reflect.DeepEqual(col.Nulls, []bool{false, true, false}) {
+				t.Fatalf("unexpected nulls of column %s: %v", col.Info.Name, col.Nulls)
+			}
+			if col.Info.Name != columns[c].Name {
+				t.Fatalf("unexpected column %s", col.Info.Name)
+			}
+		}
+		if !reflect.DeepEqual(cols[0].UUIDs, []UUID{id, {}, id}) {
+			t.Errorf("unexpected uuids %v", cols[0].UUIDs)
+		}
+		if !reflect.DeepEqual(cols[1].Ints, []int64{-3, 0, 7}) {
+			t.Errorf("unexpected ints %v", cols[1].Ints)
+		}
+		if !reflect.DeepEqual(cols[2].Strings, []string{"first", "", ""}) {
+			t.Errorf("unexpected strings %q", cols[2].Strings)
+		}
+		if !reflect.DeepEqual(cols[3].Float64s, []float64{1.5, 0, 0}) {
+			t.Errorf("unexpected doubles %v", cols[3].Float64s)
+		}
+		if !reflect.DeepEqual(cols[4].Float32Vectors, [][]float32{{1, 2}, nil, {3, 4}}) {
+			t.Errorf("unexpected vectors %v", cols[4].Float32Vectors)
+		}
+		if len(cols[5].Bytes) != 3 || cols[5].Bytes[1] != nil || len(cols[5].Bytes[0]) != 12 {
+			t.Errorf("unexpected bytes %v", cols[5].Bytes)
+		}
+		if cols[0].Ints != nil || cols[1].Strings != nil {
+			t.Error("only the slice of the type of the column should be set")
+		}
+	}
+
+	// the strings are immutable
+	first := r.Columns()[2].Strings[0]
+	page := newPage()
+	page.iter.framer.(*cellFramer).cells = append([][]byte{cells[0], cells[1], []byte("other")}, cells[3:]...)
+	if err := r.Read(page); err != nil {
+		t.Fatal(err)
+	}
+	if first != "first" || r.Columns()[2].Strings[0] != "other" {
+		t.Fatalf("unexpected strings %q and %q", first, r.Columns()[2].Strings[0])
+	}
+
+	// the buffers are reused, only the strings of the text column are allocated
+	page = newPage()
+	allocs := testing.AllocsPerRun(10, func() {
+		page.iter.framer.(*cellFramer).cells = cells
+		page.iter.pos = 0
+		if err := r.Read(page); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if allocs != 1 {
+		t.Errorf("Read allocated %v times, expected a single allocation", allocs)
+	}
+
+	page = newPage()
+	page.iter.framer.(*cellFramer).cells = append([][]byte{cells[0], {1}}, cells[2:]...)
+	if err := r.Read(page); err == nil || page.iter.err == nil {
+		t.Fatal("expected an error for an invalid smallint")
+	}
+}
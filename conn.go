@@ -127,6 +127,15 @@ type SslOptions struct {
 	//
 	// See SslOptions documentation to see how EnableHostVerification interacts with the provided tls.Config.
 	EnableHostVerification bool
+	// ReloadInterval enables reloading CertPath, KeyPath and CaPath once they change, so new connections use
+	// rotated certificates without recreating the session. The files are checked at most once per interval,
+	// when connections are opened; if they can't be loaded, e.g. while they are being replaced, the previous
+	// ones are used. Session.RotateCredentials reloads the files and replaces the open connections.
+	//
+	// Callbacks of Config such as GetClientCertificate and VerifyConnection are called for every new
+	// connection, so they can also provide rotated material.
+	// Default: 0, the files are loaded once
+	ReloadInterval time.Duration
 }
 
 type ConnConfig struct {
@@ -191,6 +200,12 @@ type Conn struct {
 	supported      map[string][]string
 	streams        *streams.IDGenerator
 	host           *HostInfo
+	// segmentCodec is set once protocol v5 modern framing is enabled, frames are then
+	// wrapped into segments and compressed per segment rather than per frame.
+	segmentCodec *segmentCodec
+	// counters are the session metrics of the host and shard of the connection,
+	// they are set once the shard is known.
+	counters *connCounters
 	// calls stores a map from stream ID to callReq.
 	// This map is protected by mu.
 	// calls should not be used when closed is true, calls is set to nil when closed=true.
@@ -564,6 +579,7 @@ func (s *startupCoordinator) options(ctx context.Context) error {
 		s.conn.host.setScyllaFeatures(s.conn.scyllaSupported.ScyllaHostFeatures)
 	}
 	s.conn.cqlProtoExts = parseCQLProtocolExtensions(s.conn.supported, s.conn.logger)
+	s.conn.counters = s.conn.session.metrics.connCounters(s.conn.host.HostID(), s.conn.observedShard())
 
 	return s.startup(ctx)
 }
@@ -579,6 +595,13 @@ func (s *startupCoordinator) startup(ctx context.Context) error {
 	m["DRIVER_NAME"] = s.conn.session.cfg.DriverName
 	m["DRIVER_VERSION"] = s.conn.session.cfg.DriverVersion
 
+	if s.conn.compressor != nil && s.conn.version >= protoVersion5 && s.conn.compressor.Name() != "lz4" {
+		// protocol v5 compresses segments instead of frames and only supports lz4
+		logEntry(s.conn.logger, LogLevelWarn, "gocql: compression is not supported with the protocol version, disabling compression",
+			"addr", s.conn.addr, "compressor", s.conn.compressor.Name(), "protocol", s.conn.version)
+		s.conn.compressor = nil
+	}
+
 	if s.conn.compressor != nil {
 		comp := s.conn.supported["COMPRESSION"]
 		name := s.conn.compressor.Name()
@@ -610,8 +633,10 @@ func (s *startupCoordinator) startup(ctx context.Context) error {
 	case error:
 		return v
 	case *frm.ReadyFrame:
+		s.conn.enableSegmentFraming()
 		return nil
 	case *frm.AuthenticateFrame:
+		s.conn.enableSegmentFraming()
 		return s.authenticateHandshake(ctx, v)
 	default:
 		return NewErrProtocol("Unknown type of response to startup frame: %s", v)
@@ -644,6 +669,9 @@ func (s *startupCoordinator) authenticateHandshake(ctx context.Context, authFram
 			}
 			return nil
 		case *frm.AuthChallengeFrame:
+			if challenger == nil {
+				return fmt.Errorf("unexpected authentication challenge (using %q)", authFrame.Class)
+			}
 			resp, challenger, err = challenger.Challenge(v.Data)
 			if err != nil {
 				return err
@@ -658,6 +686,19 @@ func (s *startupCoordinator) authenticateHandshake(ctx context.Context, authFram
 	}
 }
 
+// enableSegmentFraming switches the connection to protocol v5 modern framing, which is used
+// for every message following the response to STARTUP. It is a no-op for older protocol versions.
+func (c *Conn) enableSegmentFraming() {
+	if c.version < protoVersion5 {
+		return
+	}
+
+	c.segmentCodec = newSegmentCodec(c.compressor)
+	// compression, if negotiated, is applied to segments from now on
+	c.compressor = nil
+	c.r = bufio.NewReader(newSegmentReader(c.r, c.segmentCodec))
+}
+
 func (c *Conn) closeWithError(err error) {
 	if c == nil {
 		return
@@ -876,7 +917,8 @@ func (c *Conn) recv(ctx context.Context) error {
 	delete(c.calls, head.Stream)
 	c.mu.Unlock()
 	if call == nil || !ok {
-		c.logger.Printf("gocql: received response for stream which has no handler: header=%v\n", head)
+		logEntry(c.logger, LogLevelWarn, "gocql: received response for stream which has no handler",
+			"addr", c.addr, "shard", c.observedShard(), "header", head)
 		return c.discardFrame(head)
 	} else if head.Stream != call.streamID {
 		panic(fmt.Sprintf("call has incorrect streamID: got %d expected %d", call.streamID, head.Stream))
@@ -891,6 +933,11 @@ func (c *Conn) recv(ctx context.Context) error {
 		if _, ok := err.(net.Error); ok {
 			return err
 		}
+		// a corrupted segment leaves the stream in an unknown position
+		var checksumErr *SegmentChecksumError
+		if errors.As(err, &checksumErr) {
+			return err
+		}
 	}
 
 	// we either, return a response to the caller, the caller timedout, or the
@@ -1182,8 +1229,10 @@ func (c *Conn) exec(ctx context.Context, req frameBuilder, tracer Tracer, reques
 	// TODO: move tracer onto conn
 	stream, ok := c.streams.GetStream()
 	if !ok {
+		c.counters.noStreams()
 		return nil, &QueryError{err: ErrNoStreams, potentiallyExecuted: false}
 	}
+	c.counters.request()
 
 	// resp is basically a waiting semaphore protecting the framer
 	framer := newFramerWithExts(c.compressor, c.version, c.cqlProtoExts, c.logger)
@@ -1218,6 +1267,9 @@ func (c *Conn) exec(ctx context.Context, req frameBuilder, tracer Tracer, reques
 	}
 
 	err := req.buildFrame(framer, stream)
+	if err == nil && c.segmentCodec != nil {
+		framer.buf, err = c.segmentCodec.encode(framer.buf)
+	}
 	if err != nil {
 		// closeWithError will block waiting for this stream to either receive a response
 		// or for us to timeout.
@@ -1314,6 +1366,7 @@ func (c *Conn) exec(ctx context.Context, req frameBuilder, tracer Tracer, reques
 		return resp.framer, nil
 	case <-timeoutCh:
 		close(call.timeout)
+		c.counters.timeout()
 		return nil, &QueryError{err: ErrTimeoutNoResponse, potentiallyExecuted: true}
 	case <-ctxDone:
 		close(call.timeout)
@@ -1380,18 +1433,42 @@ type inflightPrepare struct {
 	done chan struct{}
 	err  error
 
+	// hostID, keyspace and statement identify the statement in the warm start snapshot.
+	hostID    string
+	keyspace  string
+	statement string
+
 	preparedStatment *preparedStatment
 }
 
-func (c *Conn) prepareStatement(ctx context.Context, stmt string, tracer Tracer, requestTimeout time.Duration) (*preparedStatment, error) {
-	stmtCacheKey := c.session.stmtsLRU.keyFor(c.host.HostID(), c.currentKeyspace, stmt)
+// statementKeyspace returns the keyspace statements are prepared in, the keyspace of the connection
+// unless the query sets one.
+func (c *Conn) statementKeyspace(keyspace string) string {
+	if keyspace == "" {
+		return c.currentKeyspace
+	}
+	return keyspace
+}
+
+// prepareStatement prepares the statement in the keyspace, the keyspace of the connection if it's empty.
+// Another keyspace requires protocol 5 or higher.
+func (c *Conn) prepareStatement(ctx context.Context, stmt, keyspace string, tracer Tracer, requestTimeout time.Duration) (*preparedStatment, error) {
+	keyspace = c.statementKeyspace(keyspace)
+	if keyspace != c.currentKeyspace && c.version < protoVersion5 {
+		return nil, fmt.Errorf("gocql: unable to prepare a statement in keyspace %q: %w", keyspace, ErrRequiresProtoV5)
+	}
+	stmtCacheKey := c.session.stmtsLRU.keyFor(c.host.HostID(), keyspace, stmt)
 	flight, ok := c.session.stmtsLRU.execIfMissing(stmtCacheKey, func(lru *lru.Cache) *inflightPrepare {
 		flight := &inflightPrepare{
-			done: make(chan struct{}),
+			done:      make(chan struct{}),
+			hostID:    c.host.HostID(),
+			keyspace:  keyspace,
+			statement: stmt,
 		}
 		lru.Add(stmtCacheKey, flight)
 		return flight
 	})
+	c.session.metrics.preparedCacheLookup(ok)
 
 	if !ok {
 		go func() {
@@ -1401,7 +1478,7 @@ func (c *Conn) prepareStatement(ctx context.Context, stmt string, tracer Tracer,
 				statement: stmt,
 			}
 			if c.version > protoVersion4 {
-				prep.keyspace = c.currentKeyspace
+				prep.keyspace = keyspace
 			}
 
 			// we won the race to do the load, if our context is canceled we shouldnt
@@ -1458,14 +1535,14 @@ func (c *Conn) prepareStatement(ctx context.Context, stmt string, tracer Tracer,
 	}
 }
 
-func marshalQueryValue(typ TypeInfo, value interface{}, dst *queryValues) error {
+func marshalQueryValue(codecs *CodecRegistry, typ TypeInfo, value interface{}, dst *queryValues) error {
 	if named, ok := value.(*namedValue); ok {
 		dst.name = named.name
 		value = named.value
 	}
 
 	if _, ok := value.(unsetColumn); !ok {
-		val, err := Marshal(typ, value)
+		val, err := marshalWith(codecs, typ, value)
 		if err != nil {
 			return err
 		}
@@ -1505,6 +1582,15 @@ func (c *Conn) executeQuery(ctx context.Context, qry *Query) (iter *Iter) {
 	}
 	if c.version > protoVersion4 {
 		params.keyspace = c.currentKeyspace
+		if qry.queryKeyspace != "" {
+			params.keyspace = qry.queryKeyspace
+		}
+		params.nowInSeconds = qry.nowInSeconds
+		params.nowInSecondsValue = qry.nowInSecondsValue
+	} else if qry.queryKeyspace != "" && qry.queryKeyspace != c.currentKeyspace {
+		return &Iter{err: fmt.Errorf("gocql: unable to execute a query in keyspace %q: %w", qry.queryKeyspace, ErrRequiresProtoV5)}
+	} else if qry.nowInSeconds {
+		return &Iter{err: fmt.Errorf("gocql: unable to set now_in_seconds of a query: %w", ErrRequiresProtoV5)}
 	}
 
 	var (
@@ -1515,7 +1601,7 @@ func (c *Conn) executeQuery(ctx context.Context, qry *Query) (iter *Iter) {
 	if !qry.skipPrepare && qry.shouldPrepare() {
 		// Prepare all DML queries. Other queries can not be prepared.
 		var err error
-		info, err = c.prepareStatement(ctx, qry.stmt, qry.trace, qry.GetRequestTimeout())
+		info, err = c.prepareStatement(ctx, qry.stmt, qry.queryKeyspace, qry.trace, qry.GetRequestTimeout())
 		if err != nil {
 			return &Iter{err: err}
 		}
@@ -1543,7 +1629,7 @@ func (c *Conn) executeQuery(ctx context.Context, qry *Query) (iter *Iter) {
 			v := &params.values[i]
 			value := values[i]
 			typ := info.request.columns[i].TypeInfo
-			if err := marshalQueryValue(typ, value, v); err != nil {
+			if err := marshalQueryValue(c.session.cfg.Codecs, typ, value, v); err != nil {
 				return &Iter{err: err}
 			}
 		}
@@ -1554,7 +1640,7 @@ func (c *Conn) executeQuery(ctx context.Context, qry *Query) (iter *Iter) {
 		frame = &writeExecuteFrame{
 			preparedID:    info.id,
 			params:        params,
-			customPayload: qry.customPayload,
+			customPayload: attemptCustomPayload(ctx, qry.customPayload),
 		}
 
 		// Set "lwt", keyspace", "table" property in the query if it is present in preparedMetadata
@@ -1567,7 +1653,7 @@ func (c *Conn) executeQuery(ctx context.Context, qry *Query) (iter *Iter) {
 		frame = &writeQueryFrame{
 			statement:     qry.stmt,
 			params:        params,
-			customPayload: qry.customPayload,
+			customPayload: attemptCustomPayload(ctx, qry.customPayload),
 		}
 	}
 
@@ -1603,6 +1689,7 @@ func (c *Conn) executeQuery(ctx context.Context, qry *Query) (iter *Iter) {
 			meta:    x.meta,
 			framer:  framer,
 			numRows: x.numRows,
+			codecs:  c.session.cfg.Codecs,
 		}
 
 		if params.skipMeta {
@@ -1618,6 +1705,7 @@ func (c *Conn) executeQuery(ctx context.Context, qry *Query) (iter *Iter) {
 			newQry := new(Query)
 			*newQry = *qry
 			newQry.pageState = copyBytes(x.meta.pagingState)
+			newQry.pageNumber = qry.pageNumber + 1
 			newQry.metrics = &queryMetrics{m: make(map[string]*hostMetrics)}
 
 			iter.next = &nextIter{
@@ -1637,14 +1725,14 @@ func (c *Conn) executeQuery(ctx context.Context, qry *Query) (iter *Iter) {
 		iter := &Iter{framer: framer}
 		if err := c.awaitSchemaAgreement(ctx); err != nil {
 			// TODO: should have this behind a flag
-			c.logger.Println(err)
+			logEntry(c.logger, LogLevelWarn, "gocql: schema agreement failed", "addr", c.addr, "err", err)
 		}
 		// dont return an error from this, might be a good idea to give a warning
 		// though. The impact of this returning an error would be that the cluster
 		// is not consistent with regards to its schema.
 		return iter
 	case *RequestErrUnprepared:
-		stmtCacheKey := c.session.stmtsLRU.keyFor(c.host.HostID(), c.currentKeyspace, qry.stmt)
+		stmtCacheKey := c.session.stmtsLRU.keyFor(c.host.HostID(), c.statementKeyspace(qry.queryKeyspace), qry.stmt)
 		c.session.stmtsLRU.evictPreparedID(stmtCacheKey, x.StatementId)
 		return c.executeQuery(ctx, qry)
 	case error:
@@ -1724,7 +1812,20 @@ func (c *Conn) executeBatch(ctx context.Context, batch *Batch) (iter *Iter) {
 		serialConsistency:     batch.serialCons,
 		defaultTimestamp:      batch.defaultTimestamp,
 		defaultTimestampValue: batch.defaultTimestampValue,
-		customPayload:         batch.CustomPayload,
+		customPayload:         attemptCustomPayload(ctx, batch.CustomPayload),
+	}
+	if batch.queryKeyspace != "" && batch.queryKeyspace != c.currentKeyspace {
+		if c.version < protoVersion5 {
+			return &Iter{err: fmt.Errorf("gocql: unable to execute a batch in keyspace %q: %w", batch.queryKeyspace, ErrRequiresProtoV5)}
+		}
+		req.keyspace = batch.queryKeyspace
+	}
+	if batch.nowInSeconds {
+		if c.version < protoVersion5 {
+			return &Iter{err: fmt.Errorf("gocql: unable to set now_in_seconds of a batch: %w", ErrRequiresProtoV5)}
+		}
+		req.nowInSeconds = true
+		req.nowInSecondsValue = batch.nowInSecondsValue
 	}
 
 	stmts := make(map[string]string, len(batch.Entries))
@@ -1736,7 +1837,7 @@ func (c *Conn) executeBatch(ctx context.Context, batch *Batch) (iter *Iter) {
 		b := &req.statements[i]
 
 		if len(entry.Args) > 0 || entry.binding != nil {
-			info, err := c.prepareStatement(batch.Context(), entry.Stmt, batch.trace, batch.GetRequestTimeout())
+			info, err := c.prepareStatement(batch.Context(), entry.Stmt, batch.queryKeyspace, batch.trace, batch.GetRequestTimeout())
 			if err != nil {
 				return &Iter{err: err}
 			}
@@ -1769,7 +1870,7 @@ func (c *Conn) executeBatch(ctx context.Context, batch *Batch) (iter *Iter) {
 				v := &b.values[j]
 				value := values[j]
 				typ := info.request.columns[j].TypeInfo
-				if err := marshalQueryValue(typ, value, v); err != nil {
+				if err := marshalQueryValue(c.session.cfg.Codecs, typ, value, v); err != nil {
 					return &Iter{err: err}
 				}
 			}
@@ -1809,7 +1910,7 @@ func (c *Conn) executeBatch(ctx context.Context, batch *Batch) (iter *Iter) {
 	case *RequestErrUnprepared:
 		stmt, found := stmts[string(x.StatementId)]
 		if found {
-			key := c.session.stmtsLRU.keyFor(c.host.HostID(), c.currentKeyspace, stmt)
+			key := c.session.stmtsLRU.keyFor(c.host.HostID(), c.statementKeyspace(batch.queryKeyspace), stmt)
 			c.session.stmtsLRU.evictPreparedID(key, x.StatementId)
 		}
 		return c.executeBatch(ctx, batch)
@@ -1818,6 +1919,7 @@ func (c *Conn) executeBatch(ctx context.Context, batch *Batch) (iter *Iter) {
 			meta:    x.meta,
 			framer:  framer,
 			numRows: x.numRows,
+			codecs:  c.session.cfg.Codecs,
 		}
 
 		return iter
@@ -1848,7 +1950,7 @@ func getSchemaAgreement(queryLocalSchemasRows []string, querySystemPeersRows []s
 
 	for _, row := range querySystemPeersRows {
 		if !row.IsValid() {
-			logger.Printf("invalid peer or peer with empty schema_version: peer=%q", row)
+			logEntry(logger, LogLevelWarn, "gocql: invalid peer or peer with empty schema_version", "peer", row)
 			continue
 		}
 		versions[row.SchemaVersion.String()] = struct{}{}
@@ -1957,6 +2059,7 @@ var (
 	ErrHostDown            = errors.New("gocql: host is nil or down")
 	ErrNoPool              = errors.New("gocql: host does not have a pool")
 	ErrNoConnectionsInPool = errors.New("gocql: host pool does not have connections")
+	ErrRateLimited         = errors.New("gocql: request rejected by client side rate limiter")
 )
 
 type ErrSchemaMismatch struct {
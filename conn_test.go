@@ -492,6 +492,99 @@ func TestQueryMultinodeWithMetrics(t *testing.T) {
 
 }
 
+type testExecutionObserver struct {
+	mu         sync.Mutex
+	executions []ObservedExecution
+	attempts   []ObservedAttempt
+}
+
+func (o *testExecutionObserver) ExecutionStarted(ctx context.Context, execution ObservedExecution) ExecutionObserverContext {
+	return o
+}
+
+func (o *testExecutionObserver) AttemptStarted(attempt ObservedAttempt) AttemptObserverContext {
+	return o
+}
+
+func (o *testExecutionObserver) CustomPayload() map[string][]byte {
+	return map[string][]byte{"trace": []byte("span")}
+}
+
+func (o *testExecutionObserver) AttemptFinished(attempt ObservedAttempt) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.attempts = append(o.attempts, attempt)
+}
+
+func (o *testExecutionObserver) ExecutionFinished(execution ObservedExecution) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.executions = append(o.executions, execution)
+}
+
+func TestExecutionObserver(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var payloadReqs int64
+	srv := newTestServerOpts{
+		addr:     "127.0.0.1:0",
+		protocol: protoVersion4,
+		recvHook: func(f *framer) {
+			if f.header.Op == frm.OpQuery && f.header.Flags&frm.FlagCustomPayload != 0 {
+				// consume the payload, the test server does not expect it
+				if payload := f.readBytesMap(); string(payload["trace"]) == "span" {
+					atomic.AddInt64(&payloadReqs, 1)
+				}
+			}
+		},
+	}.newServer(t, ctx)
+	defer srv.Stop()
+
+	db, err := newTestSession(protoVersion4, srv.Address)
+	if err != nil {
+		t.Fatalf("NewCluster: %v", err)
+	}
+	defer db.Close()
+
+	observer := &testExecutionObserver{}
+	rt := &testRetryPolicy{NumRetries: 2}
+	if err := db.Query("kill").RetryPolicy(rt).Idempotent(true).ExecutionObserver(observer).Exec(); err == nil {
+		t.Fatal("expected error")
+	}
+
+	observer.mu.Lock()
+	defer observer.mu.Unlock()
+
+	if len(observer.executions) != 1 {
+		t.Fatalf("expected one execution, got %d", len(observer.executions))
+	}
+	execution := observer.executions[0]
+	if execution.Statement != "kill" || execution.Err == nil || execution.Attempts != rt.NumRetries+1 {
+		t.Fatalf("unexpected execution: %+v", execution)
+	}
+
+	if len(observer.attempts) != rt.NumRetries+1 {
+		t.Fatalf("expected %d attempts, got %d", rt.NumRetries+1, len(observer.attempts))
+	}
+	for i, attempt := range observer.attempts {
+		if attempt.Attempt != i || attempt.Err == nil || attempt.Host == nil || attempt.SpeculativeExecution != 0 {
+			t.Fatalf("unexpected attempt %d: %+v", i, attempt)
+		}
+		expected := Retry
+		if i == rt.NumRetries {
+			expected = Rethrow
+		}
+		if attempt.RetryDecision != expected {
+			t.Fatalf("expected attempt %d retry decision %v, got %v", i, expected, attempt.RetryDecision)
+		}
+	}
+
+	if n := atomic.LoadInt64(&payloadReqs); n != int64(rt.NumRetries+1) {
+		t.Fatalf("expected every attempt to carry the observer custom payload, got %d of %d", n, rt.NumRetries+1)
+	}
+}
+
 type testRetryPolicy struct {
 	NumRetries int
 }
@@ -567,6 +660,88 @@ func TestSpeculativeExecution(t *testing.T) {
 	}
 }
 
+// orderedExecutionObserver records the order of the events of an execution.
+type orderedExecutionObserver struct {
+	mu        sync.Mutex
+	events    []string
+	execution ObservedExecution
+}
+
+func (o *orderedExecutionObserver) record(event string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.events = append(o.events, event)
+}
+
+func (o *orderedExecutionObserver) ExecutionStarted(ctx context.Context, execution ObservedExecution) ExecutionObserverContext {
+	return o
+}
+
+func (o *orderedExecutionObserver) AttemptStarted(attempt ObservedAttempt) AttemptObserverContext {
+	o.record("attempt started")
+	return o
+}
+
+func (o *orderedExecutionObserver) CustomPayload() map[string][]byte {
+	return nil
+}
+
+func (o *orderedExecutionObserver) AttemptFinished(attempt ObservedAttempt) {
+	o.record("attempt finished")
+}
+
+func (o *orderedExecutionObserver) ExecutionFinished(execution ObservedExecution) {
+	o.record("execution finished")
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.execution = execution
+}
+
+func TestSpeculativeExecutionObserver(t *testing.T) {
+	ctx := context.Background()
+	var nodes []*TestServer
+	for _, ip := range []string{"127.0.0.1", "127.0.0.2"} {
+		srv := NewTestServerWithAddress(ip+":0", t, defaultProto, ctx)
+		defer srv.Stop()
+		nodes = append(nodes, srv)
+	}
+
+	db, err := newTestSession(defaultProto, nodes[0].Address, nodes[1].Address)
+	if err != nil {
+		t.Fatalf("NewCluster: %v", err)
+	}
+	defer db.Close()
+
+	// the execution which loses is still retrying when the other one succeeds
+	observer := &orderedExecutionObserver{}
+	rt := &testRetryPolicy{NumRetries: 8}
+	sp := &SimpleSpeculativeExecution{NumAttempts: 1, TimeoutDelay: 200 * time.Millisecond}
+	qry := db.Query("speculative").RetryPolicy(rt).SetSpeculativeExecutionPolicy(sp).Idempotent(true).ExecutionObserver(observer)
+	if err := qry.Exec(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(300 * time.Millisecond)
+
+	observer.mu.Lock()
+	defer observer.mu.Unlock()
+	var started, finished int
+	for i, event := range observer.events {
+		switch event {
+		case "attempt started":
+			started++
+		case "attempt finished":
+			finished++
+		case "execution finished":
+			if i != len(observer.events)-1 {
+				t.Fatalf("expected the execution to finish after all attempts, got %q", observer.events)
+			}
+		}
+	}
+	if started != finished || started != observer.execution.Attempts {
+		t.Fatalf("expected %d attempts to be started and finished, got %d and %d", observer.execution.Attempts, started, finished)
+	}
+}
+
 // This tests that the policy connection pool handles SSL correctly
 func TestPolicyConnPoolSSL(t *testing.T) {
 	srv := NewSSLTestServer(t, defaultProto, context.Background())
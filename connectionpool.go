@@ -25,6 +25,8 @@
 package gocql
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"math/rand"
 	"net"
@@ -77,10 +79,10 @@ func connConfig(cfg *ClusterConfig) (*ConnConfig, error) {
 		}
 
 		hostDialer = &scyllaDialer{
-			dialer:    dialer,
-			logger:    cfg.logger(),
-			tlsConfig: cfg.getActualTLSConfig(),
-			cfg:       cfg,
+			dialer: dialer,
+			logger: cfg.logger(),
+			tls:    cfg.getTLSConfigSource(),
+			cfg:    cfg,
 		}
 	}
 
@@ -243,6 +245,62 @@ func (p *policyConnPool) addHost(host *HostInfo) {
 	pool.fill_debounce()
 }
 
+// rotate replaces the pools of the hosts one by one, see rotateHost. A host which fails to rotate
+// doesn't stop the rotation of the other hosts, the errors of all hosts are joined.
+func (p *policyConnPool) rotate(ctx context.Context) error {
+	p.mu.RLock()
+	pools := make([]*hostConnPool, 0, len(p.hostConnPools))
+	for _, pool := range p.hostConnPools {
+		pools = append(pools, pool)
+	}
+	p.mu.RUnlock()
+
+	var errs []error
+	for _, pool := range pools {
+		if err := p.rotateHost(ctx, pool.host.HostID()); err != nil {
+			errs = append(errs, fmt.Errorf("gocql: unable to rotate connections of %s: %w", pool.host.ConnectAddressAndPort(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// rotateHost replaces the pool of a host with a new one, the connections of the old pool are closed
+// once their in-flight requests complete or ctx is done. It's a no-op if the host has no connections.
+func (p *policyConnPool) rotateHost(ctx context.Context, hostID string) error {
+	old, ok := p.getPoolByHostID(hostID)
+	if !ok || old.IsClosed() || old.Size() == 0 {
+		return nil
+	}
+
+	pool := newHostConnPool(p.session, old.host, p.numConns, p.keyspace)
+	// keep the state of the rate limiters, the requests in flight on the old pool are still counted
+	pool.limiter = old.limiter
+	// the first connection is made directly, a failure must not mark the host down as the old pool works
+	if err := pool.connect(); err != nil {
+		pool.Close()
+		return err
+	}
+	pool.fill()
+	if err := pool.waitFilling(ctx); err != nil {
+		pool.Close()
+		return err
+	}
+
+	p.mu.Lock()
+	if p.hostConnPools[hostID] != old {
+		// the host was removed or reconnected meanwhile
+		p.mu.Unlock()
+		pool.Close()
+		return nil
+	}
+	p.hostConnPools[hostID] = pool
+	p.mu.Unlock()
+
+	err := old.drain(ctx)
+	old.Close()
+	return err
+}
+
 func (p *policyConnPool) removeHost(hostID string) {
 	p.mu.Lock()
 	pool, ok := p.hostConnPools[hostID]
@@ -265,6 +323,7 @@ type hostConnPool struct {
 	session    *Session
 	host       *HostInfo
 	debouncer  *debounce.SimpleDebouncer
+	limiter    *hostRateLimiter
 	keyspace   string
 	size       int
 	// protection for connPicker, closed, filling
@@ -292,6 +351,7 @@ func newHostConnPool(session *Session, host *HostInfo, size int, keyspace string
 		closed:     false,
 		logger:     session.logger,
 		debouncer:  debounce.NewSimpleDebouncer(),
+		limiter:    newHostRateLimiter(session.cfg.PoolConfig.RateLimiter),
 	}
 
 	// the pool is not filled or connected
@@ -421,6 +481,41 @@ func (pool *hostConnPool) fill() {
 	}()
 }
 
+// waitFilling waits until the pool stops filling.
+func (pool *hostConnPool) waitFilling(ctx context.Context) error {
+	return pollUntil(ctx, func() bool {
+		pool.mu.RLock()
+		defer pool.mu.RUnlock()
+		return !pool.filling
+	})
+}
+
+// drain waits until the pool has no in-flight requests.
+func (pool *hostConnPool) drain(ctx context.Context) error {
+	return pollUntil(ctx, func() bool {
+		pool.mu.RLock()
+		defer pool.mu.RUnlock()
+		// the InFlight of defaultConnPicker is its number of connections
+		if p, ok := pool.connPicker.(*defaultConnPicker); ok {
+			return p.inFlightRequests() == 0
+		}
+		return pool.connPicker.InFlight() == 0
+	})
+}
+
+// pollUntil calls cond periodically until it returns true or ctx is done.
+func pollUntil(ctx context.Context, cond func() bool) error {
+	const interval = 10 * time.Millisecond
+	for !cond() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+	return nil
+}
+
 func (pool *hostConnPool) fill_debounce() {
 	pool.debouncer.Debounce(pool.fill)
 }
@@ -430,11 +525,11 @@ func (pool *hostConnPool) logConnectErr(err error) {
 		// connection refused
 		// these are typical during a node outage so avoid log spam.
 		if debug.Enabled {
-			pool.logger.Printf("unable to dial %q: %v\n", pool.host, err)
+			logEntry(pool.logger, LogLevelDebug, "gocql: unable to dial", "host", pool.host, "err", err)
 		}
 	} else if err != nil {
 		// unexpected error
-		pool.logger.Printf("error: failed to connect to %q due to error: %v", pool.host, err)
+		logEntry(pool.logger, LogLevelError, "gocql: failed to connect", "host", pool.host, "err", err)
 	}
 }
 
@@ -442,7 +537,7 @@ func (pool *hostConnPool) logConnectErr(err error) {
 func (pool *hostConnPool) fillingStopped(err error) {
 	if err != nil {
 		if debug.Enabled {
-			pool.logger.Printf("gocql: filling stopped %q: %v\n", pool.host.ConnectAddress(), err)
+			logEntry(pool.logger, LogLevelDebug, "gocql: filling stopped", "host", pool.host.ConnectAddress(), "err", err)
 		}
 		// wait for some time to avoid back-to-back filling
 		// this provides some time between failed attempts
@@ -460,7 +555,7 @@ func (pool *hostConnPool) fillingStopped(err error) {
 	// if we errored and the size is now zero, make sure the host is marked as down
 	// see https://github.com/apache/cassandra-gocql-driver/issues/1614
 	if debug.Enabled {
-		pool.logger.Printf("gocql: conns of pool after stopped %q: %v\n", host.ConnectAddress(), count)
+		logEntry(pool.logger, LogLevelDebug, "gocql: conns of pool after stopped", "host", host.ConnectAddress(), "count", count)
 	}
 	if err != nil && count == 0 {
 		if pool.session.cfg.ConvictionPolicy.AddFailure(err, host) {
@@ -522,8 +617,8 @@ func (pool *hostConnPool) connect() (err error) {
 			}
 		}
 		if debug.Enabled {
-			pool.logger.Printf("gocql: connection failed %q: %v, reconnecting with %T\n",
-				pool.host.ConnectAddress(), err, reconnectionPolicy)
+			logEntry(pool.logger, LogLevelDebug, "gocql: connection failed, reconnecting",
+				"host", pool.host.ConnectAddress(), "err", err, "policy", fmt.Sprintf("%T", reconnectionPolicy))
 		}
 		time.Sleep(reconnectionPolicy.GetInterval(i))
 	}
@@ -554,7 +649,8 @@ func (pool *hostConnPool) connect() (err error) {
 	if err := pool.connPicker.Put(conn); err != nil {
 		conn.Close()
 		if debug.Enabled {
-			pool.logger.Printf("gocql: pool connection was not added to the pool: %w", err)
+			logEntry(pool.logger, LogLevelDebug, "gocql: pool connection was not added to the pool",
+				"host", pool.host.ConnectAddress(), "shard", conn.observedShard(), "err", err)
 		}
 		return nil
 	}
@@ -594,7 +690,7 @@ func (pool *hostConnPool) HandleError(conn *Conn, err error, closed bool) {
 	}
 
 	if debug.Enabled {
-		pool.logger.Printf("gocql: pool connection error %q: %v\n", conn.addr, err)
+		logEntry(pool.logger, LogLevelDebug, "gocql: pool connection error", "addr", conn.addr, "shard", conn.observedShard(), "err", err)
 	}
 
 	pool.connPicker.Remove(conn)
@@ -623,6 +719,12 @@ func (pool *hostConnPool) Host() HostInformation {
 	return pool.host
 }
 
+// RateLimiterState returns the state of the client side rate limiters of the shards of the host,
+// nil if rate limiting is disabled.
+func (pool *hostConnPool) RateLimiterState() []RateLimiterState {
+	return pool.limiter.state()
+}
+
 func (pool *hostConnPool) IsClosed() bool {
 	pool.mu.Lock()
 	defer pool.mu.Unlock()
@@ -86,6 +86,17 @@ func (p *defaultConnPicker) InFlight() int {
 	return size
 }
 
+// inFlightRequests returns the number of requests waiting for responses on the connections.
+func (p *defaultConnPicker) inFlightRequests() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	var n int
+	for _, conn := range p.conns {
+		n += conn.streams.InUse()
+	}
+	return n
+}
+
 func (p *defaultConnPicker) Size() (int, int) {
 	size := len(p.conns)
 	return size, p.size - size
@@ -72,6 +72,7 @@ type controlConnection interface {
 	close()
 	getSession() *Session
 	reconnect() error
+	rotate() error
 }
 
 // Ensure that the atomic variable is aligned to a 64bit boundary
@@ -286,14 +287,16 @@ func (c *controlConn) connect(hosts []*HostInfo) error {
 		conn, err = c.session.dial(c.session.ctx, host, &cfg, c)
 		// conn.finalizeConnection() to be called outside of this function, since initialization process is not completed yet
 		if err != nil {
-			c.session.logger.Printf("gocql: unable to dial control conn %v:%v: %v\n", host.ConnectAddress(), host.Port(), err)
+			logEntry(c.session.logger, LogLevelWarn, "gocql: unable to dial control conn",
+				"host", host.ConnectAddress(), "port", host.Port(), "err", err)
 			continue
 		}
 		err = c.setupConn(conn)
 		if err == nil {
 			break
 		}
-		c.session.logger.Printf("gocql: unable setup control conn %v:%v: %v\n", host.ConnectAddress(), host.Port(), err)
+		logEntry(c.session.logger, LogLevelWarn, "gocql: unable setup control conn",
+			"host", host.ConnectAddress(), "port", host.Port(), "err", err)
 		conn.Close()
 		conn = nil
 	}
@@ -410,23 +413,47 @@ func (c *controlConn) reconnect() error {
 
 	err := c.attemptReconnect()
 	if err != nil {
-		err = fmt.Errorf("gocql: unable to reconnect control connection: %w\n", err)
-		c.session.logger.Printf(err.Error())
+		err = fmt.Errorf("gocql: unable to reconnect control connection: %w", err)
+		logEntry(c.session.logger, LogLevelError, "gocql: unable to reconnect control connection", "err", err)
 		return err
 	}
 
 	err = c.session.refreshRingNow()
 	if err != nil {
-		c.session.logger.Printf("gocql: unable to refresh ring: %v\n", err)
+		logEntry(c.session.logger, LogLevelWarn, "gocql: unable to refresh ring", "err", err)
 	}
 
 	err = c.session.metadataDescriber.refreshAllSchema()
 	if err != nil {
-		c.session.logger.Printf("gocql: unable to refresh the schema: %v\n", err)
+		logEntry(c.session.logger, LogLevelWarn, "gocql: unable to refresh the schema", "err", err)
 	}
 	return nil
 }
 
+// rotate replaces the connection with a new one to the same host, the old connection is closed once
+// the new one is set up. The connection is kept if the new one can't be set up.
+func (c *controlConn) rotate() error {
+	if atomic.LoadInt32(&c.state) == controlConnClosing {
+		return fmt.Errorf("control connection is closing")
+	}
+	old := c.getConn()
+	if old == nil {
+		return c.reconnect()
+	}
+
+	conn, err := c.session.connect(c.session.ctx, old.host, c)
+	if err != nil {
+		return fmt.Errorf("gocql: unable to connect control connection: %w", err)
+	}
+	if err := c.setupConn(conn); err != nil {
+		conn.Close()
+		return fmt.Errorf("gocql: unable to setup control connection: %w", err)
+	}
+	conn.finalizeConnection()
+	old.conn.Close()
+	return nil
+}
+
 func (c *controlConn) attemptReconnect() error {
 	hosts := c.session.hostSource.getHostsList()
 	hosts = shuffleHosts(hosts)
@@ -449,8 +476,7 @@ func (c *controlConn) attemptReconnect() error {
 		return nil
 	}
 
-	c.session.logger.Printf("gocql: unable to connect to any ring node: %v\n", err)
-	c.session.logger.Printf("gocql: control falling back to initial contact points.\n")
+	logEntry(c.session.logger, LogLevelWarn, "gocql: unable to connect to any ring node, control falling back to initial contact points", "err", err)
 	// Fallback to initial contact points, as it may be the case that all known initialHosts
 	// changed their IPs while keeping the same hostname(s).
 	initialHosts, resolvErr := resolveInitialEndpoints(c.session.cfg.DNSResolver, c.session.cfg.Hosts, c.session.cfg.Port, c.session.logger)
@@ -468,12 +494,14 @@ func (c *controlConn) attemptReconnectToAnyOfHosts(hosts []*HostInfo) error {
 			if c.session.cfg.ConvictionPolicy.AddFailure(err, host) {
 				c.session.handleNodeDown(host.ConnectAddress(), host.Port())
 			}
-			c.session.logger.Printf("gocql: unable to dial control conn %v:%v: %v\n", host.ConnectAddress(), host.Port(), err)
+			logEntry(c.session.logger, LogLevelWarn, "gocql: unable to dial control conn",
+				"host", host.ConnectAddress(), "port", host.Port(), "err", err)
 			continue
 		}
 		err = c.setupConn(conn)
 		if err != nil {
-			c.session.logger.Printf("gocql: unable setup control conn %v:%v: %v\n", host.ConnectAddress(), host.Port(), err)
+			logEntry(c.session.logger, LogLevelWarn, "gocql: unable setup control conn",
+				"host", host.ConnectAddress(), "port", host.Port(), "err", err)
 			conn.Close()
 			continue
 		}
@@ -547,7 +575,7 @@ func (c *controlConn) runQuery(qry *Query) (iter *Iter) {
 		iter = ch.conn.executeQuery(context.TODO(), qry)
 
 		if debug.Enabled && iter.err != nil {
-			c.session.logger.Printf("control: error executing %q: %v\n", qry.stmt, iter.err)
+			logEntry(c.session.logger, LogLevelWarn, "control: error executing query", "stmt", qry.stmt, "err", iter.err)
 		}
 
 		qry.AddAttempts(1, ch.host)
@@ -122,6 +122,10 @@
 //	}
 //	defer session.Close()
 //
+// The certificates loaded from files can be rotated without recreating the session: with SslOptions.ReloadInterval
+// new connections use the files once they change, and Session.RotateCredentials replaces the open connections,
+// which also picks up the credentials returned by ClusterConfig.AuthProvider.
+//
 // # Data-center awareness and query routing
 //
 // To route queries to local DC first, use DCAwareRoundRobinPolicy. For example, if the datacenter you
@@ -218,6 +222,22 @@
 //
 // See Example for complete example.
 //
+// Rows can also be scanned into structs, with columns mapped to fields by the cql tag or field name,
+// using ScanAll or by ranging over Rows:
+//
+//	type Tweet struct {
+//		ID   gocql.UUID `cql:"id"`
+//		Text string     `cql:"text"`
+//	}
+//
+//	for tweet, err := range gocql.Rows[Tweet](session.Query(`SELECT id, text FROM tweet WHERE timeline = ?`,
+//		"me").WithContext(ctx)) {
+//		if err != nil {
+//			log.Fatal(err)
+//		}
+//		fmt.Println("Tweet:", tweet.ID, tweet.Text)
+//	}
+//
 // # Prepared statements
 //
 // The driver automatically prepares DML queries (SELECT/INSERT/UPDATE/DELETE/BATCH statements) and maintains a cache
@@ -229,6 +249,9 @@
 // The main advantage is the ability to keep the same prepared statement even when you don't
 // want to update some fields, where before you needed to make another prepared statement.
 //
+// Values can also be bound by name, using the bound variables metadata of the prepared statement, with
+// Query.BindStruct and Query.BindMap. Struct fields tagged with omitempty are bound as gocql.UnsetValue when empty.
+//
 // # Executing multiple queries concurrently
 //
 // Session is safe to use from multiple goroutines, so to execute multiple concurrent queries, just execute them
@@ -317,6 +340,10 @@
 // Iter.PageState to Query.PageState of a subsequent query to get the next page. If the length of slice returned
 // by Iter.PageState is zero, there are no more pages available (or an error occurred).
 //
+// Iter.Rows and Iter.Pages return iterators which can be used with range-over-func loops. They close the Iter once
+// the loop ends and yield the error of the query or iteration, if any, as the last element. Iter.Pages keeps the page
+// boundaries and the paging state of every page observable while still prefetching the next page.
+//
 // Using too low values of PageSize will negatively affect performance, a value below 100 is probably too low.
 // While Cassandra returns exactly PageSize items (except for last page) in a page currently, the protocol authors
 // explicitly reserved the right to return smaller or larger amount of items in a page for performance reasons, so don't
@@ -25,7 +25,9 @@
 package gocql
 
 import (
+	"errors"
 	"net"
+	"slices"
 	"sync"
 	"time"
 
@@ -149,21 +151,49 @@ func (s *Session) handleEvent(framer *framer) {
 
 func (s *Session) handleSchemaEvent(frames []frame) {
 	// TODO: debounce events
+	var watched []string
 	for _, frame := range frames {
+		var keyspace string
 		switch f := frame.(type) {
 		case *frm.SchemaChangeKeyspace:
+			keyspace = f.Keyspace
 			s.metadataDescriber.clearSchema(f.Keyspace)
 			s.handleKeyspaceChange(f.Keyspace, f.Change)
 		case *frm.SchemaChangeTable:
+			keyspace = f.Keyspace
 			s.metadataDescriber.clearSchema(f.Keyspace)
 			s.handleTableChange(f.Keyspace, f.Object, f.Change)
 		case *frm.SchemaChangeAggregate:
+			keyspace = f.Keyspace
 			s.metadataDescriber.clearSchema(f.Keyspace)
 		case *frm.SchemaChangeFunction:
+			keyspace = f.Keyspace
 			s.metadataDescriber.clearSchema(f.Keyspace)
 		case *frm.SchemaChangeType:
+			keyspace = f.Keyspace
 			s.metadataDescriber.clearSchema(f.Keyspace)
 		}
+		if keyspace != "" && !slices.Contains(watched, keyspace) && s.metadataDescriber.isWatched(keyspace) {
+			watched = append(watched, keyspace)
+		}
+	}
+	s.refreshWatchedSchema(watched)
+}
+
+// refreshWatchedSchema reloads the metadata of the changed keyspaces watched by WatchSchema,
+// which publishes their updates.
+func (s *Session) refreshWatchedSchema(keyspaces []string) {
+	if len(keyspaces) == 0 {
+		return
+	}
+	if err := s.control.awaitSchemaAgreement(); err != nil {
+		logEntry(s.logger, LogLevelWarn, "gocql: schema agreement failed, the schema updates may be incomplete", "err", err)
+	}
+	for _, keyspace := range keyspaces {
+		err := s.metadataDescriber.refreshSchema(keyspace)
+		if err != nil && !errors.Is(err, ErrKeyspaceDoesNotExist) {
+			logEntry(s.logger, LogLevelWarn, "gocql: unable to refresh the metadata of a watched keyspace", "keyspace", keyspace, "err", err)
+		}
 	}
 }
 
@@ -172,7 +202,7 @@ func (s *Session) handleKeyspaceChange(keyspace, change string) {
 	if change == "DROPPED" || change == "UPDATED" {
 		s.metadataDescriber.RemoveTabletsWithKeyspace(keyspace)
 	}
-	s.policy.KeyspaceChanged(KeyspaceUpdateEvent{Keyspace: keyspace, Change: change})
+	s.policies.KeyspaceChanged(KeyspaceUpdateEvent{Keyspace: keyspace, Change: change})
 }
 
 func (s *Session) handleTableChange(keyspace, table, change string) {
@@ -264,7 +294,7 @@ func (s *Session) handleNodeUp(eventIp net.IP, eventPort int) {
 func (s *Session) startPoolFill(host *HostInfo) {
 	// we let the pool call handleNodeConnected to change the host state
 	s.pool.addHost(host)
-	s.policy.AddHost(host)
+	s.policies.AddHost(host)
 }
 
 func (s *Session) handleNodeConnected(host *HostInfo) {
@@ -275,7 +305,7 @@ func (s *Session) handleNodeConnected(host *HostInfo) {
 	host.setState(NodeUp)
 
 	if !s.cfg.filterHost(host) {
-		s.policy.HostUp(host)
+		s.policies.HostUp(host)
 	}
 }
 
@@ -291,7 +321,7 @@ func (s *Session) handleNodeDown(ip net.IP, port int) {
 			return
 		}
 
-		s.policy.HostDown(host)
+		s.policies.HostDown(host)
 		hostID := host.HostID()
 		s.pool.removeHost(hostID)
 	}
@@ -0,0 +1,166 @@
+package gocql
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// ExecutionProfile holds the defaults of queries and batches of a workload. Profiles are registered
+// by name in ClusterConfig.ExecutionProfiles and selected with Query.ExecutionProfile and
+// Batch.ExecutionProfile, so that workloads with different requirements can share a Session
+// and its connections:
+//
+//	cluster.ExecutionProfiles = map[string]*gocql.ExecutionProfile{
+//		"analytics": {
+//			Consistency:         gocql.One,
+//			Timeout:             time.Minute,
+//			PageSize:            10000,
+//			HostSelectionPolicy: gocql.DCAwareRoundRobinPolicy("analytics"),
+//		},
+//	}
+//	...
+//	iter := session.Query(`SELECT * FROM events`).ExecutionProfile("analytics").Iter()
+//
+// Zero fields are inherited from the ClusterConfig, so Any can't be used as the consistency
+// of a profile.
+type ExecutionProfile struct {
+	// Consistency is the consistency level of queries and batches.
+	Consistency Consistency
+	// SerialConsistency is the serial consistency level of conditional queries and batches.
+	SerialConsistency Consistency
+	// Timeout is the time to wait for a response from the server.
+	Timeout time.Duration
+	// RetryPolicy is the retry policy of queries and batches.
+	RetryPolicy RetryPolicy
+	// SpeculativeExecutionPolicy is the speculative execution policy of idempotent queries and batches.
+	// The session default is NonSpeculativeExecution.
+	SpeculativeExecutionPolicy SpeculativeExecutionPolicy
+	// PageSize is the number of rows fetched per page by queries.
+	PageSize int
+	// HostSelectionPolicy selects the hosts queries and batches are sent to. It is initialized and
+	// notified of topology changes by the session like PoolConfig.HostSelectionPolicy, so it can't be
+	// shared between sessions, but it can be shared by profiles of the same cluster.
+	HostSelectionPolicy HostSelectionPolicy
+}
+
+// Validate checks the profile.
+func (p *ExecutionProfile) Validate() error {
+	if p == nil {
+		return errors.New("profile is nil")
+	}
+	if p.SerialConsistency > 0 && !p.SerialConsistency.IsSerial() {
+		return fmt.Errorf("SerialConsistency is not allowed to be anything else but SERIAL or LOCAL_SERIAL. Recived value: %v", p.SerialConsistency)
+	}
+	if p.Timeout < 0 {
+		return errors.New("Timeout should be positive time.Duration or zero")
+	}
+	if p.PageSize < 0 {
+		return errors.New("PageSize should be positive number or zero")
+	}
+	return nil
+}
+
+// checkExecutionProfile returns an error if the profile with the given name does not exist,
+// the empty name stands for the defaults of the session.
+func (s *Session) checkExecutionProfile(name string) error {
+	if _, ok := s.executionProfiles[name]; name != "" && !ok {
+		return fmt.Errorf("gocql: unknown execution profile %q", name)
+	}
+	return nil
+}
+
+// hostSelectionPolicies are the host selection policies of the session, the default one first,
+// followed by those of the execution profiles.
+type hostSelectionPolicies []HostSelectionPolicy
+
+func newHostSelectionPolicies(policy HostSelectionPolicy, profiles map[string]*ExecutionProfile) hostSelectionPolicies {
+	policies := hostSelectionPolicies{policy}
+	for _, p := range profiles {
+		if p.HostSelectionPolicy != nil && !policies.contains(p.HostSelectionPolicy) {
+			policies = append(policies, p.HostSelectionPolicy)
+		}
+	}
+	return policies
+}
+
+// contains reports whether the policy is one of the policies. Only pointers are compared, comparing
+// other values with == panics if their type is not comparable, e.g. a struct holding a slice.
+func (ps hostSelectionPolicies) contains(policy HostSelectionPolicy) bool {
+	if reflect.TypeOf(policy).Kind() != reflect.Pointer {
+		return false
+	}
+	for _, p := range ps {
+		if reflect.TypeOf(p) == reflect.TypeOf(policy) && p == policy {
+			return true
+		}
+	}
+	return false
+}
+
+// profiles returns the policies of the execution profiles.
+func (ps hostSelectionPolicies) profiles() hostSelectionPolicies {
+	return ps[1:]
+}
+
+func (ps hostSelectionPolicies) AddHost(host *HostInfo) {
+	for _, p := range ps {
+		p.AddHost(host)
+	}
+}
+
+// AddHosts adds the hosts to the policies, using AddHosts if a policy supports it.
+func (ps hostSelectionPolicies) AddHosts(hosts []*HostInfo) {
+	type bulkAddHosts interface {
+		AddHosts([]*HostInfo)
+	}
+	for _, p := range ps {
+		if v, ok := p.(bulkAddHosts); ok {
+			v.AddHosts(hosts)
+			continue
+		}
+		for _, host := range hosts {
+			p.AddHost(host)
+		}
+	}
+}
+
+func (ps hostSelectionPolicies) RemoveHost(host *HostInfo) {
+	for _, p := range ps {
+		p.RemoveHost(host)
+	}
+}
+
+func (ps hostSelectionPolicies) HostUp(host *HostInfo) {
+	for _, p := range ps {
+		p.HostUp(host)
+	}
+}
+
+func (ps hostSelectionPolicies) HostDown(host *HostInfo) {
+	for _, p := range ps {
+		p.HostDown(host)
+	}
+}
+
+func (ps hostSelectionPolicies) SetPartitioner(partitioner string) {
+	for _, p := range ps {
+		p.SetPartitioner(partitioner)
+	}
+}
+
+func (ps hostSelectionPolicies) KeyspaceChanged(update KeyspaceUpdateEvent) {
+	for _, p := range ps {
+		p.KeyspaceChanged(update)
+	}
+}
+
+func (ps hostSelectionPolicies) IsOperational(s *Session) error {
+	for _, p := range ps {
+		if err := p.IsOperational(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
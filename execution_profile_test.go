@@ -0,0 +1,242 @@
+//go:build unit
+// +build unit
+
+package gocql
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type countingHostPolicy struct {
+	HostSelectionPolicy
+	inits int32
+	adds  int32
+	picks int32
+}
+
+func (p *countingHostPolicy) Init(s *Session) {
+	atomic.AddInt32(&p.inits, 1)
+	p.HostSelectionPolicy.Init(s)
+}
+
+func (p *countingHostPolicy) AddHost(host *HostInfo) {
+	atomic.AddInt32(&p.adds, 1)
+	p.HostSelectionPolicy.AddHost(host)
+}
+
+func (p *countingHostPolicy) Pick(qry ExecutableQuery) NextHost {
+	atomic.AddInt32(&p.picks, 1)
+	return p.HostSelectionPolicy.Pick(qry)
+}
+
+func TestExecutionProfileDefaults(t *testing.T) {
+	rt := &SimpleRetryPolicy{NumRetries: 7}
+	spec := &SimpleSpeculativeExecution{NumAttempts: 1, TimeoutDelay: time.Millisecond}
+	policy := RoundRobinHostPolicy()
+	s := &Session{
+		cfg: ClusterConfig{
+			Timeout:           time.Second,
+			SerialConsistency: Serial,
+			RetryPolicy:       &SimpleRetryPolicy{NumRetries: 3},
+		},
+		cons:     Quorum,
+		pageSize: 5000,
+		executionProfiles: map[string]*ExecutionProfile{
+			"analytics": {
+				Consistency:                One,
+				SerialConsistency:          LocalSerial,
+				Timeout:                    time.Minute,
+				RetryPolicy:                rt,
+				SpeculativeExecutionPolicy: spec,
+				PageSize:                   100,
+				HostSelectionPolicy:        policy,
+			},
+			"partial": {Consistency: LocalOne},
+		},
+	}
+
+	q := s.Query("SELECT * FROM t").ExecutionProfile("analytics")
+	if q.cons != One || q.serialCons != LocalSerial || q.requestTimeout != time.Minute ||
+		q.rt != rt || q.spec != spec || q.pageSize != 100 || q.policy != policy {
+		t.Fatalf("profile not applied to query: %+v", q)
+	}
+
+	q.ExecutionProfile("partial")
+	if q.cons != LocalOne || q.serialCons != Serial || q.requestTimeout != time.Second ||
+		q.rt != s.cfg.RetryPolicy || q.pageSize != 5000 || q.policy != nil {
+		t.Fatalf("unexpected query defaults of a partial profile: %+v", q)
+	}
+	if _, ok := q.spec.(*NonSpeculativeExecution); !ok {
+		t.Fatalf("expected NonSpeculativeExecution, got %T", q.spec)
+	}
+
+	q.ExecutionProfile("")
+	if q.cons != Quorum || q.policy != nil {
+		t.Fatalf("session defaults not restored: %+v", q)
+	}
+
+	b := s.Batch(LoggedBatch).ExecutionProfile("analytics")
+	if b.Cons != One || b.serialCons != LocalSerial || b.requestTimeout != time.Minute ||
+		b.rt != rt || b.spec != spec || b.policy != policy {
+		t.Fatalf("profile not applied to batch: %+v", b)
+	}
+	b.ExecutionProfile("")
+	if b.Cons != Quorum || b.requestTimeout != time.Second || b.rt != s.cfg.RetryPolicy || b.policy != nil {
+		t.Fatalf("session defaults not restored: %+v", b)
+	}
+}
+
+type testBatchObserver struct{}
+
+func (*testBatchObserver) ObserveBatch(ctx context.Context, b ObservedBatch) {}
+
+func TestExecutionProfileKeepsSettings(t *testing.T) {
+	s := &Session{
+		cfg:      ClusterConfig{Timeout: time.Second},
+		cons:     Quorum,
+		pageSize: 5000,
+		executionProfiles: map[string]*ExecutionProfile{
+			"analytics": {Consistency: One},
+		},
+	}
+	tracer := NewTraceWriter(s, nil)
+	observer := &testQueryObserver{}
+	executionObserver := &testExecutionObserver{}
+
+	q := s.Query("SELECT * FROM t").
+		Idempotent(true).
+		DefaultTimestamp(false).
+		Prefetch(0.5).
+		Trace(tracer).
+		Observer(observer).
+		ExecutionObserver(executionObserver)
+	metrics := q.metrics
+	q.ExecutionProfile("analytics")
+	if q.cons != One {
+		t.Fatalf("profile not applied to query: %+v", q)
+	}
+	if !q.idempotent || q.defaultTimestamp || q.prefetch != 0.5 || q.trace != tracer ||
+		q.observer != observer || q.executionObserver != executionObserver || q.metrics != metrics {
+		t.Fatalf("settings of the query were reset: %+v", q)
+	}
+
+	batchObserver := &testBatchObserver{}
+	b := s.Batch(LoggedBatch).
+		DefaultTimestamp(false).
+		Trace(tracer).
+		Observer(batchObserver).
+		ExecutionObserver(executionObserver)
+	metrics = b.metrics
+	b.ExecutionProfile("analytics")
+	if b.Cons != One {
+		t.Fatalf("profile not applied to batch: %+v", b)
+	}
+	if b.defaultTimestamp || b.trace != tracer || b.observer != batchObserver ||
+		b.executionObserver != executionObserver || b.metrics != metrics {
+		t.Fatalf("settings of the batch were reset: %+v", b)
+	}
+}
+
+func TestExecutionProfileValidate(t *testing.T) {
+	cfg := NewCluster("127.0.0.1")
+	cfg.ExecutionProfiles = map[string]*ExecutionProfile{
+		"ok": {Consistency: One, SerialConsistency: LocalSerial, Timeout: time.Second, PageSize: 10},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	invalid := []*ExecutionProfile{
+		nil,
+		{SerialConsistency: Quorum},
+		{Timeout: -time.Second},
+		{PageSize: -1},
+	}
+	for _, p := range invalid {
+		cfg.ExecutionProfiles = map[string]*ExecutionProfile{"bad": p}
+		if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), `ExecutionProfiles["bad"]`) {
+			t.Errorf("%+v: expected an error, got %v", p, err)
+		}
+	}
+}
+
+// valueHostPolicy is a host selection policy of a type which is not comparable.
+type valueHostPolicy struct {
+	HostSelectionPolicy
+	hosts []string
+}
+
+func TestHostSelectionPoliciesUncomparable(t *testing.T) {
+	shared := &countingHostPolicy{HostSelectionPolicy: RoundRobinHostPolicy()}
+	value := valueHostPolicy{HostSelectionPolicy: RoundRobinHostPolicy()}
+	policies := newHostSelectionPolicies(shared, map[string]*ExecutionProfile{
+		"a": {HostSelectionPolicy: value},
+		"b": {HostSelectionPolicy: value},
+		"c": {HostSelectionPolicy: shared},
+	})
+	// the pointer is deduplicated, the values which can't be compared are not
+	if len(policies) != 3 {
+		t.Fatalf("expected 3 policies, got %d", len(policies))
+	}
+	if !policies.contains(shared) || policies.contains(value) {
+		t.Fatal("only the pointer should be contained")
+	}
+}
+
+func TestSessionExecutionProfile(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	srv := NewTestServer(t, protoVersion4, ctx)
+	defer srv.Stop()
+
+	policy := &countingHostPolicy{HostSelectionPolicy: RoundRobinHostPolicy()}
+	cluster := testCluster(protoVersion4, srv.Address)
+	cluster.ExecutionProfiles = map[string]*ExecutionProfile{
+		"a": {Consistency: One, HostSelectionPolicy: policy},
+		"b": {HostSelectionPolicy: policy},
+	}
+
+	db, err := cluster.CreateSession()
+	if err != nil {
+		t.Fatalf("NewCluster: %v", err)
+	}
+	defer db.Close()
+
+	// the policy is shared by two profiles, but it is initialized and notified once
+	if n := atomic.LoadInt32(&policy.inits); n != 1 {
+		t.Fatalf("expected the profile policy to be initialized once, got %d", n)
+	}
+	if n := atomic.LoadInt32(&policy.adds); n != 1 {
+		t.Fatalf("expected the profile policy to be notified of 1 host, got %d", n)
+	}
+
+	if err := db.Query("void").Exec(); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&policy.picks); n != 0 {
+		t.Fatalf("expected the session policy to be used without a profile, got %d picks", n)
+	}
+
+	if err := db.Query("void").ExecutionProfile("a").Exec(); err != nil {
+		t.Fatal(err)
+	}
+	// the test server doesn't support batches, but the host is picked before the batch fails
+	_ = db.Batch(LoggedBatch).ExecutionProfile("b").Query("void").Exec()
+	if n := atomic.LoadInt32(&policy.picks); n != 2 {
+		t.Fatalf("expected 2 picks of the profile policy, got %d", n)
+	}
+
+	err = db.Query("void").ExecutionProfile("missing").Exec()
+	if err == nil || !strings.Contains(err.Error(), `unknown execution profile "missing"`) {
+		t.Fatalf("expected an unknown profile error, got %v", err)
+	}
+	err = db.Batch(LoggedBatch).ExecutionProfile("missing").Query("void").Exec()
+	if err == nil || !strings.Contains(err.Error(), `unknown execution profile "missing"`) {
+		t.Fatalf("expected an unknown profile error, got %v", err)
+	}
+}
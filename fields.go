@@ -0,0 +1,122 @@
+package gocql
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// cqlField is a struct field mapped to a column.
+type cqlField struct {
+	typ reflect.Type
+	// index is the index sequence of the field for reflect.Value.FieldByIndex.
+	index []int
+	// offset is the offset of the field from the start of the outer struct.
+	offset    uintptr
+	omitEmpty bool
+}
+
+// cqlFields maps column names to the fields of a struct.
+//
+// The cql tag specifies the column name of a field, optionally followed by ",omitempty".
+// Fields tagged with `cql:"-"` are ignored. Untagged exported fields are matched to columns
+// case-insensitively by name. Fields of embedded structs are promoted following Go's rules, a field
+// is shadowed by a field with the same name at a shallower depth and fields with the same name at the
+// same depth are ambiguous and ignored.
+type cqlFields struct {
+	tagged   map[string]cqlField
+	untagged map[string]cqlField
+}
+
+// fieldsCache caches *cqlFields by struct type.
+var fieldsCache sync.Map
+
+func structFields(t reflect.Type) *cqlFields {
+	if f, ok := fieldsCache.Load(t); ok {
+		return f.(*cqlFields)
+	}
+
+	f := &cqlFields{
+		tagged:   make(map[string]cqlField),
+		untagged: make(map[string]cqlField),
+	}
+	f.collect(t)
+	fieldsCache.Store(t, f)
+	return f
+}
+
+// lookup returns the field of the column name.
+func (f *cqlFields) lookup(name string) (cqlField, bool) {
+	if field, ok := f.tagged[name]; ok {
+		return field, true
+	}
+	field, ok := f.untagged[strings.ToLower(name)]
+	return field, ok
+}
+
+// collect adds the fields of t and of its embedded structs level by level, like Go promotes fields:
+// a field hides the fields with the same name in deeper embedded structs, while fields with the same
+// name at the same depth are ambiguous, they are ignored and hide the deeper ones too.
+func (f *cqlFields) collect(t reflect.Type) {
+	type embeddedStruct struct {
+		typ    reflect.Type
+		index  []int
+		offset uintptr
+	}
+
+	// seen holds the names of the shallower levels, including the ambiguous ones
+	seenTagged := make(map[string]bool)
+	seenUntagged := make(map[string]bool)
+	add := func(fields map[string]cqlField, seen map[string]bool, level map[string][]cqlField) {
+		for name, candidates := range level {
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			if len(candidates) == 1 {
+				fields[name] = candidates[0]
+			}
+		}
+	}
+
+	for current := []embeddedStruct{{typ: t}}; len(current) > 0; {
+		var next []embeddedStruct
+		tagged := make(map[string][]cqlField)
+		untagged := make(map[string][]cqlField)
+		for _, s := range current {
+			for i := 0; i < s.typ.NumField(); i++ {
+				sf := s.typ.Field(i)
+				name, opts, _ := strings.Cut(sf.Tag.Get("cql"), ",")
+				if name == "-" {
+					continue
+				}
+
+				index := append(append([]int(nil), s.index...), i)
+				if name == "" && sf.Anonymous && sf.Type.Kind() == reflect.Struct && !scanAsWhole(sf.Type) {
+					next = append(next, embeddedStruct{typ: sf.Type, index: index, offset: s.offset + sf.Offset})
+					continue
+				}
+
+				if !sf.IsExported() {
+					continue
+				}
+
+				field := cqlField{
+					typ:       sf.Type,
+					index:     index,
+					offset:    s.offset + sf.Offset,
+					omitEmpty: opts == "omitempty",
+				}
+				if name != "" {
+					tagged[name] = append(tagged[name], field)
+					continue
+				}
+				name = strings.ToLower(sf.Name)
+				untagged[name] = append(untagged[name], field)
+			}
+		}
+		add(f.tagged, seenTagged, tagged)
+		add(f.untagged, seenUntagged, untagged)
+		current = next
+	}
+}
@@ -381,7 +381,7 @@ func (f *framer) readFrame(r io.Reader, head *frm.FrameHeader) error {
 	// assume the underlying reader takes care of timeouts and retries
 	n, err := io.ReadFull(r, f.buf)
 	if err != nil {
-		return fmt.Errorf("unable to read frame body: read %d/%d bytes: %v", n, head.Length, err)
+		return fmt.Errorf("unable to read frame body: read %d/%d bytes: %w", n, head.Length, err)
 	}
 
 	if head.Flags&frm.FlagCompress == frm.FlagCompress {
@@ -1169,10 +1169,12 @@ type queryParams struct {
 	pagingState           []byte
 	pageSize              int
 	defaultTimestampValue int64
+	nowInSecondsValue     int32
 	consistency           Consistency
 	serialConsistency     Consistency
 	skipMeta              bool
 	defaultTimestamp      bool
+	nowInSeconds          bool
 }
 
 func (q queryParams) String() string {
@@ -1180,7 +1182,7 @@ func (q queryParams) String() string {
 		q.consistency, q.skipMeta, q.pageSize, q.pagingState, q.serialConsistency, q.defaultTimestamp, q.values, q.keyspace)
 }
 
-func (f *framer) writeQueryParams(opts *queryParams) {
+func (f *framer) writeQueryParams(opts *queryParams) error {
 	f.writeConsistency(opts.consistency)
 
 	var flags byte
@@ -1212,16 +1214,19 @@ func (f *framer) writeQueryParams(opts *queryParams) {
 		names = true
 	}
 
+	if (opts.keyspace != "" || opts.nowInSeconds) && f.proto < protoVersion5 {
+		return fmt.Errorf("gocql: the keyspace and now_in_seconds of a query can only be set with protocol 5 or higher")
+	}
 	if opts.keyspace != "" {
-		if f.proto > protoVersion4 {
-			flags |= frm.FlagWithKeyspace
-		} else {
-			panic(fmt.Errorf("the keyspace can only be set with protocol 5 or higher"))
-		}
+		flags |= frm.FlagWithKeyspace
 	}
 
 	if f.proto > protoVersion4 {
-		f.writeUint(uint32(flags))
+		var v5Flags uint32
+		if opts.nowInSeconds {
+			v5Flags |= frm.FlagWithNowInSeconds
+		}
+		f.writeUint(uint32(flags) | v5Flags)
 	} else {
 		f.writeByte(flags)
 	}
@@ -1267,6 +1272,11 @@ func (f *framer) writeQueryParams(opts *queryParams) {
 	if opts.keyspace != "" {
 		f.writeString(opts.keyspace)
 	}
+
+	if opts.nowInSeconds {
+		f.writeInt(opts.nowInSecondsValue)
+	}
+	return nil
 }
 
 type writeQueryFrame struct {
@@ -1290,7 +1300,9 @@ func (f *framer) writeQueryFrame(streamID int, statement string, params *queryPa
 	f.writeHeader(f.flags, frm.OpQuery, streamID)
 	f.writeCustomPayload(&customPayload)
 	f.writeLongString(statement)
-	f.writeQueryParams(params)
+	if err := f.writeQueryParams(params); err != nil {
+		return err
+	}
 
 	return f.finish()
 }
@@ -1326,7 +1338,9 @@ func (f *framer) writeExecuteFrame(streamID int, preparedID []byte, params *quer
 	f.writeHeader(f.flags, frm.OpExecute, streamID)
 	f.writeCustomPayload(customPayload)
 	f.writeShortBytes(preparedID)
-	f.writeQueryParams(params)
+	if err := f.writeQueryParams(params); err != nil {
+		return err
+	}
 
 	return f.finish()
 }
@@ -1341,12 +1355,15 @@ type batchStatment struct {
 
 type writeBatchFrame struct {
 	customPayload         map[string][]byte
+	keyspace              string
 	statements            []batchStatment
 	defaultTimestampValue int64
+	nowInSecondsValue     int32
 	consistency           Consistency
 	serialConsistency     Consistency
 	typ                   BatchType
 	defaultTimestamp      bool
+	nowInSeconds          bool
 }
 
 func (w *writeBatchFrame) buildFrame(framer *framer, streamID int) error {
@@ -1404,9 +1421,19 @@ func (f *framer) writeBatchFrame(streamID int, w *writeBatchFrame, customPayload
 	if w.defaultTimestamp {
 		flags |= frm.FlagDefaultTimestamp
 	}
+	if (w.keyspace != "" || w.nowInSeconds) && f.proto < protoVersion5 {
+		return fmt.Errorf("gocql: the keyspace and now_in_seconds of a batch can only be set with protocol 5 or higher")
+	}
+	if w.keyspace != "" {
+		flags |= frm.FlagWithKeyspace
+	}
 
 	if f.proto > protoVersion4 {
-		f.writeUint(uint32(flags))
+		var v5Flags uint32
+		if w.nowInSeconds {
+			v5Flags |= frm.FlagWithNowInSeconds
+		}
+		f.writeUint(uint32(flags) | v5Flags)
 	} else {
 		f.writeByte(flags)
 	}
@@ -1425,6 +1452,14 @@ func (f *framer) writeBatchFrame(streamID int, w *writeBatchFrame, customPayload
 		f.writeLong(ts)
 	}
 
+	if w.keyspace != "" {
+		f.writeString(w.keyspace)
+	}
+
+	if w.nowInSeconds {
+		f.writeInt(w.nowInSecondsValue)
+	}
+
 	return f.finish()
 }
 
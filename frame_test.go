@@ -162,3 +162,57 @@ func TestParseEventFrame_ClientRoutesChanged(t *testing.T) {
 		t.Fatalf("HostIDs = %v, want empty", evt.HostIDs)
 	}
 }
+
+func TestWriteQueryKeyspaceAndNowInSeconds(t *testing.T) {
+	t.Parallel()
+
+	frames := map[string]frameBuilder{
+		"query": &writeQueryFrame{
+			statement: "SELECT * FROM t",
+			params:    queryParams{consistency: One, keyspace: "ks", nowInSeconds: true, nowInSecondsValue: 1700000000},
+		},
+		"batch": &writeBatchFrame{
+			statements:        []batchStatment{{statement: "INSERT INTO t (id) VALUES (1)"}},
+			consistency:       One,
+			keyspace:          "ks",
+			nowInSeconds:      true,
+			nowInSecondsValue: 1700000000,
+		},
+	}
+	for name, frame := range frames {
+		framer := newFramer(nil, protoVersion5)
+		if err := frame.buildFrame(framer, 0); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		r := newFramer(nil, protoVersion5)
+		r.buf = framer.buf[headSize:]
+		if name == "query" {
+			r.readLongString()
+		} else {
+			r.readByte()  // type
+			r.readShort() // statements
+			r.readByte()  // kind
+			r.readLongString()
+			r.readShort() // values
+		}
+		if cons := r.readConsistency(); cons != One {
+			t.Fatalf("%s: unexpected consistency %v", name, cons)
+		}
+		if flags := r.readInt(); flags != int(frm.FlagWithKeyspace)|int(frm.FlagWithNowInSeconds) {
+			t.Fatalf("%s: unexpected flags %#x", name, flags)
+		}
+		if ks, now := r.readString(), r.readInt(); ks != "ks" || now != 1700000000 || len(r.buf) != 0 {
+			t.Fatalf("%s: unexpected keyspace %q, now_in_seconds %d and %d trailing bytes", name, ks, now, len(r.buf))
+		}
+
+		if err := frame.buildFrame(newFramer(nil, protoVersion4), 0); err == nil {
+			t.Fatalf("%s: expected an error with protocol 4", name)
+		}
+	}
+
+	execute := &writeExecuteFrame{preparedID: []byte{1}, params: queryParams{consistency: One, nowInSeconds: true}}
+	if err := execute.buildFrame(newFramer(nil, protoVersion4), 0); err == nil {
+		t.Fatal("execute: expected an error with protocol 4")
+	}
+}
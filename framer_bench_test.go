@@ -32,6 +32,8 @@ import (
 	"io/ioutil"
 	"os"
 	"testing"
+
+	frm "github.com/gocql/gocql/internal/frame"
 )
 
 func readGzipData(path string) ([]byte, error) {
@@ -59,10 +61,10 @@ func BenchmarkParseRowsFrame(b *testing.B) {
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		framer := &framer{
-			header: &frameHeader{
-				version: protoVersion4 | 0x80,
-				op:      frm.OpResult,
-				length:  len(data),
+			header: &frm.FrameHeader{
+				Version: protoVersion4 | 0x80,
+				Op:      frm.OpResult,
+				Length:  len(data),
 			},
 			buf: data,
 		}
@@ -73,3 +75,61 @@ func BenchmarkParseRowsFrame(b *testing.B) {
 		}
 	}
 }
+
+// parseBenchRows parses the rows of the bench_parse_result frame.
+func parseBenchRows(b *testing.B, data []byte) *Iter {
+	framer := &framer{
+		header: &frm.FrameHeader{
+			Version: protoVersion4 | 0x80,
+			Op:      frm.OpResult,
+			Length:  len(data),
+		},
+		buf: data,
+	}
+
+	frame, err := framer.parseFrame()
+	if err != nil {
+		b.Fatal(err)
+	}
+	rows := frame.(*resultRowsFrame)
+	return &Iter{framer: framer, meta: rows.meta, numRows: rows.numRows}
+}
+
+func BenchmarkDecodeRowsScan(b *testing.B) {
+	data, err := readGzipData("testdata/frames/bench_parse_result.gz")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	var (
+		table, column, validator, indexName, indexType, indexOptions, kind string
+		componentIndex                                                     int
+	)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		iter := parseBenchRows(b, data)
+		for iter.Scan(&table, &column, &componentIndex, &validator, &indexName, &indexType, &indexOptions, &kind) {
+		}
+		if err := iter.Close(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeRowsColumnReader(b *testing.B) {
+	data, err := readGzipData("testdata/frames/bench_parse_result.gz")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	var r ColumnReader
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		iter := parseBenchRows(b, data)
+		if err := r.Read(&Page{iter: iter}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
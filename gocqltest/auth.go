@@ -0,0 +1,49 @@
+package gocqltest
+
+import (
+	"bytes"
+	"errors"
+)
+
+// Authenticator is the authenticator of the nodes, it authenticates the connections with SASL exchanges.
+type Authenticator struct {
+	// Class is the authenticator class name sent to the clients.
+	// Default: org.apache.cassandra.auth.PasswordAuthenticator
+	Class string
+	// NewExchange starts the authentication of a connection, it's called for every STARTUP request.
+	NewExchange func() AuthExchange
+}
+
+// AuthExchange processes the tokens sent by a client authenticating a connection. It returns the challenge
+// sent to the client or, if done is true, the final token sent with the success of the authentication.
+// An error fails the authentication with a bad credentials error.
+type AuthExchange func(token []byte) (challenge []byte, done bool, err error)
+
+// PasswordAuthenticator returns an authenticator accepting the SASL PLAIN credentials of users,
+// a map of usernames to passwords, like the Cassandra PasswordAuthenticator. The authorization id
+// of the credentials is ignored.
+func PasswordAuthenticator(users map[string]string) *Authenticator {
+	return &Authenticator{
+		NewExchange: func() AuthExchange {
+			return func(token []byte) ([]byte, bool, error) {
+				_, user, password, err := ParsePlain(token)
+				if err != nil {
+					return nil, false, err
+				}
+				if expected, ok := users[user]; !ok || expected != password {
+					return nil, false, errors.New("Provided username " + user + " and/or password are incorrect")
+				}
+				return nil, true, nil
+			}
+		},
+	}
+}
+
+// ParsePlain parses the SASL PLAIN token authzid NUL authcid NUL passwd.
+func ParsePlain(token []byte) (authzID, user, password string, err error) {
+	parts := bytes.Split(token, []byte{0})
+	if len(parts) != 3 {
+		return "", "", "", errors.New("invalid PLAIN token")
+	}
+	return string(parts[0]), string(parts[1]), string(parts[2]), nil
+}
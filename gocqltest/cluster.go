@@ -0,0 +1,742 @@
+// Package gocqltest provides an in-process fake CQL cluster for testing applications which use gocql.
+//
+// The nodes of a Cluster speak the native protocol versions 3 and 4 and serve the system tables the
+// driver uses to discover the topology. Every other request is answered by the rules registered with
+// Cluster.When and Node.When, or with an empty result:
+//
+//	cluster, err := gocqltest.NewCluster(gocqltest.Config{Nodes: make([]gocqltest.NodeConfig, 3)})
+//	...
+//	defer cluster.Close()
+//
+//	cluster.When(`SELECT name FROM ks\.users`).
+//		Params(gocqltest.Col("id", gocql.TypeInt)).
+//		Respond(gocqltest.Rows([]gocqltest.Column{gocqltest.Col("name", gocql.TypeText)}, []interface{}{"alice"}))
+//	cluster.When(`INSERT INTO ks\.users`).Times(1).Respond(gocqltest.WriteTimeout(gocql.Quorum, 1, 2, "SIMPLE"))
+//
+//	session, err := cluster.ClusterConfig().CreateSession()
+//
+// With Config.Authenticator the nodes require the connections to authenticate before sending requests.
+//
+// Rules can also simulate delays, dropped connections and requests which are never answered,
+// nodes can be stopped and started again, and with Config.Shards the nodes behave like sharded
+// Scylla nodes, including the shard-aware port. Cluster.PushSchemaChange sends schema change events
+// to the driver.
+package gocqltest
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gocql/gocql"
+)
+
+// Config configures a fake cluster.
+type Config struct {
+	// Nodes are the nodes of the cluster.
+	// Default: a single node
+	Nodes []NodeConfig
+	// Shards is the number of shards of every node. If it's not zero, the nodes advertise the Scylla
+	// sharding extensions and listen on a shard-aware port.
+	// Default: 0
+	Shards int
+	// Host is the IP address the nodes listen on, every node listens on its own port.
+	// Default: 127.0.0.1
+	Host string
+	// ClusterName is the name of the cluster in system.local.
+	// Default: gocqltest
+	ClusterName string
+	// ReleaseVersion is the release version of the nodes.
+	// Default: 3.0.8
+	ReleaseVersion string
+	// Partitioner is the partitioner of the cluster.
+	// Default: org.apache.cassandra.dht.Murmur3Partitioner
+	Partitioner string
+	// Authenticator makes the nodes require the connections to authenticate, see PasswordAuthenticator.
+	// Default: nil, connections are not authenticated
+	Authenticator *Authenticator
+}
+
+// NodeConfig configures a node of a fake cluster.
+type NodeConfig struct {
+	// Default: datacenter1
+	Datacenter string
+	// Default: rack1
+	Rack string
+}
+
+// Cluster is a fake CQL cluster.
+type Cluster struct {
+	cfg           Config
+	nodes         []*Node
+	schemaVersion gocql.UUID
+
+	mu       sync.Mutex
+	rules    []*Rule
+	requests []*Request
+}
+
+// NewCluster starts the nodes of a fake cluster.
+func NewCluster(cfg Config) (*Cluster, error) {
+	if len(cfg.Nodes) == 0 {
+		cfg.Nodes = []NodeConfig{{}}
+	}
+	if cfg.Shards < 0 {
+		return nil, errors.New("gocqltest: Shards should be positive number or zero")
+	}
+	if cfg.Host == "" {
+		cfg.Host = "127.0.0.1"
+	}
+	if cfg.ClusterName == "" {
+		cfg.ClusterName = "gocqltest"
+	}
+	if cfg.ReleaseVersion == "" {
+		cfg.ReleaseVersion = "3.0.8"
+	}
+	if cfg.Partitioner == "" {
+		cfg.Partitioner = "org.apache.cassandra.dht.Murmur3Partitioner"
+	}
+	if a := cfg.Authenticator; a != nil {
+		if a.NewExchange == nil {
+			return nil, errors.New("gocqltest: Authenticator.NewExchange is not set")
+		}
+		if a.Class == "" {
+			a = &Authenticator{Class: "org.apache.cassandra.auth.PasswordAuthenticator", NewExchange: a.NewExchange}
+			cfg.Authenticator = a
+		}
+	}
+
+	schemaVersion, err := gocql.RandomUUID()
+	if err != nil {
+		return nil, err
+	}
+	c := &Cluster{cfg: cfg, schemaVersion: schemaVersion}
+
+	// the tokens split the murmur3 ring evenly
+	step := ^uint64(0)/uint64(len(cfg.Nodes)) + 1
+	for i, nodeCfg := range cfg.Nodes {
+		if nodeCfg.Datacenter == "" {
+			nodeCfg.Datacenter = "datacenter1"
+		}
+		if nodeCfg.Rack == "" {
+			nodeCfg.Rack = "rack1"
+		}
+		hostID, err := gocql.RandomUUID()
+		if err != nil {
+			return nil, err
+		}
+		n := &Node{
+			cluster:   c,
+			cfg:       nodeCfg,
+			hostID:    hostID,
+			broadcast: net.IPv4(127, 0, 1, byte(i+1)),
+			token:     strconv.FormatInt(int64(uint64(1)<<63+uint64(i)*step), 10),
+			addr:      net.JoinHostPort(cfg.Host, "0"),
+		}
+		if cfg.Shards > 0 {
+			n.shardAwareAddr = net.JoinHostPort(cfg.Host, "0")
+		}
+		c.nodes = append(c.nodes, n)
+		if err := n.Start(); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// Nodes returns the nodes of the cluster.
+func (c *Cluster) Nodes() []*Node {
+	return c.nodes
+}
+
+// Hosts returns the addresses of the nodes.
+func (c *Cluster) Hosts() []string {
+	hosts := make([]string, len(c.nodes))
+	for i, n := range c.nodes {
+		hosts[i] = n.Address()
+	}
+	return hosts
+}
+
+// ClusterConfig returns a configuration of the driver which connects to the cluster.
+func (c *Cluster) ClusterConfig() *gocql.ClusterConfig {
+	cfg := gocql.NewCluster(c.nodes[0].Address())
+	cfg.ProtoVersion = maxProtoVersion
+	return cfg
+}
+
+// Close stops all nodes.
+func (c *Cluster) Close() {
+	for _, n := range c.nodes {
+		n.Stop()
+	}
+}
+
+// When registers a rule for statements of all nodes which match the regular expression pattern,
+// case insensitively. Rules registered later take precedence, rules of a node take precedence over
+// the rules of the cluster.
+func (c *Cluster) When(pattern string) *Rule {
+	r := newRule(pattern)
+	c.mu.Lock()
+	c.rules = append(c.rules, r)
+	c.mu.Unlock()
+	return r
+}
+
+// PushSchemaChange sends a SCHEMA_CHANGE event to the connections which registered for schema changes,
+// e.g. PushSchemaChange("UPDATED", "TABLE", "ks", "users"). The change is CREATED, UPDATED or DROPPED and
+// the target KEYSPACE, TABLE, TYPE, FUNCTION or AGGREGATE. The name is ignored for keyspaces, the argument
+// types are sent for functions and aggregates.
+func (c *Cluster) PushSchemaChange(change, target, keyspace, name string, argTypes ...string) {
+	for _, n := range c.nodes {
+		n.pushEvent("SCHEMA_CHANGE", func(w *writer) {
+			w.writeString(change)
+			w.writeString(target)
+			w.writeString(keyspace)
+			switch target {
+			case "KEYSPACE":
+			case "FUNCTION", "AGGREGATE":
+				w.writeString(name)
+				w.writeStringList(argTypes)
+			default:
+				w.writeString(name)
+			}
+		})
+	}
+}
+
+// Requests returns the requests received by the nodes, including the queries of the system tables
+// the driver uses.
+func (c *Cluster) Requests() []*Request {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*Request(nil), c.requests...)
+}
+
+// Count returns the number of received statements which match the regular expression pattern, case insensitively.
+func (c *Cluster) Count(pattern string) int {
+	re := regexp.MustCompile("(?i)" + pattern)
+	var n int
+	for _, req := range c.Requests() {
+		if re.MatchString(req.Statement) {
+			n++
+		}
+	}
+	return n
+}
+
+func (c *Cluster) record(req *Request) {
+	c.mu.Lock()
+	c.requests = append(c.requests, req)
+	c.mu.Unlock()
+}
+
+// respond returns the response to a request, it's the response of the first matching rule or a built-in one.
+func (c *Cluster) respond(req *Request) Response {
+	c.record(req)
+	if resp := c.match(req); resp != nil {
+		return resp
+	}
+	return c.builtin(req)
+}
+
+// rulesOf returns the rules of a node and the cluster in the order of precedence.
+func (c *Cluster) rulesOf(n *Node) []*Rule {
+	n.mu.Lock()
+	rules := make([]*Rule, 0, len(n.rules))
+	for i := len(n.rules) - 1; i >= 0; i-- {
+		rules = append(rules, n.rules[i])
+	}
+	n.mu.Unlock()
+
+	c.mu.Lock()
+	for i := len(c.rules) - 1; i >= 0; i-- {
+		rules = append(rules, c.rules[i])
+	}
+	c.mu.Unlock()
+	return rules
+}
+
+// match returns the response of the first matching rule, nil if there is none.
+func (c *Cluster) match(req *Request) Response {
+	for _, r := range c.rulesOf(req.Node) {
+		if !r.re.MatchString(req.Statement) {
+			continue
+		}
+		if h := r.activeHandler(); h != nil {
+			if resp := h(req); resp != nil && r.use() {
+				return resp
+			}
+		}
+	}
+	return nil
+}
+
+// params returns the columns of the bound values of a statement being prepared.
+func (c *Cluster) params(n *Node, stmt string) []Column {
+	for _, r := range c.rulesOf(n) {
+		if params := r.declaredParams(); params != nil && r.re.MatchString(stmt) {
+			return params
+		}
+	}
+	return bindMarkers(stmt)
+}
+
+var (
+	useRe         = regexp.MustCompile(`(?is)^\s*USE\s+("(?:[^"]|"")+"|\w+)\s*;?\s*$`)
+	systemLocalRe = regexp.MustCompile(`(?is)^\s*SELECT\s.*\bFROM\s+system\.local\b`)
+	systemPeersRe = regexp.MustCompile(`(?is)^\s*SELECT\s.*\bFROM\s+system\.peers(_v2)?\b`)
+	selectRe      = regexp.MustCompile(`(?is)^\s*SELECT\s`)
+	selectColsRe  = regexp.MustCompile(`(?is)^\s*SELECT\s+(.*?)\s+FROM\s`)
+)
+
+// builtin returns the response to requests no rule matched.
+func (c *Cluster) builtin(req *Request) Response {
+	if m := useRe.FindStringSubmatch(req.Statement); m != nil {
+		ks := m[1]
+		if strings.HasPrefix(ks, `"`) {
+			ks = strings.ReplaceAll(ks[1:len(ks)-1], `""`, `"`)
+		} else {
+			ks = strings.ToLower(ks)
+		}
+		return keyspaceResponse(ks)
+	}
+	if systemLocalRe.MatchString(req.Statement) {
+		return project(req.Statement, req.Node.localRows())
+	}
+	if m := systemPeersRe.FindStringSubmatch(req.Statement); m != nil {
+		return project(req.Statement, c.peerRows(req.Node, m[1] != ""))
+	}
+	if selectRe.MatchString(req.Statement) {
+		return Rows(nil)
+	}
+	return Void()
+}
+
+var (
+	localColumns = []Column{
+		Col("key", gocql.TypeVarchar),
+		Col("bootstrapped", gocql.TypeVarchar),
+		Col("broadcast_address", gocql.TypeInet),
+		Col("cluster_name", gocql.TypeVarchar),
+		Col("cql_version", gocql.TypeVarchar),
+		Col("data_center", gocql.TypeVarchar),
+		Col("host_id", gocql.TypeUUID),
+		Col("listen_address", gocql.TypeInet),
+		Col("native_port", gocql.TypeInt),
+		Col("partitioner", gocql.TypeVarchar),
+		Col("rack", gocql.TypeVarchar),
+		Col("release_version", gocql.TypeVarchar),
+		Col("rpc_address", gocql.TypeInet),
+		Col("schema_version", gocql.TypeUUID),
+		{Name: "tokens", Type: SetOf(Native(gocql.TypeVarchar))},
+	}
+	peersColumns = []Column{
+		Col("peer", gocql.TypeInet),
+		Col("data_center", gocql.TypeVarchar),
+		Col("host_id", gocql.TypeUUID),
+		Col("native_port", gocql.TypeInt),
+		Col("rack", gocql.TypeVarchar),
+		Col("release_version", gocql.TypeVarchar),
+		Col("rpc_address", gocql.TypeInet),
+		Col("schema_version", gocql.TypeUUID),
+		{Name: "tokens", Type: SetOf(Native(gocql.TypeVarchar))},
+	}
+	peersV2Columns = []Column{
+		Col("peer", gocql.TypeInet),
+		Col("peer_port", gocql.TypeInt),
+		Col("data_center", gocql.TypeVarchar),
+		Col("host_id", gocql.TypeUUID),
+		Col("native_address", gocql.TypeInet),
+		Col("native_port", gocql.TypeInt),
+		Col("rack", gocql.TypeVarchar),
+		Col("release_version", gocql.TypeVarchar),
+		Col("schema_version", gocql.TypeUUID),
+		{Name: "tokens", Type: SetOf(Native(gocql.TypeVarchar))},
+	}
+)
+
+// project returns the columns of the rows of a system table selected by the statement, all of them
+// for SELECT *. Selected columns the table doesn't have are null.
+func project(stmt string, resp *rowsResponse) Response {
+	m := selectColsRe.FindStringSubmatch(stmt)
+	if m == nil || strings.TrimSpace(m[1]) == "*" {
+		return resp
+	}
+	var (
+		cols    []Column
+		indexes []int
+	)
+	for _, name := range strings.Split(m[1], ",") {
+		name = strings.TrimSpace(name)
+		i := -1
+		for j, col := range resp.cols {
+			if strings.EqualFold(col.Name, name) {
+				i = j
+				break
+			}
+		}
+		if i < 0 {
+			cols = append(cols, Col(name, gocql.TypeBlob))
+		} else {
+			cols = append(cols, resp.cols[i])
+		}
+		indexes = append(indexes, i)
+	}
+	rows := make([][]interface{}, 0, len(resp.rows))
+	for _, row := range resp.rows {
+		projected := make([]interface{}, len(indexes))
+		for k, i := range indexes {
+			if i >= 0 {
+				projected[k] = row[i]
+			}
+		}
+		rows = append(rows, projected)
+	}
+	return &rowsResponse{cols: cols, rows: rows}
+}
+
+func (c *Cluster) peerRows(local *Node, v2 bool) *rowsResponse {
+	var rows [][]interface{}
+	for _, n := range c.nodes {
+		if n == local {
+			continue
+		}
+		ip, port := n.hostPort()
+		if v2 {
+			rows = append(rows, []interface{}{
+				n.broadcast, 7000, n.cfg.Datacenter, n.hostID, ip, port, n.cfg.Rack,
+				c.cfg.ReleaseVersion, c.schemaVersion, []string{n.token},
+			})
+		} else {
+			rows = append(rows, []interface{}{
+				n.broadcast, n.cfg.Datacenter, n.hostID, port, n.cfg.Rack,
+				c.cfg.ReleaseVersion, ip, c.schemaVersion, []string{n.token},
+			})
+		}
+	}
+	if v2 {
+		return &rowsResponse{cols: peersV2Columns, rows: rows}
+	}
+	return &rowsResponse{cols: peersColumns, rows: rows}
+}
+
+// Rule decides the response to the statements which match its pattern.
+type Rule struct {
+	re *regexp.Regexp
+
+	mu        sync.Mutex
+	handler   Handler
+	params    []Column
+	remaining int
+}
+
+func newRule(pattern string) *Rule {
+	return &Rule{re: regexp.MustCompile("(?i)" + pattern), remaining: -1}
+}
+
+// Respond sets the response to the matching statements.
+func (r *Rule) Respond(resp Response) *Rule {
+	return r.Handle(func(*Request) Response { return resp })
+}
+
+// Handle sets the handler which responds to the matching statements.
+func (r *Rule) Handle(h Handler) *Rule {
+	r.mu.Lock()
+	r.handler = h
+	r.mu.Unlock()
+	return r
+}
+
+// Params declares the bound values of the matching statements, so that the driver can marshal them
+// when it prepares the statements. By default every bind marker is a blob, which accepts strings
+// and byte slices.
+func (r *Rule) Params(cols ...Column) *Rule {
+	r.mu.Lock()
+	r.params = append([]Column{}, cols...)
+	r.mu.Unlock()
+	return r
+}
+
+// Times limits the number of responses of the rule, after that the statements are left to other
+// rules. It's useful to simulate transient errors.
+func (r *Rule) Times(n int) *Rule {
+	r.mu.Lock()
+	r.remaining = n
+	r.mu.Unlock()
+	return r
+}
+
+// activeHandler returns the handler of the rule, nil if it's not set or the rule has expired.
+func (r *Rule) activeHandler() Handler {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.remaining == 0 {
+		return nil
+	}
+	return r.handler
+}
+
+func (r *Rule) declaredParams() []Column {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.params
+}
+
+// use counts a response of the rule, it returns false if the rule has already expired.
+func (r *Rule) use() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.remaining == 0 {
+		return false
+	}
+	if r.remaining > 0 {
+		r.remaining--
+	}
+	return true
+}
+
+// Node is a node of a fake cluster.
+type Node struct {
+	cluster   *Cluster
+	cfg       NodeConfig
+	hostID    gocql.UUID
+	broadcast net.IP
+	token     string
+
+	mu             sync.Mutex
+	addr           string
+	shardAwareAddr string
+	listeners      []net.Listener
+	conns          map[*conn]struct{}
+	nextShard      int
+	rules          []*Rule
+	statements     map[string]preparedStatement
+	wg             sync.WaitGroup
+}
+
+type preparedStatement struct {
+	statement string
+	params    []Column
+}
+
+// Address returns the address the node listens on.
+func (n *Node) Address() string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.addr
+}
+
+// HostID returns the host ID of the node.
+func (n *Node) HostID() string {
+	return n.hostID.String()
+}
+
+// When registers a rule for statements of the node, see Cluster.When.
+func (n *Node) When(pattern string) *Rule {
+	r := newRule(pattern)
+	n.mu.Lock()
+	n.rules = append(n.rules, r)
+	n.mu.Unlock()
+	return r
+}
+
+// Requests returns the requests received by the node.
+func (n *Node) Requests() []*Request {
+	var reqs []*Request
+	for _, req := range n.cluster.Requests() {
+		if req.Node == n {
+			reqs = append(reqs, req)
+		}
+	}
+	return reqs
+}
+
+// Start starts a stopped node on the address it listened on before. The node forgets the
+// statements prepared before it was stopped.
+func (n *Node) Start() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.listeners != nil {
+		return nil
+	}
+
+	l, err := net.Listen("tcp", n.addr)
+	if err != nil {
+		return err
+	}
+	n.addr = l.Addr().String()
+	n.listeners = append(n.listeners, l)
+	if n.shardAwareAddr != "" {
+		sa, err := net.Listen("tcp", n.shardAwareAddr)
+		if err != nil {
+			l.Close()
+			n.listeners = nil
+			return err
+		}
+		n.shardAwareAddr = sa.Addr().String()
+		n.listeners = append(n.listeners, sa)
+	}
+	n.conns = make(map[*conn]struct{})
+	n.statements = make(map[string]preparedStatement)
+
+	for i, l := range n.listeners {
+		n.wg.Add(1)
+		go n.serve(l, i == 1)
+	}
+	return nil
+}
+
+// Stop closes the connections of the node and stops listening.
+func (n *Node) Stop() {
+	n.mu.Lock()
+	for _, l := range n.listeners {
+		l.Close()
+	}
+	n.listeners = nil
+	for c := range n.conns {
+		c.close()
+	}
+	n.mu.Unlock()
+
+	n.wg.Wait()
+}
+
+// Prepared returns the statements prepared on the node since it was started, sorted.
+func (n *Node) Prepared() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	stmts := make([]string, 0, len(n.statements))
+	for _, stmt := range n.statements {
+		stmts = append(stmts, stmt.statement)
+	}
+	sort.Strings(stmts)
+	return stmts
+}
+
+// Connections returns the number of open connections of the node.
+func (n *Node) Connections() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return len(n.conns)
+}
+
+func (n *Node) pushEvent(event string, write func(w *writer)) {
+	n.mu.Lock()
+	conns := make([]*conn, 0, len(n.conns))
+	for c := range n.conns {
+		conns = append(conns, c)
+	}
+	n.mu.Unlock()
+	for _, c := range conns {
+		c.pushEvent(event, write)
+	}
+}
+
+// DropConnections closes the connections of the node, the node keeps listening.
+func (n *Node) DropConnections() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for c := range n.conns {
+		c.close()
+	}
+}
+
+func (n *Node) serve(l net.Listener, shardAware bool) {
+	defer n.wg.Done()
+	for {
+		nc, err := l.Accept()
+		if err != nil {
+			return
+		}
+
+		shard := -1
+		if shards := n.cluster.cfg.Shards; shards > 0 {
+			if shardAware {
+				shard = nc.RemoteAddr().(*net.TCPAddr).Port % shards
+			} else {
+				n.mu.Lock()
+				shard = n.nextShard
+				n.nextShard = (n.nextShard + 1) % shards
+				n.mu.Unlock()
+			}
+		}
+
+		c := newConn(n, nc, shard)
+		n.mu.Lock()
+		if n.listeners == nil {
+			// the node was stopped
+			n.mu.Unlock()
+			nc.Close()
+			return
+		}
+		n.conns[c] = struct{}{}
+		n.mu.Unlock()
+
+		n.wg.Add(1)
+		go func() {
+			defer n.wg.Done()
+			c.serve(&n.wg)
+			n.mu.Lock()
+			delete(n.conns, c)
+			n.mu.Unlock()
+		}()
+	}
+}
+
+func (n *Node) prepare(id []byte, stmt preparedStatement) {
+	n.mu.Lock()
+	n.statements[string(id)] = stmt
+	n.mu.Unlock()
+}
+
+func (n *Node) prepared(id []byte) (preparedStatement, bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	stmt, ok := n.statements[string(id)]
+	return stmt, ok
+}
+
+func (n *Node) hostPort() (net.IP, int) {
+	addr := n.Address()
+	host, port, _ := net.SplitHostPort(addr)
+	p, _ := strconv.Atoi(port)
+	return net.ParseIP(host), p
+}
+
+func (n *Node) supported(c *conn) map[string][]string {
+	supported := map[string][]string{
+		"CQL_VERSION":             {"3.4.5"},
+		"COMPRESSION":             {},
+		"PROTOCOL_VERSIONS":       {"3/v3", "4/v4"},
+		"SCYLLA_RATE_LIMIT_ERROR": {fmt.Sprintf("ERROR_CODE=%d", rateLimitErrorCode)},
+	}
+	if shards := n.cluster.cfg.Shards; shards > 0 {
+		n.mu.Lock()
+		_, port, _ := net.SplitHostPort(n.shardAwareAddr)
+		n.mu.Unlock()
+		supported["SCYLLA_SHARD"] = []string{strconv.Itoa(c.shard)}
+		supported["SCYLLA_NR_SHARDS"] = []string{strconv.Itoa(shards)}
+		supported["SCYLLA_PARTITIONER"] = []string{n.cluster.cfg.Partitioner}
+		supported["SCYLLA_SHARDING_ALGORITHM"] = []string{"biased-token-round-robin"}
+		supported["SCYLLA_SHARDING_IGNORE_MSB"] = []string{"12"}
+		supported["SCYLLA_SHARD_AWARE_PORT"] = []string{port}
+	}
+	return supported
+}
+
+func (n *Node) localRows() *rowsResponse {
+	c := n.cluster
+	ip, port := n.hostPort()
+	return &rowsResponse{cols: localColumns, rows: [][]interface{}{{
+		"local", "COMPLETED", n.broadcast, c.cfg.ClusterName, "3.4.5", n.cfg.Datacenter, n.hostID,
+		n.broadcast, port, c.cfg.Partitioner, n.cfg.Rack, c.cfg.ReleaseVersion, ip, c.schemaVersion,
+		[]string{n.token},
+	}}}
+}
@@ -0,0 +1,328 @@
+//go:build unit
+// +build unit
+
+package gocqltest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/events"
+	frm "github.com/gocql/gocql/internal/frame"
+)
+
+func newTestCluster(t *testing.T, cfg Config) (*Cluster, *gocql.Session) {
+	t.Helper()
+	c, err := NewCluster(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(c.Close)
+
+	clusterCfg := c.ClusterConfig()
+	clusterCfg.Timeout = 500 * time.Millisecond
+	clusterCfg.RetryPolicy = &gocql.SimpleRetryPolicy{}
+	session, err := clusterCfg.CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(session.Close)
+	return c, session
+}
+
+func TestRows(t *testing.T) {
+	c, session := newTestCluster(t, Config{})
+
+	var (
+		mu  sync.Mutex
+		ids []int
+	)
+	c.When(`SELECT id, name, tags FROM ks\.users WHERE id > \?`).
+		Params(Col("id", gocql.TypeInt)).
+		Handle(func(req *Request) Response {
+			var min int
+			if err := req.Bind(0, &min); err != nil {
+				return Error(gocql.ErrCodeInvalid, err.Error())
+			}
+			mu.Lock()
+			ids = append(ids, min)
+			mu.Unlock()
+			cols := []Column{Col("id", gocql.TypeInt), Col("name", gocql.TypeText), {Name: "tags", Type: SetOf(Native(gocql.TypeText))}}
+			var rows [][]interface{}
+			for i := min + 1; i <= 5; i++ {
+				rows = append(rows, []interface{}{i, "user", []string{"a", "b"}})
+			}
+			return Rows(cols, rows...)
+		})
+
+	iter := session.Query(`SELECT id, name, tags FROM ks.users WHERE id > ?`, 0).PageSize(2).Iter()
+	var (
+		id   int
+		name string
+		tags []string
+		got  []int
+	)
+	for iter.Scan(&id, &name, &tags) {
+		if name != "user" || len(tags) != 2 {
+			t.Fatalf("unexpected row: %d %q %v", id, name, tags)
+		}
+		got = append(got, id)
+	}
+	if err := iter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 5 || got[0] != 1 || got[4] != 5 {
+		t.Fatalf("unexpected ids: %v", got)
+	}
+	// 3 pages
+	mu.Lock()
+	defer mu.Unlock()
+	if len(ids) != 3 {
+		t.Fatalf("expected 3 requests, got %d", len(ids))
+	}
+	if n := c.Count(`FROM ks\.users`); n != 3 {
+		t.Fatalf("expected 3 recorded requests, got %d", n)
+	}
+
+	// statements without a rule return no rows
+	if err := session.Query(`SELECT * FROM ks.other`).Scan(&id); !errors.Is(err, gocql.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if err := session.Query(`INSERT INTO ks.other (id) VALUES (?)`, "id").Exec(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	c, session := newTestCluster(t, Config{})
+
+	c.When(`unavailable`).Respond(Unavailable(gocql.Quorum, 2, 1))
+	var unavailable *gocql.RequestErrUnavailable
+	if err := session.Query(`INSERT INTO unavailable (id) VALUES (1)`).Exec(); !errors.As(err, &unavailable) {
+		t.Fatalf("expected an unavailable error, got %v", err)
+	} else if unavailable.Consistency != gocql.Quorum || unavailable.Required != 2 || unavailable.Alive != 1 {
+		t.Fatalf("unexpected error: %+v", unavailable)
+	}
+
+	c.When(`read_timeout`).Respond(ReadTimeout(gocql.One, 0, 1, false))
+	var readTimeout *gocql.RequestErrReadTimeout
+	if err := session.Query(`SELECT * FROM read_timeout`).Exec(); !errors.As(err, &readTimeout) {
+		t.Fatalf("expected a read timeout, got %v", err)
+	}
+
+	c.When(`rate_limit`).Respond(RateLimitReached(gocql.OpTypeWrite, true))
+	var rateLimited *gocql.RequestErrRateLimitReached
+	if err := session.Query(`INSERT INTO rate_limit (id) VALUES (1)`).Exec(); !errors.As(err, &rateLimited) {
+		t.Fatalf("expected a rate limit error, got %v", err)
+	} else if rateLimited.OpType != gocql.OpTypeWrite || !rateLimited.RejectedByCoordinator {
+		t.Fatalf("unexpected error: %+v", rateLimited)
+	}
+
+	c.When(`overloaded`).Respond(Overloaded())
+	var errFrame frm.ErrorFrame
+	if err := session.Query(`INSERT INTO overloaded (id) VALUES (1)`).Exec(); !errors.As(err, &errFrame) || errFrame.Code != gocql.ErrCodeOverloaded {
+		t.Fatalf("expected an overloaded error, got %v", err)
+	}
+
+	// the write timeout is returned once, the retry succeeds
+	c.When(`write_timeout`).Times(1).Respond(WriteTimeout(gocql.Quorum, 1, 2, "SIMPLE"))
+	var writeTimeout *gocql.RequestErrWriteTimeout
+	if err := session.Query(`INSERT INTO write_timeout (id) VALUES (1)`).Exec(); !errors.As(err, &writeTimeout) {
+		t.Fatalf("expected a write timeout, got %v", err)
+	} else if writeTimeout.WriteType != "SIMPLE" {
+		t.Fatalf("unexpected error: %+v", writeTimeout)
+	}
+	if err := session.Query(`INSERT INTO write_timeout (id) VALUES (1)`).Exec(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDelays(t *testing.T) {
+	c, session := newTestCluster(t, Config{})
+
+	c.When(`slow`).Respond(Delay(50*time.Millisecond, Void()))
+	start := time.Now()
+	if err := session.Query(`INSERT INTO slow (id) VALUES (1)`).Exec(); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Fatalf("expected the response to be delayed, got it after %v", elapsed)
+	}
+
+	c.When(`hang`).Respond(NoResponse())
+	if err := session.Query(`INSERT INTO hang (id) VALUES (1)`).Exec(); !errors.Is(err, gocql.ErrTimeoutNoResponse) {
+		t.Fatalf("expected ErrTimeoutNoResponse, got %v", err)
+	}
+
+	c.When(`drop`).Respond(DropConnection())
+	if err := session.Query(`INSERT INTO drop (id) VALUES (1)`).Exec(); err == nil {
+		t.Fatal("expected an error of the dropped connection")
+	}
+}
+
+func TestTopology(t *testing.T) {
+	c, session := newTestCluster(t, Config{Nodes: []NodeConfig{{}, {}, {Datacenter: "dc2"}}})
+
+	var pools int
+	session.IterateHostPools(func(info gocql.HostPoolInfo) bool {
+		pools++
+		return true
+	})
+	if pools != 3 {
+		t.Fatalf("expected pools of 3 hosts, got %d", pools)
+	}
+
+	received := func(n *Node) bool {
+		for _, req := range n.Requests() {
+			if req.Statement == `INSERT INTO ks.t (id) VALUES (1)` {
+				return true
+			}
+		}
+		return false
+	}
+	for i := 0; i < 30; i++ {
+		if err := session.Query(`INSERT INTO ks.t (id) VALUES (1)`).Exec(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, n := range c.Nodes() {
+		if !received(n) {
+			t.Fatalf("node %d received no queries", i)
+		}
+	}
+
+	// rules of a node take precedence
+	node := c.Nodes()[1]
+	node.When(`ks\.t`).Respond(Overloaded())
+	c.When(`ks\.t`).Respond(Void())
+	var failed int
+	for i := 0; i < 9; i++ {
+		if err := session.Query(`INSERT INTO ks.t (id) VALUES (1)`).Exec(); err != nil {
+			failed++
+		}
+	}
+	if failed == 0 || failed == 9 {
+		t.Fatalf("expected only the queries sent to node 1 to fail, %d of 9 failed", failed)
+	}
+}
+
+func TestSystemTableColumns(t *testing.T) {
+	c, session := newTestCluster(t, Config{Nodes: make([]NodeConfig, 3)})
+
+	if err := session.AwaitSchemaAgreement(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// only the selected columns are returned, unknown columns are null
+	var (
+		hostID  gocql.UUID
+		dc      string
+		unknown []byte
+	)
+	if err := session.Query(`SELECT host_id, data_center, unknown FROM system.local`).Scan(&hostID, &dc, &unknown); err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, n := range c.Nodes() {
+		found = found || n.HostID() == hostID.String()
+	}
+	if !found || dc != "datacenter1" || unknown != nil {
+		t.Fatalf("unexpected row %v, %q, %v", hostID, dc, unknown)
+	}
+}
+
+func TestPushSchemaChange(t *testing.T) {
+	c, session := newTestCluster(t, Config{})
+
+	sub := session.SubscribeToEvents("test", 10, func(e events.Event) bool {
+		return e.Type() == events.ClusterEventTypeSchemaChangeFunction
+	})
+	defer sub.Stop()
+
+	c.PushSchemaChange("CREATED", "FUNCTION", "ks", "f", "int", "text")
+	select {
+	case e := <-sub.Events():
+		f := e.(*events.SchemaChangeFunctionEvent)
+		if f.Change != "CREATED" || f.Keyspace != "ks" || f.Function != "f" || len(f.Arguments) != 2 || f.Arguments[1] != "text" {
+			t.Fatalf("unexpected event %+v", f)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the event")
+	}
+}
+
+func TestPreparedAfterRestart(t *testing.T) {
+	c, session := newTestCluster(t, Config{})
+
+	const stmt = `INSERT INTO ks.t (id) VALUES (?)`
+	if err := session.Query(stmt, "a").Exec(); err != nil {
+		t.Fatal(err)
+	}
+
+	node := c.Nodes()[0]
+	node.Stop()
+	if err := node.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the driver reconnects and prepares the statement again
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		err := session.Query(stmt, "b").Exec()
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal(err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	reqs := node.Requests()
+	var values []string
+	for _, req := range reqs {
+		if req.Statement == stmt {
+			values = append(values, string(req.Values[0]))
+		}
+	}
+	if len(values) != 2 || values[0] != "a" || values[1] != "b" {
+		t.Fatalf("unexpected bound values: %q", values)
+	}
+}
+
+func TestShards(t *testing.T) {
+	c, session := newTestCluster(t, Config{Shards: 2})
+
+	shards := make(map[int]bool)
+	deadline := time.Now().Add(5 * time.Second)
+	for len(shards) < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("queries were sent to shards %v", shards)
+		}
+		if err := session.Query(`INSERT INTO ks.t (id) VALUES (1)`).Exec(); err != nil {
+			t.Fatal(err)
+		}
+		for _, req := range c.Requests() {
+			shards[req.Shard] = true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if shards[-1] {
+		t.Fatal("request on an unsharded connection")
+	}
+}
+
+func TestBindMarkers(t *testing.T) {
+	cols := bindMarkers(`SELECT * FROM t WHERE a = ? AND b = :b AND c = '?:x' AND d IN ? AND e = {'k':1}`)
+	var names []string
+	for _, col := range cols {
+		names = append(names, col.Name)
+	}
+	if len(names) != 3 || names[0] != "p0" || names[1] != "b" || names[2] != "p2" {
+		t.Fatalf("unexpected bind markers: %v", names)
+	}
+}
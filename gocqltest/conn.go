@@ -0,0 +1,395 @@
+package gocqltest
+
+import (
+	"crypto/md5"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gocql/gocql"
+	frm "github.com/gocql/gocql/internal/frame"
+)
+
+// conn is a client connection to a node.
+type conn struct {
+	node  *Node
+	nc    net.Conn
+	shard int
+
+	mu       sync.Mutex
+	keyspace string
+	// auth is the authentication in progress, authenticated is set once it succeeds.
+	auth          AuthExchange
+	authenticated bool
+	// version is the protocol version of the connection, events are the events it registered for.
+	version byte
+	events  map[string]bool
+
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func newConn(node *Node, nc net.Conn, shard int) *conn {
+	return &conn{
+		node:   node,
+		nc:     nc,
+		shard:  shard,
+		closed: make(chan struct{}),
+	}
+}
+
+func (c *conn) close() {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+		c.nc.Close()
+	})
+}
+
+func (c *conn) write(frame []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, err := c.nc.Write(frame); err != nil {
+		c.close()
+	}
+}
+
+// pushEvent sends an EVENT frame with the body written by write if the connection registered for the event.
+func (c *conn) pushEvent(event string, write func(w *writer)) {
+	c.mu.Lock()
+	registered, version := c.events[event], c.version
+	c.mu.Unlock()
+	if !registered {
+		return
+	}
+	w := newWriter(version, -1, frm.OpEvent)
+	w.writeString(event)
+	write(w)
+	c.write(w.finish())
+}
+
+func (c *conn) setKeyspace(ks string) {
+	c.mu.Lock()
+	c.keyspace = ks
+	c.mu.Unlock()
+}
+
+func (c *conn) getKeyspace() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.keyspace
+}
+
+func (c *conn) isAuthenticated() bool {
+	if c.node.cluster.cfg.Authenticator == nil {
+		return true
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.authenticated
+}
+
+// authenticate processes a token of the client, the authentication fails if the exchange returns an error.
+func (c *conn) authenticate(x *exchange, token []byte) {
+	c.mu.Lock()
+	auth := c.auth
+	c.mu.Unlock()
+	if auth == nil {
+		Error(errCodeProtocol, "unexpected AUTH_RESPONSE").respond(x)
+		return
+	}
+
+	challenge, done, err := auth(token)
+	if err != nil {
+		c.mu.Lock()
+		c.auth = nil
+		c.mu.Unlock()
+		Error(gocql.ErrCodeCredentials, err.Error()).respond(x)
+		return
+	}
+	op := frm.OpAuthChallenge
+	if done {
+		op = frm.OpAuthSuccess
+		c.mu.Lock()
+		c.auth, c.authenticated = nil, true
+		c.mu.Unlock()
+	}
+	w := x.newFrame(op)
+	w.writeBytes(challenge)
+	x.send(w)
+}
+
+// serve reads requests until the connection is closed, every request is processed in its own goroutine.
+func (c *conn) serve(wg *sync.WaitGroup) {
+	defer c.close()
+	for {
+		head, err := readHeader(c.nc)
+		if err != nil {
+			return
+		}
+		body := make([]byte, head.length)
+		if _, err := io.ReadFull(c.nc, body); err != nil {
+			return
+		}
+		if head.version&0x80 != 0 {
+			// a response frame sent by the client
+			return
+		}
+
+		x := &exchange{conn: c, version: head.version, stream: head.stream}
+		if v := head.version; v < minProtoVersion || v > maxProtoVersion {
+			if v > maxProtoVersion {
+				x.version = maxProtoVersion
+			}
+			Error(errCodeProtocol, fmt.Sprintf("Invalid or unsupported protocol version (%d); the lowest supported version is %d and the greatest is %d",
+				v, minProtoVersion, maxProtoVersion)).respond(x)
+			continue
+		}
+		if head.flags&flagHeaderCompression != 0 {
+			Error(errCodeProtocol, "compression is not supported").respond(x)
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.process(x, head, body)
+		}()
+	}
+}
+
+func (c *conn) process(x *exchange, head frameHeader, body []byte) {
+	r := &reader{buf: body}
+	if head.flags&frm.FlagCustomPayload != 0 {
+		r.readBytesMap()
+	}
+
+	var resp Response
+	switch head.op {
+	case frm.OpQuery, frm.OpPrepare, frm.OpExecute, frm.OpBatch, frm.OpRegister:
+		if !c.isAuthenticated() {
+			Error(errCodeProtocol, "the connection is not authenticated").respond(x)
+			return
+		}
+	}
+	switch head.op {
+	case frm.OpOptions:
+		w := x.newFrame(frm.OpSupported)
+		w.writeStringMultiMap(c.node.supported(c))
+		x.send(w)
+		return
+	case frm.OpStartup:
+		c.mu.Lock()
+		c.version = x.version
+		c.mu.Unlock()
+		opts := r.readStringMap()
+		if r.err == nil && opts["COMPRESSION"] != "" {
+			resp = Error(errCodeProtocol, "compression is not supported")
+			break
+		}
+		if a := c.node.cluster.cfg.Authenticator; a != nil {
+			c.mu.Lock()
+			c.auth, c.authenticated = a.NewExchange(), false
+			c.mu.Unlock()
+			w := x.newFrame(frm.OpAuthenticate)
+			w.writeString(a.Class)
+			x.send(w)
+			return
+		}
+		x.send(x.newFrame(frm.OpReady))
+		return
+	case frm.OpAuthResponse:
+		token := r.readBytes()
+		if r.err == nil {
+			c.authenticate(x, token)
+			return
+		}
+	case frm.OpRegister:
+		events := r.readStringList()
+		if r.err == nil {
+			c.mu.Lock()
+			c.events = make(map[string]bool, len(events))
+			for _, e := range events {
+				c.events[e] = true
+			}
+			c.mu.Unlock()
+			x.send(x.newFrame(frm.OpReady))
+			return
+		}
+	case frm.OpQuery:
+		x.req = c.newRequest(r.readLongString())
+		r.readQueryParams(x.req)
+		if r.err == nil {
+			resp = c.node.cluster.respond(x.req)
+		}
+	case frm.OpPrepare:
+		stmt := r.readLongString()
+		if r.err == nil {
+			c.prepare(x, stmt)
+			return
+		}
+	case frm.OpExecute:
+		id := r.readShortBytes()
+		stmt, ok := c.node.prepared(id)
+		if !ok {
+			resp = unprepared(id)
+			break
+		}
+		x.req = c.newRequest(stmt.statement)
+		x.req.Params = stmt.params
+		r.readQueryParams(x.req)
+		if r.err == nil {
+			resp = c.node.cluster.respond(x.req)
+		}
+	case frm.OpBatch:
+		resp = c.batch(x, r)
+	default:
+		resp = Error(errCodeProtocol, "unsupported request "+head.op.String())
+	}
+
+	if r.err != nil {
+		resp = Error(errCodeProtocol, r.err.Error())
+	}
+	if x.req == nil {
+		// the response of errors doesn't depend on the request, but paging does
+		x.req = &Request{}
+	}
+	resp.respond(x)
+}
+
+func (c *conn) newRequest(stmt string) *Request {
+	return &Request{
+		Statement: stmt,
+		Keyspace:  c.getKeyspace(),
+		Node:      c.node,
+		Shard:     c.shard,
+	}
+}
+
+func (c *conn) prepare(x *exchange, stmt string) {
+	sum := md5.Sum([]byte(stmt))
+	id := sum[:]
+	params := c.node.cluster.params(c.node, stmt)
+	c.node.prepare(id, preparedStatement{statement: stmt, params: params})
+
+	w := x.newFrame(frm.OpResult)
+	w.writeInt(frm.ResultKindPrepared)
+	w.writeShortBytes(id)
+	// <metadata> of the bound values
+	w.writeInt(0)
+	w.writeInt(int32(len(params)))
+	if x.version >= 4 {
+		w.writeInt(0) // <pk_count>
+	}
+	for _, col := range params {
+		w.writeString(col.Keyspace)
+		w.writeString(col.Table)
+		w.writeString(col.Name)
+		w.writeType(col.Type)
+	}
+	// <result_metadata>, the columns are sent with the rows
+	w.writeInt(0)
+	w.writeInt(0)
+	x.send(w)
+}
+
+// batch decodes a BATCH request. The response is the response to the first statement of the batch
+// which matches a rule, Void if there is none.
+func (c *conn) batch(x *exchange, r *reader) Response {
+	r.readByte() // <type>
+	n := int(r.readShort())
+	reqs := make([]*Request, 0, n)
+	var resp Response
+	for i := 0; i < n && r.err == nil; i++ {
+		var req *Request
+		if kind := r.readByte(); kind == 0 {
+			req = c.newRequest(r.readLongString())
+		} else {
+			id := r.readShortBytes()
+			stmt, ok := c.node.prepared(id)
+			if !ok && resp == nil {
+				resp = unprepared(id)
+			}
+			req = c.newRequest(stmt.statement)
+			req.Params = stmt.params
+		}
+		req.Batch = true
+		m := int(r.readShort())
+		for j := 0; j < m && r.err == nil; j++ {
+			req.Values = append(req.Values, r.readBytes())
+		}
+		reqs = append(reqs, req)
+	}
+	cons := r.readShort()
+	flags := r.readByte()
+	var serialCons uint16
+	var timestamp int64
+	if flags&flagWithSerialCons != 0 {
+		serialCons = r.readShort()
+	}
+	if flags&flagDefaultTimestamp != 0 {
+		timestamp = r.readLong()
+	}
+	if r.err != nil || resp != nil {
+		return resp
+	}
+
+	for _, req := range reqs {
+		req.Consistency = gocql.Consistency(cons)
+		req.SerialConsistency = gocql.Consistency(serialCons)
+		req.Timestamp = timestamp
+		if resp == nil {
+			resp = c.node.cluster.match(req)
+		}
+		c.node.cluster.record(req)
+	}
+	if resp == nil {
+		resp = Void()
+	}
+	return resp
+}
+
+// bindMarkers returns the columns of the bind markers of a statement. The type of the columns
+// is blob, which accepts strings and byte slices.
+func bindMarkers(stmt string) []Column {
+	var cols []Column
+	blob := Native(gocql.TypeBlob)
+	for i := 0; i < len(stmt); i++ {
+		switch ch := stmt[i]; ch {
+		case '\'', '"':
+			// skip string literals and quoted identifiers, quotes are escaped by doubling them
+			for i++; i < len(stmt); i++ {
+				if stmt[i] == ch {
+					if i+1 < len(stmt) && stmt[i+1] == ch {
+						i++
+						continue
+					}
+					break
+				}
+			}
+		case '?':
+			cols = append(cols, Column{Name: "p" + strconv.Itoa(len(cols)), Type: blob})
+		case ':':
+			if i > 0 && isIdentChar(stmt[i-1]) || i+1 >= len(stmt) || !isIdentStart(stmt[i+1]) {
+				continue
+			}
+			j := i + 1
+			for j < len(stmt) && isIdentChar(stmt[j]) {
+				j++
+			}
+			cols = append(cols, Column{Name: strings.ToLower(stmt[i+1 : j]), Type: blob})
+			i = j - 1
+		}
+	}
+	return cols
+}
+
+func isIdentStart(ch byte) bool {
+	return ch == '_' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z'
+}
+
+func isIdentChar(ch byte) bool {
+	return isIdentStart(ch) || ch >= '0' && ch <= '9'
+}
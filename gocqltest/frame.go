@@ -0,0 +1,312 @@
+package gocqltest
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/gocql/gocql"
+	frm "github.com/gocql/gocql/internal/frame"
+)
+
+const (
+	headerSize   = 9
+	maxFrameSize = 256 * 1024 * 1024
+
+	minProtoVersion = 3
+	maxProtoVersion = 4
+
+	// error codes of the native protocol, see gocql.ErrCodeServer and the other constants
+	errCodeServer       = 0x0000
+	errCodeProtocol     = 0x000A
+	errCodeUnavailable  = 0x1000
+	errCodeOverloaded   = 0x1001
+	errCodeWriteTimeout = 0x1100
+	errCodeReadTimeout  = 0x1200
+	errCodeUnprepared   = 0x2500
+
+	// rateLimitErrorCode is the code of the rate limit error advertised in the SCYLLA_RATE_LIMIT_ERROR extension,
+	// it is the same one Scylla uses.
+	rateLimitErrorCode = 0xF000
+
+	// flags of the query parameters
+	flagValues            = 0x01
+	flagPageSize          = 0x04
+	flagWithPagingState   = 0x08
+	flagWithSerialCons    = 0x10
+	flagDefaultTimestamp  = 0x20
+	flagWithNameValues    = 0x40
+	flagHeaderCompression = 0x01
+)
+
+var errShortFrame = errors.New("gocqltest: frame is too short")
+
+type frameHeader struct {
+	version byte
+	flags   byte
+	stream  int16
+	op      frm.Op
+	length  int
+}
+
+func readHeader(r io.Reader) (frameHeader, error) {
+	var buf [headerSize]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return frameHeader{}, err
+	}
+	head := frameHeader{
+		version: buf[0],
+		flags:   buf[1],
+		stream:  int16(binary.BigEndian.Uint16(buf[2:4])),
+		op:      frm.Op(buf[4]),
+		length:  int(binary.BigEndian.Uint32(buf[5:9])),
+	}
+	if head.length < 0 || head.length > maxFrameSize {
+		return frameHeader{}, fmt.Errorf("gocqltest: invalid frame length %d", head.length)
+	}
+	return head, nil
+}
+
+// reader decodes the body of a request frame. Decoding errors are sticky, they are reported by err
+// once the whole request is decoded.
+type reader struct {
+	buf []byte
+	err error
+}
+
+func (r *reader) read(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || len(r.buf) < n {
+		r.err = errShortFrame
+		return nil
+	}
+	b := r.buf[:n]
+	r.buf = r.buf[n:]
+	return b
+}
+
+func (r *reader) readByte() byte {
+	b := r.read(1)
+	if b == nil {
+		return 0
+	}
+	return b[0]
+}
+
+func (r *reader) readShort() uint16 {
+	b := r.read(2)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint16(b)
+}
+
+func (r *reader) readInt() int32 {
+	b := r.read(4)
+	if b == nil {
+		return 0
+	}
+	return int32(binary.BigEndian.Uint32(b))
+}
+
+func (r *reader) readLong() int64 {
+	b := r.read(8)
+	if b == nil {
+		return 0
+	}
+	return int64(binary.BigEndian.Uint64(b))
+}
+
+func (r *reader) readString() string {
+	return string(r.read(int(r.readShort())))
+}
+
+func (r *reader) readLongString() string {
+	return string(r.read(int(r.readInt())))
+}
+
+func (r *reader) readShortBytes() []byte {
+	return r.read(int(r.readShort()))
+}
+
+// readBytes returns nil for null and unset values.
+func (r *reader) readBytes() []byte {
+	n := r.readInt()
+	if n < 0 {
+		return nil
+	}
+	return r.read(int(n))
+}
+
+func (r *reader) readStringList() []string {
+	n := int(r.readShort())
+	list := make([]string, 0, n)
+	for i := 0; i < n && r.err == nil; i++ {
+		list = append(list, r.readString())
+	}
+	return list
+}
+
+func (r *reader) readStringMap() map[string]string {
+	n := int(r.readShort())
+	m := make(map[string]string, n)
+	for i := 0; i < n && r.err == nil; i++ {
+		k := r.readString()
+		m[k] = r.readString()
+	}
+	return m
+}
+
+func (r *reader) readBytesMap() {
+	n := int(r.readShort())
+	for i := 0; i < n && r.err == nil; i++ {
+		r.readString()
+		r.readBytes()
+	}
+}
+
+// readQueryParams decodes the <query_parameters> of QUERY and EXECUTE requests into req.
+func (r *reader) readQueryParams(req *Request) {
+	req.Consistency = gocql.Consistency(r.readShort())
+	flags := r.readByte()
+	if flags&flagValues != 0 {
+		n := int(r.readShort())
+		for i := 0; i < n && r.err == nil; i++ {
+			if flags&flagWithNameValues != 0 {
+				req.Names = append(req.Names, r.readString())
+			}
+			req.Values = append(req.Values, r.readBytes())
+		}
+	}
+	if flags&flagPageSize != 0 {
+		req.PageSize = int(r.readInt())
+	}
+	if flags&flagWithPagingState != 0 {
+		req.PagingState = r.readBytes()
+	}
+	if flags&flagWithSerialCons != 0 {
+		req.SerialConsistency = gocql.Consistency(r.readShort())
+	}
+	if flags&flagDefaultTimestamp != 0 {
+		req.Timestamp = r.readLong()
+	}
+}
+
+// writer encodes a response frame.
+type writer struct {
+	buf []byte
+}
+
+func newWriter(version byte, stream int16, op frm.Op) *writer {
+	w := &writer{buf: make([]byte, headerSize, 128)}
+	w.buf[0] = version | 0x80
+	binary.BigEndian.PutUint16(w.buf[2:4], uint16(stream))
+	w.buf[4] = byte(op)
+	return w
+}
+
+// finish sets the length of the body in the header and returns the frame.
+func (w *writer) finish() []byte {
+	binary.BigEndian.PutUint32(w.buf[5:9], uint32(len(w.buf)-headerSize))
+	return w.buf
+}
+
+func (w *writer) writeByte(b byte) {
+	w.buf = append(w.buf, b)
+}
+
+func (w *writer) writeShort(n uint16) {
+	w.buf = binary.BigEndian.AppendUint16(w.buf, n)
+}
+
+func (w *writer) writeInt(n int32) {
+	w.buf = binary.BigEndian.AppendUint32(w.buf, uint32(n))
+}
+
+func (w *writer) writeString(s string) {
+	w.writeShort(uint16(len(s)))
+	w.buf = append(w.buf, s...)
+}
+
+func (w *writer) writeShortBytes(b []byte) {
+	w.writeShort(uint16(len(b)))
+	w.buf = append(w.buf, b...)
+}
+
+// writeBytes writes nil as null.
+func (w *writer) writeBytes(b []byte) {
+	if b == nil {
+		w.writeInt(-1)
+		return
+	}
+	w.writeInt(int32(len(b)))
+	w.buf = append(w.buf, b...)
+}
+
+func (w *writer) writeStringList(list []string) {
+	w.writeShort(uint16(len(list)))
+	for _, s := range list {
+		w.writeString(s)
+	}
+}
+
+func (w *writer) writeStringMultiMap(m map[string][]string) {
+	w.writeShort(uint16(len(m)))
+	for k, v := range m {
+		w.writeString(k)
+		w.writeShort(uint16(len(v)))
+		for _, s := range v {
+			w.writeString(s)
+		}
+	}
+}
+
+// writeType writes the <option> describing a CQL type.
+func (w *writer) writeType(info gocql.TypeInfo) {
+	w.writeShort(uint16(info.Type()))
+	switch t := info.(type) {
+	case gocql.CollectionType:
+		if t.Type() == gocql.TypeMap {
+			w.writeType(t.Key)
+		}
+		w.writeType(t.Elem)
+	case gocql.TupleTypeInfo:
+		w.writeShort(uint16(len(t.Elems)))
+		for _, elem := range t.Elems {
+			w.writeType(elem)
+		}
+	case gocql.UDTTypeInfo:
+		w.writeString(t.KeySpace)
+		w.writeString(t.Name)
+		w.writeShort(uint16(len(t.Elements)))
+		for _, field := range t.Elements {
+			w.writeString(field.Name)
+			w.writeType(field.Type)
+		}
+	default:
+		if info.Type() == gocql.TypeCustom {
+			w.writeString(info.Custom())
+		}
+	}
+}
+
+// writeMetadata writes the <metadata> of rows, without a global table spec.
+func (w *writer) writeMetadata(flags int32, pagingState []byte, cols []Column) {
+	if pagingState != nil {
+		flags |= int32(frm.FlagHasMorePages)
+	}
+	w.writeInt(flags)
+	w.writeInt(int32(len(cols)))
+	if pagingState != nil {
+		w.writeBytes(pagingState)
+	}
+	for _, col := range cols {
+		w.writeString(col.Keyspace)
+		w.writeString(col.Table)
+		w.writeString(col.Name)
+		w.writeType(col.Type)
+	}
+}
@@ -0,0 +1,322 @@
+package gocqltest
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/gocql/gocql"
+	frm "github.com/gocql/gocql/internal/frame"
+)
+
+// Request is a QUERY or EXECUTE request, or a statement of a BATCH request, received by a node.
+type Request struct {
+	// Statement is the CQL statement, prepared statements are resolved to the statement they were prepared from.
+	Statement string
+	// Values are the serialized bound values, nil for null and unset values.
+	Values [][]byte
+	// Names are the names of the bound values if they are bound by name.
+	Names []string
+	// Params are the columns of the bound values of a prepared statement, see Rule.Params.
+	Params []Column
+	// Consistency is the consistency of the request.
+	Consistency gocql.Consistency
+	// SerialConsistency is the serial consistency of the request, zero if not set.
+	SerialConsistency gocql.Consistency
+	// PageSize is the requested page size, zero if not set.
+	PageSize int
+	// PagingState is the paging state of the request, nil for the first page.
+	PagingState []byte
+	// Timestamp is the default timestamp of the request in microseconds, zero if not set.
+	Timestamp int64
+	// Keyspace is the keyspace the connection switched to with USE.
+	Keyspace string
+	// Batch is true if the statement is a part of a BATCH request.
+	Batch bool
+	// Node is the node which received the request.
+	Node *Node
+	// Shard is the shard of the connection, -1 if the cluster is not sharded.
+	Shard int
+}
+
+// Bind unmarshals the i-th bound value into dest using the type declared with Rule.Params.
+func (r *Request) Bind(i int, dest interface{}) error {
+	if i < 0 || i >= len(r.Values) {
+		return fmt.Errorf("gocqltest: request has %d bound values, can't bind value %d", len(r.Values), i)
+	}
+	if i >= len(r.Params) {
+		return fmt.Errorf("gocqltest: the type of bound value %d is unknown", i)
+	}
+	return gocql.Unmarshal(r.Params[i].Type, r.Values[i], dest)
+}
+
+// Column describes a column of rows returned by Rows or a bound value declared with Rule.Params.
+type Column struct {
+	Keyspace string
+	Table    string
+	Name     string
+	Type     gocql.TypeInfo
+}
+
+// Col returns a column of a native type.
+func Col(name string, typ gocql.Type) Column {
+	return Column{Name: name, Type: Native(typ)}
+}
+
+// Native returns the type info of a native type.
+func Native(typ gocql.Type) gocql.TypeInfo {
+	return gocql.NewNativeType(maxProtoVersion, typ)
+}
+
+// ListOf returns the type info of a list.
+func ListOf(elem gocql.TypeInfo) gocql.TypeInfo {
+	return gocql.NewCollectionType(gocql.NewNativeType(maxProtoVersion, gocql.TypeList), nil, elem)
+}
+
+// SetOf returns the type info of a set.
+func SetOf(elem gocql.TypeInfo) gocql.TypeInfo {
+	return gocql.NewCollectionType(gocql.NewNativeType(maxProtoVersion, gocql.TypeSet), nil, elem)
+}
+
+// MapOf returns the type info of a map.
+func MapOf(key, elem gocql.TypeInfo) gocql.TypeInfo {
+	return gocql.NewCollectionType(gocql.NewNativeType(maxProtoVersion, gocql.TypeMap), key, elem)
+}
+
+// Handler returns the response to a request, nil to leave the request to the rules registered
+// before it and the built-in responses.
+type Handler func(req *Request) Response
+
+// Response is the response of a node to a request.
+type Response interface {
+	respond(x *exchange)
+}
+
+// exchange is a request being responded to.
+type exchange struct {
+	conn    *conn
+	req     *Request
+	version byte
+	stream  int16
+}
+
+func (x *exchange) newFrame(op frm.Op) *writer {
+	return newWriter(x.version, x.stream, op)
+}
+
+func (x *exchange) send(w *writer) {
+	x.conn.write(w.finish())
+}
+
+type voidResponse struct{}
+
+// Void returns an empty result, the response to statements which don't return rows.
+func Void() Response {
+	return voidResponse{}
+}
+
+func (voidResponse) respond(x *exchange) {
+	w := x.newFrame(frm.OpResult)
+	w.writeInt(frm.ResultKindVoid)
+	x.send(w)
+}
+
+type rowsResponse struct {
+	cols []Column
+	rows [][]interface{}
+}
+
+// Rows returns rows with the given columns, the values are marshaled with gocql.Marshal. The rows are
+// split into pages of the page size of the request.
+func Rows(cols []Column, rows ...[]interface{}) Response {
+	return &rowsResponse{cols: cols, rows: rows}
+}
+
+func (r *rowsResponse) respond(x *exchange) {
+	rows := r.rows
+	var offset int
+	if state := x.req.PagingState; len(state) == 8 {
+		offset = int(binary.BigEndian.Uint64(state))
+	}
+	if offset > len(rows) {
+		offset = len(rows)
+	}
+	rows = rows[offset:]
+
+	var pagingState []byte
+	if size := x.req.PageSize; size > 0 && len(rows) > size {
+		rows = rows[:size]
+		pagingState = binary.BigEndian.AppendUint64(nil, uint64(offset+size))
+	}
+
+	w := x.newFrame(frm.OpResult)
+	w.writeInt(frm.ResultKindRows)
+	w.writeMetadata(0, pagingState, r.cols)
+	w.writeInt(int32(len(rows)))
+	for i, row := range rows {
+		if len(row) != len(r.cols) {
+			Error(errCodeServer, fmt.Sprintf("gocqltest: row %d has %d values, expected %d", offset+i, len(row), len(r.cols))).respond(x)
+			return
+		}
+		for j, v := range row {
+			b, err := gocql.Marshal(r.cols[j].Type, v)
+			if err != nil {
+				Error(errCodeServer, fmt.Sprintf("gocqltest: column %s of row %d: %v", r.cols[j].Name, offset+i, err)).respond(x)
+				return
+			}
+			w.writeBytes(b)
+		}
+	}
+	x.send(w)
+}
+
+type keyspaceResponse string
+
+func (ks keyspaceResponse) respond(x *exchange) {
+	x.conn.setKeyspace(string(ks))
+	w := x.newFrame(frm.OpResult)
+	w.writeInt(frm.ResultKindKeyspace)
+	w.writeString(string(ks))
+	x.send(w)
+}
+
+type errorResponse struct {
+	code    int32
+	message string
+	// body writes the additional information of the error.
+	body func(w *writer)
+}
+
+// Error returns an error with the given code, e.g. gocql.ErrCodeInvalid, and message.
+func Error(code int, message string) Response {
+	return &errorResponse{code: int32(code), message: message}
+}
+
+// Unavailable returns an unavailable error, it's received as *gocql.RequestErrUnavailable.
+func Unavailable(cons gocql.Consistency, required, alive int) Response {
+	return &errorResponse{
+		code:    errCodeUnavailable,
+		message: "Cannot achieve consistency level",
+		body: func(w *writer) {
+			w.writeShort(uint16(cons))
+			w.writeInt(int32(required))
+			w.writeInt(int32(alive))
+		},
+	}
+}
+
+// ReadTimeout returns a read timeout error, it's received as *gocql.RequestErrReadTimeout.
+func ReadTimeout(cons gocql.Consistency, received, blockFor int, dataPresent bool) Response {
+	return &errorResponse{
+		code:    errCodeReadTimeout,
+		message: "Operation timed out - received only " + fmt.Sprint(received) + " responses.",
+		body: func(w *writer) {
+			w.writeShort(uint16(cons))
+			w.writeInt(int32(received))
+			w.writeInt(int32(blockFor))
+			if dataPresent {
+				w.writeByte(1)
+			} else {
+				w.writeByte(0)
+			}
+		},
+	}
+}
+
+// WriteTimeout returns a write timeout error, it's received as *gocql.RequestErrWriteTimeout.
+// The write type is e.g. "SIMPLE", "BATCH" or "CAS".
+func WriteTimeout(cons gocql.Consistency, received, blockFor int, writeType string) Response {
+	return &errorResponse{
+		code:    errCodeWriteTimeout,
+		message: "Operation timed out - received only " + fmt.Sprint(received) + " responses.",
+		body: func(w *writer) {
+			w.writeShort(uint16(cons))
+			w.writeInt(int32(received))
+			w.writeInt(int32(blockFor))
+			w.writeString(writeType)
+		},
+	}
+}
+
+// Overloaded returns an overloaded error.
+func Overloaded() Response {
+	return Error(errCodeOverloaded, "Coordinator is overloaded")
+}
+
+// RateLimitReached returns the rate limit error of Scylla, it's received as *gocql.RequestErrRateLimitReached.
+func RateLimitReached(opType gocql.OpType, rejectedByCoordinator bool) Response {
+	return &errorResponse{
+		code:    rateLimitErrorCode,
+		message: "Per-partition rate limit reached",
+		body: func(w *writer) {
+			w.writeByte(byte(opType))
+			if rejectedByCoordinator {
+				w.writeByte(1)
+			} else {
+				w.writeByte(0)
+			}
+		},
+	}
+}
+
+func unprepared(id []byte) Response {
+	return &errorResponse{
+		code:    errCodeUnprepared,
+		message: "Prepared query with ID " + fmt.Sprintf("%x", id) + " not found",
+		body: func(w *writer) {
+			w.writeShortBytes(id)
+		},
+	}
+}
+
+func (e *errorResponse) respond(x *exchange) {
+	w := x.newFrame(frm.OpError)
+	w.writeInt(e.code)
+	w.writeString(e.message)
+	if e.body != nil {
+		e.body(w)
+	}
+	x.send(w)
+}
+
+type delayResponse struct {
+	delay time.Duration
+	resp  Response
+}
+
+// Delay returns resp after the given delay.
+func Delay(delay time.Duration, resp Response) Response {
+	return &delayResponse{delay: delay, resp: resp}
+}
+
+func (d *delayResponse) respond(x *exchange) {
+	timer := time.NewTimer(d.delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		d.resp.respond(x)
+	case <-x.conn.closed:
+	}
+}
+
+type dropResponse struct{}
+
+// DropConnection closes the connection the request was received on without responding.
+func DropConnection() Response {
+	return dropResponse{}
+}
+
+func (dropResponse) respond(x *exchange) {
+	x.conn.close()
+}
+
+type noResponse struct{}
+
+// NoResponse never responds to the request, the client times out.
+func NoResponse() Response {
+	return noResponse{}
+}
+
+func (noResponse) respond(x *exchange) {
+	<-x.conn.closed
+}
@@ -811,7 +811,7 @@ func (s *Session) refreshRing() error {
 		s.metadataDescriber.RemoveTabletsWithHost(host)
 		s.removeHost(host)
 	}
-	s.policy.SetPartitioner(partitioner)
+	s.policies.SetPartitioner(partitioner)
 
 	return nil
 }
@@ -53,10 +53,11 @@ type subscriber[T any] struct {
 
 // Subscriber provides access to events and control over the subscription
 type Subscriber[T any] struct {
-	ch   <-chan T
-	eb   *EventBus[T]
-	name string
-	id   int
+	ch     <-chan T
+	eb     *EventBus[T]
+	onStop func()
+	name   string
+	id     int
 }
 
 // Events returns the channel to receive events from
@@ -66,7 +67,11 @@ func (s *Subscriber[T]) Events() <-chan T {
 
 // Stop unsubscribes and closes the subscriber's channel
 func (s *Subscriber[T]) Stop() error {
-	return s.eb.remove(s)
+	err := s.eb.remove(s)
+	if err == nil && s.onStop != nil {
+		s.onStop()
+	}
+	return err
 }
 
 type status uint8
@@ -204,6 +209,13 @@ func (eb *EventBus[T]) Subscribe(name string, queueSize int, filter FilterFunc[T
 	}
 }
 
+// SubscribeWithStop is like Subscribe, onStop is called once the subscriber is stopped with Stop.
+func (eb *EventBus[T]) SubscribeWithStop(name string, queueSize int, filter FilterFunc[T], onStop func()) *Subscriber[T] {
+	sub := eb.Subscribe(name, queueSize, filter)
+	sub.onStop = onStop
+	return sub
+}
+
 // Unsubscribe removes a subscriber from the event bus and closes its channel.
 // Returns ErrSubscriberNotFound if the subscriber doesn't exist.
 func (eb *EventBus[T]) remove(s *Subscriber[T]) error {
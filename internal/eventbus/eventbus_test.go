@@ -327,6 +327,26 @@ func TestSubscribeWithContext(t *testing.T) {
 	}
 }
 
+func TestSubscribeWithStop(t *testing.T) {
+	eb := New[int](EventBusConfig{InputEventsQueueSize: 10}, nil)
+	if err := eb.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer eb.Stop()
+
+	stopped := 0
+	sub := eb.SubscribeWithStop("test", 10, nil, func() { stopped++ })
+	if err := sub.Stop(); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	if err := sub.Stop(); err != ErrSubscriberNotFound {
+		t.Fatalf("expected ErrSubscriberNotFound, got %v", err)
+	}
+	if stopped != 1 {
+		t.Fatalf("expected onStop to be called once, got %d", stopped)
+	}
+}
+
 func TestChannelClosedOnStop(t *testing.T) {
 	eb := New[int](
 		EventBusConfig{
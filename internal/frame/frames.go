@@ -63,6 +63,8 @@ const (
 	FlagDefaultTimestamp      byte = 0x20
 	FlagWithNameValues        byte = 0x40
 	FlagWithKeyspace          byte = 0x80
+	// FlagWithNowInSeconds is a query and batch flag of protocol 5, the flags are an int since then.
+	FlagWithNowInSeconds uint32 = 0x100
 
 	// prepare flags
 	FlagWithPreparedKeyspace uint32 = 0x01
@@ -140,6 +140,20 @@ func (c *Cache) removeElement(e *list.Element) {
 	}
 }
 
+// Range calls fn for the items from the most to the least recently used, until fn returns false.
+// It doesn't change the order of the items.
+func (c *Cache) Range(fn func(key string, value interface{}) bool) {
+	if c.cache == nil {
+		return
+	}
+	for e := c.ll.Front(); e != nil; e = e.Next() {
+		kv := e.Value.(*entry)
+		if !fn(kv.key, kv.value) {
+			return
+		}
+	}
+}
+
 // Len returns the number of items in the cache.
 func (c *Cache) Len() int {
 	if c.cache == nil {
@@ -91,3 +91,22 @@ func TestRemove(t *testing.T) {
 		t.Fatal("TestRemove returned a removed entry")
 	}
 }
+
+func TestRange(t *testing.T) {
+	t.Parallel()
+
+	lru := New(0)
+	lru.Add("a", 1)
+	lru.Add("b", 2)
+	lru.Add("c", 3)
+	lru.Get("a")
+
+	var keys []string
+	lru.Range(func(key string, value interface{}) bool {
+		keys = append(keys, key)
+		return len(keys) < 2
+	})
+	if len(keys) != 2 || keys[0] != "a" || keys[1] != "c" {
+		t.Fatalf("expected [a c], got %v", keys)
+	}
+}
@@ -0,0 +1,158 @@
+package gocql
+
+import (
+	"iter"
+)
+
+// Row is a row yielded by Iter.Rows or Page.Rows. It is only valid until the iteration
+// moves on to the next row.
+type Row struct {
+	iter *Iter
+	cols [][]byte
+}
+
+// Columns returns the name and type of the columns of the row.
+func (r *Row) Columns() []ColumnInfo {
+	return r.iter.meta.columns
+}
+
+// Scan copies the columns of the row into the values pointed at by dest, see Iter.Scan.
+func (r *Row) Scan(dest ...interface{}) error {
+	return scanColumns(r.iter.codecs, &r.iter.meta, r.cols, dest)
+}
+
+// PageState returns the paging state of the page the row belongs to, which can be passed
+// to Query.PageState to resume fetching rows with the following page.
+func (r *Row) PageState() []byte {
+	return r.iter.meta.pagingState
+}
+
+// read reads the columns of the current row into r and advances the iterator.
+func (r *Row) read() error {
+	if cap(r.cols) < len(r.iter.meta.columns) {
+		r.cols = make([][]byte, len(r.iter.meta.columns))
+	}
+	r.cols = r.cols[:len(r.iter.meta.columns)]
+
+	for i := range r.cols {
+		col, err := r.iter.readColumn()
+		if err != nil {
+			return err
+		}
+		r.cols[i] = col
+	}
+	r.iter.pos++
+	return nil
+}
+
+// Rows returns an iterator over the remaining rows. The next pages are fetched as needed,
+// prefetching them as configured by Query.Prefetch. The yielded row is reused, it is only
+// valid until the next iteration.
+//
+// The iterator is closed once the iteration stops, including when the loop is exited early.
+// If an error occurred during the query or the iteration, it is yielded with a nil row as the
+// last element, so it can't be missed:
+//
+//	for row, err := range session.Query(`SELECT id, text FROM tweet WHERE timeline = ?`, "me").Iter().Rows() {
+//		if err != nil {
+//			return err
+//		}
+//		if err := row.Scan(&id, &text); err != nil {
+//			return err
+//		}
+//	}
+//
+// The iter should NOT be used again after calling this method.
+func (iter *Iter) Rows() iter.Seq2[*Row, error] {
+	return func(yield func(*Row, error) bool) {
+		row := &Row{iter: iter}
+		for iter.nextRow() {
+			if err := row.read(); err != nil {
+				iter.err = err
+				break
+			}
+			if !yield(row, nil) {
+				iter.Close()
+				return
+			}
+		}
+
+		if err := iter.Close(); err != nil {
+			yield(nil, err)
+		}
+	}
+}
+
+// Page is a page of rows yielded by Iter.Pages. It is only valid until the iteration moves
+// on to the next page.
+type Page struct {
+	iter *Iter
+}
+
+// NumRows returns the number of rows in the page.
+func (p *Page) NumRows() int {
+	return p.iter.numRows
+}
+
+// PageState returns the paging state which can be passed to Query.PageState to fetch the
+// page following this one. It is empty on the last page.
+func (p *Page) PageState() []byte {
+	return p.iter.meta.pagingState
+}
+
+// Columns returns the name and type of the columns of the rows in the page.
+func (p *Page) Columns() []ColumnInfo {
+	return p.iter.meta.columns
+}
+
+// Rows returns an iterator over the remaining rows of the page. Unlike Iter.Rows it never
+// crosses the page boundary, although the next page is prefetched as configured by Query.Prefetch.
+// Errors reading the rows are yielded with a nil row and recorded on the Iter, so they are
+// also yielded by Iter.Pages.
+func (p *Page) Rows() iter.Seq2[*Row, error] {
+	return func(yield func(*Row, error) bool) {
+		it := p.iter
+		row := &Row{iter: it}
+		for it.err == nil && it.pos < it.numRows {
+			if it.next != nil && it.pos >= it.next.pos {
+				it.next.fetchAsync()
+			}
+			if err := row.read(); err != nil {
+				it.err = err
+				yield(nil, err)
+				return
+			}
+			if !yield(row, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Pages returns an iterator over the remaining pages of the iterator, starting with the
+// current one. Rows of a page left unread are skipped when moving on to the next page.
+//
+// The iterator is closed once the iteration stops, including when the loop is exited early.
+// If an error occurred during the query or the iteration, it is yielded with a nil page as the
+// last element.
+//
+// The iter should NOT be used again after calling this method.
+func (iter *Iter) Pages() iter.Seq2[*Page, error] {
+	return func(yield func(*Page, error) bool) {
+		page := &Page{iter: iter}
+		for iter.err == nil {
+			if !yield(page, nil) {
+				iter.Close()
+				return
+			}
+			if iter.err != nil || iter.next == nil {
+				break
+			}
+			*iter = *iter.next.fetch()
+		}
+
+		if err := iter.Close(); err != nil {
+			yield(nil, err)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+//go:build unit
+// +build unit
+
+package gocql
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// newPagedTestIter returns an iterator over the given pages of scanTestMetadata rows with ids
+// starting from 1, the next pages are fetched without a query.
+func newPagedTestIter(pageSizes ...int) *Iter {
+	var (
+		first *Iter
+		prev  *Iter
+		id    int
+	)
+	for i, size := range pageSizes {
+		rows := make([][]interface{}, size)
+		for j := range rows {
+			id++
+			rows[j] = []interface{}{id, "name", "email", time.Now()}
+		}
+
+		page := newScanTestIter(scanTestMetadata, rows...)
+		if i < len(pageSizes)-1 {
+			page.meta.pagingState = []byte{byte(i + 1)}
+		}
+
+		if prev == nil {
+			first = page
+		} else {
+			next := &nextIter{next: page}
+			next.once.Do(func() {})
+			prev.next = next
+		}
+		prev = page
+	}
+	return first
+}
+
+func TestIterRows(t *testing.T) {
+	t.Parallel()
+
+	iter := newPagedTestIter(2, 0, 3)
+
+	var (
+		ids        []int
+		pageStates []string
+	)
+	for row, err := range iter.Rows() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		var u scanTestUser
+		if err := row.Scan(&u.ID, &u.Name, &u.Email, &u.Created); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, u.ID)
+		pageStates = append(pageStates, string(row.PageState()))
+	}
+
+	if !reflect.DeepEqual(ids, []int{1, 2, 3, 4, 5}) {
+		t.Fatalf("unexpected rows %v", ids)
+	}
+	if !reflect.DeepEqual(pageStates, []string{"\x01", "\x01", "", "", ""}) {
+		t.Fatalf("unexpected page states %q", pageStates)
+	}
+	if iter.closed != 1 {
+		t.Fatal("expected the iterator to be closed")
+	}
+}
+
+func TestIterRowsError(t *testing.T) {
+	t.Parallel()
+
+	iter := newPagedTestIter(1, 1)
+	fetchErr := errors.New("fetch failed")
+	iter.next.next = &Iter{err: fetchErr}
+
+	var rows, errs int
+	for row, err := range iter.Rows() {
+		if err != nil {
+			if row != nil || !errors.Is(err, fetchErr) {
+				t.Fatalf("unexpected error %v with row %v", err, row)
+			}
+			errs++
+			continue
+		}
+		rows++
+	}
+
+	if rows != 1 || errs != 1 {
+		t.Fatalf("expected one row and one error, got %d rows and %d errors", rows, errs)
+	}
+}
+
+func TestIterRowsEarlyExit(t *testing.T) {
+	t.Parallel()
+
+	iter := newPagedTestIter(3)
+	for _, err := range iter.Rows() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		break
+	}
+	if iter.closed != 1 {
+		t.Fatal("expected the iterator to be closed")
+	}
+}
+
+func TestIterPages(t *testing.T) {
+	t.Parallel()
+
+	iter := newPagedTestIter(3, 2, 1)
+
+	var (
+		pages [][]int
+		sizes []int
+	)
+	for page, err := range iter.Pages() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		sizes = append(sizes, page.NumRows())
+
+		var ids []int
+		for row, err := range page.Rows() {
+			if err != nil {
+				t.Fatal(err)
+			}
+			var u scanTestUser
+			if err := row.Scan(&u.ID, &u.Name, &u.Email, &u.Created); err != nil {
+				t.Fatal(err)
+			}
+			ids = append(ids, u.ID)
+			// leave the rest of the first page unread
+			if len(pages) == 0 {
+				break
+			}
+		}
+		pages = append(pages, ids)
+
+		if len(page.PageState()) == 0 && len(pages) != 3 {
+			t.Fatalf("expected only the last page to have no page state, got it on page %d", len(pages))
+		}
+	}
+
+	if !reflect.DeepEqual(sizes, []int{3, 2, 1}) {
+		t.Fatalf("unexpected page sizes %v", sizes)
+	}
+	if !reflect.DeepEqual(pages, [][]int{{1}, {4, 5}, {6}}) {
+		t.Fatalf("unexpected pages %v", pages)
+	}
+	if iter.closed != 1 {
+		t.Fatal("expected the iterator to be closed")
+	}
+}
@@ -0,0 +1,973 @@
+package gocql
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gopkg.in/inf.v0"
+)
+
+// The conversions between CQL values and JSON follow the SELECT JSON and INSERT JSON
+// statements of Cassandra:
+//
+//	CQL type                    | JSON                     | Note
+//	ascii, varchar, text        | string                   |
+//	blob, custom                | string                   | hex with 0x prefix
+//	boolean                     | boolean                  | "true" and "false" are accepted
+//	tinyint, smallint, int      | number                   | strings are accepted
+//	bigint, counter, varint     | number                   | strings are accepted
+//	decimal                     | number                   | strings are accepted
+//	float, double               | number                   | "NaN", "Infinity" and "-Infinity" for the special values
+//	uuid, timeuuid, inet        | string                   |
+//	timestamp                   | string                   | "2006-01-02 15:04:05.000Z", milliseconds since Unix epoch are accepted
+//	date                        | string                   | "2006-01-02"
+//	time                        | string                   | "15:04:05.000000000", nanoseconds since midnight are accepted
+//	duration                    | string                   | "1y2mo3d4h5m6s7ms8us9ns", ISO 8601 durations are accepted
+//	list, set, vector           | array                    |
+//	map                         | object                   | keys of non-text types are the JSON of the key as a string
+//	tuple                       | array                    |
+//	user-defined type           | object                   |
+//	null                        | null                     |
+//
+// The names of the columns and the fields of user-defined types which aren't lower case
+// identifiers are quoted, for example {"id": 1, "\"Name\"": "a"}.
+
+const (
+	jsonTimestampLayout = "2006-01-02 15:04:05.000Z"
+	jsonDateLayout      = "2006-01-02"
+)
+
+// jsonTimestampLayouts are the accepted formats of timestamps, fractional seconds are accepted by each of them.
+var jsonTimestampLayouts = []string{
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02T15:04:05Z07:00",
+	"2006-01-02 15:04:05Z0700",
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04Z07:00",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02 15:04Z0700",
+	"2006-01-02T15:04Z0700",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04",
+	"2006-01-02Z07:00",
+	"2006-01-02Z0700",
+	"2006-01-02",
+}
+
+// CQLToJSON converts the CQL encoded data of the type described by info to JSON,
+// like the SELECT JSON statement.
+func CQLToJSON(info TypeInfo, data []byte) ([]byte, error) {
+	return appendCQLJSON(nil, info, data)
+}
+
+// JSONToCQL converts the JSON to the CQL encoding of the type described by info,
+// like the INSERT JSON statement. JSON null is converted to CQL null.
+func JSONToCQL(info TypeInfo, data []byte) ([]byte, error) {
+	value, err := decodeJSON(data)
+	if err != nil {
+		return nil, marshalErrorf("can not marshal JSON into %s: %v", info, err)
+	}
+	return JSONValueToCQL(info, value)
+}
+
+// JSONValueToCQL converts a value decoded by encoding/json to the CQL encoding of the type
+// described by info, see JSONToCQL. Numbers can be decoded as json.Number or float64.
+func JSONValueToCQL(info TypeInfo, value interface{}) ([]byte, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	switch info.Type() {
+	case TypeAscii, TypeVarchar, TypeText:
+		s, ok := value.(string)
+		if !ok {
+			return nil, jsonMarshalError(info, value)
+		}
+		return Marshal(info, s)
+	case TypeBoolean:
+		switch v := value.(type) {
+		case bool:
+			return Marshal(info, v)
+		case string:
+			switch strings.ToLower(v) {
+			case "true":
+				return Marshal(info, true)
+			case "false":
+				return Marshal(info, false)
+			}
+		}
+		return nil, jsonMarshalError(info, value)
+	case TypeTinyInt, TypeSmallInt, TypeInt, TypeBigInt, TypeCounter, TypeVarint:
+		n, err := parseJSONInteger(value)
+		if err != nil {
+			return nil, marshalErrorf("can not marshal JSON %v into %s: %v", value, info, err)
+		}
+		if info.Type() == TypeVarint {
+			return Marshal(info, n)
+		}
+		if !n.IsInt64() {
+			return nil, marshalErrorf("can not marshal JSON %v into %s: value out of range", value, info)
+		}
+		return Marshal(info, n.Int64())
+	case TypeDecimal:
+		dec, err := parseJSONDecimal(value)
+		if err != nil {
+			return nil, marshalErrorf("can not marshal JSON %v into %s: %v", value, info, err)
+		}
+		return Marshal(info, dec)
+	case TypeFloat, TypeDouble:
+		f, err := parseJSONFloat(value)
+		if err != nil {
+			return nil, marshalErrorf("can not marshal JSON %v into %s: %v", value, info, err)
+		}
+		if info.Type() == TypeFloat {
+			return Marshal(info, float32(f))
+		}
+		return Marshal(info, f)
+	case TypeUUID, TypeTimeUUID, TypeInet:
+		s, ok := value.(string)
+		if !ok {
+			return nil, jsonMarshalError(info, value)
+		}
+		return Marshal(info, s)
+	case TypeTimestamp:
+		if s, ok := value.(string); ok {
+			t, err := parseJSONTimestamp(s)
+			if err != nil {
+				return nil, marshalErrorf("can not marshal JSON %q into %s: %v", s, info, err)
+			}
+			return Marshal(info, t)
+		}
+		n, err := parseJSONInteger(value)
+		if err != nil || !n.IsInt64() {
+			return nil, jsonMarshalError(info, value)
+		}
+		return Marshal(info, n.Int64())
+	case TypeDate:
+		s, ok := value.(string)
+		if !ok {
+			return nil, jsonMarshalError(info, value)
+		}
+		t, err := time.Parse(jsonDateLayout, s)
+		if err != nil {
+			return nil, marshalErrorf("can not marshal JSON %q into %s: %v", s, info, err)
+		}
+		return Marshal(info, t)
+	case TypeTime:
+		if s, ok := value.(string); ok {
+			d, err := parseJSONTime(s)
+			if err != nil {
+				return nil, marshalErrorf("can not marshal JSON %q into %s: %v", s, info, err)
+			}
+			return Marshal(info, d)
+		}
+		n, err := parseJSONInteger(value)
+		if err != nil || !n.IsInt64() {
+			return nil, jsonMarshalError(info, value)
+		}
+		return Marshal(info, time.Duration(n.Int64()))
+	case TypeDuration:
+		s, ok := value.(string)
+		if !ok {
+			return nil, jsonMarshalError(info, value)
+		}
+		d, err := parseJSONDuration(s)
+		if err != nil {
+			return nil, marshalErrorf("can not marshal JSON %q into %s: %v", s, info, err)
+		}
+		return Marshal(info, d)
+	case TypeList, TypeSet:
+		collection, ok := info.(CollectionType)
+		if !ok {
+			return nil, jsonMarshalError(info, value)
+		}
+		elems, err := jsonElements(collection.Elem, value)
+		if err != nil {
+			return nil, err
+		}
+		return Marshal(info, elems)
+	case TypeMap:
+		return marshalJSONMap(info, value)
+	case TypeTuple:
+		tuple, ok := info.(TupleTypeInfo)
+		v, isArray := value.([]interface{})
+		if !ok || !isArray || len(v) > len(tuple.Elems) {
+			return nil, jsonMarshalError(info, value)
+		}
+		elems := make([]interface{}, len(tuple.Elems))
+		for i, elem := range v {
+			data, err := JSONValueToCQL(tuple.Elems[i], elem)
+			if err != nil {
+				return nil, err
+			}
+			if data != nil {
+				elems[i] = encodedValue(data)
+			}
+		}
+		return Marshal(info, elems)
+	case TypeUDT:
+		return marshalJSONUDT(info, value)
+	case TypeCustom:
+		if vector, ok := info.(VectorType); ok {
+			elems, err := jsonElements(vector.SubType, value)
+			if err != nil {
+				return nil, err
+			}
+			return Marshal(info, elems)
+		}
+	}
+
+	// blob and custom types
+	s, ok := value.(string)
+	if !ok || !strings.HasPrefix(s, "0x") {
+		return nil, jsonMarshalError(info, value)
+	}
+	data, err := hex.DecodeString(s[2:])
+	if err != nil {
+		return nil, marshalErrorf("can not marshal JSON %q into %s: %v", s, info, err)
+	}
+	return data, nil
+}
+
+// JSONRowValues converts the JSON object, decoded by encoding/json, to the values of the columns,
+// like the INSERT JSON statement. The keys are matched to the column names case-insensitively
+// unless they are quoted, the columns which are not present are null. A key which doesn't match
+// any of the columns is an error.
+//
+// It can be used with Session.Bind, which passes the columns of the prepared statement to the binding:
+//
+//	session.Bind(`INSERT INTO users (id, name) VALUES (?, ?)`, func(q *gocql.QueryInfo) ([]interface{}, error) {
+//		return gocql.JSONRowValues(q.Args, row)
+//	})
+func JSONRowValues(columns []ColumnInfo, row map[string]interface{}) ([]interface{}, error) {
+	indexes := make(map[string]int, len(columns))
+	for i, col := range columns {
+		indexes[col.Name] = i
+	}
+
+	values := make([]interface{}, len(columns))
+	for key, value := range row {
+		i, ok := indexes[jsonKeyName(key)]
+		if !ok {
+			return nil, fmt.Errorf("gocql: JSON key %q doesn't match any of the columns", key)
+		}
+		data, err := JSONValueToCQL(columns[i].TypeInfo, value)
+		if err != nil {
+			return nil, fmt.Errorf("gocql: can not convert JSON of column %q: %w", columns[i].Name, err)
+		}
+		if data != nil {
+			values[i] = DirectMarshal(data)
+		}
+	}
+	return values, nil
+}
+
+// ScanJSON consumes the next row of the iterator and stores it in dest as a JSON object
+// of the column names and the values, like the rows of the SELECT JSON statement.
+// The buffer of dest is reused.
+//
+// ScanJSON returns true if the row was successfully converted or false if the end of
+// the result set was reached or if an error occurred, see Iter.Scan.
+func (iter *Iter) ScanJSON(dest *[]byte) bool {
+	if !iter.nextRow() {
+		return false
+	}
+
+	buf := append((*dest)[:0], '{')
+	for i, col := range iter.meta.columns {
+		data, err := iter.readColumn()
+		if err != nil {
+			iter.err = err
+			return false
+		}
+		if i > 0 {
+			buf = append(buf, ", "...)
+		}
+		buf = appendJSONString(buf, jsonFieldName(col.Name))
+		buf = append(buf, ": "...)
+		if buf, err = appendCQLJSON(buf, col.TypeInfo, data); err != nil {
+			iter.err = fmt.Errorf("gocql: can not convert column %q to JSON: %w", col.Name, err)
+			return false
+		}
+	}
+	*dest = append(buf, '}')
+
+	iter.pos++
+	return true
+}
+
+// encodedValue is a CQL encoded element of a collection, which can be a map key.
+type encodedValue string
+
+func (v encodedValue) MarshalCQL(TypeInfo) ([]byte, error) {
+	return []byte(v), nil
+}
+
+func jsonMarshalError(info TypeInfo, value interface{}) error {
+	return marshalErrorf("can not marshal JSON %T into %s", value, info)
+}
+
+func decodeJSON(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after the JSON value")
+	}
+	return value, nil
+}
+
+// jsonNumber returns the text of a JSON number, or of a string if the strings are accepted.
+func jsonNumber(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case json.Number:
+		return v.String(), true
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), true
+	case string:
+		return strings.TrimSpace(v), true
+	}
+	return "", false
+}
+
+func parseJSONInteger(value interface{}) (*big.Int, error) {
+	s, ok := jsonNumber(value)
+	if !ok {
+		return nil, fmt.Errorf("%T is not a number", value)
+	}
+	r, ok := new(big.Rat).SetString(s)
+	if !ok || !r.IsInt() {
+		return nil, fmt.Errorf("%q is not an integer", s)
+	}
+	return r.Num(), nil
+}
+
+func parseJSONDecimal(value interface{}) (*inf.Dec, error) {
+	s, ok := jsonNumber(value)
+	if !ok {
+		return nil, fmt.Errorf("%T is not a number", value)
+	}
+	mantissa, exponent := s, 0
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		exp, err := strconv.Atoi(strings.TrimPrefix(s[i+1:], "+"))
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a decimal", s)
+		}
+		mantissa, exponent = s[:i], exp
+	}
+	dec, ok := new(inf.Dec).SetString(mantissa)
+	if !ok {
+		return nil, fmt.Errorf("%q is not a decimal", s)
+	}
+	return dec.SetScale(dec.Scale() - inf.Scale(exponent)), nil
+}
+
+func parseJSONFloat(value interface{}) (float64, error) {
+	s, ok := jsonNumber(value)
+	if !ok {
+		return 0, fmt.Errorf("%T is not a number", value)
+	}
+	switch s {
+	case "NaN":
+		return math.NaN(), nil
+	case "Infinity":
+		return math.Inf(1), nil
+	case "-Infinity":
+		return math.Inf(-1), nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || strings.ContainsAny(s, "nN") {
+		return 0, fmt.Errorf("%q is not a number", s)
+	}
+	return f, nil
+}
+
+func parseJSONTimestamp(s string) (time.Time, error) {
+	for _, layout := range jsonTimestampLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.New("unknown timestamp format")
+}
+
+// parseJSONTime parses the time of day in the 15:04:05.999999999 format, seconds and the fraction are optional.
+func parseJSONTime(s string) (time.Duration, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, errors.New("unknown time format")
+	}
+	var fraction string
+	if len(parts) == 3 {
+		parts[2], fraction, _ = strings.Cut(parts[2], ".")
+	}
+	var d time.Duration
+	for i, unit := range []time.Duration{time.Hour, time.Minute, time.Second}[:len(parts)] {
+		n, err := strconv.ParseUint(parts[i], 10, 8)
+		if err != nil || len(parts[i]) != 2 {
+			return 0, errors.New("unknown time format")
+		}
+		d += time.Duration(n) * unit
+	}
+	if fraction != "" {
+		if len(fraction) > 9 {
+			return 0, errors.New("fraction of the second is too long")
+		}
+		n, err := strconv.ParseUint(fraction+strings.Repeat("0", 9-len(fraction)), 10, 64)
+		if err != nil {
+			return 0, errors.New("unknown time format")
+		}
+		d += time.Duration(n)
+	}
+	if d >= 24*time.Hour {
+		return 0, errors.New("time out of range")
+	}
+	return d, nil
+}
+
+// parseJSONDuration parses the 1y2mo3w4d5h6m7s8ms9us10ns and the ISO 8601 P1Y2M3DT4H5M6S and P1W formats.
+func parseJSONDuration(s string) (Duration, error) {
+	var d Duration
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	iso := strings.HasPrefix(s, "P")
+	if iso {
+		s = s[1:]
+	}
+	if s == "" {
+		return d, errors.New("empty duration")
+	}
+
+	var months, days, nanos int64
+	timePart := false
+	for s != "" {
+		if iso && s[0] == 'T' && !timePart {
+			timePart = true
+			s = s[1:]
+			continue
+		}
+		i := 0
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+		}
+		if i == 0 {
+			return d, fmt.Errorf("expected a number at %q", s)
+		}
+		n, err := strconv.ParseInt(s[:i], 10, 64)
+		if err != nil {
+			return d, err
+		}
+		s = s[i:]
+		j := 0
+		for j < len(s) && (s[j] < '0' || s[j] > '9') && s[j] != 'T' {
+			j++
+		}
+		unit := s[:j]
+		s = s[j:]
+		if iso {
+			unit = isoDurationUnit(unit, timePart)
+		}
+
+		switch strings.ToLower(unit) {
+		case "y":
+			months += n * 12
+		case "mo":
+			months += n
+		case "w":
+			days += n * 7
+		case "d":
+			days += n
+		case "h":
+			nanos += n * int64(time.Hour)
+		case "m":
+			nanos += n * int64(time.Minute)
+		case "s":
+			nanos += n * int64(time.Second)
+		case "ms":
+			nanos += n * int64(time.Millisecond)
+		case "us", "µs":
+			nanos += n * int64(time.Microsecond)
+		case "ns":
+			nanos += n
+		default:
+			return d, fmt.Errorf("unknown unit %q", unit)
+		}
+	}
+	if months > math.MaxInt32 || days > math.MaxInt32 {
+		return d, errors.New("duration out of range")
+	}
+
+	d = Duration{Months: int32(months), Days: int32(days), Nanoseconds: nanos}
+	if neg {
+		d = Duration{Months: -d.Months, Days: -d.Days, Nanoseconds: -d.Nanoseconds}
+	}
+	return d, nil
+}
+
+// isoDurationUnit returns the unit of the ISO 8601 designator, M is month before T and minute after.
+func isoDurationUnit(designator string, timePart bool) string {
+	switch {
+	case designator == "M" && !timePart:
+		return "mo"
+	case (designator == "Y" || designator == "W" || designator == "D") && !timePart,
+		(designator == "H" || designator == "M" || designator == "S") && timePart:
+		return designator
+	}
+	return "?" + designator
+}
+
+// jsonElements converts the elements of a JSON array, which must not be null.
+func jsonElements(elem TypeInfo, value interface{}) ([]interface{}, error) {
+	v, ok := value.([]interface{})
+	if !ok {
+		return nil, marshalErrorf("can not marshal JSON %T into a collection of %s", value, elem)
+	}
+	elems := make([]interface{}, len(v))
+	for i, e := range v {
+		if e == nil {
+			return nil, marshalErrorf("can not marshal JSON null into an element of a collection")
+		}
+		data, err := JSONValueToCQL(elem, e)
+		if err != nil {
+			return nil, err
+		}
+		elems[i] = encodedValue(data)
+	}
+	return elems, nil
+}
+
+func marshalJSONMap(info TypeInfo, value interface{}) ([]byte, error) {
+	mapInfo, ok := info.(CollectionType)
+	v, isObject := value.(map[string]interface{})
+	if !ok || !isObject {
+		return nil, jsonMarshalError(info, value)
+	}
+
+	m := make(map[encodedValue]encodedValue, len(v))
+	for key, elem := range v {
+		if elem == nil {
+			return nil, marshalErrorf("can not marshal JSON null into a value of a map")
+		}
+		var keyValue interface{} = key
+		if !isJSONString(mapInfo.Key) {
+			// the keys of the other types are their JSON as a string, plain strings are accepted too
+			if decoded, err := decodeJSON([]byte(key)); err == nil {
+				keyValue = decoded
+			}
+		}
+		k, err := JSONValueToCQL(mapInfo.Key, keyValue)
+		if err != nil {
+			return nil, err
+		}
+		data, err := JSONValueToCQL(mapInfo.Elem, elem)
+		if err != nil {
+			return nil, err
+		}
+		m[encodedValue(k)] = encodedValue(data)
+	}
+	return Marshal(info, m)
+}
+
+func marshalJSONUDT(info TypeInfo, value interface{}) ([]byte, error) {
+	udt, ok := info.(UDTTypeInfo)
+	v, isObject := value.(map[string]interface{})
+	if !ok || !isObject {
+		return nil, jsonMarshalError(info, value)
+	}
+
+	fields := make(map[string]interface{}, len(v))
+	for key, elem := range v {
+		name := jsonKeyName(key)
+		i := 0
+		for i < len(udt.Elements) && udt.Elements[i].Name != name {
+			i++
+		}
+		if i == len(udt.Elements) {
+			return nil, marshalErrorf("can not marshal JSON into %s: unknown field %q", info, key)
+		}
+		data, err := JSONValueToCQL(udt.Elements[i].Type, elem)
+		if err != nil {
+			return nil, err
+		}
+		if data != nil {
+			fields[name] = encodedValue(data)
+		}
+	}
+	return Marshal(info, fields)
+}
+
+func isJSONString(info TypeInfo) bool {
+	switch info.Type() {
+	case TypeAscii, TypeVarchar, TypeText:
+		return true
+	}
+	return false
+}
+
+// jsonFieldName returns the JSON key of a column or field name, which is quoted unless it's a lower case identifier.
+func jsonFieldName(name string) string {
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if !(c >= 'a' && c <= 'z' || i > 0 && (c >= '0' && c <= '9' || c == '_')) {
+			return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+		}
+	}
+	return name
+}
+
+// jsonKeyName returns the column or field name of a JSON key, see jsonFieldName.
+func jsonKeyName(key string) string {
+	if len(key) >= 2 && key[0] == '"' && key[len(key)-1] == '"' {
+		return strings.ReplaceAll(key[1:len(key)-1], `""`, `"`)
+	}
+	return strings.ToLower(key)
+}
+
+func appendCQLJSON(buf []byte, info TypeInfo, data []byte) ([]byte, error) {
+	if data == nil {
+		return append(buf, "null"...), nil
+	}
+
+	switch info.Type() {
+	case TypeAscii, TypeVarchar, TypeText:
+		return appendJSONString(buf, string(data)), nil
+	case TypeBlob:
+		return appendJSONBlob(buf, data), nil
+	}
+	if len(data) == 0 {
+		return append(buf, "null"...), nil
+	}
+
+	switch info.Type() {
+	case TypeBoolean:
+		var v bool
+		if err := Unmarshal(info, data, &v); err != nil {
+			return nil, err
+		}
+		return strconv.AppendBool(buf, v), nil
+	case TypeTinyInt, TypeSmallInt, TypeInt, TypeBigInt, TypeCounter:
+		var v int64
+		if err := Unmarshal(info, data, &v); err != nil {
+			return nil, err
+		}
+		return strconv.AppendInt(buf, v, 10), nil
+	case TypeVarint:
+		var v big.Int
+		if err := Unmarshal(info, data, &v); err != nil {
+			return nil, err
+		}
+		return v.Append(buf, 10), nil
+	case TypeDecimal:
+		var v inf.Dec
+		if err := Unmarshal(info, data, &v); err != nil {
+			return nil, err
+		}
+		return append(buf, v.String()...), nil
+	case TypeFloat:
+		var v float32
+		if err := Unmarshal(info, data, &v); err != nil {
+			return nil, err
+		}
+		return appendJSONFloat(buf, float64(v), 32), nil
+	case TypeDouble:
+		var v float64
+		if err := Unmarshal(info, data, &v); err != nil {
+			return nil, err
+		}
+		return appendJSONFloat(buf, v, 64), nil
+	case TypeUUID, TypeTimeUUID:
+		var v UUID
+		if err := Unmarshal(info, data, &v); err != nil {
+			return nil, err
+		}
+		return appendJSONString(buf, v.String()), nil
+	case TypeInet:
+		var v net.IP
+		if err := Unmarshal(info, data, &v); err != nil {
+			return nil, err
+		}
+		return appendJSONString(buf, v.String()), nil
+	case TypeTimestamp:
+		var v time.Time
+		if err := Unmarshal(info, data, &v); err != nil {
+			return nil, err
+		}
+		return appendJSONString(buf, v.UTC().Format(jsonTimestampLayout)), nil
+	case TypeDate:
+		var v time.Time
+		if err := Unmarshal(info, data, &v); err != nil {
+			return nil, err
+		}
+		return appendJSONString(buf, v.UTC().Format(jsonDateLayout)), nil
+	case TypeTime:
+		var v time.Duration
+		if err := Unmarshal(info, data, &v); err != nil {
+			return nil, err
+		}
+		return appendJSONString(buf, fmt.Sprintf("%02d:%02d:%02d.%09d", v/time.Hour, v%time.Hour/time.Minute, v%time.Minute/time.Second, v%time.Second)), nil
+	case TypeDuration:
+		var v Duration
+		if err := Unmarshal(info, data, &v); err != nil {
+			return nil, err
+		}
+		return appendJSONString(buf, formatJSONDuration(v)), nil
+	case TypeList, TypeSet:
+		collection, ok := info.(CollectionType)
+		if !ok {
+			break
+		}
+		n, data, err := readJSONCount(data)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, '[')
+		for i := 0; i < n; i++ {
+			var elem []byte
+			if elem, data, err = readJSONElement(data); err != nil {
+				return nil, err
+			}
+			if i > 0 {
+				buf = append(buf, ", "...)
+			}
+			if buf, err = appendCQLJSON(buf, collection.Elem, elem); err != nil {
+				return nil, err
+			}
+		}
+		return append(buf, ']'), nil
+	case TypeMap:
+		collection, ok := info.(CollectionType)
+		if !ok {
+			break
+		}
+		n, data, err := readJSONCount(data)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, '{')
+		for i := 0; i < n; i++ {
+			var key, elem []byte
+			if key, data, err = readJSONElement(data); err != nil {
+				return nil, err
+			}
+			if elem, data, err = readJSONElement(data); err != nil {
+				return nil, err
+			}
+			if i > 0 {
+				buf = append(buf, ", "...)
+			}
+			keyJSON, err := appendCQLJSON(nil, collection.Key, key)
+			if err != nil {
+				return nil, err
+			}
+			if keyJSON[0] == '"' {
+				buf = append(buf, keyJSON...)
+			} else {
+				buf = appendJSONString(buf, string(keyJSON))
+			}
+			buf = append(buf, ": "...)
+			if buf, err = appendCQLJSON(buf, collection.Elem, elem); err != nil {
+				return nil, err
+			}
+		}
+		return append(buf, '}'), nil
+	case TypeTuple:
+		tuple, ok := info.(TupleTypeInfo)
+		if !ok {
+			break
+		}
+		buf = append(buf, '[')
+		for i, elemInfo := range tuple.Elems {
+			var elem []byte
+			if len(data) > 0 {
+				var err error
+				if elem, data, err = readJSONElement(data); err != nil {
+					return nil, err
+				}
+			}
+			if i > 0 {
+				buf = append(buf, ", "...)
+			}
+			var err error
+			if buf, err = appendCQLJSON(buf, elemInfo, elem); err != nil {
+				return nil, err
+			}
+		}
+		return append(buf, ']'), nil
+	case TypeUDT:
+		udt, ok := info.(UDTTypeInfo)
+		if !ok {
+			break
+		}
+		buf = append(buf, '{')
+		for i, e := range udt.Elements {
+			var elem []byte
+			if len(data) > 0 {
+				var err error
+				if elem, data, err = readJSONElement(data); err != nil {
+					return nil, err
+				}
+			}
+			if i > 0 {
+				buf = append(buf, ", "...)
+			}
+			buf = appendJSONString(buf, jsonFieldName(e.Name))
+			buf = append(buf, ": "...)
+			var err error
+			if buf, err = appendCQLJSON(buf, e.Type, elem); err != nil {
+				return nil, err
+			}
+		}
+		return append(buf, '}'), nil
+	case TypeCustom:
+		vector, ok := info.(VectorType)
+		if !ok {
+			break
+		}
+		var elems []DirectUnmarshal
+		if err := Unmarshal(info, data, &elems); err != nil {
+			return nil, err
+		}
+		buf = append(buf, '[')
+		for i, elem := range elems {
+			if i > 0 {
+				buf = append(buf, ", "...)
+			}
+			var err error
+			if buf, err = appendCQLJSON(buf, vector.SubType, elem); err != nil {
+				return nil, err
+			}
+		}
+		return append(buf, ']'), nil
+	}
+
+	return appendJSONBlob(buf, data), nil
+}
+
+func readJSONCount(data []byte) (int, []byte, error) {
+	if len(data) < 4 {
+		return 0, nil, unmarshalErrorf("unmarshal collection: unexpected eof")
+	}
+	n := int(int32(binary.BigEndian.Uint32(data)))
+	if n < 0 {
+		return 0, nil, unmarshalErrorf("unmarshal collection: negative size %d", n)
+	}
+	return n, data[4:], nil
+}
+
+// readJSONElement reads a [bytes] element of a collection, tuple or user-defined type, nil if it's null.
+func readJSONElement(data []byte) (elem, rest []byte, err error) {
+	if len(data) < 4 {
+		return nil, nil, unmarshalErrorf("unmarshal element: unexpected eof")
+	}
+	n := int(int32(binary.BigEndian.Uint32(data)))
+	data = data[4:]
+	if n < 0 {
+		return nil, data, nil
+	}
+	if len(data) < n {
+		return nil, nil, unmarshalErrorf("unmarshal element: unexpected eof")
+	}
+	return data[:n:n], data[n:], nil
+}
+
+func appendJSONFloat(buf []byte, f float64, bitSize int) []byte {
+	switch {
+	case math.IsNaN(f):
+		return append(buf, `"NaN"`...)
+	case math.IsInf(f, 1):
+		return append(buf, `"Infinity"`...)
+	case math.IsInf(f, -1):
+		return append(buf, `"-Infinity"`...)
+	}
+	return strconv.AppendFloat(buf, f, 'g', -1, bitSize)
+}
+
+func appendJSONBlob(buf []byte, data []byte) []byte {
+	buf = append(buf, `"0x`...)
+	buf = hex.AppendEncode(buf, data)
+	return append(buf, '"')
+}
+
+func formatJSONDuration(d Duration) string {
+	if d == (Duration{}) {
+		return "0s"
+	}
+
+	var b strings.Builder
+	months, days, nanos := int64(d.Months), int64(d.Days), d.Nanoseconds
+	if months < 0 || days < 0 || nanos < 0 {
+		b.WriteByte('-')
+		months, days, nanos = -months, -days, -nanos
+	}
+	for _, u := range []struct {
+		n    int64
+		unit string
+	}{
+		{months / 12, "y"},
+		{months % 12, "mo"},
+		{days, "d"},
+		{nanos / int64(time.Hour), "h"},
+		{nanos % int64(time.Hour) / int64(time.Minute), "m"},
+		{nanos % int64(time.Minute) / int64(time.Second), "s"},
+		{nanos % int64(time.Second) / int64(time.Millisecond), "ms"},
+		{nanos % int64(time.Millisecond) / int64(time.Microsecond), "us"},
+		{nanos % int64(time.Microsecond), "ns"},
+	} {
+		if u.n != 0 {
+			b.WriteString(strconv.FormatInt(u.n, 10))
+			b.WriteString(u.unit)
+		}
+	}
+	return b.String()
+}
+
+func appendJSONString(buf []byte, s string) []byte {
+	const hexDigits = "0123456789abcdef"
+	buf = append(buf, '"')
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			switch {
+			case c == '"' || c == '\\':
+				buf = append(buf, '\\', c)
+			case c == '\n':
+				buf = append(buf, '\\', 'n')
+			case c == '\r':
+				buf = append(buf, '\\', 'r')
+			case c == '\t':
+				buf = append(buf, '\\', 't')
+			case c < 0x20:
+				buf = append(buf, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xf])
+			default:
+				buf = append(buf, c)
+			}
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			buf = append(buf, `\ufffd`...)
+		} else {
+			buf = append(buf, s[i:i+size]...)
+		}
+		i += size
+	}
+	return append(buf, '"')
+}
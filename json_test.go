@@ -0,0 +1,196 @@
+//go:build unit
+// +build unit
+
+package gocql_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+func TestJSONConversion(t *testing.T) {
+	native := func(typ gocql.Type) gocql.TypeInfo { return gocql.NewNativeType(4, typ) }
+	list := func(elem gocql.TypeInfo) gocql.TypeInfo {
+		return gocql.NewCollectionType(gocql.NewNativeType(4, gocql.TypeList), nil, elem)
+	}
+
+	for _, tc := range []struct {
+		name string
+		info gocql.TypeInfo
+		in   string
+		out  string
+	}{
+		{"text", native(gocql.TypeText), `"a \"b\"\n"`, `"a \"b\"\n"`},
+		{"blob", native(gocql.TypeBlob), `"0xcafe"`, `"0xcafe"`},
+		{"boolean", native(gocql.TypeBoolean), `"TRUE"`, `true`},
+		{"int", native(gocql.TypeInt), `"-42"`, `-42`},
+		{"bigint", native(gocql.TypeBigInt), `1e3`, `1000`},
+		{"varint", native(gocql.TypeVarint), `"123456789012345678901234567890"`, `123456789012345678901234567890`},
+		{"decimal", native(gocql.TypeDecimal), `1.25e1`, `12.5`},
+		{"double", native(gocql.TypeDouble), `"NaN"`, `"NaN"`},
+		{"float", native(gocql.TypeFloat), `1.5`, `1.5`},
+		{"uuid", native(gocql.TypeUUID), `"6bddc89a-5644-11e4-97fc-56847afe9799"`, `"6bddc89a-5644-11e4-97fc-56847afe9799"`},
+		{"inet", native(gocql.TypeInet), `"10.0.0.1"`, `"10.0.0.1"`},
+		{"timestamp", native(gocql.TypeTimestamp), `"2021-03-04T05:06:07.123+01:00"`, `"2021-03-04 04:06:07.123Z"`},
+		{"timestamp millis", native(gocql.TypeTimestamp), `1000`, `"1970-01-01 00:00:01.000Z"`},
+		{"date", native(gocql.TypeDate), `"2021-03-04"`, `"2021-03-04"`},
+		{"time", native(gocql.TypeTime), `"08:12:54.5"`, `"08:12:54.500000000"`},
+		{"duration", native(gocql.TypeDuration), `"1y2mo3d4h5m6s7ms8us9ns"`, `"1y2mo3d4h5m6s7ms8us9ns"`},
+		{"duration iso", native(gocql.TypeDuration), `"-P1WT1H"`, `"-7d1h"`},
+		{"null", native(gocql.TypeInt), `null`, `null`},
+		{"list", list(native(gocql.TypeInt)), `[1, "2"]`, `[1, 2]`},
+		{
+			"map with int keys",
+			gocql.NewCollectionType(gocql.NewNativeType(4, gocql.TypeMap), native(gocql.TypeInt), native(gocql.TypeText)),
+			`{"1": "a"}`,
+			`{"1": "a"}`,
+		},
+		{
+			"map with uuid keys",
+			gocql.NewCollectionType(gocql.NewNativeType(4, gocql.TypeMap), native(gocql.TypeUUID), native(gocql.TypeInt)),
+			`{"6bddc89a-5644-11e4-97fc-56847afe9799": 1}`,
+			`{"6bddc89a-5644-11e4-97fc-56847afe9799": 1}`,
+		},
+		{
+			"map with list keys",
+			gocql.NewCollectionType(gocql.NewNativeType(4, gocql.TypeMap), list(native(gocql.TypeInt)), native(gocql.TypeInt)),
+			`{"[1, 2]": 3}`,
+			`{"[1, 2]": 3}`,
+		},
+		{
+			"tuple",
+			gocql.TupleTypeInfo{NativeType: gocql.NewNativeType(4, gocql.TypeTuple), Elems: []gocql.TypeInfo{native(gocql.TypeInt), native(gocql.TypeText)}},
+			`[1]`,
+			`[1, null]`,
+		},
+		{
+			"udt",
+			gocql.NewUDTType(4, "address", "ks",
+				gocql.UDTField{Name: "street", Type: native(gocql.TypeText)},
+				gocql.UDTField{Name: "Zip", Type: native(gocql.TypeInt)}),
+			`{"STREET": "main", "\"Zip\"": 1}`,
+			`{"street": "main", "\"Zip\"": 1}`,
+		},
+		{
+			"vector",
+			gocql.VectorType{NativeType: gocql.NewCustomType(4, gocql.TypeCustom, "org.apache.cassandra.db.marshal.VectorType"), SubType: native(gocql.TypeFloat), Dimensions: 2},
+			`[1, 2.5]`,
+			`[1, 2.5]`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := gocql.JSONToCQL(tc.info, []byte(tc.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			out, err := gocql.CQLToJSON(tc.info, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(out) != tc.out {
+				t.Fatalf("got %s, want %s", out, tc.out)
+			}
+			if !json.Valid(out) {
+				t.Fatalf("invalid JSON %s", out)
+			}
+		})
+	}
+
+	for _, tc := range []struct {
+		info gocql.TypeInfo
+		in   string
+	}{
+		{native(gocql.TypeInt), `1.5`},
+		{native(gocql.TypeTinyInt), `1000`},
+		{native(gocql.TypeText), `1`},
+		{native(gocql.TypeBlob), `"cafe"`},
+		{native(gocql.TypeDuration), `"1x"`},
+		{list(native(gocql.TypeInt)), `[null]`},
+		{native(gocql.TypeInt), `1 2`},
+	} {
+		if _, err := gocql.JSONToCQL(tc.info, []byte(tc.in)); err == nil {
+			t.Errorf("expected an error for %s into %s", tc.in, tc.info)
+		}
+	}
+}
+
+func TestJSONRowValuesAndScanJSON(t *testing.T) {
+	c, err := gocqltest.NewCluster(gocqltest.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	var (
+		id   int32
+		name string
+	)
+	c.When(`INSERT INTO ks\.users`).
+		Params(gocqltest.Col("id", gocql.TypeInt), gocqltest.Col("Name", gocql.TypeText)).
+		Handle(func(req *gocqltest.Request) gocqltest.Response {
+			if err := req.Bind(0, &id); err != nil {
+				return gocqltest.Error(gocql.ErrCodeInvalid, err.Error())
+			}
+			if err := req.Bind(1, &name); err != nil {
+				return gocqltest.Error(gocql.ErrCodeInvalid, err.Error())
+			}
+			return gocqltest.Rows(nil)
+		})
+	c.When(`SELECT \* FROM ks\.users`).
+		Respond(gocqltest.Rows([]gocqltest.Column{
+			gocqltest.Col("id", gocql.TypeInt),
+			gocqltest.Col("Name", gocql.TypeText),
+			{Name: "tags", Type: gocqltest.MapOf(gocqltest.Native(gocql.TypeInt), gocqltest.Native(gocql.TypeText))},
+		},
+			[]interface{}{1, "a", map[int]string{1: "x"}},
+			[]interface{}{2, nil, nil},
+		))
+
+	cfg := c.ClusterConfig()
+	cfg.Timeout = time.Second
+	session, err := cfg.CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	var row map[string]interface{}
+	if err := json.Unmarshal([]byte(`{"ID": 7, "\"Name\"": "b"}`), &row); err != nil {
+		t.Fatal(err)
+	}
+	err = session.Bind(`INSERT INTO ks.users (id, "Name") VALUES (?, ?)`, func(q *gocql.QueryInfo) ([]interface{}, error) {
+		return gocql.JSONRowValues(q.Args, row)
+	}).Exec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 7 || name != "b" {
+		t.Fatalf("unexpected values %d, %q", id, name)
+	}
+	if _, err := gocql.JSONRowValues(nil, row); err == nil {
+		t.Fatal("expected an error for keys without columns")
+	}
+
+	iter := session.Query(`SELECT * FROM ks.users`).Iter()
+	var (
+		buf  []byte
+		rows []string
+	)
+	for iter.ScanJSON(&buf) {
+		rows = append(rows, string(buf))
+	}
+	if err := iter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`{"id": 1, "\"Name\"": "a", "tags": {"1": "x"}}`,
+		`{"id": 2, "\"Name\"": null, "tags": null}`,
+	}
+	if len(rows) != len(want) || rows[0] != want[0] || rows[1] != want[1] {
+		t.Fatalf("got %q, want %q", rows, want)
+	}
+}
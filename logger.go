@@ -26,8 +26,11 @@ package gocql
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"log"
+	"log/slog"
+	"strings"
 )
 
 type StdLogger interface {
@@ -58,3 +61,102 @@ type defaultLogger struct{}
 func (l *defaultLogger) Print(v ...interface{})                 { log.Print(v...) }
 func (l *defaultLogger) Printf(format string, v ...interface{}) { log.Printf(format, v...) }
 func (l *defaultLogger) Println(v ...interface{})               { log.Println(v...) }
+
+// LogLevel is the severity of a structured log entry.
+type LogLevel int
+
+const (
+	LogLevelDebug LogLevel = iota
+	LogLevelInfo
+	LogLevelWarn
+	LogLevelError
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LogLevelDebug:
+		return "DEBUG"
+	case LogLevelInfo:
+		return "INFO"
+	case LogLevelWarn:
+		return "WARN"
+	case LogLevelError:
+		return "ERROR"
+	default:
+		return fmt.Sprintf("LogLevel(%d)", int(l))
+	}
+}
+
+// StructuredLogger is implemented by loggers which accept entries with a level and attributes.
+// If ClusterConfig.Logger implements it, the driver logs structured entries to it, e.g. with the address
+// and the shard of a connection as attributes. Other loggers receive the entries formatted as text.
+type StructuredLogger interface {
+	// Log logs an entry, attrs are alternating keys and values as in log/slog.
+	Log(level LogLevel, msg string, attrs ...interface{})
+}
+
+// logEntry logs a structured entry, loggers which don't implement StructuredLogger receive it as text.
+func logEntry(logger StdLogger, level LogLevel, msg string, attrs ...interface{}) {
+	if l, ok := logger.(StructuredLogger); ok {
+		l.Log(level, msg, attrs...)
+		return
+	}
+	logger.Print(formatLogEntry(msg, attrs))
+}
+
+// formatLogEntry formats an entry as msg followed by key=value pairs.
+func formatLogEntry(msg string, attrs []interface{}) string {
+	var b strings.Builder
+	b.WriteString(msg)
+	for i := 0; i < len(attrs); i += 2 {
+		if i+1 < len(attrs) {
+			fmt.Fprintf(&b, " %v=%v", attrs[i], attrs[i+1])
+		} else {
+			fmt.Fprintf(&b, " !BADKEY=%v", attrs[i])
+		}
+	}
+	return b.String()
+}
+
+// SlogLogger adapts a *slog.Logger to StdLogger and StructuredLogger, so it can be used as ClusterConfig.Logger.
+// Entries logged with Print, Printf and Println are logged at the info level.
+type SlogLogger struct {
+	logger *slog.Logger
+}
+
+// NewSlogLogger returns a SlogLogger logging to logger, slog.Default() if it's nil.
+func NewSlogLogger(logger *slog.Logger) *SlogLogger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &SlogLogger{logger: logger}
+}
+
+func (l *SlogLogger) Log(level LogLevel, msg string, attrs ...interface{}) {
+	l.logger.Log(context.Background(), level.slogLevel(), msg, attrs...)
+}
+
+func (l *SlogLogger) Print(v ...interface{}) {
+	l.logger.Info(strings.TrimSuffix(fmt.Sprint(v...), "\n"))
+}
+
+func (l *SlogLogger) Printf(format string, v ...interface{}) {
+	l.logger.Info(strings.TrimSuffix(fmt.Sprintf(format, v...), "\n"))
+}
+
+func (l *SlogLogger) Println(v ...interface{}) {
+	l.logger.Info(strings.TrimSuffix(fmt.Sprintln(v...), "\n"))
+}
+
+func (l LogLevel) slogLevel() slog.Level {
+	switch l {
+	case LogLevelDebug:
+		return slog.LevelDebug
+	case LogLevelInfo:
+		return slog.LevelInfo
+	case LogLevelWarn:
+		return slog.LevelWarn
+	default:
+		return slog.LevelError
+	}
+}
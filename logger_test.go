@@ -0,0 +1,63 @@
+//go:build unit
+// +build unit
+
+package gocql
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+type capturingStructuredLogger struct {
+	testLogger
+	level LogLevel
+	msg   string
+	attrs []interface{}
+}
+
+func (l *capturingStructuredLogger) Log(level LogLevel, msg string, attrs ...interface{}) {
+	l.level, l.msg, l.attrs = level, msg, attrs
+}
+
+func TestLogEntry(t *testing.T) {
+	std := &testLogger{}
+	logEntry(std, LogLevelWarn, "gocql: unable to dial", "host", "10.0.0.1", "err", errors.New("refused"), "odd")
+	if got, want := std.String(), "gocql: unable to dial host=10.0.0.1 err=refused !BADKEY=odd"; got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+
+	structured := &capturingStructuredLogger{}
+	logEntry(structured, LogLevelError, "gocql: failed", "shard", 3)
+	if structured.level != LogLevelError || structured.msg != "gocql: failed" || len(structured.attrs) != 2 || structured.attrs[1] != 3 {
+		t.Fatalf("unexpected entry: %v %q %v", structured.level, structured.msg, structured.attrs)
+	}
+	if structured.String() != "" {
+		t.Fatalf("structured entries should not be logged as text, got %q", structured.String())
+	}
+}
+
+func TestSlogLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewSlogLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo})))
+
+	logEntry(logger, LogLevelWarn, "gocql: unable to dial", "host", "10.0.0.1", "shard", 2)
+	if out := buf.String(); !strings.Contains(out, "level=WARN") || !strings.Contains(out, `msg="gocql: unable to dial"`) ||
+		!strings.Contains(out, "host=10.0.0.1") || !strings.Contains(out, "shard=2") {
+		t.Fatalf("unexpected output: %q", out)
+	}
+
+	buf.Reset()
+	logEntry(logger, LogLevelDebug, "gocql: filling stopped")
+	if buf.Len() != 0 {
+		t.Fatalf("debug entries should be filtered by the handler, got %q", buf.String())
+	}
+
+	// StdLogger methods log at the info level without the trailing newline
+	logger.Printf("gocql: %s\n", "text")
+	if out := buf.String(); !strings.Contains(out, "level=INFO") || !strings.Contains(out, `msg="gocql: text"`) {
+		t.Fatalf("unexpected output: %q", out)
+	}
+}
@@ -26,6 +26,7 @@ package gocql
 
 import (
 	"bytes"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"math"
@@ -112,6 +113,7 @@ func (d *DirectUnmarshal) UnmarshalCQL(_ TypeInfo, data []byte) error {
 // internal type described by the info parameter.
 //
 // nil is serialized as CQL null.
+// If a codec of the built-in CodecRegistry matches the types, it's used to marshal the data.
 // If value implements Marshaler, its MarshalCQL method is called to marshal the data.
 // If value is a pointer, the pointed-to value is marshaled.
 //
@@ -160,6 +162,10 @@ func (d *DirectUnmarshal) UnmarshalCQL(_ TypeInfo, data []byte) error {
 // The marshal/unmarshal error provides a list of supported types when an unsupported type is attempted.
 
 func Marshal(info TypeInfo, value interface{}) ([]byte, error) {
+	return marshalWith(defaultCodecs, info, value)
+}
+
+func marshalWith(codecs *CodecRegistry, info TypeInfo, value interface{}) ([]byte, error) {
 	if info.Version() < protoVersion1 {
 		panic("protocol version not set")
 	}
@@ -170,10 +176,14 @@ func Marshal(info TypeInfo, value interface{}) ([]byte, error) {
 		} else if v, ok := value.(Marshaler); ok {
 			return v.MarshalCQL(info)
 		} else {
-			return Marshal(info, valueRef.Elem().Interface())
+			return marshalWith(codecs, info, valueRef.Elem().Interface())
 		}
 	}
 
+	if marshal := codecs.marshaler(info, value); marshal != nil {
+		return marshal(info, value)
+	}
+
 	if v, ok := value.(Marshaler); ok {
 		return v.MarshalCQL(info)
 	}
@@ -210,9 +220,9 @@ func Marshal(info TypeInfo, value interface{}) ([]byte, error) {
 	case TypeTimestamp:
 		return marshalTimestamp(value)
 	case TypeList, TypeSet:
-		return marshalList(info, value)
+		return marshalList(codecs, info, value)
 	case TypeMap:
-		return marshalMap(info, value)
+		return marshalMap(codecs, info, value)
 	case TypeUUID:
 		return marshalUUID(value)
 	case TypeTimeUUID:
@@ -222,16 +232,16 @@ func Marshal(info TypeInfo, value interface{}) ([]byte, error) {
 	case TypeInet:
 		return marshalInet(value)
 	case TypeTuple:
-		return marshalTuple(info, value)
+		return marshalTuple(codecs, info, value)
 	case TypeUDT:
-		return marshalUDT(info, value)
+		return marshalUDT(codecs, info, value)
 	case TypeDate:
 		return marshalDate(value)
 	case TypeDuration:
 		return marshalDuration(value)
 	case TypeCustom:
 		if vector, ok := info.(VectorType); ok {
-			return marshalVector(vector, value)
+			return marshalVector(codecs, vector, value)
 		}
 	}
 
@@ -243,6 +253,8 @@ func Marshal(info TypeInfo, value interface{}) ([]byte, error) {
 // describes the Cassandra internal data type and stores the result in the
 // value pointed by value.
 //
+// If a codec of the built-in CodecRegistry matches the types, it's used to
+// unmarshal the data.
 // If value implements Unmarshaler, it's UnmarshalCQL method is called to
 // unmarshal the data.
 // If value is a pointer to pointer, it is set to nil if the CQL value is
@@ -281,12 +293,20 @@ func Marshal(info TypeInfo, value interface{}) ([]byte, error) {
 //	date                                    | *string                 | formatted with 2006-01-02 format
 //	duration                                | *gocql.Duration         |
 func Unmarshal(info TypeInfo, data []byte, value interface{}) error {
+	return unmarshalWith(defaultCodecs, info, data, value)
+}
+
+func unmarshalWith(codecs *CodecRegistry, info TypeInfo, data []byte, value interface{}) error {
+	if unmarshal := codecs.unmarshaler(info, value); unmarshal != nil {
+		return unmarshal(info, data, value)
+	}
+
 	if v, ok := value.(Unmarshaler); ok {
 		return v.UnmarshalCQL(info, data)
 	}
 
 	if isNullableValue(value) {
-		return unmarshalNullable(info, data, value)
+		return unmarshalNullable(codecs, info, data, value)
 	}
 
 	switch info.Type() {
@@ -323,9 +343,9 @@ func Unmarshal(info TypeInfo, data []byte, value interface{}) error {
 	case TypeTimestamp:
 		return unmarshalTimestamp(data, value)
 	case TypeList, TypeSet:
-		return unmarshalList(info, data, value)
+		return unmarshalList(codecs, info, data, value)
 	case TypeMap:
-		return unmarshalMap(info, data, value)
+		return unmarshalMap(codecs, info, data, value)
 	case TypeTimeUUID:
 		return unmarshalTimeUUID(data, value)
 	case TypeUUID:
@@ -333,16 +353,16 @@ func Unmarshal(info TypeInfo, data []byte, value interface{}) error {
 	case TypeInet:
 		return unmarshalInet(data, value)
 	case TypeTuple:
-		return unmarshalTuple(info, data, value)
+		return unmarshalTuple(codecs, info, data, value)
 	case TypeUDT:
-		return unmarshalUDT(info, data, value)
+		return unmarshalUDT(codecs, info, data, value)
 	case TypeDate:
 		return unmarshalDate(data, value)
 	case TypeDuration:
 		return unmarshalDuration(data, value)
 	case TypeCustom:
 		if vector, ok := info.(VectorType); ok {
-			return unmarshalVector(vector, data, value)
+			return unmarshalVector(codecs, vector, data, value)
 		}
 	}
 
@@ -359,7 +379,7 @@ func isNullData(info TypeInfo, data []byte) bool {
 	return data == nil
 }
 
-func unmarshalNullable(info TypeInfo, data []byte, value interface{}) error {
+func unmarshalNullable(codecs *CodecRegistry, info TypeInfo, data []byte, value interface{}) error {
 	valueRef := reflect.ValueOf(value)
 
 	if isNullData(info, data) {
@@ -370,7 +390,7 @@ func unmarshalNullable(info TypeInfo, data []byte, value interface{}) error {
 
 	newValue := reflect.New(valueRef.Type().Elem().Elem())
 	valueRef.Elem().Set(newValue)
-	return Unmarshal(info, data, newValue.Interface())
+	return unmarshalWith(codecs, info, data, newValue.Interface())
 }
 
 func marshalVarchar(value interface{}) ([]byte, error) {
@@ -685,7 +705,7 @@ func writeCollectionSize(info CollectionType, n int, buf *bytes.Buffer) error {
 	return nil
 }
 
-func marshalList(info TypeInfo, value interface{}) ([]byte, error) {
+func marshalList(codecs *CodecRegistry, info TypeInfo, value interface{}) ([]byte, error) {
 	listInfo, ok := info.(CollectionType)
 	if !ok {
 		return nil, marshalErrorf("marshal: can not marshal non collection type into list")
@@ -714,7 +734,7 @@ func marshalList(info TypeInfo, value interface{}) ([]byte, error) {
 		}
 
 		for i := 0; i < n; i++ {
-			item, err := Marshal(listInfo.Elem, rv.Index(i).Interface())
+			item, err := marshalWith(codecs, listInfo.Elem, rv.Index(i).Interface())
 			if err != nil {
 				return nil, err
 			}
@@ -737,7 +757,7 @@ func marshalList(info TypeInfo, value interface{}) ([]byte, error) {
 			for i := 0; i < len(keys); i++ {
 				keys[i] = rkeys[i].Interface()
 			}
-			return marshalList(listInfo, keys)
+			return marshalList(codecs, listInfo, keys)
 		}
 	}
 	return nil, marshalErrorf("can not marshal %T into %s", value, info)
@@ -752,7 +772,7 @@ func readCollectionSize(info CollectionType, data []byte) (size, read int, err e
 	return
 }
 
-func unmarshalList(info TypeInfo, data []byte, value interface{}) error {
+func unmarshalList(codecs *CodecRegistry, info TypeInfo, data []byte, value interface{}) error {
 	listInfo, ok := info.(CollectionType)
 	if !ok {
 		return unmarshalErrorf("unmarshal: can not unmarshal none collection type into list")
@@ -805,7 +825,7 @@ func unmarshalList(info TypeInfo, data []byte, value interface{}) error {
 				unmarshalData = data[:m]
 				data = data[m:]
 			}
-			if err := Unmarshal(listInfo.Elem, unmarshalData, rv.Index(i).Addr().Interface()); err != nil {
+			if err := unmarshalWith(codecs, listInfo.Elem, unmarshalData, rv.Index(i).Addr().Interface()); err != nil {
 				return err
 			}
 		}
@@ -814,7 +834,7 @@ func unmarshalList(info TypeInfo, data []byte, value interface{}) error {
 	return unmarshalErrorf("can not unmarshal %s into %T", info, value)
 }
 
-func marshalVector(info VectorType, value interface{}) ([]byte, error) {
+func marshalVector(codecs *CodecRegistry, info VectorType, value interface{}) ([]byte, error) {
 	if value == nil {
 		return nil, nil
 	} else if _, ok := value.(unsetColumn); ok {
@@ -828,17 +848,21 @@ func marshalVector(info VectorType, value interface{}) ([]byte, error) {
 		return nil, nil
 	}
 
+	if data, ok, err := marshalVectorFast(codecs, info, value); ok {
+		return data, err
+	}
+
 	switch k {
 	case reflect.Slice, reflect.Array:
 		buf := &bytes.Buffer{}
 		n := rv.Len()
 		if n != info.Dimensions {
-			return nil, marshalErrorf("expected vector with %d dimensions, received %d", info.Dimensions, n)
+			return nil, vectorDimensionsError(info, n)
 		}
 
 		isLengthType := isVectorVariableLengthType(info.SubType)
 		for i := 0; i < n; i++ {
-			item, err := Marshal(info.SubType, rv.Index(i).Interface())
+			item, err := marshalWith(codecs, info.SubType, rv.Index(i).Interface())
 			if err != nil {
 				return nil, err
 			}
@@ -852,7 +876,7 @@ func marshalVector(info VectorType, value interface{}) ([]byte, error) {
 	return nil, marshalErrorf("can not marshal %T into %s. Accepted types: slice, array.", value, info)
 }
 
-func unmarshalVector(info VectorType, data []byte, value interface{}) error {
+func unmarshalVector(codecs *CodecRegistry, info VectorType, data []byte, value interface{}) error {
 	rv := reflect.ValueOf(value)
 	if rv.Kind() != reflect.Ptr {
 		return unmarshalErrorf("can not unmarshal into non-pointer %T", value)
@@ -866,6 +890,10 @@ func unmarshalVector(info VectorType, data []byte, value interface{}) error {
 		t = reflect.TypeOf(info.Zero())
 	}
 
+	if ok, err := unmarshalVectorFast(codecs, info, data, value); ok {
+		return err
+	}
+
 	k := t.Kind()
 	switch k {
 	case reflect.Slice, reflect.Array:
@@ -912,7 +940,7 @@ func unmarshalVector(info VectorType, data []byte, value interface{}) error {
 				unmarshalData = data[:elemSize]
 				data = data[elemSize:]
 			}
-			err := Unmarshal(info.SubType, unmarshalData, rv.Index(i).Addr().Interface())
+			err := unmarshalWith(codecs, info.SubType, unmarshalData, rv.Index(i).Addr().Interface())
 			if err != nil {
 				return unmarshalErrorf("failed to unmarshal %s into %T: %s", info.SubType, unmarshalData, err.Error())
 			}
@@ -922,6 +950,132 @@ func unmarshalVector(info VectorType, data []byte, value interface{}) error {
 	return unmarshalErrorf("can not unmarshal %s into %T. Accepted types: *slice, *array, *interface{}.", info, value)
 }
 
+// ErrVectorDimensions is the cause of the marshal error of a vector whose length is not the
+// number of dimensions of the vector type.
+var ErrVectorDimensions = errors.New("vector dimensions mismatch")
+
+func vectorDimensionsError(info VectorType, n int) error {
+	return wrapMarshalErrorf(ErrVectorDimensions, "expected vector with %d dimensions, received %d", info.Dimensions, n)
+}
+
+// marshalVectorFast encodes []float32, []float64 and []int8 vectors of the matching element types
+// without the per element reflection. ok is false if it doesn't apply to the value, including when
+// a codec is registered for the elements.
+func marshalVectorFast(codecs *CodecRegistry, info VectorType, value interface{}) (data []byte, ok bool, err error) {
+	switch v := value.(type) {
+	case []float32:
+		if !vectorFastPath[float32](codecs, info, TypeFloat) {
+			return nil, false, nil
+		}
+		if len(v) != info.Dimensions {
+			return nil, true, vectorDimensionsError(info, len(v))
+		}
+		data = make([]byte, 4*len(v))
+		for i, f := range v {
+			binary.BigEndian.PutUint32(data[4*i:], math.Float32bits(f))
+		}
+		return data, true, nil
+	case []float64:
+		if !vectorFastPath[float64](codecs, info, TypeDouble) {
+			return nil, false, nil
+		}
+		if len(v) != info.Dimensions {
+			return nil, true, vectorDimensionsError(info, len(v))
+		}
+		data = make([]byte, 8*len(v))
+		for i, f := range v {
+			binary.BigEndian.PutUint64(data[8*i:], math.Float64bits(f))
+		}
+		return data, true, nil
+	case []int8:
+		if !vectorFastPath[int8](codecs, info, TypeTinyInt) {
+			return nil, false, nil
+		}
+		if len(v) != info.Dimensions {
+			return nil, true, vectorDimensionsError(info, len(v))
+		}
+		// tinyint is a variable length type, each element is prefixed with its length
+		data = make([]byte, 2*len(v))
+		for i, n := range v {
+			data[2*i] = 1
+			data[2*i+1] = byte(n)
+		}
+		return data, true, nil
+	}
+	return nil, false, nil
+}
+
+// unmarshalVectorFast is the counterpart of marshalVectorFast for *[]float32, *[]float64 and *[]int8.
+func unmarshalVectorFast(codecs *CodecRegistry, info VectorType, data []byte, value interface{}) (ok bool, err error) {
+	switch v := value.(type) {
+	case *[]float32:
+		if !vectorFastPath[float32](codecs, info, TypeFloat) {
+			return false, nil
+		}
+		if data == nil {
+			*v = nil
+			return true, nil
+		}
+		if len(data) != 4*info.Dimensions {
+			return true, unmarshalErrorf("unmarshal vector: expected %d bytes of %d dimensions, got %d", 4*info.Dimensions, info.Dimensions, len(data))
+		}
+		vector := make([]float32, info.Dimensions)
+		for i := range vector {
+			vector[i] = math.Float32frombits(binary.BigEndian.Uint32(data[4*i:]))
+		}
+		*v = vector
+		return true, nil
+	case *[]float64:
+		if !vectorFastPath[float64](codecs, info, TypeDouble) {
+			return false, nil
+		}
+		if data == nil {
+			*v = nil
+			return true, nil
+		}
+		if len(data) != 8*info.Dimensions {
+			return true, unmarshalErrorf("unmarshal vector: expected %d bytes of %d dimensions, got %d", 8*info.Dimensions, info.Dimensions, len(data))
+		}
+		vector := make([]float64, info.Dimensions)
+		for i := range vector {
+			vector[i] = math.Float64frombits(binary.BigEndian.Uint64(data[8*i:]))
+		}
+		*v = vector
+		return true, nil
+	case *[]int8:
+		if !vectorFastPath[int8](codecs, info, TypeTinyInt) {
+			return false, nil
+		}
+		if data == nil {
+			*v = nil
+			return true, nil
+		}
+		if len(data) != 2*info.Dimensions {
+			return true, unmarshalErrorf("unmarshal vector: expected %d bytes of %d dimensions, got %d", 2*info.Dimensions, info.Dimensions, len(data))
+		}
+		vector := make([]int8, info.Dimensions)
+		for i := range vector {
+			if data[2*i] != 1 {
+				return true, unmarshalErrorf("unmarshal vector: invalid length %d of a tinyint", data[2*i])
+			}
+			vector[i] = int8(data[2*i+1])
+		}
+		*v = vector
+		return true, nil
+	}
+	return false, nil
+}
+
+// vectorFastPath reports whether the elements of the vector are of the CQL type typ and there is no codec
+// registered for them and the Go type T.
+func vectorFastPath[T any](codecs *CodecRegistry, info VectorType, typ Type) bool {
+	if info.SubType.Type() != typ {
+		return false
+	}
+	_, ok := codecs.lookup(typ, reflect.TypeFor[T]())
+	return !ok
+}
+
 // isVectorVariableLengthType determines if a type requires explicit length serialization within a vector.
 // Variable-length types need their length encoded before the actual data to allow proper deserialization.
 // Fixed-length types, on the other hand, don't require this kind of length prefix.
@@ -985,7 +1139,7 @@ func computeUnsignedVIntSize(v uint64) int {
 	return (639 - lead0*9) >> 6
 }
 
-func marshalMap(info TypeInfo, value interface{}) ([]byte, error) {
+func marshalMap(codecs *CodecRegistry, info TypeInfo, value interface{}) ([]byte, error) {
 	mapInfo, ok := info.(CollectionType)
 	if !ok {
 		return nil, marshalErrorf("marshal: can not marshal none collection type into map")
@@ -1017,7 +1171,7 @@ func marshalMap(info TypeInfo, value interface{}) ([]byte, error) {
 
 	keys := rv.MapKeys()
 	for _, key := range keys {
-		item, err := Marshal(mapInfo.Key, key.Interface())
+		item, err := marshalWith(codecs, mapInfo.Key, key.Interface())
 		if err != nil {
 			return nil, err
 		}
@@ -1031,7 +1185,7 @@ func marshalMap(info TypeInfo, value interface{}) ([]byte, error) {
 		}
 		buf.Write(item)
 
-		item, err = Marshal(mapInfo.Elem, rv.MapIndex(key).Interface())
+		item, err = marshalWith(codecs, mapInfo.Elem, rv.MapIndex(key).Interface())
 		if err != nil {
 			return nil, err
 		}
@@ -1048,7 +1202,7 @@ func marshalMap(info TypeInfo, value interface{}) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
-func unmarshalMap(info TypeInfo, data []byte, value interface{}) error {
+func unmarshalMap(codecs *CodecRegistry, info TypeInfo, data []byte, value interface{}) error {
 	mapInfo, ok := info.(CollectionType)
 	if !ok {
 		return unmarshalErrorf("unmarshal: can not unmarshal none collection type into map")
@@ -1092,7 +1246,7 @@ func unmarshalMap(info TypeInfo, data []byte, value interface{}) error {
 			unmarshalData = data[:m]
 			data = data[m:]
 		}
-		if err := Unmarshal(mapInfo.Key, unmarshalData, key.Interface()); err != nil {
+		if err := unmarshalWith(codecs, mapInfo.Key, unmarshalData, key.Interface()); err != nil {
 			return err
 		}
 
@@ -1112,7 +1266,7 @@ func unmarshalMap(info TypeInfo, data []byte, value interface{}) error {
 			unmarshalData = data[:m]
 			data = data[m:]
 		}
-		if err := Unmarshal(mapInfo.Elem, unmarshalData, val.Interface()); err != nil {
+		if err := unmarshalWith(codecs, mapInfo.Elem, unmarshalData, val.Interface()); err != nil {
 			return err
 		}
 
@@ -1201,7 +1355,7 @@ func unmarshalInet(data []byte, value interface{}) error {
 	return nil
 }
 
-func marshalTuple(info TypeInfo, value interface{}) ([]byte, error) {
+func marshalTuple(codecs *CodecRegistry, info TypeInfo, value interface{}) ([]byte, error) {
 	tuple := info.(TupleTypeInfo)
 	switch v := value.(type) {
 	case unsetColumn:
@@ -1218,7 +1372,7 @@ func marshalTuple(info TypeInfo, value interface{}) ([]byte, error) {
 				continue
 			}
 
-			data, err := Marshal(tuple.Elems[i], elem)
+			data, err := marshalWith(codecs, tuple.Elems[i], elem)
 			if err != nil {
 				return nil, err
 			}
@@ -1250,7 +1404,7 @@ func marshalTuple(info TypeInfo, value interface{}) ([]byte, error) {
 				continue
 			}
 
-			data, err := Marshal(elem, field.Interface())
+			data, err := marshalWith(codecs, elem, field.Interface())
 			if err != nil {
 				return nil, err
 			}
@@ -1276,7 +1430,7 @@ func marshalTuple(info TypeInfo, value interface{}) ([]byte, error) {
 				continue
 			}
 
-			data, err := Marshal(elem, item.Interface())
+			data, err := marshalWith(codecs, elem, item.Interface())
 			if err != nil {
 				return nil, err
 			}
@@ -1305,7 +1459,7 @@ func readBytes(p []byte) ([]byte, []byte) {
 // currently only support unmarshal into a list of values, this makes it possible
 // to support tuples without changing the query API. In the future this can be extend
 // to allow unmarshalling into custom tuple types.
-func unmarshalTuple(info TypeInfo, data []byte, value interface{}) error {
+func unmarshalTuple(codecs *CodecRegistry, info TypeInfo, data []byte, value interface{}) error {
 	if v, ok := value.(Unmarshaler); ok {
 		return v.UnmarshalCQL(info, data)
 	}
@@ -1319,7 +1473,7 @@ func unmarshalTuple(info TypeInfo, data []byte, value interface{}) error {
 			if len(data) >= 4 {
 				p, data = readBytes(data)
 			}
-			err := Unmarshal(elem, p, v[i])
+			err := unmarshalWith(codecs, elem, p, v[i])
 			if err != nil {
 				return err
 			}
@@ -1349,11 +1503,19 @@ func unmarshalTuple(info TypeInfo, data []byte, value interface{}) error {
 				p, data = readBytes(data)
 			}
 
+			// a field of a type with a codec is unmarshaled directly
+			if field := rv.Field(i).Addr().Interface(); codecs.unmarshaler(elem, field) != nil {
+				if err := unmarshalWith(codecs, elem, p, field); err != nil {
+					return err
+				}
+				continue
+			}
+
 			v, err := elem.NewWithError()
 			if err != nil {
 				return err
 			}
-			if err := Unmarshal(elem, p, v); err != nil {
+			if err := unmarshalWith(codecs, elem, p, v); err != nil {
 				return err
 			}
 
@@ -1390,7 +1552,7 @@ func unmarshalTuple(info TypeInfo, data []byte, value interface{}) error {
 			if err != nil {
 				return err
 			}
-			if err := Unmarshal(elem, p, v); err != nil {
+			if err := unmarshalWith(codecs, elem, p, v); err != nil {
 				return err
 			}
 
@@ -1431,7 +1593,7 @@ type UDTUnmarshaler interface {
 	UnmarshalUDT(name string, info TypeInfo, data []byte) error
 }
 
-func marshalUDT(info TypeInfo, value interface{}) ([]byte, error) {
+func marshalUDT(codecs *CodecRegistry, info TypeInfo, value interface{}) ([]byte, error) {
 	udt := info.(UDTTypeInfo)
 
 	switch v := value.(type) {
@@ -1459,7 +1621,7 @@ func marshalUDT(info TypeInfo, value interface{}) ([]byte, error) {
 
 			if ok {
 				var err error
-				data, err = Marshal(e.Type, val)
+				data, err = marshalWith(codecs, e.Type, val)
 				if err != nil {
 					return nil, err
 				}
@@ -1503,7 +1665,7 @@ func marshalUDT(info TypeInfo, value interface{}) ([]byte, error) {
 		var data []byte
 		if f.IsValid() && f.CanInterface() {
 			var err error
-			data, err = Marshal(e.Type, f.Interface())
+			data, err = marshalWith(codecs, e.Type, f.Interface())
 			if err != nil {
 				return nil, err
 			}
@@ -1515,7 +1677,7 @@ func marshalUDT(info TypeInfo, value interface{}) ([]byte, error) {
 	return buf, nil
 }
 
-func unmarshalUDT(info TypeInfo, data []byte, value interface{}) error {
+func unmarshalUDT(codecs *CodecRegistry, info TypeInfo, data []byte, value interface{}) error {
 	switch v := value.(type) {
 	case Unmarshaler:
 		return v.UnmarshalCQL(info, data)
@@ -1576,7 +1738,7 @@ func unmarshalUDT(info TypeInfo, data []byte, value interface{}) error {
 			var p []byte
 			p, data = readBytes(data)
 
-			if err := Unmarshal(e.Type, p, val.Interface()); err != nil {
+			if err := unmarshalWith(codecs, e.Type, p, val.Interface()); err != nil {
 				return err
 			}
 
@@ -1641,7 +1803,7 @@ func unmarshalUDT(info TypeInfo, data []byte, value interface{}) error {
 		}
 
 		fk := f.Addr().Interface()
-		if err := Unmarshal(e.Type, p, fk); err != nil {
+		if err := unmarshalWith(codecs, e.Type, p, fk); err != nil {
 			return err
 		}
 	}
@@ -30,6 +30,7 @@ package gocql
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"math"
 	"math/big"
@@ -726,6 +727,140 @@ func BenchmarkUnmarshalVarchar(b *testing.B) {
 	}
 }
 
+func TestMarshalVectorFastPath(t *testing.T) {
+	t.Parallel()
+
+	// the named slice types go through the generic path
+	type float32s []float32
+	type float64s []float64
+	type int8s []int8
+	vector := func(typ Type, dims int) VectorType {
+		return VectorType{
+			NativeType: NativeType{proto: protoVersion4, typ: TypeCustom},
+			SubType:    NativeType{proto: protoVersion4, typ: typ},
+			Dimensions: dims,
+		}
+	}
+
+	tests := []struct {
+		info    VectorType
+		value   interface{}
+		generic interface{}
+		dest    interface{}
+	}{
+		{vector(TypeFloat, 3), []float32{1.5, -2, float32(math.Inf(1))}, float32s{1.5, -2, float32(math.Inf(1))}, new([]float32)},
+		{vector(TypeDouble, 2), []float64{math.MaxFloat64, -0.25}, float64s{math.MaxFloat64, -0.25}, new([]float64)},
+		{vector(TypeTinyInt, 3), []int8{-128, 0, 127}, int8s{-128, 0, 127}, new([]int8)},
+	}
+	for _, test := range tests {
+		data, err := Marshal(test.info, test.value)
+		if err != nil {
+			t.Fatalf("%T: %v", test.value, err)
+		}
+		want, err := Marshal(test.info, test.generic)
+		if err != nil {
+			t.Fatalf("%T: %v", test.generic, err)
+		}
+		if !bytes.Equal(data, want) {
+			t.Errorf("%T: marshalled to %x, expected %x", test.value, data, want)
+		}
+
+		if err := Unmarshal(test.info, data, test.dest); err != nil {
+			t.Fatalf("%T: %v", test.dest, err)
+		}
+		if got := reflect.ValueOf(test.dest).Elem().Interface(); !reflect.DeepEqual(got, test.value) {
+			t.Errorf("unmarshalled %v, expected %v", got, test.value)
+		}
+
+		if err := Unmarshal(test.info, data[:len(data)-1], test.dest); err == nil {
+			t.Errorf("%T: expected an error for truncated data", test.dest)
+		}
+		if err := Unmarshal(test.info, nil, test.dest); err != nil {
+			t.Fatal(err)
+		} else if !reflect.ValueOf(test.dest).Elem().IsNil() {
+			t.Errorf("%T: expected nil for null", test.dest)
+		}
+	}
+
+	for _, value := range []interface{}{[]float32{1, 2}, float32s{1, 2}} {
+		_, err := Marshal(vector(TypeFloat, 3), value)
+		if !errors.Is(err, ErrVectorDimensions) {
+			t.Errorf("%T: expected ErrVectorDimensions, got %v", value, err)
+		}
+	}
+	if data, err := Marshal(vector(TypeFloat, 3), []float32(nil)); err != nil || data != nil {
+		t.Errorf("expected nil for a nil vector, got %x, %v", data, err)
+	}
+	// the element type has to match
+	if _, err := Marshal(vector(TypeDouble, 1), []float32{1}); err == nil {
+		t.Error("expected an error for float32 elements of a vector of doubles")
+	}
+
+	// codecs of the elements take precedence
+	codecs := NewCodecRegistry()
+	RegisterCodec(codecs, func(info TypeInfo, v float32) ([]byte, error) {
+		return []byte{0, 0, 0, 1}, nil
+	}, func(info TypeInfo, data []byte, v *float32) error {
+		*v = 42
+		return nil
+	}, TypeFloat)
+	data, err := marshalWith(codecs, vector(TypeFloat, 2), []float32{1, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []byte{0, 0, 0, 1, 0, 0, 0, 1}; !bytes.Equal(data, want) {
+		t.Errorf("marshalled to %x, expected %x", data, want)
+	}
+	var got []float32
+	if err := unmarshalWith(codecs, vector(TypeFloat, 2), data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, []float32{42, 42}) {
+		t.Errorf("unmarshalled %v, expected the values of the codec", got)
+	}
+}
+
+func BenchmarkMarshalVectorFloat32(b *testing.B) {
+	info := VectorType{
+		NativeType: NativeType{proto: protoVersion4, typ: TypeCustom},
+		SubType:    NativeType{proto: protoVersion4, typ: TypeFloat},
+		Dimensions: 1536,
+	}
+	embedding := make([]float32, info.Dimensions)
+	for i := range embedding {
+		embedding[i] = float32(i) / 1536
+	}
+	b.ReportAllocs()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Marshal(info, embedding); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUnmarshalVectorFloat32(b *testing.B) {
+	info := VectorType{
+		NativeType: NativeType{proto: protoVersion4, typ: TypeCustom},
+		SubType:    NativeType{proto: protoVersion4, typ: TypeFloat},
+		Dimensions: 1536,
+	}
+	data, err := Marshal(info, make([]float32, info.Dimensions))
+	if err != nil {
+		b.Fatal(err)
+	}
+	var embedding []float32
+	b.ReportAllocs()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := Unmarshal(info, data, &embedding); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
 func TestReadCollectionSize(t *testing.T) {
 	t.Parallel()
 
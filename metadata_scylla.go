@@ -442,6 +442,13 @@ type metadataDescriber struct {
 	session  *Session
 	metadata *Metadata
 	mu       sync.Mutex
+
+	// watched is the last metadata of the keyspaces watched by Session.WatchSchema,
+	// nil if the keyspace doesn't exist.
+	watched map[string]*KeyspaceMetadata
+	// watchers is the number of subscribers watching each keyspace.
+	watchers map[string]int
+	watchMu  sync.Mutex
 }
 
 // creates a session bound schema describer which will query and cache
@@ -570,7 +577,10 @@ func (s *metadataDescriber) refreshSchema(keyspaceName string) error {
 	// query the system keyspace for schema data
 	// TODO retrieve concurrently
 	keyspace, err := getKeyspaceMetadata(s.session, keyspaceName)
-	if err != nil {
+	if errors.Is(err, ErrKeyspaceDoesNotExist) {
+		s.notifyRefreshed(keyspaceName, nil)
+		return err
+	} else if err != nil {
 		return err
 	}
 	tables, err := getTableMetadata(s.session, keyspaceName)
@@ -612,6 +622,7 @@ func (s *metadataDescriber) refreshSchema(keyspaceName string) error {
 
 	// update the cache
 	s.metadata.keyspaceMetadata.set(keyspaceName, keyspace)
+	s.notifyRefreshed(keyspaceName, keyspace)
 
 	return nil
 }
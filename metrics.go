@@ -0,0 +1,265 @@
+package gocql
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const defaultMetricsExportInterval = 10 * time.Second
+
+// SessionMetrics is a snapshot of the session-wide metrics.
+type SessionMetrics struct {
+	// Retries holds the number of decisions taken by retry policies after failed attempts, by RetryType.
+	Retries map[RetryType]int64
+	// Hosts holds the metrics of the hosts the session has a connection pool for.
+	Hosts []HostPoolMetrics
+	// PreparedCacheHits is the number of statements found in the prepared statements cache.
+	PreparedCacheHits int64
+	// PreparedCacheMisses is the number of statements which had to be prepared.
+	PreparedCacheMisses int64
+	// SpeculativeExecutions is the number of speculative executions launched.
+	SpeculativeExecutions int64
+}
+
+// HostPoolMetrics holds the metrics of a single host.
+type HostPoolMetrics struct {
+	Host HostInformation
+	// Shards holds the metrics per shard of the host.
+	// Hosts which are not sharded, e.g. Cassandra nodes, have a single entry with Shard set to -1.
+	Shards []ShardMetrics
+	// ExcessConnections is the number of connections opened by the pool that are not bound to a shard yet.
+	ExcessConnections int
+}
+
+// ShardMetrics holds the metrics of a single shard of a host.
+type ShardMetrics struct {
+	// Shard is the shard number, it is -1 for hosts which are not sharded.
+	Shard int
+	// Connections is the number of open connections to the shard.
+	Connections int
+	// InFlight is the number of requests waiting for a response.
+	InFlight int
+	// Requests is the number of requests sent.
+	Requests int64
+	// Timeouts is the number of requests which did not get a response within the request timeout.
+	Timeouts int64
+	// StreamExhaustion is the number of requests which could not be sent because
+	// all the streams of the connection were in use.
+	StreamExhaustion int64
+}
+
+// MetricsExporter receives periodic snapshots of the session metrics, see ClusterConfig.MetricsExporter.
+type MetricsExporter interface {
+	ExportMetrics(ctx context.Context, metrics SessionMetrics)
+}
+
+// connCounters are the counters shared by all connections to the same host and shard,
+// so they survive reconnections.
+type connCounters struct {
+	requests         atomic.Int64
+	timeouts         atomic.Int64
+	streamExhaustion atomic.Int64
+}
+
+type connCountersKey struct {
+	hostID string
+	shard  int
+}
+
+// sessionMetrics is the session-wide metrics registry.
+// All methods are safe to call on a nil registry, which records nothing.
+type sessionMetrics struct {
+	conns                 map[connCountersKey]*connCounters
+	retries               [Rethrow + 1]atomic.Int64
+	preparedCacheHits     atomic.Int64
+	preparedCacheMisses   atomic.Int64
+	speculativeExecutions atomic.Int64
+	mu                    sync.RWMutex
+}
+
+func newSessionMetrics() *sessionMetrics {
+	return &sessionMetrics{conns: make(map[connCountersKey]*connCounters)}
+}
+
+// connCounters returns the counters of the given host and shard.
+func (m *sessionMetrics) connCounters(hostID string, shard int) *connCounters {
+	if m == nil {
+		return nil
+	}
+
+	key := connCountersKey{hostID: hostID, shard: shard}
+	m.mu.RLock()
+	c, ok := m.conns[key]
+	m.mu.RUnlock()
+	if ok {
+		return c
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if c, ok = m.conns[key]; !ok {
+		c = &connCounters{}
+		m.conns[key] = c
+	}
+	return c
+}
+
+func (m *sessionMetrics) removeHost(hostID string) {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key := range m.conns {
+		if key.hostID == hostID {
+			delete(m.conns, key)
+		}
+	}
+}
+
+func (m *sessionMetrics) retry(rt RetryType) {
+	if m == nil || int(rt) >= len(m.retries) {
+		return
+	}
+	m.retries[rt].Add(1)
+}
+
+func (m *sessionMetrics) preparedCacheLookup(hit bool) {
+	if m == nil {
+		return
+	}
+	if hit {
+		m.preparedCacheHits.Add(1)
+	} else {
+		m.preparedCacheMisses.Add(1)
+	}
+}
+
+func (m *sessionMetrics) speculativeExecution() {
+	if m == nil {
+		return
+	}
+	m.speculativeExecutions.Add(1)
+}
+
+func (c *connCounters) request() {
+	if c != nil {
+		c.requests.Add(1)
+	}
+}
+
+func (c *connCounters) timeout() {
+	if c != nil {
+		c.timeouts.Add(1)
+	}
+}
+
+func (c *connCounters) noStreams() {
+	if c != nil {
+		c.streamExhaustion.Add(1)
+	}
+}
+
+// Metrics returns a snapshot of the session metrics.
+func (s *Session) Metrics() SessionMetrics {
+	m := s.metrics
+	if m == nil {
+		return SessionMetrics{}
+	}
+
+	snapshot := SessionMetrics{
+		Retries:               make(map[RetryType]int64, len(m.retries)),
+		PreparedCacheHits:     m.preparedCacheHits.Load(),
+		PreparedCacheMisses:   m.preparedCacheMisses.Load(),
+		SpeculativeExecutions: m.speculativeExecutions.Load(),
+	}
+	for rt := range m.retries {
+		snapshot.Retries[RetryType(rt)] = m.retries[rt].Load()
+	}
+
+	if s.pool == nil {
+		return snapshot
+	}
+
+	s.pool.iteratePool(func(info HostPoolInfo) bool {
+		pool, ok := info.(*hostConnPool)
+		if !ok {
+			return true
+		}
+
+		hostMetrics := HostPoolMetrics{
+			Host:              pool.host,
+			Shards:            pool.shardMetrics(),
+			ExcessConnections: pool.GetExcessConnectionCount(),
+		}
+
+		hostID := pool.host.HostID()
+		m.mu.RLock()
+		for i := range hostMetrics.Shards {
+			shard := &hostMetrics.Shards[i]
+			if c, ok := m.conns[connCountersKey{hostID: hostID, shard: shard.Shard}]; ok {
+				shard.Requests = c.requests.Load()
+				shard.Timeouts = c.timeouts.Load()
+				shard.StreamExhaustion = c.streamExhaustion.Load()
+			}
+		}
+		m.mu.RUnlock()
+
+		snapshot.Hosts = append(snapshot.Hosts, hostMetrics)
+		return true
+	})
+
+	sort.Slice(snapshot.Hosts, func(i, j int) bool {
+		return snapshot.Hosts[i].Host.HostID() < snapshot.Hosts[j].Host.HostID()
+	})
+
+	return snapshot
+}
+
+// exportMetrics periodically passes the session metrics to the exporter until ctx is done.
+func (s *Session) exportMetrics(ctx context.Context, exporter MetricsExporter, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			exporter.ExportMetrics(ctx, s.Metrics())
+		}
+	}
+}
+
+// shardMetrics returns the pool state of every shard of the host.
+func (pool *hostConnPool) shardMetrics() []ShardMetrics {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	switch p := pool.connPicker.(type) {
+	case *scyllaConnPicker:
+		shards := make([]ShardMetrics, p.nrShards)
+		for i := range shards {
+			shards[i].Shard = i
+			if i < len(p.conns) && p.conns[i] != nil {
+				shards[i].Connections = 1
+				shards[i].InFlight = p.conns[i].streams.InUse()
+			}
+		}
+		return shards
+	case *defaultConnPicker:
+		p.mu.RLock()
+		defer p.mu.RUnlock()
+		shard := ShardMetrics{Shard: -1, Connections: len(p.conns)}
+		for _, conn := range p.conns {
+			shard.InFlight += conn.streams.InUse()
+		}
+		return []ShardMetrics{shard}
+	default:
+		return []ShardMetrics{{Shard: -1}}
+	}
+}
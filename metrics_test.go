@@ -0,0 +1,131 @@
+//go:build unit
+// +build unit
+
+package gocql
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type testMetricsExporter struct {
+	metrics chan SessionMetrics
+}
+
+func (e *testMetricsExporter) ExportMetrics(_ context.Context, metrics SessionMetrics) {
+	select {
+	case e.metrics <- metrics:
+	default:
+	}
+}
+
+func TestSessionMetrics(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	srv := NewTestServer(t, protoVersion4, ctx)
+	defer srv.Stop()
+
+	cluster := testCluster(protoVersion4, srv.Address)
+	cluster.Timeout = 50 * time.Millisecond
+	cluster.NumConns = 2
+	exporter := &testMetricsExporter{metrics: make(chan SessionMetrics, 1)}
+	cluster.MetricsExporter = exporter
+	cluster.MetricsExportInterval = 10 * time.Millisecond
+
+	db, err := cluster.CreateSession()
+	if err != nil {
+		t.Fatalf("NewCluster: %v", err)
+	}
+	defer db.Close()
+
+	const queries = 5
+	for i := 0; i < queries; i++ {
+		if err := db.Query("void").Exec(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if err := db.Query("select nometadata").Exec(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.Query("timeout").Exec(); err == nil {
+		t.Fatal("expected timeout")
+	}
+	rt := &testRetryPolicy{NumRetries: 2}
+	if err := db.Query("kill").RetryPolicy(rt).Idempotent(true).Exec(); err == nil {
+		t.Fatal("expected error")
+	}
+
+	metrics := db.Metrics()
+
+	if metrics.PreparedCacheMisses != 1 || metrics.PreparedCacheHits != 1 {
+		t.Fatalf("expected one prepared cache miss and one hit, got %d misses and %d hits",
+			metrics.PreparedCacheMisses, metrics.PreparedCacheHits)
+	}
+	if metrics.Retries[Retry] != int64(rt.NumRetries) {
+		t.Fatalf("expected %d retries, got %d", rt.NumRetries, metrics.Retries[Retry])
+	}
+	// the timed out query is not idempotent so it is rethrown as well
+	if metrics.Retries[Rethrow] != 2 {
+		t.Fatalf("expected 2 rethrown errors, got %d", metrics.Retries[Rethrow])
+	}
+
+	if len(metrics.Hosts) != 1 {
+		t.Fatalf("expected metrics of one host, got %d", len(metrics.Hosts))
+	}
+	host := metrics.Hosts[0]
+	if len(host.Shards) != 1 {
+		t.Fatalf("expected a single shard for a non sharded host, got %d", len(host.Shards))
+	}
+	shard := host.Shards[0]
+	if shard.Shard != -1 {
+		t.Fatalf("expected shard -1, got %d", shard.Shard)
+	}
+	if shard.Connections != cluster.NumConns {
+		t.Fatalf("expected %d connections, got %d", cluster.NumConns, shard.Connections)
+	}
+	// queries, prepare and execute requests, the timed out query and all attempts of the killed one
+	if expected := int64(queries + 3 + 1 + rt.NumRetries + 1); shard.Requests < expected {
+		t.Fatalf("expected at least %d requests, got %d", expected, shard.Requests)
+	}
+	if shard.Timeouts != 1 {
+		t.Fatalf("expected 1 timeout, got %d", shard.Timeouts)
+	}
+
+	select {
+	case exported := <-exporter.metrics:
+		if len(exported.Hosts) != 1 {
+			t.Fatalf("expected exported metrics of one host, got %d", len(exported.Hosts))
+		}
+	case <-time.After(time.Second):
+		t.Fatal("metrics were not exported")
+	}
+}
+
+func TestSessionMetricsRemoveHost(t *testing.T) {
+	t.Parallel()
+
+	m := newSessionMetrics()
+	m.connCounters("host1", 0).request()
+	m.connCounters("host1", 1).request()
+	m.connCounters("host2", -1).request()
+
+	if c := m.connCounters("host1", 0); c.requests.Load() != 1 {
+		t.Fatalf("expected counters to be shared, got %d requests", c.requests.Load())
+	}
+
+	m.removeHost("host1")
+	if len(m.conns) != 1 {
+		t.Fatalf("expected counters of a single host to be left, got %d", len(m.conns))
+	}
+
+	// a nil registry records nothing
+	var nilMetrics *sessionMetrics
+	nilMetrics.connCounters("host1", 0).request()
+	nilMetrics.retry(Retry)
+	nilMetrics.preparedCacheLookup(true)
+	nilMetrics.speculativeExecution()
+}
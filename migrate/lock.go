@@ -0,0 +1,92 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// lock is the lock of the lock table held by the migrator.
+type lock struct {
+	m *Migrator
+}
+
+// lock acquires the lock, it fails with ErrLocked if another runner holds it.
+func (m *Migrator) lock(ctx context.Context) (*lock, error) {
+	row := make(map[string]interface{})
+	applied, err := m.session.Query(`INSERT INTO `+m.cfg.Table+`_lock (name, owner, acquired_at) VALUES (?, ?, ?) IF NOT EXISTS USING TTL ?`,
+		lockName, m.cfg.Owner, time.Now(), m.ttl()).
+		WithContext(ctx).Consistency(m.cfg.Consistency).MapScanCAS(row)
+	if err != nil {
+		return nil, fmt.Errorf("migrate: failed to acquire the lock: %w", err)
+	}
+	if !applied {
+		return nil, fmt.Errorf("%w: held by %v since %v", ErrLocked, row["owner"], row["acquired_at"])
+	}
+	return &lock{m: m}, nil
+}
+
+func (m *Migrator) ttl() int {
+	return int(m.cfg.LockTTL / time.Second)
+}
+
+// keep renews the lock until stop is called, which releases it. The returned context is canceled with
+// ErrLockLost if the lock can't be renewed.
+func (l *lock) keep(ctx context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(l.m.cfg.LockTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if err := l.renew(ctx); err != nil {
+				cancel(fmt.Errorf("%w: %v", ErrLockLost, err))
+				return
+			}
+		}
+	}()
+
+	return ctx, func() {
+		close(done)
+		<-stopped
+		cancel(nil)
+		if err := l.release(); err != nil {
+			l.m.cfg.Logger.Printf("migrate: failed to release the lock, it expires in %v: %v", l.m.cfg.LockTTL, err)
+		}
+	}
+}
+
+func (l *lock) renew(ctx context.Context) error {
+	m := l.m
+	row := make(map[string]interface{})
+	applied, err := m.session.Query(`UPDATE `+m.cfg.Table+`_lock USING TTL ? SET owner = ?, acquired_at = ? WHERE name = ? IF owner = ?`,
+		m.ttl(), m.cfg.Owner, time.Now(), lockName, m.cfg.Owner).
+		WithContext(ctx).Consistency(m.cfg.Consistency).MapScanCAS(row)
+	if err != nil {
+		return err
+	}
+	if !applied {
+		return fmt.Errorf("held by %v", row["owner"])
+	}
+	return nil
+}
+
+// release deletes the lock, it's called with a new context so the lock is released even
+// if the context of Apply is done.
+func (l *lock) release() error {
+	m := l.m
+	ctx, cancel := context.WithTimeout(context.Background(), m.cfg.LockTTL)
+	defer cancel()
+	_, err := m.session.Query(`DELETE FROM `+m.cfg.Table+`_lock WHERE name = ? IF owner = ?`, lockName, m.cfg.Owner).
+		WithContext(ctx).Consistency(m.cfg.Consistency).MapScanCAS(make(map[string]interface{}))
+	return err
+}
@@ -0,0 +1,311 @@
+// Package migrate applies versioned CQL migrations to a cluster.
+//
+// Migrations are .cql files whose names start with their version, e.g. 0001_create_users.cql,
+// 0002_add_email.cql. A Migrator applies the files which were not applied yet in the order of their
+// versions, statement by statement, and waits for schema agreement after every schema change:
+//
+//	m, err := migrate.New(session, os.DirFS("migrations"), migrate.Config{Table: "ks.schema_migrations"})
+//	...
+//	applied, err := m.Apply(ctx)
+//
+// The applied migrations and their checksums are recorded in a tracking table, the migrations which
+// were applied must not be modified. The progress is recorded after every statement, so a migration
+// which failed in the middle is resumed from the statement which failed.
+//
+// A lock acquired with a lightweight transaction prevents concurrent runners from applying migrations
+// at the same time. The lock expires after Config.LockTTL unless it's renewed by the runner holding it,
+// so a crashed runner doesn't block the others forever.
+//
+// DryRun writes the statements which would be applied without changing anything.
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+var (
+	// ErrLocked is returned by Apply when another runner holds the lock.
+	ErrLocked = errors.New("migrate: migrations are locked by another runner")
+	// ErrLockLost is returned by Apply when the lock expired or was taken by another runner
+	// while migrations were applied.
+	ErrLockLost = errors.New("migrate: the lock was lost")
+	// ErrChecksumMismatch is returned when an applied migration was modified.
+	ErrChecksumMismatch = errors.New("migrate: checksum mismatch")
+)
+
+const lockName = "migrate"
+
+// Config configures a Migrator.
+type Config struct {
+	// Table is the tracking table in the keyspace.table format, it's created by Apply if it doesn't exist.
+	// The lock is kept in the <Table>_lock table.
+	Table string
+	// Consistency is the consistency of the queries of the tracking and lock tables.
+	// Default: Quorum
+	Consistency gocql.Consistency
+	// LockTTL is how long the lock is held without being renewed, it's renewed every third of it.
+	// Default: 1m
+	LockTTL time.Duration
+	// Owner identifies the runner in the lock table.
+	// Default: <hostname>:<pid>
+	Owner string
+	// Logger logs the applied migrations.
+	// Default: the standard logger of the log package
+	Logger gocql.StdLogger
+}
+
+// Validate checks the configuration.
+func (cfg *Config) Validate() error {
+	if ks, name, ok := strings.Cut(cfg.Table, "."); !ok || ks == "" || name == "" {
+		return fmt.Errorf("Table should be in the keyspace.table format, got %q", cfg.Table)
+	}
+	if cfg.LockTTL != 0 && cfg.LockTTL < time.Second {
+		return errors.New("LockTTL should be at least 1s or zero")
+	}
+	return nil
+}
+
+// AppliedMigration is a row of the tracking table.
+type AppliedMigration struct {
+	Version  int64
+	Name     string
+	Checksum string
+	// Statements is the number of statements of the migration which were applied.
+	Statements int
+	// Completed is true if all statements of the migration were applied.
+	Completed bool
+	// AppliedAt is the time the last statement was applied.
+	AppliedAt time.Time
+}
+
+// Migrator applies migrations.
+type Migrator struct {
+	session    *gocql.Session
+	cfg        Config
+	migrations []*Migration
+}
+
+// New returns a migrator of the migrations in the root directory of fsys, see Load.
+func New(session *gocql.Session, fsys fs.FS, cfg Config) (*Migrator, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("migrate: invalid config: %w", err)
+	}
+	if cfg.Consistency == gocql.Any {
+		cfg.Consistency = gocql.Quorum
+	}
+	if cfg.LockTTL == 0 {
+		cfg.LockTTL = time.Minute
+	}
+	if cfg.Owner == "" {
+		host, _ := os.Hostname()
+		cfg.Owner = fmt.Sprintf("%s:%d", host, os.Getpid())
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = log.Default()
+	}
+
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{session: session, cfg: cfg, migrations: migrations}, nil
+}
+
+// Migrations returns the migrations sorted by version.
+func (m *Migrator) Migrations() []*Migration {
+	return m.migrations
+}
+
+// Applied returns the rows of the tracking table sorted by version, none if it doesn't exist.
+func (m *Migrator) Applied(ctx context.Context) ([]AppliedMigration, error) {
+	iter := m.session.Query(`SELECT version, name, checksum, statements, completed, applied_at FROM ` + m.cfg.Table).
+		WithContext(ctx).Consistency(m.cfg.Consistency).Iter()
+	var (
+		applied []AppliedMigration
+		a       AppliedMigration
+	)
+	for iter.Scan(&a.Version, &a.Name, &a.Checksum, &a.Statements, &a.Completed, &a.AppliedAt) {
+		applied = append(applied, a)
+	}
+	if err := iter.Close(); err != nil {
+		var reqErr interface{ GetCode() int }
+		if errors.As(err, &reqErr) && reqErr.GetCode() == gocql.ErrCodeInvalid {
+			// the tracking table was not created yet
+			return nil, nil
+		}
+		return nil, fmt.Errorf("migrate: failed to read %s: %w", m.cfg.Table, err)
+	}
+	sort.Slice(applied, func(i, j int) bool { return applied[i].Version < applied[j].Version })
+	return applied, nil
+}
+
+// pendingMigration is a migration which was not applied yet, or only its first applied statements.
+type pendingMigration struct {
+	*Migration
+	applied int
+}
+
+// pending returns the migrations which were not applied yet. It fails with ErrChecksumMismatch
+// if an applied migration was modified.
+func (m *Migrator) pending(ctx context.Context) ([]pendingMigration, error) {
+	applied, err := m.Applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[int64]AppliedMigration, len(applied))
+	for _, a := range applied {
+		byVersion[a.Version] = a
+	}
+
+	var pending []pendingMigration
+	for _, mig := range m.migrations {
+		a, ok := byVersion[mig.Version]
+		if !ok {
+			pending = append(pending, pendingMigration{Migration: mig})
+			continue
+		}
+		if a.Checksum != mig.Checksum {
+			return nil, fmt.Errorf("%w: %s was modified after it was applied as %s", ErrChecksumMismatch, mig.Name, a.Name)
+		}
+		if !a.Completed {
+			pending = append(pending, pendingMigration{Migration: mig, applied: a.Statements})
+		}
+	}
+	return pending, nil
+}
+
+// Pending returns the migrations which were not applied yet, including a migration which was
+// applied partially. It fails with ErrChecksumMismatch if an applied migration was modified.
+func (m *Migrator) Pending(ctx context.Context) ([]*Migration, error) {
+	pending, err := m.pending(ctx)
+	if err != nil {
+		return nil, err
+	}
+	migrations := make([]*Migration, 0, len(pending))
+	for _, p := range pending {
+		migrations = append(migrations, p.Migration)
+	}
+	return migrations, nil
+}
+
+// DryRun writes the statements Apply would execute to w, without changing anything.
+func (m *Migrator) DryRun(ctx context.Context, w io.Writer) error {
+	pending, err := m.pending(ctx)
+	if err != nil {
+		return err
+	}
+	for _, p := range pending {
+		header := "-- " + p.Name
+		if p.applied > 0 {
+			header += fmt.Sprintf(" (resumed, %d of %d statements already applied)", p.applied, len(p.Statements))
+		}
+		if _, err := fmt.Fprintln(w, header); err != nil {
+			return err
+		}
+		for _, stmt := range p.Statements[p.applied:] {
+			if _, err := fmt.Fprintf(w, "%s;\n", stmt); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintln(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Apply applies the pending migrations and returns them. It creates the tracking and lock tables if
+// they don't exist, and fails with ErrLocked if another runner is applying migrations. A statement which
+// fails stops Apply, the statements applied before it are recorded.
+func (m *Migrator) Apply(ctx context.Context) ([]*Migration, error) {
+	if err := m.createTables(ctx); err != nil {
+		return nil, err
+	}
+
+	lock, err := m.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ctx, stop := lock.keep(ctx)
+	defer stop()
+
+	pending, err := m.pending(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var applied []*Migration
+	for _, p := range pending {
+		if err := m.apply(ctx, p); err != nil {
+			return applied, err
+		}
+		applied = append(applied, p.Migration)
+	}
+	return applied, nil
+}
+
+func (m *Migrator) apply(ctx context.Context, p pendingMigration) error {
+	for i := p.applied; i < len(p.Statements); i++ {
+		stmt := p.Statements[i]
+		if err := m.session.Query(stmt).WithContext(ctx).Exec(); err != nil {
+			return fmt.Errorf("migrate: statement %d of %s failed: %w", i+1, p.Name, contextCause(ctx, err))
+		}
+		if isDDL(stmt) {
+			if err := m.session.AwaitSchemaAgreement(ctx); err != nil {
+				return fmt.Errorf("migrate: schema agreement after statement %d of %s: %w", i+1, p.Name, contextCause(ctx, err))
+			}
+		}
+		err := m.session.Query(`INSERT INTO `+m.cfg.Table+` (version, name, checksum, statements, completed, applied_at) VALUES (?, ?, ?, ?, ?, ?)`,
+			p.Version, p.Name, p.Checksum, i+1, i+1 == len(p.Statements), time.Now()).
+			WithContext(ctx).Consistency(m.cfg.Consistency).Exec()
+		if err != nil {
+			return fmt.Errorf("migrate: failed to record statement %d of %s: %w", i+1, p.Name, contextCause(ctx, err))
+		}
+	}
+	m.cfg.Logger.Printf("migrate: applied %s", p.Name)
+	return nil
+}
+
+func (m *Migrator) createTables(ctx context.Context) error {
+	for _, stmt := range []string{
+		`CREATE TABLE IF NOT EXISTS ` + m.cfg.Table + ` (
+			version bigint PRIMARY KEY,
+			name text,
+			checksum text,
+			statements int,
+			completed boolean,
+			applied_at timestamp
+		)`,
+		`CREATE TABLE IF NOT EXISTS ` + m.cfg.Table + `_lock (
+			name text PRIMARY KEY,
+			owner text,
+			acquired_at timestamp
+		)`,
+	} {
+		if err := m.session.Query(stmt).WithContext(ctx).Exec(); err != nil {
+			return fmt.Errorf("migrate: failed to create tracking table %s: %w", m.cfg.Table, err)
+		}
+	}
+	if err := m.session.AwaitSchemaAgreement(ctx); err != nil {
+		return fmt.Errorf("migrate: schema agreement after creating %s: %w", m.cfg.Table, err)
+	}
+	return nil
+}
+
+// contextCause returns the cause of the cancellation of ctx, e.g. ErrLockLost, instead of err if ctx is done.
+func contextCause(ctx context.Context, err error) error {
+	if cause := context.Cause(ctx); cause != nil && ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+		return cause
+	}
+	return err
+}
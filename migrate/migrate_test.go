@@ -0,0 +1,355 @@
+//go:build unit
+// +build unit
+
+package migrate
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+// fakeTracking serves the tracking and lock tables of ks.migrations from a fake cluster.
+type fakeTracking struct {
+	mu        sync.Mutex
+	created   bool
+	rows      map[int64]AppliedMigration
+	owner     string
+	renewed   int
+	executed  []string
+	failFirst string
+}
+
+var (
+	trackingColumns = []gocqltest.Column{
+		gocqltest.Col("version", gocql.TypeBigInt),
+		gocqltest.Col("name", gocql.TypeText),
+		gocqltest.Col("checksum", gocql.TypeText),
+		gocqltest.Col("statements", gocql.TypeInt),
+		gocqltest.Col("completed", gocql.TypeBoolean),
+		gocqltest.Col("applied_at", gocql.TypeTimestamp),
+	}
+	lockColumns = []gocqltest.Column{
+		gocqltest.Col("[applied]", gocql.TypeBoolean),
+		gocqltest.Col("name", gocql.TypeText),
+		gocqltest.Col("acquired_at", gocql.TypeTimestamp),
+		gocqltest.Col("owner", gocql.TypeText),
+	}
+)
+
+func newFakeTracking(t *testing.T) (*fakeTracking, *gocql.Session) {
+	t.Helper()
+	c, err := gocqltest.NewCluster(gocqltest.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(c.Close)
+
+	f := &fakeTracking{rows: make(map[int64]AppliedMigration)}
+	bind := func(req *gocqltest.Request, dest ...interface{}) gocqltest.Response {
+		for i, d := range dest {
+			if err := req.Bind(i, d); err != nil {
+				return gocqltest.Error(gocql.ErrCodeInvalid, err.Error())
+			}
+		}
+		return nil
+	}
+	casResult := func(applied bool, owner string) gocqltest.Response {
+		if applied {
+			return gocqltest.Rows(lockColumns[:1], []interface{}{true})
+		}
+		return gocqltest.Rows(lockColumns, []interface{}{false, lockName, time.Now(), owner})
+	}
+
+	// the statements of the migrations
+	c.When(`ks\.(users|events)`).Handle(func(req *gocqltest.Request) gocqltest.Response {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if f.failFirst != "" && strings.Contains(req.Statement, f.failFirst) {
+			f.failFirst = ""
+			return gocqltest.Error(gocql.ErrCodeInvalid, "boom")
+		}
+		f.executed = append(f.executed, req.Statement)
+		return gocqltest.Void()
+	})
+	c.When(`^CREATE TABLE IF NOT EXISTS ks\.migrations \(`).Handle(func(req *gocqltest.Request) gocqltest.Response {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.created = true
+		return gocqltest.Void()
+	})
+	c.When(`^SELECT .* FROM ks\.migrations$`).Handle(func(req *gocqltest.Request) gocqltest.Response {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if !f.created {
+			return gocqltest.Error(gocql.ErrCodeInvalid, "unconfigured table migrations")
+		}
+		var rows [][]interface{}
+		for _, a := range f.rows {
+			rows = append(rows, []interface{}{a.Version, a.Name, a.Checksum, a.Statements, a.Completed, a.AppliedAt})
+		}
+		return gocqltest.Rows(trackingColumns, rows...)
+	})
+	c.When(`^INSERT INTO ks\.migrations `).Params(trackingColumns...).Handle(func(req *gocqltest.Request) gocqltest.Response {
+		var a AppliedMigration
+		if resp := bind(req, &a.Version, &a.Name, &a.Checksum, &a.Statements, &a.Completed, &a.AppliedAt); resp != nil {
+			return resp
+		}
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.rows[a.Version] = a
+		return gocqltest.Void()
+	})
+	c.When(`^INSERT INTO ks\.migrations_lock .* IF NOT EXISTS`).
+		Params(gocqltest.Col("name", gocql.TypeText), gocqltest.Col("owner", gocql.TypeText),
+			gocqltest.Col("acquired_at", gocql.TypeTimestamp), gocqltest.Col("[ttl]", gocql.TypeInt)).
+		Handle(func(req *gocqltest.Request) gocqltest.Response {
+			var owner string
+			if resp := bind(req, new(string), &owner); resp != nil {
+				return resp
+			}
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			if f.owner != "" {
+				return casResult(false, f.owner)
+			}
+			f.owner = owner
+			return casResult(true, "")
+		})
+	c.When(`^UPDATE ks\.migrations_lock .* IF owner = \?`).
+		Params(gocqltest.Col("[ttl]", gocql.TypeInt), gocqltest.Col("owner", gocql.TypeText),
+			gocqltest.Col("acquired_at", gocql.TypeTimestamp), gocqltest.Col("name", gocql.TypeText),
+			gocqltest.Col("owner", gocql.TypeText)).
+		Handle(func(req *gocqltest.Request) gocqltest.Response {
+			var owner string
+			if resp := bind(req, new(int), &owner); resp != nil {
+				return resp
+			}
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			if f.owner != owner {
+				return casResult(false, f.owner)
+			}
+			f.renewed++
+			return casResult(true, "")
+		})
+	c.When(`^DELETE FROM ks\.migrations_lock .* IF owner = \?`).
+		Params(gocqltest.Col("name", gocql.TypeText), gocqltest.Col("owner", gocql.TypeText)).
+		Handle(func(req *gocqltest.Request) gocqltest.Response {
+			var owner string
+			if resp := bind(req, new(string), &owner); resp != nil {
+				return resp
+			}
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			if f.owner != owner {
+				return casResult(false, f.owner)
+			}
+			f.owner = ""
+			return casResult(true, "")
+		})
+
+	cfg := c.ClusterConfig()
+	cfg.Timeout = time.Second
+	session, err := cfg.CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(session.Close)
+	return f, session
+}
+
+func (f *fakeTracking) get() ([]string, []AppliedMigration, string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var applied []AppliedMigration
+	for _, a := range f.rows {
+		applied = append(applied, a)
+	}
+	sort.Slice(applied, func(i, j int) bool { return applied[i].Version < applied[j].Version })
+	return append([]string(nil), f.executed...), applied, f.owner
+}
+
+var testMigrations = fstest.MapFS{
+	"0001_create_users.cql": {Data: []byte(`
+-- the users
+CREATE TABLE ks.users (id int PRIMARY KEY, name text);
+INSERT INTO ks.users (id, name) VALUES (1, 'admin; root');
+`)},
+	"0002_events.cql": {Data: []byte(`CREATE TABLE ks.events (id int PRIMARY KEY);
+ALTER TABLE ks.users ADD email text;`)},
+	"README.md": {Data: []byte("not a migration")},
+}
+
+func newTestMigrator(t *testing.T, session *gocql.Session, fsys fstest.MapFS) *Migrator {
+	t.Helper()
+	m, err := New(session, fsys, Config{Table: "ks.migrations", Owner: "test", LockTTL: 3 * time.Second, Logger: &testLogger{t}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+type testLogger struct{ t *testing.T }
+
+func (l *testLogger) Print(v ...interface{})                 { l.t.Log(v...) }
+func (l *testLogger) Printf(format string, v ...interface{}) { l.t.Logf(format, v...) }
+func (l *testLogger) Println(v ...interface{})               { l.t.Log(v...) }
+
+func TestApply(t *testing.T) {
+	f, session := newFakeTracking(t)
+	m := newTestMigrator(t, session, testMigrations)
+
+	var out bytes.Buffer
+	if err := m.DryRun(context.Background(), &out); err != nil {
+		t.Fatal(err)
+	}
+	expected := `-- 0001_create_users.cql
+CREATE TABLE ks.users (id int PRIMARY KEY, name text);
+INSERT INTO ks.users (id, name) VALUES (1, 'admin; root');
+
+-- 0002_events.cql
+CREATE TABLE ks.events (id int PRIMARY KEY);
+ALTER TABLE ks.users ADD email text;
+
+`
+	if out.String() != expected {
+		t.Fatalf("unexpected dry run output:\n%s", out.String())
+	}
+	if executed, applied, _ := f.get(); len(executed) != 0 || len(applied) != 0 {
+		t.Fatalf("dry run changed the schema: %q", executed)
+	}
+
+	migrations, err := m.Apply(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) != 2 {
+		t.Fatalf("expected 2 applied migrations, got %d", len(migrations))
+	}
+	executed, applied, owner := f.get()
+	if len(executed) != 4 || executed[1] != "INSERT INTO ks.users (id, name) VALUES (1, 'admin; root')" {
+		t.Fatalf("unexpected statements %q", executed)
+	}
+	if len(applied) != 2 || !applied[0].Completed || applied[1].Statements != 2 || applied[1].Checksum != m.Migrations()[1].Checksum {
+		t.Fatalf("unexpected tracking rows %+v", applied)
+	}
+	if owner != "" {
+		t.Fatalf("expected the lock to be released, held by %q", owner)
+	}
+
+	// nothing is pending anymore
+	if migrations, err := m.Apply(context.Background()); err != nil || len(migrations) != 0 {
+		t.Fatalf("expected no migrations to be applied, got %d: %v", len(migrations), err)
+	}
+	if executed, _, _ := f.get(); len(executed) != 4 {
+		t.Fatalf("unexpected statements %q", executed)
+	}
+}
+
+func TestApplyResume(t *testing.T) {
+	f, session := newFakeTracking(t)
+	m := newTestMigrator(t, session, testMigrations)
+
+	f.failFirst = "ALTER TABLE ks.users"
+	migrations, err := m.Apply(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "statement 2 of 0002_events.cql") {
+		t.Fatalf("expected the second statement to fail, got %v", err)
+	}
+	if len(migrations) != 1 {
+		t.Fatalf("expected the first migration to be applied, got %d", len(migrations))
+	}
+	_, applied, owner := f.get()
+	if len(applied) != 2 || applied[1].Statements != 1 || applied[1].Completed {
+		t.Fatalf("unexpected tracking rows %+v", applied)
+	}
+	if owner != "" {
+		t.Fatalf("expected the lock to be released, held by %q", owner)
+	}
+
+	var out bytes.Buffer
+	if err := m.DryRun(context.Background(), &out); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "-- 0002_events.cql (resumed, 1 of 2 statements already applied)\nALTER TABLE ks.users ADD email text;\n\n"; out.String() != expected {
+		t.Fatalf("unexpected dry run output:\n%s", out.String())
+	}
+
+	if _, err := m.Apply(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	executed, applied, _ := f.get()
+	if len(executed) != 4 || executed[3] != "ALTER TABLE ks.users ADD email text" || !applied[1].Completed {
+		t.Fatalf("unexpected statements %q", executed)
+	}
+}
+
+func TestApplyChecksumMismatch(t *testing.T) {
+	_, session := newFakeTracking(t)
+	if _, err := newTestMigrator(t, session, testMigrations).Apply(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	modified := fstest.MapFS{
+		"0001_create_users.cql": {Data: []byte(`CREATE TABLE ks.users (id int PRIMARY KEY);`)},
+	}
+	if _, err := newTestMigrator(t, session, modified).Apply(context.Background()); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("expected a checksum mismatch, got %v", err)
+	}
+}
+
+func TestApplyLocked(t *testing.T) {
+	f, session := newFakeTracking(t)
+	f.owner = "other"
+
+	m := newTestMigrator(t, session, testMigrations)
+	if _, err := m.Apply(context.Background()); !errors.Is(err, ErrLocked) || !strings.Contains(err.Error(), "other") {
+		t.Fatalf("expected the migrations to be locked, got %v", err)
+	}
+	if executed, _, owner := f.get(); len(executed) != 0 || owner != "other" {
+		t.Fatalf("expected nothing to be applied, got %q", executed)
+	}
+}
+
+func TestLockRenewal(t *testing.T) {
+	f, session := newFakeTracking(t)
+	m := newTestMigrator(t, session, testMigrations)
+	m.cfg.LockTTL = 30 * time.Millisecond
+
+	l, err := m.lock(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, stop := l.keep(context.Background())
+	time.Sleep(100 * time.Millisecond)
+	f.mu.Lock()
+	renewed := f.renewed
+	// another runner takes the lock
+	f.owner = "other"
+	f.mu.Unlock()
+	if renewed == 0 {
+		t.Fatal("expected the lock to be renewed")
+	}
+
+	select {
+	case <-ctx.Done():
+		if !errors.Is(context.Cause(ctx), ErrLockLost) {
+			t.Fatalf("expected the lock to be lost, got %v", context.Cause(ctx))
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the context to be canceled")
+	}
+	stop()
+	if _, _, owner := f.get(); owner != "other" {
+		t.Fatalf("the lock of another runner was released")
+	}
+}
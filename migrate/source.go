@@ -0,0 +1,173 @@
+package migrate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Migration is a versioned .cql file.
+type Migration struct {
+	// Version is the number the file name starts with, e.g. 2 for 0002_add_email.cql.
+	Version int64
+	// Name is the file name.
+	Name string
+	// Checksum is the hex encoded SHA-256 checksum of the file.
+	Checksum string
+	// Statements are the CQL statements of the file, without comments and trailing semicolons.
+	Statements []string
+}
+
+// Load reads the migrations from the .cql files in the root directory of fsys, sorted by version.
+// The file names start with the version followed by an underscore or a dot, e.g. 0001_create_users.cql
+// or 2.cql. Other files and subdirectories are ignored.
+func Load(fsys fs.FS) ([]*Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("migrate: failed to list migrations: %w", err)
+	}
+
+	var migrations []*Migration
+	versions := make(map[int64]string)
+	for _, e := range entries {
+		if e.IsDir() || path.Ext(e.Name()) != ".cql" {
+			continue
+		}
+		version, err := parseVersion(e.Name())
+		if err != nil {
+			return nil, err
+		}
+		if other, ok := versions[version]; ok {
+			return nil, fmt.Errorf("migrate: %s and %s have the same version %d", other, e.Name(), version)
+		}
+		versions[version] = e.Name()
+
+		content, err := fs.ReadFile(fsys, e.Name())
+		if err != nil {
+			return nil, fmt.Errorf("migrate: failed to read %s: %w", e.Name(), err)
+		}
+		stmts, err := splitStatements(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("migrate: failed to parse %s: %w", e.Name(), err)
+		}
+		sum := sha256.Sum256(content)
+		migrations = append(migrations, &Migration{
+			Version:    version,
+			Name:       e.Name(),
+			Checksum:   hex.EncodeToString(sum[:]),
+			Statements: stmts,
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+func parseVersion(name string) (int64, error) {
+	digits := strings.TrimSuffix(name, ".cql")
+	if i := strings.IndexAny(digits, "_."); i >= 0 {
+		digits = digits[:i]
+	}
+	version, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil || version < 0 {
+		return 0, fmt.Errorf("migrate: the name of %s doesn't start with a version", name)
+	}
+	return version, nil
+}
+
+// splitStatements splits CQL into statements separated by semicolons. Semicolons in string literals,
+// quoted identifiers, $$ function bodies, comments and between BEGIN BATCH and APPLY BATCH don't separate
+// statements, comments are removed.
+func splitStatements(cql string) ([]string, error) {
+	var (
+		stmts []string
+		sb    strings.Builder
+	)
+	flush := func() {
+		if stmt := strings.TrimSpace(sb.String()); stmt != "" {
+			stmts = append(stmts, stmt)
+		}
+		sb.Reset()
+	}
+
+	for i := 0; i < len(cql); {
+		rest := cql[i:]
+		switch {
+		case strings.HasPrefix(rest, "--"), strings.HasPrefix(rest, "//"):
+			end := strings.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+			sb.WriteByte(' ')
+			i += end
+		case strings.HasPrefix(rest, "/*"):
+			end := strings.Index(rest[2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated comment at offset %d", i)
+			}
+			sb.WriteByte(' ')
+			i += end + 4
+		case strings.HasPrefix(rest, "$$"):
+			end := strings.Index(rest[2:], "$$")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated $$ string at offset %d", i)
+			}
+			sb.WriteString(rest[:end+4])
+			i += end + 4
+		case rest[0] == '\'' || rest[0] == '"':
+			// a quote is escaped by doubling it, which is the same as two adjacent literals
+			end := strings.IndexByte(rest[1:], rest[0])
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated %c quote at offset %d", rest[0], i)
+			}
+			sb.WriteString(rest[:end+2])
+			i += end + 2
+		case rest[0] == ';':
+			if inBatch(sb.String()) {
+				sb.WriteByte(';')
+			} else {
+				flush()
+			}
+			i++
+		default:
+			sb.WriteByte(rest[0])
+			i++
+		}
+	}
+	flush()
+	return stmts, nil
+}
+
+// inBatch reports whether the statement is a BEGIN [UNLOGGED|COUNTER] BATCH not terminated by APPLY BATCH yet.
+func inBatch(stmt string) bool {
+	fields := strings.Fields(strings.ToUpper(stmt))
+	if len(fields) < 2 || fields[0] != "BEGIN" {
+		return false
+	}
+	if fields[1] == "UNLOGGED" || fields[1] == "COUNTER" {
+		fields = fields[1:]
+	}
+	if len(fields) < 2 || fields[1] != "BATCH" {
+		return false
+	}
+	n := len(fields)
+	return n < 4 || fields[n-2] != "APPLY" || fields[n-1] != "BATCH"
+}
+
+// isDDL reports whether the statement changes the schema.
+func isDDL(stmt string) bool {
+	fields := strings.Fields(stmt)
+	if len(fields) == 0 {
+		return false
+	}
+	switch strings.ToUpper(fields[0]) {
+	case "CREATE", "ALTER", "DROP":
+		return true
+	}
+	return false
+}
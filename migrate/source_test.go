@@ -0,0 +1,75 @@
+//go:build unit
+// +build unit
+
+package migrate
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestSplitStatements(t *testing.T) {
+	stmts, err := splitStatements(`
+/* users; and their functions */
+CREATE TABLE ks.users (id int PRIMARY KEY, "we;ird" text); -- trailing; comment
+// another comment;
+INSERT INTO ks.users (id, "we;ird") VALUES (1, 'it''s; fine');
+CREATE FUNCTION ks.f (a int) RETURNS NULL ON NULL INPUT RETURNS int LANGUAGE lua AS $$ return a; $$;
+;
+BEGIN UNLOGGED BATCH
+  INSERT INTO ks.users (id) VALUES (2); -- in a batch;
+  INSERT INTO ks.users (id) VALUES (3);
+APPLY BATCH;
+begin batch insert into ks.users (id) values (4); apply batch;
+INSERT INTO ks.users (id) VALUES (5);
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		`CREATE TABLE ks.users (id int PRIMARY KEY, "we;ird" text)`,
+		`INSERT INTO ks.users (id, "we;ird") VALUES (1, 'it''s; fine')`,
+		`CREATE FUNCTION ks.f (a int) RETURNS NULL ON NULL INPUT RETURNS int LANGUAGE lua AS $$ return a; $$`,
+		"BEGIN UNLOGGED BATCH\n  INSERT INTO ks.users (id) VALUES (2);  \n  INSERT INTO ks.users (id) VALUES (3);\nAPPLY BATCH",
+		`begin batch insert into ks.users (id) values (4); apply batch`,
+		`INSERT INTO ks.users (id) VALUES (5)`,
+	}
+	if !reflect.DeepEqual(stmts, expected) {
+		t.Fatalf("expected %q, got %q", expected, stmts)
+	}
+
+	for _, cql := range []string{`SELECT 'abc`, `/* comment`, `AS $$ body`, `SELECT "abc`} {
+		if _, err := splitStatements(cql); err == nil {
+			t.Errorf("expected an error for %q", cql)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	migrations, err := Load(fstest.MapFS{
+		"10_later.cql":          {Data: []byte("SELECT 2;")},
+		"2.cql":                 {Data: []byte("SELECT 1;")},
+		"notes.txt":             {Data: []byte("ignored")},
+		"old/0001_archived.cql": {Data: []byte("ignored")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) != 2 || migrations[0].Version != 2 || migrations[1].Version != 10 || migrations[1].Name != "10_later.cql" {
+		t.Fatalf("unexpected migrations %+v", migrations)
+	}
+	if migrations[0].Checksum == migrations[1].Checksum || len(migrations[0].Checksum) != 64 {
+		t.Fatalf("unexpected checksums %q and %q", migrations[0].Checksum, migrations[1].Checksum)
+	}
+
+	for name, fsys := range map[string]fstest.MapFS{
+		"no version": {"create_users.cql": {}},
+		"duplicate":  {"1_a.cql": {}, "0001_b.cql": {}},
+		"invalid":    {"1.cql": {Data: []byte("SELECT 'abc")}},
+	} {
+		if _, err := Load(fsys); err == nil {
+			t.Errorf("expected an error for %s", name)
+		}
+	}
+}
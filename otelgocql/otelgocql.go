@@ -0,0 +1,267 @@
+// Package otelgocql provides OpenTelemetry tracing for the gocql driver.
+//
+// The observer returned by NewExecutionObserver creates a span for every Query and Batch
+// execution and a child span for every attempt made while executing it, including retries
+// and speculative executions. The trace context of each attempt is propagated to the server
+// in the custom payload of the request, so server side tracing can be correlated with the
+// client spans.
+//
+//	cluster := gocql.NewCluster("127.0.0.1")
+//	cluster.ExecutionObserver = otelgocql.NewExecutionObserver()
+//	cluster.ConnectObserver = otelgocql.NewConnectObserver()
+package otelgocql
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/gocql/gocql"
+)
+
+// ScopeName is the instrumentation scope name used by the tracer.
+const ScopeName = "github.com/gocql/gocql/otelgocql"
+
+// Attribute keys set on spans in addition to the database semantic conventions.
+const (
+	ConsistencyKey          = attribute.Key("db.cassandra.consistency_level")
+	IdempotenceKey          = attribute.Key("db.cassandra.idempotence")
+	PageNumberKey           = attribute.Key("db.cassandra.page_number")
+	CoordinatorIDKey        = attribute.Key("db.cassandra.coordinator.id")
+	CoordinatorDCKey        = attribute.Key("db.cassandra.coordinator.dc")
+	SpeculativeExecutionKey = attribute.Key("db.cassandra.speculative_execution")
+	ShardKey                = attribute.Key("db.scylladb.shard")
+	AttemptKey              = attribute.Key("gocql.attempt")
+	AttemptsKey             = attribute.Key("gocql.attempts")
+	RetryDecisionKey        = attribute.Key("gocql.retry_decision")
+)
+
+const (
+	dbSystemKey       = attribute.Key("db.system")
+	dbNamespaceKey    = attribute.Key("db.namespace")
+	dbQueryTextKey    = attribute.Key("db.query.text")
+	dbBatchSizeKey    = attribute.Key("db.operation.batch.size")
+	serverAddressKey  = attribute.Key("server.address")
+	serverPortKey     = attribute.Key("server.port")
+	dbSystemCassandra = "cassandra"
+)
+
+type config struct {
+	tracer             trace.Tracer
+	propagator         propagation.TextMapPropagator
+	payloadPropagation bool
+	queryText          bool
+}
+
+// Option configures the observers created by this package.
+type Option func(*config)
+
+// WithTracerProvider sets the tracer provider used to create spans.
+// The global tracer provider is used by default.
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(c *config) {
+		c.tracer = provider.Tracer(ScopeName)
+	}
+}
+
+// WithPropagator sets the propagator used to inject trace context into custom payloads.
+// The global text map propagator is used by default.
+func WithPropagator(propagator propagation.TextMapPropagator) Option {
+	return func(c *config) {
+		c.propagator = propagator
+	}
+}
+
+// WithCustomPayloadPropagation enables or disables propagation of the trace context in the
+// custom payload of requests. It is enabled by default. Custom payloads require protocol v4 or newer.
+func WithCustomPayloadPropagation(enabled bool) Option {
+	return func(c *config) {
+		c.payloadPropagation = enabled
+	}
+}
+
+// WithQueryText enables or disables recording of statements in the db.query.text attribute.
+// It is enabled by default, bound values are never recorded.
+func WithQueryText(enabled bool) Option {
+	return func(c *config) {
+		c.queryText = enabled
+	}
+}
+
+func newConfig(opts []Option) *config {
+	c := &config{
+		payloadPropagation: true,
+		queryText:          true,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.tracer == nil {
+		c.tracer = otel.GetTracerProvider().Tracer(ScopeName)
+	}
+	if c.propagator == nil {
+		c.propagator = otel.GetTextMapPropagator()
+	}
+	return c
+}
+
+// NewExecutionObserver returns a gocql.ExecutionObserver which creates a span per execution
+// and a child span per attempt.
+func NewExecutionObserver(opts ...Option) gocql.ExecutionObserver {
+	return &executionObserver{cfg: newConfig(opts)}
+}
+
+type executionObserver struct {
+	cfg *config
+}
+
+func (o *executionObserver) ExecutionStarted(ctx context.Context, execution gocql.ObservedExecution) gocql.ExecutionObserverContext {
+	attrs := []attribute.KeyValue{
+		dbSystemKey.String(dbSystemCassandra),
+		ConsistencyKey.String(execution.Consistency.String()),
+		IdempotenceKey.Bool(execution.Idempotent),
+	}
+	if execution.Keyspace != "" {
+		attrs = append(attrs, dbNamespaceKey.String(execution.Keyspace))
+	}
+
+	name := "gocql.query"
+	if execution.Batch {
+		name = "gocql.batch"
+		attrs = append(attrs, dbBatchSizeKey.Int(len(execution.Statements)))
+	} else {
+		attrs = append(attrs, PageNumberKey.Int(execution.PageNumber))
+		if o.cfg.queryText {
+			attrs = append(attrs, dbQueryTextKey.String(execution.Statement))
+		}
+	}
+
+	ctx, span := o.cfg.tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithTimestamp(execution.Start),
+		trace.WithAttributes(attrs...),
+	)
+	return &executionSpan{cfg: o.cfg, ctx: ctx, span: span}
+}
+
+type executionSpan struct {
+	cfg  *config
+	ctx  context.Context
+	span trace.Span
+}
+
+func (e *executionSpan) AttemptStarted(attempt gocql.ObservedAttempt) gocql.AttemptObserverContext {
+	attrs := []attribute.KeyValue{
+		AttemptKey.Int(attempt.Attempt),
+		SpeculativeExecutionKey.Int(attempt.SpeculativeExecution),
+		ConsistencyKey.String(attempt.Consistency.String()),
+	}
+	if attempt.Shard >= 0 {
+		attrs = append(attrs, ShardKey.Int(attempt.Shard))
+	}
+	attrs = append(attrs, hostAttributes(attempt.Host)...)
+
+	ctx, span := e.cfg.tracer.Start(e.ctx, "gocql.attempt",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithTimestamp(attempt.Start),
+		trace.WithAttributes(attrs...),
+	)
+	return &attemptSpan{cfg: e.cfg, ctx: ctx, span: span}
+}
+
+func (e *executionSpan) ExecutionFinished(execution gocql.ObservedExecution) {
+	e.span.SetAttributes(
+		AttemptsKey.Int(execution.Attempts),
+		ConsistencyKey.String(execution.Consistency.String()),
+	)
+	finishSpan(e.span, execution.Err, execution.End)
+}
+
+type attemptSpan struct {
+	cfg  *config
+	ctx  context.Context
+	span trace.Span
+}
+
+func (a *attemptSpan) CustomPayload() map[string][]byte {
+	if !a.cfg.payloadPropagation {
+		return nil
+	}
+
+	carrier := propagation.MapCarrier{}
+	a.cfg.propagator.Inject(a.ctx, carrier)
+	if len(carrier) == 0 {
+		return nil
+	}
+
+	payload := make(map[string][]byte, len(carrier))
+	for k, v := range carrier {
+		payload[k] = []byte(v)
+	}
+	return payload
+}
+
+func (a *attemptSpan) AttemptFinished(attempt gocql.ObservedAttempt) {
+	if attempt.Err != nil {
+		a.span.SetAttributes(RetryDecisionKey.String(attempt.RetryDecision.String()))
+	}
+	finishSpan(a.span, attempt.Err, attempt.End)
+}
+
+// NewConnectObserver returns a gocql.ConnectObserver which creates a span for every connection
+// attempt. Connections are not made on behalf of a query, so the spans are root spans.
+func NewConnectObserver(opts ...Option) gocql.ConnectObserver {
+	return &connectObserver{cfg: newConfig(opts)}
+}
+
+type connectObserver struct {
+	cfg *config
+}
+
+func (o *connectObserver) ObserveConnect(connect gocql.ObservedConnect) {
+	attrs := append([]attribute.KeyValue{dbSystemKey.String(dbSystemCassandra)}, hostAttributes(connect.Host)...)
+	_, span := o.cfg.tracer.Start(context.Background(), "gocql.connect",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithTimestamp(connect.Start),
+		trace.WithAttributes(attrs...),
+	)
+	finishSpan(span, connect.Err, connect.End)
+}
+
+func hostAttributes(host *gocql.HostInfo) []attribute.KeyValue {
+	if host == nil {
+		return nil
+	}
+
+	var attrs []attribute.KeyValue
+	if addr := host.ConnectAddress(); addr != nil {
+		attrs = append(attrs,
+			serverAddressKey.String(addr.String()),
+			serverPortKey.Int(host.Port()),
+		)
+	}
+	if id := host.HostID(); id != "" {
+		attrs = append(attrs, CoordinatorIDKey.String(id))
+	}
+	if dc := host.DataCenter(); dc != "" {
+		attrs = append(attrs, CoordinatorDCKey.String(dc))
+	}
+	return attrs
+}
+
+func finishSpan(span trace.Span, err error, end time.Time) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	if end.IsZero() {
+		span.End()
+		return
+	}
+	span.End(trace.WithTimestamp(end))
+}
@@ -0,0 +1,155 @@
+package otelgocql
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/gocql/gocql"
+)
+
+func newTestObserver(opts ...Option) (gocql.ExecutionObserver, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	opts = append([]Option{WithTracerProvider(provider), WithPropagator(propagation.TraceContext{})}, opts...)
+	return NewExecutionObserver(opts...), recorder
+}
+
+func attributeValue(span sdktrace.ReadOnlySpan, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestExecutionSpans(t *testing.T) {
+	t.Parallel()
+
+	observer, recorder := newTestObserver()
+
+	start := time.Now()
+	execution := gocql.ObservedExecution{
+		Start:       start,
+		Keyspace:    "ks",
+		Statement:   "SELECT * FROM tbl",
+		Consistency: gocql.Quorum,
+		PageNumber:  2,
+		Idempotent:  true,
+	}
+	obs := observer.ExecutionStarted(context.Background(), execution)
+
+	attemptErr := errors.New("overloaded")
+	attempts := []gocql.ObservedAttempt{
+		{Start: start, End: start.Add(time.Millisecond), Err: attemptErr, Shard: 3, Consistency: gocql.Quorum, RetryDecision: gocql.RetryNextHost},
+		{Start: start.Add(2 * time.Millisecond), End: start.Add(3 * time.Millisecond), Shard: -1, Attempt: 1, SpeculativeExecution: 1, Consistency: gocql.One},
+	}
+	for _, attempt := range attempts {
+		attemptObs := obs.AttemptStarted(attempt)
+		payload := attemptObs.CustomPayload()
+		if len(payload["traceparent"]) == 0 {
+			t.Fatalf("expected trace context in custom payload, got %v", payload)
+		}
+		attemptObs.AttemptFinished(attempt)
+	}
+
+	execution.End = start.Add(4 * time.Millisecond)
+	execution.Attempts = 2
+	execution.Consistency = gocql.One
+	obs.ExecutionFinished(execution)
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("expected 3 spans, got %d", len(spans))
+	}
+
+	parent := spans[2]
+	if parent.Name() != "gocql.query" {
+		t.Fatalf("expected the execution span to end last, got %q", parent.Name())
+	}
+	if v, _ := attributeValue(parent, PageNumberKey); v.AsInt64() != 2 {
+		t.Fatalf("expected page number 2, got %v", v.AsInt64())
+	}
+	if v, _ := attributeValue(parent, AttemptsKey); v.AsInt64() != 2 {
+		t.Fatalf("expected 2 attempts, got %v", v.AsInt64())
+	}
+	if !parent.EndTime().Equal(execution.End) {
+		t.Fatalf("expected execution span to end at %v, got %v", execution.End, parent.EndTime())
+	}
+
+	for i, span := range spans[:2] {
+		if span.Name() != "gocql.attempt" {
+			t.Fatalf("expected attempt span, got %q", span.Name())
+		}
+		if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+			t.Fatalf("attempt %d is not a child of the execution span", i)
+		}
+		if v, _ := attributeValue(span, AttemptKey); v.AsInt64() != int64(attempts[i].Attempt) {
+			t.Fatalf("expected attempt %d, got %v", attempts[i].Attempt, v.AsInt64())
+		}
+	}
+
+	failed := spans[0]
+	if failed.Status().Code != codes.Error {
+		t.Fatalf("expected failed attempt to have error status, got %v", failed.Status())
+	}
+	if v, _ := attributeValue(failed, RetryDecisionKey); v.AsString() != "RETRY_NEXT_HOST" {
+		t.Fatalf("expected retry decision RETRY_NEXT_HOST, got %q", v.AsString())
+	}
+	if v, _ := attributeValue(failed, ShardKey); v.AsInt64() != 3 {
+		t.Fatalf("expected shard 3, got %v", v.AsInt64())
+	}
+
+	succeeded := spans[1]
+	if _, ok := attributeValue(succeeded, RetryDecisionKey); ok {
+		t.Fatal("expected no retry decision on successful attempt")
+	}
+	if _, ok := attributeValue(succeeded, ShardKey); ok {
+		t.Fatal("expected no shard for non-Scylla connection")
+	}
+	if v, _ := attributeValue(succeeded, SpeculativeExecutionKey); v.AsInt64() != 1 {
+		t.Fatalf("expected speculative execution 1, got %v", v.AsInt64())
+	}
+}
+
+func TestBatchSpan(t *testing.T) {
+	t.Parallel()
+
+	observer, recorder := newTestObserver(WithCustomPayloadPropagation(false))
+
+	obs := observer.ExecutionStarted(context.Background(), gocql.ObservedExecution{
+		Start:      time.Now(),
+		Statements: []string{"INSERT 1", "INSERT 2"},
+		Batch:      true,
+	})
+	attempt := gocql.ObservedAttempt{Start: time.Now(), Shard: -1}
+	attemptObs := obs.AttemptStarted(attempt)
+	if payload := attemptObs.CustomPayload(); payload != nil {
+		t.Fatalf("expected no custom payload when propagation is disabled, got %v", payload)
+	}
+	attemptObs.AttemptFinished(attempt)
+	obs.ExecutionFinished(gocql.ObservedExecution{End: time.Now(), Batch: true, Attempts: 1})
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	batch := spans[1]
+	if batch.Name() != "gocql.batch" {
+		t.Fatalf("expected batch span, got %q", batch.Name())
+	}
+	if v, _ := attributeValue(batch, dbBatchSizeKey); v.AsInt64() != 2 {
+		t.Fatalf("expected batch size 2, got %v", v.AsInt64())
+	}
+	if _, ok := attributeValue(batch, dbQueryTextKey); ok {
+		t.Fatal("expected no query text for batches")
+	}
+}
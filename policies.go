@@ -130,6 +130,21 @@ const (
 	Rethrow       RetryType = 0x03 // raise error and stop retrying
 )
 
+func (r RetryType) String() string {
+	switch r {
+	case Retry:
+		return "RETRY"
+	case RetryNextHost:
+		return "RETRY_NEXT_HOST"
+	case Ignore:
+		return "IGNORE"
+	case Rethrow:
+		return "RETHROW"
+	default:
+		return fmt.Sprintf("UNKNOWN_RETRY_TYPE_0x%x", uint16(r))
+	}
+}
+
 // ErrUnknownRetryType is returned if the retry policy returns a retry type
 // unknown to the query executor.
 var ErrUnknownRetryType = errors.New("unknown retry type returned by retry policy")
@@ -678,6 +693,22 @@ func (t *tokenAwareHostPolicy) HostDown(host *HostInfo) {
 	t.fallback.HostDown(host)
 }
 
+// TrackLatency passes the latency to the fallback policy, e.g. LatencyAwareHostPolicy.
+func (t *tokenAwareHostPolicy) TrackLatency(host *HostInfo, latency time.Duration, err error) {
+	if tracker, ok := t.fallback.(LatencyTracker); ok {
+		tracker.TrackLatency(host, latency, err)
+	}
+}
+
+// Ready defers to the fallback policy. If it's not a ReadyPolicy the session waits for all hosts
+// to connect, as it does for policies which are not a ReadyPolicy.
+func (t *tokenAwareHostPolicy) Ready() bool {
+	if rdy, ok := t.fallback.(ReadyPolicy); ok {
+		return rdy.Ready()
+	}
+	return false
+}
+
 // getMetadataReadOnly returns current cluster metadata.
 // Metadata uses copy on write, so the returned value should be only used for reading.
 // To obtain a copy that could be updated, use getMetadataForUpdate instead.
@@ -1093,6 +1124,304 @@ func (d *rackAwareRR) Pick(q ExecutableQuery) NextHost {
 	return roundRobbin(int(nextStartOffset), d.hosts[0].get(), d.hosts[1].get(), d.hosts[2].get())
 }
 
+// LatencyTracker is implemented by host selection policies which need the latencies of query
+// attempts, TrackLatency is called by the session after every attempt.
+type LatencyTracker interface {
+	TrackLatency(host *HostInfo, latency time.Duration, err error)
+}
+
+// LatencyAwareExclusionThreshold sets how many times the average latency of a host has to be higher
+// than the lowest average latency of all hosts for the host to be excluded.
+// Default: 2
+func LatencyAwareExclusionThreshold(threshold float64) func(*latencyAwareHostPolicy) {
+	return func(p *latencyAwareHostPolicy) {
+		p.exclusionThreshold = threshold
+	}
+}
+
+// LatencyAwareScale sets how fast the weight of older latencies decays, the weight of the average
+// of previous latencies is log(d/scale+1)/(d/scale+1) where d is the time elapsed since the previous attempt.
+// Default: 100ms
+func LatencyAwareScale(scale time.Duration) func(*latencyAwareHostPolicy) {
+	return func(p *latencyAwareHostPolicy) {
+		p.scale = scale
+	}
+}
+
+// LatencyAwareRetryPeriod sets for how long a host is excluded, after the period elapses since its
+// latest measured attempt the host is tried again.
+// Default: 10s
+func LatencyAwareRetryPeriod(period time.Duration) func(*latencyAwareHostPolicy) {
+	return func(p *latencyAwareHostPolicy) {
+		p.retryPeriod = period
+	}
+}
+
+// LatencyAwareUpdateRate sets how often the lowest average latency of all hosts is recomputed.
+// Default: 100ms
+func LatencyAwareUpdateRate(rate time.Duration) func(*latencyAwareHostPolicy) {
+	return func(p *latencyAwareHostPolicy) {
+		p.updateRate = rate
+	}
+}
+
+// LatencyAwareMinMeasure sets the number of measured attempts needed before a host is considered
+// for exclusion.
+// Default: 50
+func LatencyAwareMinMeasure(n int) func(*latencyAwareHostPolicy) {
+	return func(p *latencyAwareHostPolicy) {
+		p.minMeasure = n
+	}
+}
+
+// LatencyAwareHostPolicy wraps a host selection policy and moves hosts which are much slower than the
+// fastest host to the end of the query plans of the wrapped policy.
+//
+// The policy keeps an exponentially decaying average of the latencies of successful and timed out
+// attempts per host. Once a host has at least LatencyAwareMinMeasure measured attempts and its average
+// latency exceeds LatencyAwareExclusionThreshold times the lowest average latency of all hosts, it is
+// excluded. Excluded hosts are only tried after all the other hosts, so they are given another chance
+// once LatencyAwareRetryPeriod elapses since their latest measured attempt.
+//
+// The policy should wrap the whole host selection, e.g.
+//
+//	cluster.PoolConfig.HostSelectionPolicy = gocql.LatencyAwareHostPolicy(
+//		gocql.TokenAwareHostPolicy(gocql.DCAwareRoundRobinPolicy("dc1")),
+//	)
+//
+// in which case the slow replicas of a partition are moved after the other hosts of the plan.
+func LatencyAwareHostPolicy(child HostSelectionPolicy, opts ...func(*latencyAwareHostPolicy)) HostSelectionPolicy {
+	p := &latencyAwareHostPolicy{
+		HostSelectionPolicy: child,
+		exclusionThreshold:  2,
+		scale:               100 * time.Millisecond,
+		retryPeriod:         10 * time.Second,
+		updateRate:          100 * time.Millisecond,
+		minMeasure:          50,
+		latencies:           make(map[string]*hostLatency),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+type latencyAwareHostPolicy struct {
+	HostSelectionPolicy
+	exclusionThreshold float64
+	scale              time.Duration
+	retryPeriod        time.Duration
+	updateRate         time.Duration
+	minMeasure         int
+
+	// mu protects latencies, minLatency and minUpdated.
+	mu         sync.RWMutex
+	latencies  map[string]*hostLatency
+	minLatency float64
+	minUpdated time.Time
+}
+
+// hostLatency is the exponentially decaying average latency of a host.
+type hostLatency struct {
+	mu       sync.Mutex
+	average  float64
+	updated  time.Time
+	measures int
+}
+
+func (l *hostLatency) add(latency time.Duration, now time.Time, scale time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.measures == 0 {
+		l.average = float64(latency)
+	} else {
+		scaled := float64(now.Sub(l.updated)) / float64(scale)
+		if scaled < 0 {
+			scaled = 0
+		}
+		weight := math.Log(scaled+1) / (scaled + 1)
+		l.average = (1-weight)*float64(latency) + weight*l.average
+	}
+	l.updated = now
+	l.measures++
+}
+
+// get returns the average latency, or false if there are not enough recent measurements.
+func (l *hostLatency) get(now time.Time, minMeasure int, retryPeriod time.Duration) (float64, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.measures < minMeasure || now.Sub(l.updated) > retryPeriod {
+		return 0, false
+	}
+	return l.average, true
+}
+
+func (p *latencyAwareHostPolicy) TrackLatency(host *HostInfo, latency time.Duration, err error) {
+	if host == nil || !isLatencyMeasurable(err) {
+		return
+	}
+
+	hostID := host.HostID()
+	p.mu.RLock()
+	l := p.latencies[hostID]
+	p.mu.RUnlock()
+	if l == nil {
+		p.mu.Lock()
+		if l = p.latencies[hostID]; l == nil {
+			l = &hostLatency{}
+			p.latencies[hostID] = l
+		}
+		p.mu.Unlock()
+	}
+	l.add(latency, time.Now(), p.scale)
+}
+
+// isLatencyMeasurable reports whether the latency of an attempt which failed with err tells how fast
+// the host is, other errors are often returned before the request is processed.
+func isLatencyMeasurable(err error) bool {
+	if err == nil || errors.Is(err, ErrTimeoutNoResponse) {
+		return true
+	}
+	var readTimeout *RequestErrReadTimeout
+	var writeTimeout *RequestErrWriteTimeout
+	return errors.As(err, &readTimeout) || errors.As(err, &writeTimeout)
+}
+
+// minAverage returns the lowest average latency of all hosts, recomputing it at most every updateRate.
+func (p *latencyAwareHostPolicy) minAverage(now time.Time) float64 {
+	p.mu.RLock()
+	if now.Sub(p.minUpdated) < p.updateRate {
+		lowest := p.minLatency
+		p.mu.RUnlock()
+		return lowest
+	}
+	p.mu.RUnlock()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if now.Sub(p.minUpdated) < p.updateRate {
+		return p.minLatency
+	}
+
+	lowest := math.Inf(1)
+	for _, l := range p.latencies {
+		if avg, ok := l.get(now, p.minMeasure, p.retryPeriod); ok && avg < lowest {
+			lowest = avg
+		}
+	}
+	if math.IsInf(lowest, 1) {
+		lowest = 0
+	}
+	p.minLatency = lowest
+	p.minUpdated = now
+	return lowest
+}
+
+func (p *latencyAwareHostPolicy) isExcluded(host *HostInfo, lowest float64, now time.Time) bool {
+	if host == nil || lowest == 0 {
+		return false
+	}
+
+	p.mu.RLock()
+	l := p.latencies[host.HostID()]
+	p.mu.RUnlock()
+	if l == nil {
+		return false
+	}
+
+	avg, ok := l.get(now, p.minMeasure, p.retryPeriod)
+	return ok && avg > p.exclusionThreshold*lowest
+}
+
+func (p *latencyAwareHostPolicy) Pick(qry ExecutableQuery) NextHost {
+	next := p.HostSelectionPolicy.Pick(qry)
+	now := time.Now()
+	lowest := p.minAverage(now)
+	if lowest == 0 {
+		return next
+	}
+
+	var excluded []SelectedHost
+	return func() SelectedHost {
+		for next != nil {
+			host := next()
+			if host == nil {
+				next = nil
+				break
+			}
+			if p.isExcluded(host.Info(), lowest, now) {
+				excluded = append(excluded, host)
+				continue
+			}
+			return host
+		}
+
+		if len(excluded) == 0 {
+			return nil
+		}
+		host := excluded[0]
+		excluded = excluded[1:]
+		return host
+	}
+}
+
+func (p *latencyAwareHostPolicy) RemoveHost(host *HostInfo) {
+	p.HostSelectionPolicy.RemoveHost(host)
+
+	p.mu.Lock()
+	delete(p.latencies, host.HostID())
+	p.mu.Unlock()
+}
+
+func (p *latencyAwareHostPolicy) Reset() {
+	p.HostSelectionPolicy.Reset()
+
+	p.mu.Lock()
+	p.latencies = make(map[string]*hostLatency)
+	p.minLatency = 0
+	p.minUpdated = time.Time{}
+	p.mu.Unlock()
+}
+
+// HostTier delegates to the wrapped policy, so that the policy can be used as a fallback of
+// TokenAwareHostPolicy.
+func (p *latencyAwareHostPolicy) HostTier(host *HostInfo) uint {
+	if tierer, ok := p.HostSelectionPolicy.(HostTierer); ok {
+		return tierer.HostTier(host)
+	}
+	if p.IsLocal(host) {
+		return 0
+	}
+	return 1
+}
+
+func (p *latencyAwareHostPolicy) MaxHostTier() uint {
+	if tierer, ok := p.HostSelectionPolicy.(HostTierer); ok {
+		return tierer.MaxHostTier()
+	}
+	return 1
+}
+
+// Ready defers to the wrapped policy, see tokenAwareHostPolicy.Ready.
+func (p *latencyAwareHostPolicy) Ready() bool {
+	if rdy, ok := p.HostSelectionPolicy.(ReadyPolicy); ok {
+		return rdy.Ready()
+	}
+	return false
+}
+
+func (p *latencyAwareHostPolicy) AddHosts(hosts []*HostInfo) {
+	if v, ok := p.HostSelectionPolicy.(interface{ AddHosts([]*HostInfo) }); ok {
+		v.AddHosts(hosts)
+		return
+	}
+	for _, host := range hosts {
+		p.HostSelectionPolicy.AddHost(host)
+	}
+}
+
 // ReadyPolicy defines a policy for when a HostSelectionPolicy can be used. After
 // each host connects during session initialization, the Ready method will be
 // called. If you only need a single Host to be up you can wrap a
@@ -1138,6 +1467,12 @@ func (s *singleHostReadyPolicy) Ready() bool {
 	return true
 }
 
+func (s *singleHostReadyPolicy) TrackLatency(host *HostInfo, latency time.Duration, err error) {
+	if tracker, ok := s.HostSelectionPolicy.(LatencyTracker); ok {
+		tracker.TrackLatency(host, latency, err)
+	}
+}
+
 // ConvictionPolicy interface is used by gocql to determine if a host should be
 // marked as DOWN based on the error and host info
 type ConvictionPolicy interface {
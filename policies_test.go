@@ -32,6 +32,7 @@
 package gocql
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net"
@@ -1218,3 +1219,224 @@ func TestTokenAwarePolicyReset(t *testing.T) {
 		t.Fatal("logger is nil")
 	}
 }
+
+func TestHostPolicy_LatencyAware(t *testing.T) {
+	t.Parallel()
+
+	policy := LatencyAwareHostPolicy(RoundRobinHostPolicy(), LatencyAwareMinMeasure(3), LatencyAwareUpdateRate(0))
+	tracker := policy.(LatencyTracker)
+
+	hosts := [...]*HostInfo{
+		{hostId: "0", connectAddress: net.IPv4(10, 0, 0, 1)},
+		{hostId: "1", connectAddress: net.IPv4(10, 0, 0, 2)},
+		{hostId: "2", connectAddress: net.IPv4(10, 0, 0, 3)},
+	}
+	for _, host := range hosts {
+		policy.AddHost(host)
+	}
+
+	track := func(host *HostInfo, latency time.Duration, n int) {
+		for i := 0; i < n; i++ {
+			tracker.TrackLatency(host, latency, nil)
+		}
+	}
+
+	// not enough measurements to exclude host 2 yet
+	track(hosts[0], time.Millisecond, 3)
+	track(hosts[1], 1500*time.Microsecond, 3)
+	track(hosts[2], 10*time.Millisecond, 2)
+	iter := policy.Pick(nil)
+	expectHosts(t, "all hosts", iter, "0", "1", "2")
+	expectNoMoreHosts(t, iter)
+
+	// errors which don't tell how fast the host is are not measured
+	tracker.TrackLatency(hosts[2], 10*time.Millisecond, &RequestErrUnavailable{})
+	iter = policy.Pick(nil)
+	expectHosts(t, "all hosts", iter, "0", "1", "2")
+	expectNoMoreHosts(t, iter)
+
+	track(hosts[2], 10*time.Millisecond, 1)
+	for i := 0; i < len(hosts); i++ {
+		iter = policy.Pick(nil)
+		expectHosts(t, "fast hosts", iter, "0", "1")
+		expectHosts(t, "slow host", iter, "2")
+		expectNoMoreHosts(t, iter)
+	}
+
+	// the host is not excluded anymore once it recovers
+	tracker.TrackLatency(hosts[2], time.Millisecond, &RequestErrReadTimeout{})
+	iter = policy.Pick(nil)
+	expectHosts(t, "all hosts", iter, "0", "1", "2")
+	expectNoMoreHosts(t, iter)
+
+	policy.RemoveHost(hosts[2])
+	iter = policy.Pick(nil)
+	expectHosts(t, "remaining hosts", iter, "0", "1")
+	expectNoMoreHosts(t, iter)
+}
+
+func TestHostPolicy_LatencyAware_Wrapped(t *testing.T) {
+	t.Parallel()
+
+	fallback := LatencyAwareHostPolicy(RoundRobinHostPolicy(), LatencyAwareMinMeasure(1), LatencyAwareUpdateRate(0))
+	policy := TokenAwareHostPolicy(fallback)
+	tracker, ok := policy.(LatencyTracker)
+	if !ok {
+		t.Fatal("the token aware policy doesn't pass the latencies to the fallback policy")
+	}
+
+	hosts := [...]*HostInfo{
+		{hostId: "0", connectAddress: net.IPv4(10, 0, 0, 1)},
+		{hostId: "1", connectAddress: net.IPv4(10, 0, 0, 2)},
+	}
+	for _, host := range hosts {
+		fallback.AddHost(host)
+	}
+	tracker.TrackLatency(hosts[0], time.Millisecond, nil)
+	tracker.TrackLatency(hosts[1], 10*time.Millisecond, nil)
+
+	for i := 0; i < len(hosts); i++ {
+		iter := policy.Pick(nil)
+		expectHosts(t, "fast host", iter, "0")
+		expectHosts(t, "slow host", iter, "1")
+		expectNoMoreHosts(t, iter)
+	}
+}
+
+func TestHostPolicy_Ready_Wrapped(t *testing.T) {
+	t.Parallel()
+
+	if TokenAwareHostPolicy(RoundRobinHostPolicy()).(ReadyPolicy).Ready() {
+		t.Fatal("a policy wrapping a policy which is not a ReadyPolicy should not be ready")
+	}
+
+	policy := TokenAwareHostPolicy(LatencyAwareHostPolicy(SingleHostReadyPolicy(RoundRobinHostPolicy())))
+	ready := policy.(ReadyPolicy)
+	if ready.Ready() {
+		t.Fatal("the policy should not be ready before a host is up")
+	}
+	policy.HostUp(&HostInfo{hostId: "0", connectAddress: net.IPv4(10, 0, 0, 1)})
+	if !ready.Ready() {
+		t.Fatal("the policy should be ready once a host is up")
+	}
+}
+
+func TestHostPolicy_LatencyAware_RetryPeriod(t *testing.T) {
+	t.Parallel()
+
+	policy := LatencyAwareHostPolicy(RoundRobinHostPolicy(),
+		LatencyAwareMinMeasure(1), LatencyAwareUpdateRate(0), LatencyAwareRetryPeriod(20*time.Millisecond))
+	tracker := policy.(LatencyTracker)
+
+	hosts := [...]*HostInfo{
+		{hostId: "0", connectAddress: net.IPv4(10, 0, 0, 1)},
+		{hostId: "1", connectAddress: net.IPv4(10, 0, 0, 2)},
+	}
+	for _, host := range hosts {
+		policy.AddHost(host)
+	}
+
+	tracker.TrackLatency(hosts[0], time.Millisecond, nil)
+	tracker.TrackLatency(hosts[1], 10*time.Millisecond, ErrTimeoutNoResponse)
+
+	iter := policy.Pick(nil)
+	expectHosts(t, "fast host", iter, "0")
+	expectHosts(t, "slow host", iter, "1")
+	expectNoMoreHosts(t, iter)
+
+	time.Sleep(30 * time.Millisecond)
+	// host 0 keeps being measured, host 1 is given another chance
+	tracker.TrackLatency(hosts[0], time.Millisecond, nil)
+	got := make(map[string]bool)
+	for i := 0; i < len(hosts); i++ {
+		got[policy.Pick(nil)().Info().HostID()] = true
+	}
+	if !got["1"] {
+		t.Fatal("expected the slow host to be tried again after the retry period")
+	}
+}
+
+func TestHostPolicy_LatencyAware_TokenAware(t *testing.T) {
+	t.Parallel()
+
+	const keyspace = "myKeyspace"
+	tokenAware := TokenAwareHostPolicy(RoundRobinHostPolicy())
+	policy := LatencyAwareHostPolicy(tokenAware, LatencyAwareMinMeasure(1), LatencyAwareUpdateRate(0))
+	policyInternal := tokenAware.(*tokenAwareHostPolicy)
+	policyInternal.getKeyspaceName = func() string { return keyspace }
+	policyInternal.getKeyspaceMetadata = func(keyspaceName string) (*KeyspaceMetadata, error) {
+		return &KeyspaceMetadata{
+			Name:          keyspace,
+			StrategyClass: "SimpleStrategy",
+			StrategyOptions: map[string]interface{}{
+				"class":              "SimpleStrategy",
+				"replication_factor": 2,
+			},
+		}, nil
+	}
+
+	query := &Query{routingInfo: &queryRoutingInfo{}}
+	query.getKeyspace = func() string { return keyspace }
+
+	hosts := [...]*HostInfo{
+		{hostId: "0", connectAddress: net.IPv4(10, 0, 0, 1), tokens: []string{"00"}},
+		{hostId: "1", connectAddress: net.IPv4(10, 0, 0, 2), tokens: []string{"25"}},
+		{hostId: "2", connectAddress: net.IPv4(10, 0, 0, 3), tokens: []string{"50"}},
+		{hostId: "3", connectAddress: net.IPv4(10, 0, 0, 4), tokens: []string{"75"}},
+	}
+	for _, host := range &hosts {
+		policy.AddHost(host)
+	}
+	policy.SetPartitioner("OrderedPartitioner")
+
+	policy.KeyspaceChanged(KeyspaceUpdateEvent{Keyspace: keyspace})
+
+	tracker := policy.(LatencyTracker)
+	for _, host := range hosts {
+		latency := time.Millisecond
+		if host.HostID() == "1" {
+			latency = 10 * time.Millisecond
+		}
+		tracker.TrackLatency(host, latency, nil)
+	}
+
+	// hosts 1 and 2 are the replicas, the slow replica is moved after the other hosts
+	query.RoutingKey([]byte("20"))
+	iter := policy.Pick(query)
+	expectHosts(t, "fast replica", iter, "2")
+	expectHosts(t, "rest", iter, "0", "3")
+	expectHosts(t, "slow replica", iter, "1")
+	expectNoMoreHosts(t, iter)
+}
+
+func TestHostPolicy_LatencyAware_Session(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	srv := NewTestServer(t, protoVersion4, ctx)
+	defer srv.Stop()
+
+	cluster := testCluster(protoVersion4, srv.Address)
+	policy := LatencyAwareHostPolicy(RoundRobinHostPolicy())
+	cluster.PoolConfig.HostSelectionPolicy = SingleHostReadyPolicy(policy)
+
+	db, err := cluster.CreateSession()
+	if err != nil {
+		t.Fatalf("NewCluster: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.Query("void").Exec(); err != nil {
+		t.Fatal(err)
+	}
+
+	latencies := policy.(*latencyAwareHostPolicy).latencies
+	if len(latencies) != 1 {
+		t.Fatalf("expected the latency of one host to be tracked, got %d", len(latencies))
+	}
+	for _, l := range latencies {
+		if l.measures != 1 {
+			t.Fatalf("expected one measured attempt, got %d", l.measures)
+		}
+	}
+}
@@ -72,6 +72,26 @@ func (p *preparedLRU) execIfMissing(key string, fn func(lru *lru.Cache) *infligh
 	return fn(p.lru), false
 }
 
+// prepared returns the statements which were prepared successfully, from the most recently used.
+func (p *preparedLRU) prepared() []*inflightPrepare {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var prepared []*inflightPrepare
+	p.lru.Range(func(_ string, val interface{}) bool {
+		flight := val.(*inflightPrepare)
+		select {
+		case <-flight.done:
+			if flight.err == nil && flight.preparedStatment != nil {
+				prepared = append(prepared, flight)
+			}
+		default:
+		}
+		return true
+	})
+	return prepared
+}
+
 func (p *preparedLRU) keyFor(hostID, keyspace, statement string) string {
 	// TODO: we should just use a struct for the key in the map
 	return hostID + keyspace + statement
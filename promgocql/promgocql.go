@@ -0,0 +1,146 @@
+// Package promgocql exports the metrics of a gocql session to Prometheus.
+//
+// The collector reads a snapshot of the session metrics every time it is scraped,
+// so no exporter has to be configured on the cluster:
+//
+//	session, err := cluster.CreateSession()
+//	...
+//	prometheus.MustRegister(promgocql.NewCollector(session))
+package promgocql
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/gocql/gocql"
+)
+
+// MetricsSource provides the metrics exported by the collector, it is implemented by *gocql.Session.
+type MetricsSource interface {
+	Metrics() gocql.SessionMetrics
+}
+
+type config struct {
+	namespace   string
+	constLabels prometheus.Labels
+}
+
+// Option configures the collector created by NewCollector.
+type Option func(*config)
+
+// WithNamespace sets the namespace of the exported metrics, it is "gocql" by default.
+func WithNamespace(namespace string) Option {
+	return func(c *config) {
+		c.namespace = namespace
+	}
+}
+
+// WithConstLabels sets labels added to all exported metrics, which is useful
+// to tell apart the metrics of several sessions registered in the same registry.
+func WithConstLabels(labels prometheus.Labels) Option {
+	return func(c *config) {
+		c.constLabels = labels
+	}
+}
+
+var (
+	hostLabels  = []string{"host_id", "address", "dc"}
+	shardLabels = append(append([]string(nil), hostLabels...), "shard")
+)
+
+type collector struct {
+	source MetricsSource
+
+	requests              *prometheus.Desc
+	timeouts              *prometheus.Desc
+	streamExhaustion      *prometheus.Desc
+	connections           *prometheus.Desc
+	inFlight              *prometheus.Desc
+	excessConnections     *prometheus.Desc
+	preparedCacheHits     *prometheus.Desc
+	preparedCacheMisses   *prometheus.Desc
+	speculativeExecutions *prometheus.Desc
+	retries               *prometheus.Desc
+}
+
+// NewCollector returns a prometheus.Collector exporting the metrics of the given source,
+// usually a *gocql.Session.
+func NewCollector(source MetricsSource, opts ...Option) prometheus.Collector {
+	cfg := &config{namespace: "gocql"}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	desc := func(name, help string, labels []string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(cfg.namespace, "", name), help, labels, cfg.constLabels)
+	}
+
+	return &collector{
+		source:                source,
+		requests:              desc("requests_total", "Number of requests sent.", shardLabels),
+		timeouts:              desc("request_timeouts_total", "Number of requests which did not get a response within the request timeout.", shardLabels),
+		streamExhaustion:      desc("stream_exhaustion_total", "Number of requests which could not be sent because no stream was available on the connection.", shardLabels),
+		connections:           desc("connections", "Number of open connections.", shardLabels),
+		inFlight:              desc("in_flight_requests", "Number of requests waiting for a response.", shardLabels),
+		excessConnections:     desc("excess_connections", "Number of connections not bound to a shard yet.", hostLabels),
+		preparedCacheHits:     desc("prepared_cache_hits_total", "Number of statements found in the prepared statements cache.", nil),
+		preparedCacheMisses:   desc("prepared_cache_misses_total", "Number of statements which had to be prepared.", nil),
+		speculativeExecutions: desc("speculative_executions_total", "Number of speculative executions launched.", nil),
+		retries:               desc("retry_decisions_total", "Number of retry policy decisions taken after failed attempts.", []string{"decision"}),
+	}
+}
+
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.requests
+	ch <- c.timeouts
+	ch <- c.streamExhaustion
+	ch <- c.connections
+	ch <- c.inFlight
+	ch <- c.excessConnections
+	ch <- c.preparedCacheHits
+	ch <- c.preparedCacheMisses
+	ch <- c.speculativeExecutions
+	ch <- c.retries
+}
+
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	metrics := c.source.Metrics()
+
+	ch <- prometheus.MustNewConstMetric(c.preparedCacheHits, prometheus.CounterValue, float64(metrics.PreparedCacheHits))
+	ch <- prometheus.MustNewConstMetric(c.preparedCacheMisses, prometheus.CounterValue, float64(metrics.PreparedCacheMisses))
+	ch <- prometheus.MustNewConstMetric(c.speculativeExecutions, prometheus.CounterValue, float64(metrics.SpeculativeExecutions))
+	for decision, n := range metrics.Retries {
+		ch <- prometheus.MustNewConstMetric(c.retries, prometheus.CounterValue, float64(n), decision.String())
+	}
+
+	for _, host := range metrics.Hosts {
+		labels := hostLabelValues(host.Host)
+		ch <- prometheus.MustNewConstMetric(c.excessConnections, prometheus.GaugeValue, float64(host.ExcessConnections), labels...)
+
+		for _, shard := range host.Shards {
+			labels := append(labels[:len(labels):len(labels)], shardLabelValue(shard.Shard))
+			ch <- prometheus.MustNewConstMetric(c.requests, prometheus.CounterValue, float64(shard.Requests), labels...)
+			ch <- prometheus.MustNewConstMetric(c.timeouts, prometheus.CounterValue, float64(shard.Timeouts), labels...)
+			ch <- prometheus.MustNewConstMetric(c.streamExhaustion, prometheus.CounterValue, float64(shard.StreamExhaustion), labels...)
+			ch <- prometheus.MustNewConstMetric(c.connections, prometheus.GaugeValue, float64(shard.Connections), labels...)
+			ch <- prometheus.MustNewConstMetric(c.inFlight, prometheus.GaugeValue, float64(shard.InFlight), labels...)
+		}
+	}
+}
+
+func hostLabelValues(host gocql.HostInformation) []string {
+	var addr string
+	if ip := host.ConnectAddress(); ip != nil {
+		addr = ip.String() + ":" + strconv.Itoa(host.Port())
+	}
+	return []string{host.HostID(), addr, host.DataCenter()}
+}
+
+// shardLabelValue returns the shard label, which is empty for hosts which are not sharded.
+func shardLabelValue(shard int) string {
+	if shard < 0 {
+		return ""
+	}
+	return strconv.Itoa(shard)
+}
@@ -0,0 +1,96 @@
+package promgocql
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/gocql/gocql"
+)
+
+type testHost struct {
+	gocql.HostInformation
+	id string
+	dc string
+}
+
+func (h testHost) HostID() string         { return h.id }
+func (h testHost) DataCenter() string     { return h.dc }
+func (h testHost) ConnectAddress() net.IP { return net.IPv4(10, 0, 0, 1) }
+func (h testHost) Port() int              { return 9042 }
+
+type testSource gocql.SessionMetrics
+
+func (s testSource) Metrics() gocql.SessionMetrics {
+	return gocql.SessionMetrics(s)
+}
+
+func TestCollector(t *testing.T) {
+	t.Parallel()
+
+	source := testSource{
+		PreparedCacheHits:     7,
+		PreparedCacheMisses:   2,
+		SpeculativeExecutions: 1,
+		Retries:               map[gocql.RetryType]int64{gocql.Retry: 3, gocql.Rethrow: 1},
+		Hosts: []gocql.HostPoolMetrics{
+			{
+				Host:              testHost{id: "scylla", dc: "dc1"},
+				ExcessConnections: 1,
+				Shards: []gocql.ShardMetrics{
+					{Shard: 0, Connections: 1, InFlight: 2, Requests: 10, Timeouts: 1},
+					{Shard: 1, Connections: 1, Requests: 5, StreamExhaustion: 4},
+				},
+			},
+			{
+				Host:   testHost{id: "cassandra", dc: "dc2"},
+				Shards: []gocql.ShardMetrics{{Shard: -1, Connections: 2, Requests: 3}},
+			},
+		},
+	}
+
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(NewCollector(source, WithConstLabels(prometheus.Labels{"session": "test"})))
+
+	expected := `
+# HELP gocql_requests_total Number of requests sent.
+# TYPE gocql_requests_total counter
+gocql_requests_total{address="10.0.0.1:9042",dc="dc1",host_id="scylla",session="test",shard="0"} 10
+gocql_requests_total{address="10.0.0.1:9042",dc="dc1",host_id="scylla",session="test",shard="1"} 5
+gocql_requests_total{address="10.0.0.1:9042",dc="dc2",host_id="cassandra",session="test",shard=""} 3
+# HELP gocql_retry_decisions_total Number of retry policy decisions taken after failed attempts.
+# TYPE gocql_retry_decisions_total counter
+gocql_retry_decisions_total{decision="RETHROW",session="test"} 1
+gocql_retry_decisions_total{decision="RETRY",session="test"} 3
+# HELP gocql_stream_exhaustion_total Number of requests which could not be sent because no stream was available on the connection.
+# TYPE gocql_stream_exhaustion_total counter
+gocql_stream_exhaustion_total{address="10.0.0.1:9042",dc="dc1",host_id="scylla",session="test",shard="0"} 0
+gocql_stream_exhaustion_total{address="10.0.0.1:9042",dc="dc1",host_id="scylla",session="test",shard="1"} 4
+gocql_stream_exhaustion_total{address="10.0.0.1:9042",dc="dc2",host_id="cassandra",session="test",shard=""} 0
+# HELP gocql_prepared_cache_hits_total Number of statements found in the prepared statements cache.
+# TYPE gocql_prepared_cache_hits_total counter
+gocql_prepared_cache_hits_total{session="test"} 7
+# HELP gocql_in_flight_requests Number of requests waiting for a response.
+# TYPE gocql_in_flight_requests gauge
+gocql_in_flight_requests{address="10.0.0.1:9042",dc="dc1",host_id="scylla",session="test",shard="0"} 2
+gocql_in_flight_requests{address="10.0.0.1:9042",dc="dc1",host_id="scylla",session="test",shard="1"} 0
+gocql_in_flight_requests{address="10.0.0.1:9042",dc="dc2",host_id="cassandra",session="test",shard=""} 0
+`
+	err := testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"gocql_requests_total",
+		"gocql_retry_decisions_total",
+		"gocql_stream_exhaustion_total",
+		"gocql_prepared_cache_hits_total",
+		"gocql_in_flight_requests",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := testutil.CollectAndCount(NewCollector(source), "gocql_excess_connections"); n != 2 {
+		t.Fatalf("expected excess connections of 2 hosts, got %d", n)
+	}
+}
@@ -0,0 +1,109 @@
+package qb
+
+import (
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+type batchEntry struct {
+	builder Builder
+	prefix  string
+}
+
+// BatchBuilder builds BATCH statements. The batch is a single statement, so it is prepared and
+// its values are bound like those of any other statement.
+type BatchBuilder struct {
+	entries  []batchEntry
+	using    using
+	unlogged bool
+	counter  bool
+}
+
+// Batch returns a builder of a logged BATCH statement.
+func Batch() *BatchBuilder {
+	return &BatchBuilder{}
+}
+
+// ToCql returns the statement and the names of its bound variables.
+func (b *BatchBuilder) ToCql() (stmt string, names []string) {
+	w := &writer{}
+
+	w.WriteString("BEGIN ")
+	if b.unlogged {
+		w.WriteString("UNLOGGED ")
+	}
+	if b.counter {
+		w.WriteString("COUNTER ")
+	}
+	w.WriteString("BATCH")
+	b.using.writeCql(w)
+	w.WriteByte(' ')
+
+	for _, e := range b.entries {
+		w.prefix = e.prefix
+		e.builder.writeCql(w)
+		w.WriteString("; ")
+	}
+	w.WriteString("APPLY BATCH")
+
+	return w.String(), w.names
+}
+
+// Query returns a query of the statement in the session.
+func (b *BatchBuilder) Query(session *gocql.Session) *gocql.Query {
+	stmt, _ := b.ToCql()
+	return session.Query(stmt)
+}
+
+// Add adds a statement to the batch.
+func (b *BatchBuilder) Add(builder Builder) *BatchBuilder {
+	return b.AddWithPrefix("", builder)
+}
+
+// AddWithPrefix adds a statement to the batch with the names of its bind markers prefixed
+// with prefix, so that the same statement can be added several times with different values:
+//
+//	insert := qb.Insert("ks.users").Columns("id", "name")
+//	stmt, names := qb.Batch().AddWithPrefix("a_", insert).AddWithPrefix("b_", insert).ToCql()
+//	// names are a_id, a_name, b_id, b_name
+func (b *BatchBuilder) AddWithPrefix(prefix string, builder Builder) *BatchBuilder {
+	b.entries = append(b.entries, batchEntry{builder: builder, prefix: prefix})
+	return b
+}
+
+// Unlogged makes the batch unlogged.
+func (b *BatchBuilder) Unlogged() *BatchBuilder {
+	b.unlogged = true
+	return b
+}
+
+// Counter makes the batch a counter batch.
+func (b *BatchBuilder) Counter() *BatchBuilder {
+	b.counter = true
+	return b
+}
+
+// Timestamp sets the USING TIMESTAMP clause of the batch.
+func (b *BatchBuilder) Timestamp(t time.Time) *BatchBuilder {
+	b.using.setTimestamp(t)
+	return b
+}
+
+// TimestampNamed sets the USING TIMESTAMP clause of the batch to a bind marker.
+func (b *BatchBuilder) TimestampNamed(name string) *BatchBuilder {
+	b.using.timestamp = param(name)
+	return b
+}
+
+// Timeout sets the Scylla specific USING TIMEOUT clause of the batch.
+func (b *BatchBuilder) Timeout(d time.Duration) *BatchBuilder {
+	b.using.setTimeout(d)
+	return b
+}
+
+// TimeoutNamed sets the Scylla specific USING TIMEOUT clause of the batch to a bind marker.
+func (b *BatchBuilder) TimeoutNamed(name string) *BatchBuilder {
+	b.using.timeout = param(name)
+	return b
+}
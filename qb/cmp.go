@@ -0,0 +1,205 @@
+package qb
+
+// value is the right hand side of a comparison or an assignment.
+type value interface {
+	writeCql(w *writer)
+}
+
+// param is a named bind marker.
+type param string
+
+func (p param) writeCql(w *writer) {
+	w.marker(string(p))
+}
+
+// lit is a literal written verbatim.
+type lit string
+
+func (l lit) writeCql(w *writer) {
+	w.WriteString(string(l))
+}
+
+// tokenParams is a token function call of named bind markers.
+type tokenParams []string
+
+func (t tokenParams) writeCql(w *writer) {
+	w.WriteString("token(")
+	for i, name := range t {
+		if i > 0 {
+			w.WriteString(", ")
+		}
+		w.marker(name)
+	}
+	w.WriteByte(')')
+}
+
+// Cmp is a comparison used in WHERE and IF clauses.
+type Cmp struct {
+	value  value
+	column string
+	op     string
+	// token is set if the column is a list of partition key columns compared by token.
+	token []string
+}
+
+func (c Cmp) writeCql(w *writer) {
+	if c.token != nil {
+		w.WriteString("token(")
+		w.idents(c.token)
+		w.WriteByte(')')
+	} else {
+		w.ident(c.column)
+	}
+	w.WriteByte(' ')
+	w.WriteString(c.op)
+	w.WriteByte(' ')
+	c.value.writeCql(w)
+}
+
+func writeCmps(w *writer, clause string, cmps []Cmp) {
+	if len(cmps) == 0 {
+		return
+	}
+	w.WriteString(clause)
+	for i, c := range cmps {
+		if i > 0 {
+			w.WriteString(" AND ")
+		}
+		c.writeCql(w)
+	}
+}
+
+func cmp(column, op string, v value) Cmp {
+	return Cmp{column: column, op: op, value: v}
+}
+
+// Eq produces column=:column.
+func Eq(column string) Cmp { return cmp(column, "=", param(column)) }
+
+// EqNamed produces column=:name.
+func EqNamed(column, name string) Cmp { return cmp(column, "=", param(name)) }
+
+// EqLit produces column=literal, the literal is not escaped.
+func EqLit(column, literal string) Cmp { return cmp(column, "=", lit(literal)) }
+
+// Ne produces column!=:column, it can only be used in IF clauses.
+func Ne(column string) Cmp { return cmp(column, "!=", param(column)) }
+
+// NeNamed produces column!=:name, it can only be used in IF clauses.
+func NeNamed(column, name string) Cmp { return cmp(column, "!=", param(name)) }
+
+// NeLit produces column!=literal, it can only be used in IF clauses.
+func NeLit(column, literal string) Cmp { return cmp(column, "!=", lit(literal)) }
+
+// Lt produces column<:column.
+func Lt(column string) Cmp { return cmp(column, "<", param(column)) }
+
+// LtNamed produces column<:name.
+func LtNamed(column, name string) Cmp { return cmp(column, "<", param(name)) }
+
+// LtLit produces column<literal.
+func LtLit(column, literal string) Cmp { return cmp(column, "<", lit(literal)) }
+
+// LtOrEq produces column<=:column.
+func LtOrEq(column string) Cmp { return cmp(column, "<=", param(column)) }
+
+// LtOrEqNamed produces column<=:name.
+func LtOrEqNamed(column, name string) Cmp { return cmp(column, "<=", param(name)) }
+
+// LtOrEqLit produces column<=literal.
+func LtOrEqLit(column, literal string) Cmp { return cmp(column, "<=", lit(literal)) }
+
+// Gt produces column>:column.
+func Gt(column string) Cmp { return cmp(column, ">", param(column)) }
+
+// GtNamed produces column>:name.
+func GtNamed(column, name string) Cmp { return cmp(column, ">", param(name)) }
+
+// GtLit produces column>literal.
+func GtLit(column, literal string) Cmp { return cmp(column, ">", lit(literal)) }
+
+// GtOrEq produces column>=:column.
+func GtOrEq(column string) Cmp { return cmp(column, ">=", param(column)) }
+
+// GtOrEqNamed produces column>=:name.
+func GtOrEqNamed(column, name string) Cmp { return cmp(column, ">=", param(name)) }
+
+// GtOrEqLit produces column>=literal.
+func GtOrEqLit(column, literal string) Cmp { return cmp(column, ">=", lit(literal)) }
+
+// In produces column IN :column, the bound value is a slice.
+func In(column string) Cmp { return cmp(column, "IN", param(column)) }
+
+// InNamed produces column IN :name, the bound value is a slice.
+func InNamed(column, name string) Cmp { return cmp(column, "IN", param(name)) }
+
+// InLit produces column IN literal, e.g. InLit("id", "(1, 2, 3)").
+func InLit(column, literal string) Cmp { return cmp(column, "IN", lit(literal)) }
+
+// Contains produces column CONTAINS :column.
+func Contains(column string) Cmp { return cmp(column, "CONTAINS", param(column)) }
+
+// ContainsNamed produces column CONTAINS :name.
+func ContainsNamed(column, name string) Cmp { return cmp(column, "CONTAINS", param(name)) }
+
+// ContainsLit produces column CONTAINS literal.
+func ContainsLit(column, literal string) Cmp { return cmp(column, "CONTAINS", lit(literal)) }
+
+// ContainsKey produces column CONTAINS KEY :column.
+func ContainsKey(column string) Cmp { return cmp(column, "CONTAINS KEY", param(column)) }
+
+// ContainsKeyNamed produces column CONTAINS KEY :name.
+func ContainsKeyNamed(column, name string) Cmp { return cmp(column, "CONTAINS KEY", param(name)) }
+
+// ContainsKeyLit produces column CONTAINS KEY literal.
+func ContainsKeyLit(column, literal string) Cmp { return cmp(column, "CONTAINS KEY", lit(literal)) }
+
+// TokenBuilder builds comparisons of the token of partition key columns, see Token.
+type TokenBuilder []string
+
+// Token starts a comparison of the token of the given partition key columns, e.g.
+//
+//	qb.Token("pk1", "pk2").Gt()
+//
+// produces token(pk1, pk2)>token(:pk1, :pk2) and
+//
+//	qb.Token("pk").GtNamed("start")
+//
+// produces token(pk)>:start.
+func Token(columns ...string) TokenBuilder {
+	return append(TokenBuilder{}, columns...)
+}
+
+func (t TokenBuilder) cmp(op string, v value) Cmp {
+	return Cmp{token: t, op: op, value: v}
+}
+
+// Eq produces token(columns)=token(:columns).
+func (t TokenBuilder) Eq() Cmp { return t.cmp("=", tokenParams(t)) }
+
+// EqNamed produces token(columns)=:name.
+func (t TokenBuilder) EqNamed(name string) Cmp { return t.cmp("=", param(name)) }
+
+// Lt produces token(columns)<token(:columns).
+func (t TokenBuilder) Lt() Cmp { return t.cmp("<", tokenParams(t)) }
+
+// LtNamed produces token(columns)<:name.
+func (t TokenBuilder) LtNamed(name string) Cmp { return t.cmp("<", param(name)) }
+
+// LtOrEq produces token(columns)<=token(:columns).
+func (t TokenBuilder) LtOrEq() Cmp { return t.cmp("<=", tokenParams(t)) }
+
+// LtOrEqNamed produces token(columns)<=:name.
+func (t TokenBuilder) LtOrEqNamed(name string) Cmp { return t.cmp("<=", param(name)) }
+
+// Gt produces token(columns)>token(:columns).
+func (t TokenBuilder) Gt() Cmp { return t.cmp(">", tokenParams(t)) }
+
+// GtNamed produces token(columns)>:name.
+func (t TokenBuilder) GtNamed(name string) Cmp { return t.cmp(">", param(name)) }
+
+// GtOrEq produces token(columns)>=token(:columns).
+func (t TokenBuilder) GtOrEq() Cmp { return t.cmp(">=", tokenParams(t)) }
+
+// GtOrEqNamed produces token(columns)>=:name.
+func (t TokenBuilder) GtOrEqNamed(name string) Cmp { return t.cmp(">=", param(name)) }
@@ -0,0 +1,106 @@
+package qb
+
+import (
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// DeleteBuilder builds DELETE statements.
+type DeleteBuilder struct {
+	table    string
+	columns  []string
+	where    []Cmp
+	ifs      []Cmp
+	using    using
+	ifExists bool
+}
+
+// Delete returns a builder of a DELETE statement from the table, optionally qualified with a keyspace.
+// Whole rows are deleted unless Columns is used.
+func Delete(table string) *DeleteBuilder {
+	return &DeleteBuilder{table: table}
+}
+
+// DeleteFrom returns a builder of a DELETE statement of the row of the table selected by its primary key.
+func DeleteFrom(t *gocql.TableMetadata) *DeleteBuilder {
+	return Delete(tableName(t)).Where(PrimaryKey(t)...)
+}
+
+// ToCql returns the statement and the names of its bound variables.
+func (b *DeleteBuilder) ToCql() (stmt string, names []string) {
+	return toCql(b)
+}
+
+// Query returns a query of the statement in the session.
+func (b *DeleteBuilder) Query(session *gocql.Session) *gocql.Query {
+	stmt, _ := b.ToCql()
+	return session.Query(stmt)
+}
+
+func (b *DeleteBuilder) writeCql(w *writer) {
+	w.WriteString("DELETE ")
+	if len(b.columns) > 0 {
+		w.idents(b.columns)
+		w.WriteByte(' ')
+	}
+	w.WriteString("FROM ")
+	w.table(b.table)
+	b.using.writeCql(w)
+
+	writeCmps(w, " WHERE ", b.where)
+	if b.ifExists {
+		w.WriteString(" IF EXISTS")
+	} else {
+		writeCmps(w, " IF ", b.ifs)
+	}
+}
+
+// Columns sets the columns to delete, they can also be elements of collections, e.g. "tags[1]".
+func (b *DeleteBuilder) Columns(columns ...string) *DeleteBuilder {
+	b.columns = append(b.columns, columns...)
+	return b
+}
+
+// Where adds comparisons to the WHERE clause.
+func (b *DeleteBuilder) Where(cmps ...Cmp) *DeleteBuilder {
+	b.where = append(b.where, cmps...)
+	return b
+}
+
+// If adds conditions to the IF clause, making the statement a lightweight transaction.
+func (b *DeleteBuilder) If(cmps ...Cmp) *DeleteBuilder {
+	b.ifs = append(b.ifs, cmps...)
+	return b
+}
+
+// IfExists adds the IF EXISTS clause, making the statement a lightweight transaction.
+// It takes precedence over conditions added with If.
+func (b *DeleteBuilder) IfExists() *DeleteBuilder {
+	b.ifExists = true
+	return b
+}
+
+// Timestamp sets the USING TIMESTAMP clause.
+func (b *DeleteBuilder) Timestamp(t time.Time) *DeleteBuilder {
+	b.using.setTimestamp(t)
+	return b
+}
+
+// TimestampNamed sets the USING TIMESTAMP clause to a bind marker.
+func (b *DeleteBuilder) TimestampNamed(name string) *DeleteBuilder {
+	b.using.timestamp = param(name)
+	return b
+}
+
+// Timeout sets the Scylla specific USING TIMEOUT clause.
+func (b *DeleteBuilder) Timeout(d time.Duration) *DeleteBuilder {
+	b.using.setTimeout(d)
+	return b
+}
+
+// TimeoutNamed sets the Scylla specific USING TIMEOUT clause to a bind marker.
+func (b *DeleteBuilder) TimeoutNamed(name string) *DeleteBuilder {
+	b.using.timeout = param(name)
+	return b
+}
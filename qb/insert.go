@@ -0,0 +1,129 @@
+package qb
+
+import (
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+type assignment struct {
+	value  value
+	column string
+}
+
+// InsertBuilder builds INSERT statements.
+type InsertBuilder struct {
+	table   string
+	columns []assignment
+	using   using
+	unique  bool
+}
+
+// Insert returns a builder of an INSERT statement into the table, optionally qualified with a keyspace.
+func Insert(table string) *InsertBuilder {
+	return &InsertBuilder{table: table}
+}
+
+// InsertInto returns a builder of an INSERT statement of all the columns of the table.
+func InsertInto(t *gocql.TableMetadata) *InsertBuilder {
+	return Insert(tableName(t)).Columns(columns(t)...)
+}
+
+// ToCql returns the statement and the names of its bound variables.
+func (b *InsertBuilder) ToCql() (stmt string, names []string) {
+	return toCql(b)
+}
+
+// Query returns a query of the statement in the session.
+func (b *InsertBuilder) Query(session *gocql.Session) *gocql.Query {
+	stmt, _ := b.ToCql()
+	return session.Query(stmt)
+}
+
+func (b *InsertBuilder) writeCql(w *writer) {
+	w.WriteString("INSERT INTO ")
+	w.table(b.table)
+
+	w.WriteString(" (")
+	for i, a := range b.columns {
+		if i > 0 {
+			w.WriteString(", ")
+		}
+		w.ident(a.column)
+	}
+	w.WriteString(") VALUES (")
+	for i, a := range b.columns {
+		if i > 0 {
+			w.WriteString(", ")
+		}
+		a.value.writeCql(w)
+	}
+	w.WriteByte(')')
+
+	if b.unique {
+		w.WriteString(" IF NOT EXISTS")
+	}
+	b.using.writeCql(w)
+}
+
+// Columns adds columns bound to bind markers named after them.
+func (b *InsertBuilder) Columns(columns ...string) *InsertBuilder {
+	for _, column := range columns {
+		b.columns = append(b.columns, assignment{column: column, value: param(column)})
+	}
+	return b
+}
+
+// NamedColumn adds a column bound to a bind marker with the given name.
+func (b *InsertBuilder) NamedColumn(column, name string) *InsertBuilder {
+	b.columns = append(b.columns, assignment{column: column, value: param(name)})
+	return b
+}
+
+// LitColumn adds a column set to a literal, the literal is not escaped.
+func (b *InsertBuilder) LitColumn(column, literal string) *InsertBuilder {
+	b.columns = append(b.columns, assignment{column: column, value: lit(literal)})
+	return b
+}
+
+// Unique adds the IF NOT EXISTS clause, making the statement a lightweight transaction.
+func (b *InsertBuilder) Unique() *InsertBuilder {
+	b.unique = true
+	return b
+}
+
+// TTL sets the USING TTL clause, the TTL is truncated to seconds.
+func (b *InsertBuilder) TTL(d time.Duration) *InsertBuilder {
+	b.using.setTTL(d)
+	return b
+}
+
+// TTLNamed sets the USING TTL clause to a bind marker.
+func (b *InsertBuilder) TTLNamed(name string) *InsertBuilder {
+	b.using.ttl = param(name)
+	return b
+}
+
+// Timestamp sets the USING TIMESTAMP clause.
+func (b *InsertBuilder) Timestamp(t time.Time) *InsertBuilder {
+	b.using.setTimestamp(t)
+	return b
+}
+
+// TimestampNamed sets the USING TIMESTAMP clause to a bind marker.
+func (b *InsertBuilder) TimestampNamed(name string) *InsertBuilder {
+	b.using.timestamp = param(name)
+	return b
+}
+
+// Timeout sets the Scylla specific USING TIMEOUT clause.
+func (b *InsertBuilder) Timeout(d time.Duration) *InsertBuilder {
+	b.using.setTimeout(d)
+	return b
+}
+
+// TimeoutNamed sets the Scylla specific USING TIMEOUT clause to a bind marker.
+func (b *InsertBuilder) TimeoutNamed(name string) *InsertBuilder {
+	b.using.timeout = param(name)
+	return b
+}
@@ -0,0 +1,194 @@
+// Package qb builds CQL statements.
+//
+// Statements are built with named bind markers, so the values can be bound by name with
+// gocql.Query.BindStruct or gocql.Query.BindMap:
+//
+//	stmt := qb.Select("ks.users").
+//		Columns("id", "name", "email").
+//		Where(qb.Eq("id")).
+//		BypassCache()
+//
+//	var user User
+//	err := stmt.Query(session).BindMap(map[string]interface{}{"id": id}).Scan(&user.ID, &user.Name, &user.Email)
+//
+// Builders can also be created from table metadata, in which case the statements refer to all columns
+// of the table and use its primary key:
+//
+//	ks, err := session.KeyspaceMetadata("ks")
+//	...
+//	err = qb.UpdateOf(ks.Tables["users"]).TTL(time.Hour).Query(session).BindStruct(user).Exec()
+//
+// Vector search queries order the rows by the similarity to a bound vector:
+//
+//	stmt := qb.Select("ks.items").
+//		Columns("id").
+//		Similarity(qb.SimilarityCosine, "embedding", "query").
+//		OrderByANN("embedding", "query").
+//		Limit(10)
+//
+// Column and table names are quoted when they contain upper case letters or are reserved keywords,
+// other expressions, e.g. function calls, are written verbatim.
+package qb
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/gocql/gocql"
+)
+
+// Builder is a builder of a statement which can be added to a batch.
+type Builder interface {
+	// ToCql returns the statement and the names of its bound variables in the order they appear.
+	ToCql() (stmt string, names []string)
+	writeCql(w *writer)
+}
+
+// writer accumulates a statement and the names of its bound variables.
+type writer struct {
+	strings.Builder
+	names []string
+	// prefix is prepended to the names of the bound variables, see BatchBuilder.AddWithPrefix.
+	prefix string
+}
+
+// ident writes a column, table or keyspace name.
+func (w *writer) ident(name string) {
+	if needsQuoting(name) {
+		w.WriteByte('"')
+		w.WriteString(strings.ReplaceAll(name, `"`, `""`))
+		w.WriteByte('"')
+		return
+	}
+	w.WriteString(name)
+}
+
+// table writes a table name, optionally qualified with a keyspace.
+func (w *writer) table(name string) {
+	if ks, table, ok := strings.Cut(name, "."); ok {
+		w.ident(ks)
+		w.WriteByte('.')
+		w.ident(table)
+		return
+	}
+	w.ident(name)
+}
+
+// marker writes a named bind marker.
+func (w *writer) marker(name string) {
+	name = w.prefix + name
+	w.WriteByte(':')
+	w.ident(name)
+	w.names = append(w.names, name)
+}
+
+func (w *writer) idents(names []string) {
+	for i, name := range names {
+		if i > 0 {
+			w.WriteString(", ")
+		}
+		w.ident(name)
+	}
+}
+
+func toCql(b Builder) (string, []string) {
+	w := &writer{}
+	b.writeCql(w)
+	return w.String(), w.names
+}
+
+// isIdent reports whether name is an identifier, as opposed to an expression like a function call.
+func isIdent(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r == '_', 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z':
+		case '0' <= r && r <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func needsQuoting(name string) bool {
+	return isIdent(name) && (strings.ToLower(name) != name || reservedKeywords[name])
+}
+
+// reservedKeywords are the CQL keywords which can't be used as unquoted identifiers.
+var reservedKeywords = map[string]bool{
+	"add": true, "allow": true, "alter": true, "and": true, "apply": true, "asc": true, "authorize": true,
+	"batch": true, "begin": true, "by": true, "columnfamily": true, "create": true, "delete": true, "desc": true,
+	"describe": true, "drop": true, "entries": true, "execute": true, "from": true, "full": true, "grant": true,
+	"if": true, "in": true, "index": true, "infinity": true, "insert": true, "into": true, "keyspace": true,
+	"limit": true, "modify": true, "nan": true, "norecursive": true, "not": true, "null": true, "of": true,
+	"on": true, "or": true, "order": true, "primary": true, "rename": true, "replace": true, "revoke": true,
+	"schema": true, "select": true, "set": true, "table": true, "to": true, "token": true, "truncate": true,
+	"unlogged": true, "update": true, "use": true, "using": true, "view": true, "where": true, "with": true,
+}
+
+func tableName(t *gocql.TableMetadata) string {
+	var w writer
+	w.ident(t.Keyspace)
+	w.WriteByte('.')
+	w.ident(t.Name)
+	// quoted names are not identifiers, so writer.table writes them verbatim
+	return w.String()
+}
+
+// columns returns the names of all columns of the table, primary key columns first.
+func columns(t *gocql.TableMetadata) []string {
+	if len(t.OrderedColumns) > 0 {
+		return t.OrderedColumns
+	}
+
+	names := make([]string, 0, len(t.Columns))
+	for _, col := range primaryKey(t) {
+		names = append(names, col.Name)
+	}
+	var rest []string
+	for name, col := range t.Columns {
+		if col.Kind != gocql.ColumnPartitionKey && col.Kind != gocql.ColumnClusteringKey {
+			rest = append(rest, name)
+		}
+	}
+	sort.Strings(rest)
+	return append(names, rest...)
+}
+
+func primaryKey(t *gocql.TableMetadata) []*gocql.ColumnMetadata {
+	return append(append([]*gocql.ColumnMetadata(nil), t.PartitionKey...), t.ClusteringColumns...)
+}
+
+// PartitionKey returns equality comparisons of the partition key columns of the table
+// with bind markers named after the columns.
+func PartitionKey(t *gocql.TableMetadata) []Cmp {
+	cmps := make([]Cmp, len(t.PartitionKey))
+	for i, col := range t.PartitionKey {
+		cmps[i] = Eq(col.Name)
+	}
+	return cmps
+}
+
+// PrimaryKey returns equality comparisons of the primary key columns of the table
+// with bind markers named after the columns.
+func PrimaryKey(t *gocql.TableMetadata) []Cmp {
+	pk := primaryKey(t)
+	cmps := make([]Cmp, len(pk))
+	for i, col := range pk {
+		cmps[i] = Eq(col.Name)
+	}
+	return cmps
+}
+
+// isPrimaryKey reports whether the column is a part of the primary key of the table.
+func isPrimaryKey(t *gocql.TableMetadata, name string) bool {
+	for _, col := range primaryKey(t) {
+		if col.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,255 @@
+//go:build unit
+// +build unit
+
+package qb
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+func testTable() *gocql.TableMetadata {
+	pk := &gocql.ColumnMetadata{Name: "id", Kind: gocql.ColumnPartitionKey}
+	ck := &gocql.ColumnMetadata{Name: "ts", Kind: gocql.ColumnClusteringKey}
+	return &gocql.TableMetadata{
+		Keyspace:          "ks",
+		Name:              "Events",
+		PartitionKey:      []*gocql.ColumnMetadata{pk},
+		ClusteringColumns: []*gocql.ColumnMetadata{ck},
+		Columns: map[string]*gocql.ColumnMetadata{
+			"id":    pk,
+			"ts":    ck,
+			"value": {Name: "value", Kind: gocql.ColumnRegular},
+			"from":  {Name: "from", Kind: gocql.ColumnRegular},
+		},
+	}
+}
+
+type cqlBuilder interface {
+	ToCql() (stmt string, names []string)
+}
+
+func TestBuilders(t *testing.T) {
+	t.Parallel()
+
+	ts := time.UnixMicro(1700000000000000)
+
+	tests := []struct {
+		name    string
+		builder cqlBuilder
+		stmt    string
+		names   []string
+	}{
+		{
+			name:    "select all",
+			builder: Select("ks.users"),
+			stmt:    "SELECT * FROM ks.users",
+		},
+		{
+			name: "select",
+			builder: Select("ks.users").
+				Columns("id", "name", "writetime(name)").
+				Where(Eq("id"), GtNamed("age", "min_age"), InLit("role", "('a', 'b')")).
+				OrderBy("name", DESC).
+				PerPartitionLimit(1).
+				LimitNamed("limit").
+				AllowFiltering().
+				BypassCache().
+				Timeout(1500 * time.Millisecond),
+			stmt:  "SELECT id, name, writetime(name) FROM ks.users WHERE id = :id AND age > :min_age AND role IN ('a', 'b') ORDER BY name DESC PER PARTITION LIMIT 1 LIMIT :\"limit\" ALLOW FILTERING BYPASS CACHE USING TIMEOUT 1s500ms",
+			names: []string{"id", "min_age", "limit"},
+		},
+		{
+			name:    "select distinct json",
+			builder: Select("users").Json().Distinct().Columns("id").Limit(10),
+			stmt:    "SELECT JSON DISTINCT id FROM users LIMIT 10",
+		},
+		{
+			name:    "select group by",
+			builder: Select("t").Columns("pk", "COUNT(*)").GroupBy("pk"),
+			stmt:    "SELECT pk, COUNT(*) FROM t GROUP BY pk",
+		},
+		{
+			name:    "select token",
+			builder: Select("t").Where(Token("a", "b").Gt(), Token("a", "b").LtOrEqNamed("end")),
+			stmt:    "SELECT * FROM t WHERE token(a, b) > token(:a, :b) AND token(a, b) <= :end",
+			names:   []string{"a", "b", "end"},
+		},
+		{
+			name: "select ann",
+			builder: Select("ks.items").
+				Columns("id").
+				Similarity(SimilarityCosine, "Embedding", "vec").
+				OrderByANN("Embedding", "vec").
+				Limit(10),
+			stmt:  `SELECT id, similarity_cosine("Embedding", :vec) FROM ks.items ORDER BY "Embedding" ANN OF :vec LIMIT 10`,
+			names: []string{"vec", "vec"},
+		},
+		{
+			name:    "quoting",
+			builder: Select("Ks.select").Columns("Name", "from").Where(Eq("Key")),
+			stmt:    `SELECT "Name", "from" FROM "Ks"."select" WHERE "Key" = :"Key"`,
+			names:   []string{"Key"},
+		},
+		{
+			name: "insert",
+			builder: Insert("ks.users").
+				Columns("id", "name").
+				NamedColumn("email", "mail").
+				LitColumn("created", "toTimestamp(now())").
+				Unique().
+				TTL(time.Hour).
+				Timestamp(ts),
+			stmt:  "INSERT INTO ks.users (id, name, email, created) VALUES (:id, :name, :mail, toTimestamp(now())) IF NOT EXISTS USING TTL 3600 AND TIMESTAMP 1700000000000000",
+			names: []string{"id", "name", "mail"},
+		},
+		{
+			name:    "insert named using",
+			builder: Insert("t").Columns("a").TTLNamed("ttl").TimeoutNamed("timeout"),
+			stmt:    "INSERT INTO t (a) VALUES (:a) USING TTL :ttl AND TIMEOUT :timeout",
+			names:   []string{"a", "ttl", "timeout"},
+		},
+		{
+			name: "update",
+			builder: Update("ks.users").
+				TTLNamed("ttl").
+				Set("name").
+				SetLit("visits", "0").
+				Add("tags").
+				Remove("roles").
+				Prepend("history").
+				SetElement("props", "key", "prop").
+				Where(Eq("id")).
+				If(EqNamed("name", "old_name"), ContainsKey("props")),
+			stmt:  "UPDATE ks.users USING TTL :ttl SET name = :name, visits = 0, tags = tags + :tags, roles = roles - :roles, history = :history + history, props[:key] = :prop WHERE id = :id IF name = :old_name AND props CONTAINS KEY :props",
+			names: []string{"ttl", "name", "tags", "roles", "history", "key", "prop", "id", "old_name", "props"},
+		},
+		{
+			name:    "update counter",
+			builder: Update("counters").AddLit("hits", "1").Where(Eq("id")).IfExists(),
+			stmt:    "UPDATE counters SET hits = hits + 1 WHERE id = :id IF EXISTS",
+			names:   []string{"id"},
+		},
+		{
+			name:    "delete",
+			builder: Delete("ks.users").Where(Eq("id")),
+			stmt:    "DELETE FROM ks.users WHERE id = :id",
+			names:   []string{"id"},
+		},
+		{
+			name:    "delete columns",
+			builder: Delete("ks.users").Columns("name", "tags[1]").TimestampNamed("ts").Where(Eq("id")).If(GtOrEqLit("version", "3")),
+			stmt:    "DELETE name, tags[1] FROM ks.users USING TIMESTAMP :ts WHERE id = :id IF version >= 3",
+			names:   []string{"ts", "id"},
+		},
+		{
+			name: "batch",
+			builder: Batch().
+				Unlogged().
+				TimestampNamed("ts").
+				Add(Insert("t").Columns("a", "b")).
+				AddWithPrefix("d_", Delete("t").Where(Eq("a"))),
+			stmt:  "BEGIN UNLOGGED BATCH USING TIMESTAMP :ts INSERT INTO t (a, b) VALUES (:a, :b); DELETE FROM t WHERE a = :d_a; APPLY BATCH",
+			names: []string{"ts", "a", "b", "d_a"},
+		},
+		{
+			name:    "counter batch",
+			builder: Batch().Counter().Add(Update("c").Add("n").Where(Eq("id"))),
+			stmt:    "BEGIN COUNTER BATCH UPDATE c SET n = n + :n WHERE id = :id; APPLY BATCH",
+			names:   []string{"n", "id"},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			stmt, names := test.builder.ToCql()
+			if stmt != test.stmt {
+				t.Errorf("statement:\n got %s\nwant %s", stmt, test.stmt)
+			}
+			if !reflect.DeepEqual(names, test.names) {
+				t.Errorf("names: got %v, want %v", names, test.names)
+			}
+		})
+	}
+}
+
+func TestBuildersFromMetadata(t *testing.T) {
+	t.Parallel()
+
+	table := testTable()
+
+	tests := []struct {
+		name    string
+		builder cqlBuilder
+		stmt    string
+		names   []string
+	}{
+		{
+			name:    "select",
+			builder: SelectFrom(table).Where(PartitionKey(table)...),
+			stmt:    `SELECT id, ts, "from", value FROM ks."Events" WHERE id = :id`,
+			names:   []string{"id"},
+		},
+		{
+			name:    "insert",
+			builder: InsertInto(table),
+			stmt:    `INSERT INTO ks."Events" (id, ts, "from", value) VALUES (:id, :ts, :"from", :value)`,
+			names:   []string{"id", "ts", "from", "value"},
+		},
+		{
+			name:    "update",
+			builder: UpdateOf(table),
+			stmt:    `UPDATE ks."Events" SET "from" = :"from", value = :value WHERE id = :id AND ts = :ts`,
+			names:   []string{"from", "value", "id", "ts"},
+		},
+		{
+			name:    "delete",
+			builder: DeleteFrom(table),
+			stmt:    `DELETE FROM ks."Events" WHERE id = :id AND ts = :ts`,
+			names:   []string{"id", "ts"},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			stmt, names := test.builder.ToCql()
+			if stmt != test.stmt {
+				t.Errorf("statement:\n got %s\nwant %s", stmt, test.stmt)
+			}
+			if !reflect.DeepEqual(names, test.names) {
+				t.Errorf("names: got %v, want %v", names, test.names)
+			}
+		})
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "0ms"},
+		{time.Microsecond, "0ms"},
+		{250 * time.Millisecond, "250ms"},
+		{time.Second, "1s"},
+		{90 * time.Minute, "1h30m"},
+		{time.Hour + 2*time.Second + 5*time.Millisecond, "1h2s5ms"},
+	}
+
+	for _, test := range tests {
+		if got := formatDuration(test.d); got != test.want {
+			t.Errorf("formatDuration(%v) = %q, want %q", test.d, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,269 @@
+package qb
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// Order is the order of a column in the ORDER BY clause.
+type Order bool
+
+const (
+	// ASC is the ascending order.
+	ASC Order = true
+	// DESC is the descending order.
+	DESC Order = false
+)
+
+func (o Order) String() string {
+	if o {
+		return "ASC"
+	}
+	return "DESC"
+}
+
+type ordering struct {
+	column string
+	order  Order
+	// ann is the name of the bind marker of the vector the column is ordered by similarity to.
+	ann string
+}
+
+// SimilarityFunction is a function of the similarity of two vectors.
+type SimilarityFunction string
+
+const (
+	// SimilarityCosine is the cosine similarity.
+	SimilarityCosine SimilarityFunction = "similarity_cosine"
+	// SimilarityEuclidean is the similarity based on the euclidean distance.
+	SimilarityEuclidean SimilarityFunction = "similarity_euclidean"
+	// SimilarityDotProduct is the dot product similarity, the vectors should be normalized.
+	SimilarityDotProduct SimilarityFunction = "similarity_dot_product"
+)
+
+// ident is a selected column.
+type ident string
+
+func (i ident) writeCql(w *writer) {
+	w.ident(string(i))
+}
+
+// similarity is a similarity function call of a vector column and a named bind marker.
+type similarity struct {
+	fn     SimilarityFunction
+	column string
+	name   string
+}
+
+func (s similarity) writeCql(w *writer) {
+	w.WriteString(string(s.fn))
+	w.WriteByte('(')
+	w.ident(s.column)
+	w.WriteString(", ")
+	w.marker(s.name)
+	w.WriteByte(')')
+}
+
+// SelectBuilder builds SELECT statements.
+type SelectBuilder struct {
+	table             string
+	columns           []value
+	where             []Cmp
+	groupBy           []string
+	orderBy           []ordering
+	limit             value
+	perPartitionLimit value
+	using             using
+	distinct          bool
+	json              bool
+	allowFiltering    bool
+	bypassCache       bool
+}
+
+// Select returns a builder of a SELECT statement from the table, optionally qualified with a keyspace.
+// All columns are selected unless Columns is used.
+func Select(table string) *SelectBuilder {
+	return &SelectBuilder{table: table}
+}
+
+// SelectFrom returns a builder of a SELECT statement of all the columns of the table.
+func SelectFrom(t *gocql.TableMetadata) *SelectBuilder {
+	return (&SelectBuilder{table: tableName(t)}).Columns(columns(t)...)
+}
+
+// ToCql returns the statement and the names of its bound variables.
+func (b *SelectBuilder) ToCql() (stmt string, names []string) {
+	return toCql(b)
+}
+
+// Query returns a query of the statement in the session.
+func (b *SelectBuilder) Query(session *gocql.Session) *gocql.Query {
+	stmt, _ := b.ToCql()
+	return session.Query(stmt)
+}
+
+func (b *SelectBuilder) writeCql(w *writer) {
+	w.WriteString("SELECT ")
+	if b.json {
+		w.WriteString("JSON ")
+	}
+	if b.distinct {
+		w.WriteString("DISTINCT ")
+	}
+	if len(b.columns) == 0 {
+		w.WriteByte('*')
+	} else {
+		for i, c := range b.columns {
+			if i > 0 {
+				w.WriteString(", ")
+			}
+			c.writeCql(w)
+		}
+	}
+	w.WriteString(" FROM ")
+	w.table(b.table)
+
+	writeCmps(w, " WHERE ", b.where)
+
+	if len(b.groupBy) > 0 {
+		w.WriteString(" GROUP BY ")
+		w.idents(b.groupBy)
+	}
+
+	for i, o := range b.orderBy {
+		if i == 0 {
+			w.WriteString(" ORDER BY ")
+		} else {
+			w.WriteString(", ")
+		}
+		w.ident(o.column)
+		if o.ann != "" {
+			w.WriteString(" ANN OF ")
+			w.marker(o.ann)
+			continue
+		}
+		w.WriteByte(' ')
+		w.WriteString(o.order.String())
+	}
+
+	if b.perPartitionLimit != nil {
+		w.WriteString(" PER PARTITION LIMIT ")
+		b.perPartitionLimit.writeCql(w)
+	}
+	if b.limit != nil {
+		w.WriteString(" LIMIT ")
+		b.limit.writeCql(w)
+	}
+	if b.allowFiltering {
+		w.WriteString(" ALLOW FILTERING")
+	}
+	if b.bypassCache {
+		w.WriteString(" BYPASS CACHE")
+	}
+	b.using.writeCql(w)
+}
+
+// Columns sets the selected columns, they can also be selectors like function calls,
+// e.g. "COUNT(*)" or "writetime(name)".
+func (b *SelectBuilder) Columns(columns ...string) *SelectBuilder {
+	for _, c := range columns {
+		b.columns = append(b.columns, ident(c))
+	}
+	return b
+}
+
+// Similarity selects the similarity of the vector column to the vector bound to the named bind marker,
+// e.g. "similarity_cosine(embedding, :query)".
+func (b *SelectBuilder) Similarity(fn SimilarityFunction, column, name string) *SelectBuilder {
+	b.columns = append(b.columns, similarity{fn: fn, column: column, name: name})
+	return b
+}
+
+// Distinct selects distinct partitions, the selected columns have to be partition key or static columns.
+func (b *SelectBuilder) Distinct() *SelectBuilder {
+	b.distinct = true
+	return b
+}
+
+// Json selects every row as a single JSON encoded column named [json].
+func (b *SelectBuilder) Json() *SelectBuilder {
+	b.json = true
+	return b
+}
+
+// Where adds comparisons to the WHERE clause.
+func (b *SelectBuilder) Where(cmps ...Cmp) *SelectBuilder {
+	b.where = append(b.where, cmps...)
+	return b
+}
+
+// GroupBy adds columns to the GROUP BY clause.
+func (b *SelectBuilder) GroupBy(columns ...string) *SelectBuilder {
+	b.groupBy = append(b.groupBy, columns...)
+	return b
+}
+
+// OrderBy adds a clustering column to the ORDER BY clause.
+func (b *SelectBuilder) OrderBy(column string, o Order) *SelectBuilder {
+	b.orderBy = append(b.orderBy, ordering{column: column, order: o})
+	return b
+}
+
+// OrderByANN adds the approximate nearest neighbor ordering of the vector column by the similarity to
+// the vector bound to the named bind marker, e.g. "ORDER BY embedding ANN OF :query". The column needs
+// a vector index and the query a LIMIT, which is the number of the nearest neighbors returned.
+func (b *SelectBuilder) OrderByANN(column, name string) *SelectBuilder {
+	b.orderBy = append(b.orderBy, ordering{column: column, ann: name})
+	return b
+}
+
+// Limit sets the LIMIT clause.
+func (b *SelectBuilder) Limit(limit uint) *SelectBuilder {
+	b.limit = lit(strconv.FormatUint(uint64(limit), 10))
+	return b
+}
+
+// LimitNamed sets the LIMIT clause to a bind marker.
+func (b *SelectBuilder) LimitNamed(name string) *SelectBuilder {
+	b.limit = param(name)
+	return b
+}
+
+// PerPartitionLimit sets the PER PARTITION LIMIT clause.
+func (b *SelectBuilder) PerPartitionLimit(limit uint) *SelectBuilder {
+	b.perPartitionLimit = lit(strconv.FormatUint(uint64(limit), 10))
+	return b
+}
+
+// PerPartitionLimitNamed sets the PER PARTITION LIMIT clause to a bind marker.
+func (b *SelectBuilder) PerPartitionLimitNamed(name string) *SelectBuilder {
+	b.perPartitionLimit = param(name)
+	return b
+}
+
+// AllowFiltering adds the ALLOW FILTERING clause.
+func (b *SelectBuilder) AllowFiltering() *SelectBuilder {
+	b.allowFiltering = true
+	return b
+}
+
+// BypassCache adds the BYPASS CACHE clause, which makes Scylla read the data from disk
+// without populating its cache. Useful for scans which would otherwise evict the hot data.
+func (b *SelectBuilder) BypassCache() *SelectBuilder {
+	b.bypassCache = true
+	return b
+}
+
+// Timeout sets the Scylla specific USING TIMEOUT clause.
+func (b *SelectBuilder) Timeout(d time.Duration) *SelectBuilder {
+	b.using.setTimeout(d)
+	return b
+}
+
+// TimeoutNamed sets the Scylla specific USING TIMEOUT clause to a bind marker.
+func (b *SelectBuilder) TimeoutNamed(name string) *SelectBuilder {
+	b.using.timeout = param(name)
+	return b
+}
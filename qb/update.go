@@ -0,0 +1,229 @@
+package qb
+
+import (
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// update is an assignment of the SET clause.
+type update struct {
+	value  value
+	column string
+	// key is the element of a collection column being set.
+	key value
+	// op is "+" or "-" for collection and counter updates, the column is added to the value if prepend is set.
+	op      string
+	prepend bool
+}
+
+func (u update) writeCql(w *writer) {
+	w.ident(u.column)
+	if u.key != nil {
+		w.WriteByte('[')
+		u.key.writeCql(w)
+		w.WriteByte(']')
+	}
+	w.WriteString(" = ")
+
+	switch {
+	case u.op == "":
+		u.value.writeCql(w)
+	case u.prepend:
+		u.value.writeCql(w)
+		w.WriteString(" " + u.op + " ")
+		w.ident(u.column)
+	default:
+		w.ident(u.column)
+		w.WriteString(" " + u.op + " ")
+		u.value.writeCql(w)
+	}
+}
+
+// UpdateBuilder builds UPDATE statements.
+type UpdateBuilder struct {
+	table    string
+	set      []update
+	where    []Cmp
+	ifs      []Cmp
+	using    using
+	ifExists bool
+}
+
+// Update returns a builder of an UPDATE statement of the table, optionally qualified with a keyspace.
+func Update(table string) *UpdateBuilder {
+	return &UpdateBuilder{table: table}
+}
+
+// UpdateOf returns a builder of an UPDATE statement setting all the regular and static columns
+// of the table, of the row selected by its primary key.
+func UpdateOf(t *gocql.TableMetadata) *UpdateBuilder {
+	b := Update(tableName(t)).Where(PrimaryKey(t)...)
+	for _, column := range columns(t) {
+		if !isPrimaryKey(t, column) {
+			b.Set(column)
+		}
+	}
+	return b
+}
+
+// ToCql returns the statement and the names of its bound variables.
+func (b *UpdateBuilder) ToCql() (stmt string, names []string) {
+	return toCql(b)
+}
+
+// Query returns a query of the statement in the session.
+func (b *UpdateBuilder) Query(session *gocql.Session) *gocql.Query {
+	stmt, _ := b.ToCql()
+	return session.Query(stmt)
+}
+
+func (b *UpdateBuilder) writeCql(w *writer) {
+	w.WriteString("UPDATE ")
+	w.table(b.table)
+	b.using.writeCql(w)
+
+	w.WriteString(" SET ")
+	for i, u := range b.set {
+		if i > 0 {
+			w.WriteString(", ")
+		}
+		u.writeCql(w)
+	}
+
+	writeCmps(w, " WHERE ", b.where)
+	if b.ifExists {
+		w.WriteString(" IF EXISTS")
+	} else {
+		writeCmps(w, " IF ", b.ifs)
+	}
+}
+
+// Set adds assignments of the columns to bind markers named after them.
+func (b *UpdateBuilder) Set(columns ...string) *UpdateBuilder {
+	for _, column := range columns {
+		b.set = append(b.set, update{column: column, value: param(column)})
+	}
+	return b
+}
+
+// SetNamed adds an assignment of the column to a bind marker with the given name.
+func (b *UpdateBuilder) SetNamed(column, name string) *UpdateBuilder {
+	b.set = append(b.set, update{column: column, value: param(name)})
+	return b
+}
+
+// SetLit adds an assignment of the column to a literal, the literal is not escaped.
+func (b *UpdateBuilder) SetLit(column, literal string) *UpdateBuilder {
+	b.set = append(b.set, update{column: column, value: lit(literal)})
+	return b
+}
+
+// SetElement adds an assignment of an element of a map or list column,
+// producing column[:key] = :name.
+func (b *UpdateBuilder) SetElement(column, key, name string) *UpdateBuilder {
+	b.set = append(b.set, update{column: column, key: param(key), value: param(name)})
+	return b
+}
+
+// Add adds the bound value to a collection or counter column, producing column = column + :column.
+// Values are appended to lists, added to sets and maps, and counters are incremented.
+func (b *UpdateBuilder) Add(column string) *UpdateBuilder {
+	return b.AddNamed(column, column)
+}
+
+// AddNamed is like Add with a bind marker with the given name.
+func (b *UpdateBuilder) AddNamed(column, name string) *UpdateBuilder {
+	b.set = append(b.set, update{column: column, value: param(name), op: "+"})
+	return b
+}
+
+// AddLit is like Add with a literal, the literal is not escaped.
+func (b *UpdateBuilder) AddLit(column, literal string) *UpdateBuilder {
+	b.set = append(b.set, update{column: column, value: lit(literal), op: "+"})
+	return b
+}
+
+// Prepend prepends the bound value to a list column, producing column = :column + column.
+func (b *UpdateBuilder) Prepend(column string) *UpdateBuilder {
+	return b.PrependNamed(column, column)
+}
+
+// PrependNamed is like Prepend with a bind marker with the given name.
+func (b *UpdateBuilder) PrependNamed(column, name string) *UpdateBuilder {
+	b.set = append(b.set, update{column: column, value: param(name), op: "+", prepend: true})
+	return b
+}
+
+// Remove removes the bound value from a collection or counter column, producing column = column - :column.
+// Values are removed from lists and sets, keys are removed from maps, and counters are decremented.
+func (b *UpdateBuilder) Remove(column string) *UpdateBuilder {
+	return b.RemoveNamed(column, column)
+}
+
+// RemoveNamed is like Remove with a bind marker with the given name.
+func (b *UpdateBuilder) RemoveNamed(column, name string) *UpdateBuilder {
+	b.set = append(b.set, update{column: column, value: param(name), op: "-"})
+	return b
+}
+
+// RemoveLit is like Remove with a literal, the literal is not escaped.
+func (b *UpdateBuilder) RemoveLit(column, literal string) *UpdateBuilder {
+	b.set = append(b.set, update{column: column, value: lit(literal), op: "-"})
+	return b
+}
+
+// Where adds comparisons to the WHERE clause.
+func (b *UpdateBuilder) Where(cmps ...Cmp) *UpdateBuilder {
+	b.where = append(b.where, cmps...)
+	return b
+}
+
+// If adds conditions to the IF clause, making the statement a lightweight transaction.
+func (b *UpdateBuilder) If(cmps ...Cmp) *UpdateBuilder {
+	b.ifs = append(b.ifs, cmps...)
+	return b
+}
+
+// IfExists adds the IF EXISTS clause, making the statement a lightweight transaction.
+// It takes precedence over conditions added with If.
+func (b *UpdateBuilder) IfExists() *UpdateBuilder {
+	b.ifExists = true
+	return b
+}
+
+// TTL sets the USING TTL clause, the TTL is truncated to seconds.
+func (b *UpdateBuilder) TTL(d time.Duration) *UpdateBuilder {
+	b.using.setTTL(d)
+	return b
+}
+
+// TTLNamed sets the USING TTL clause to a bind marker.
+func (b *UpdateBuilder) TTLNamed(name string) *UpdateBuilder {
+	b.using.ttl = param(name)
+	return b
+}
+
+// Timestamp sets the USING TIMESTAMP clause.
+func (b *UpdateBuilder) Timestamp(t time.Time) *UpdateBuilder {
+	b.using.setTimestamp(t)
+	return b
+}
+
+// TimestampNamed sets the USING TIMESTAMP clause to a bind marker.
+func (b *UpdateBuilder) TimestampNamed(name string) *UpdateBuilder {
+	b.using.timestamp = param(name)
+	return b
+}
+
+// Timeout sets the Scylla specific USING TIMEOUT clause.
+func (b *UpdateBuilder) Timeout(d time.Duration) *UpdateBuilder {
+	b.using.setTimeout(d)
+	return b
+}
+
+// TimeoutNamed sets the Scylla specific USING TIMEOUT clause to a bind marker.
+func (b *UpdateBuilder) TimeoutNamed(name string) *UpdateBuilder {
+	b.using.timeout = param(name)
+	return b
+}
@@ -0,0 +1,73 @@
+package qb
+
+import (
+	"strconv"
+	"time"
+)
+
+// using is the USING clause of a statement.
+type using struct {
+	ttl       value
+	timestamp value
+	timeout   value
+}
+
+func (u *using) setTTL(d time.Duration) {
+	u.ttl = lit(strconv.FormatInt(int64(d/time.Second), 10))
+}
+
+func (u *using) setTimestamp(t time.Time) {
+	u.timestamp = lit(strconv.FormatInt(t.UnixMicro(), 10))
+}
+
+func (u *using) setTimeout(d time.Duration) {
+	u.timeout = lit(formatDuration(d))
+}
+
+func (u *using) writeCql(w *writer) {
+	first := true
+	write := func(keyword string, v value) {
+		if v == nil {
+			return
+		}
+		if first {
+			w.WriteString(" USING ")
+			first = false
+		} else {
+			w.WriteString(" AND ")
+		}
+		w.WriteString(keyword)
+		w.WriteByte(' ')
+		v.writeCql(w)
+	}
+
+	write("TTL", u.ttl)
+	write("TIMESTAMP", u.timestamp)
+	write("TIMEOUT", u.timeout)
+}
+
+// formatDuration formats d as a CQL duration literal with millisecond precision, e.g. 1s500ms.
+func formatDuration(d time.Duration) string {
+	ms := d.Milliseconds()
+	if ms <= 0 {
+		return "0ms"
+	}
+
+	var s string
+	if h := ms / int64(time.Hour/time.Millisecond); h > 0 {
+		s += strconv.FormatInt(h, 10) + "h"
+		ms -= h * int64(time.Hour/time.Millisecond)
+	}
+	if m := ms / int64(time.Minute/time.Millisecond); m > 0 {
+		s += strconv.FormatInt(m, 10) + "m"
+		ms -= m * int64(time.Minute/time.Millisecond)
+	}
+	if sec := ms / 1000; sec > 0 {
+		s += strconv.FormatInt(sec, 10) + "s"
+		ms -= sec * 1000
+	}
+	if ms > 0 {
+		s += strconv.FormatInt(ms, 10) + "ms"
+	}
+	return s
+}
@@ -29,6 +29,7 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -37,8 +38,12 @@ type ExecutableQuery interface {
 	releaseAfterExecution() // Used when a goroutine finishes its execution attempts, either with ok result or an error.
 	execute(ctx context.Context, conn *Conn) *Iter
 	attempt(keyspace string, end, start time.Time, iter *Iter, host *HostInfo)
+	getExecutionObserver() ExecutionObserver
+	observedExecution() ObservedExecution
 	retryPolicy() RetryPolicy
 	speculativeExecutionPolicy() SpeculativeExecutionPolicy
+	// hostSelectionPolicy returns the policy of the execution profile, nil if the session's policy is used.
+	hostSelectionPolicy() HostSelectionPolicy
 	GetRoutingKey() ([]byte, error)
 	Keyspace() string
 	Table() string
@@ -55,30 +60,104 @@ type ExecutableQuery interface {
 }
 
 type queryExecutor struct {
-	pool   *policyConnPool
-	policy HostSelectionPolicy
+	pool    *policyConnPool
+	policy  HostSelectionPolicy
+	metrics *sessionMetrics
+	// latencyTracker is the policy if it implements LatencyTracker.
+	latencyTracker LatencyTracker
 }
 
-func (q *queryExecutor) attemptQuery(ctx context.Context, qry ExecutableQuery, conn *Conn) *Iter {
+// observedAttempt tracks an attempt reported to an ExecutionObserverContext.
+type observedAttempt struct {
+	obs      AttemptObserverContext
+	observed ObservedAttempt
+}
+
+func (a *observedAttempt) finish(retry RetryType) {
+	if a == nil {
+		return
+	}
+	if a.observed.Err != nil {
+		a.observed.RetryDecision = retry
+	}
+	a.obs.AttemptFinished(a.observed)
+}
+
+func (q *queryExecutor) attemptQuery(ctx context.Context, qry ExecutableQuery, conn *Conn, exec *observedExecution,
+	attempt int) (*Iter, *observedAttempt) {
 	start := time.Now()
+
+	var observed *observedAttempt
+	if exec != nil {
+		observed = exec.attemptStarted(ObservedAttempt{
+			Start:                start,
+			Host:                 conn.host,
+			Shard:                conn.observedShard(),
+			Consistency:          qry.GetConsistency(),
+			Attempt:              attempt,
+			SpeculativeExecution: exec.speculativeExecution,
+		})
+		// custom payloads are only supported since protocol v4
+		if observed != nil && conn.version >= protoVersion4 {
+			ctx = withAttemptCustomPayload(ctx, observed.obs.CustomPayload())
+		}
+	}
+
 	iter := qry.execute(ctx, conn)
 	end := time.Now()
 
 	qry.attempt(q.pool.keyspace, end, start, iter, conn.host)
+	if policy := qry.hostSelectionPolicy(); policy != nil {
+		if tracker, ok := policy.(LatencyTracker); ok {
+			tracker.TrackLatency(conn.host, end.Sub(start), iter.err)
+		}
+	} else if q.latencyTracker != nil {
+		q.latencyTracker.TrackLatency(conn.host, end.Sub(start), iter.err)
+	}
+
+	if observed != nil {
+		observed.observed.End = end
+		observed.observed.Err = iter.err
+	}
+
+	return iter, observed
+}
+
+// observedExecution tracks an execution reported to an ExecutionObserver.
+type observedExecution struct {
+	obs ExecutionObserverContext
+	// attempts counts the attempts of all speculative executions.
+	attempts             *int32
+	speculativeExecution int
+}
+
+func (e *observedExecution) attemptStarted(attempt ObservedAttempt) *observedAttempt {
+	atomic.AddInt32(e.attempts, 1)
+	obs := e.obs.AttemptStarted(attempt)
+	if obs == nil {
+		return nil
+	}
+	return &observedAttempt{obs: obs, observed: attempt}
+}
 
-	return iter
+// speculative returns the observed execution to be used by the i-th speculative execution.
+func (e *observedExecution) speculative(i int) *observedExecution {
+	if e == nil {
+		return nil
+	}
+	return &observedExecution{obs: e.obs, attempts: e.attempts, speculativeExecution: i}
 }
 
-func (q *queryExecutor) speculate(ctx context.Context, qry ExecutableQuery, sp SpeculativeExecutionPolicy,
-	hostIter NextHost, results chan *Iter) *Iter {
+func (q *queryExecutor) speculate(ctx context.Context, sp SpeculativeExecutionPolicy, results chan *Iter,
+	exec *observedExecution, launch func(exec *observedExecution)) *Iter {
 	ticker := time.NewTicker(sp.Delay())
 	defer ticker.Stop()
 
 	for i := 0; i < sp.Attempts(); i++ {
 		select {
 		case <-ticker.C:
-			qry.borrowForExecution() // ensure liveness in case of executing Query to prevent races with Query.Release().
-			go q.run(ctx, qry, hostIter, results)
+			q.metrics.speculativeExecution()
+			launch(exec.speculative(i + 1))
 		case <-ctx.Done():
 			return &Iter{err: ctx.Err()}
 		case iter := <-results:
@@ -90,6 +169,34 @@ func (q *queryExecutor) speculate(ctx context.Context, qry ExecutableQuery, sp S
 }
 
 func (q *queryExecutor) executeQuery(qry ExecutableQuery) (*Iter, error) {
+	observer := qry.getExecutionObserver()
+	if observer == nil {
+		return q.executeObservedQuery(qry, nil)
+	}
+
+	observed := qry.observedExecution()
+	observed.Start = time.Now()
+	obs := observer.ExecutionStarted(qry.Context(), observed)
+	if obs == nil {
+		return q.executeObservedQuery(qry, nil)
+	}
+
+	exec := &observedExecution{obs: obs, attempts: new(int32)}
+	iter, err := q.executeObservedQuery(qry, exec)
+
+	observed.End = time.Now()
+	observed.Attempts = int(atomic.LoadInt32(exec.attempts))
+	observed.Consistency = qry.GetConsistency()
+	observed.Err = err
+	if err == nil && iter != nil {
+		observed.Err = iter.err
+	}
+	obs.ExecutionFinished(observed)
+
+	return iter, err
+}
+
+func (q *queryExecutor) executeObservedQuery(qry ExecutableQuery, exec *observedExecution) (*Iter, error) {
 	var hostIter NextHost
 
 	// check if the hostID is specified for the query,
@@ -105,6 +212,8 @@ func (q *queryExecutor) executeQuery(qry ExecutableQuery) (*Iter, error) {
 			return nil, fmt.Errorf("query is targeting host id %s that driver is not connected to: %w", hostID, ErrNoConnectionsInPool)
 		}
 		hostIter = newSingleHost(pool.host, 5, 200*time.Millisecond).selectHost
+	} else if policy := qry.hostSelectionPolicy(); policy != nil {
+		hostIter = policy.Pick(qry)
 	} else {
 		hostIter = q.policy.Pick(qry)
 	}
@@ -113,7 +222,7 @@ func (q *queryExecutor) executeQuery(qry ExecutableQuery) (*Iter, error) {
 	// it is, we force the policy to NonSpeculative
 	sp := qry.speculativeExecutionPolicy()
 	if qry.GetHostID() != "" || !qry.IsIdempotent() || sp.Attempts() == 0 {
-		return q.do(qry.Context(), qry, hostIter), nil
+		return q.do(qry.Context(), qry, hostIter, exec), nil
 	}
 
 	// When speculative execution is enabled, we could be accessing the host iterator from multiple goroutines below.
@@ -126,19 +235,33 @@ func (q *queryExecutor) executeQuery(qry ExecutableQuery) (*Iter, error) {
 		return origHostIter()
 	}
 
+	// An observed execution is finished only once the attempts of all its executions were reported,
+	// so the executions which lost are canceled and waited for before returning.
+	var wg sync.WaitGroup
+	if exec != nil {
+		defer wg.Wait()
+	}
+
 	ctx, cancel := context.WithCancel(qry.Context())
 	defer cancel()
 
 	results := make(chan *Iter, 1)
+	launch := func(exec *observedExecution) {
+		qry.borrowForExecution() // ensure liveness in case of executing Query to prevent races with Query.Release().
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.run(ctx, qry, hostIter, results, exec)
+		}()
+	}
 
 	// Launch the main execution
-	qry.borrowForExecution() // ensure liveness in case of executing Query to prevent races with Query.Release().
-	go q.run(ctx, qry, hostIter, results)
+	launch(exec)
 
 	// The speculative executions are launched _in addition_ to the main
 	// execution, on a timer. So Speculation{2} would make 3 executions running
 	// in total.
-	if iter := q.speculate(ctx, qry, sp, hostIter, results); iter != nil {
+	if iter := q.speculate(ctx, sp, results, exec, launch); iter != nil {
 		return iter, nil
 	}
 
@@ -150,7 +273,7 @@ func (q *queryExecutor) executeQuery(qry ExecutableQuery) (*Iter, error) {
 	}
 }
 
-func (q *queryExecutor) do(ctx context.Context, qry ExecutableQuery, hostIter NextHost) *Iter {
+func (q *queryExecutor) do(ctx context.Context, qry ExecutableQuery, hostIter NextHost, exec *observedExecution) *Iter {
 	rt := qry.retryPolicy()
 	if rt == nil {
 		rt = &SimpleRetryPolicy{NumRetries: 3}
@@ -170,6 +293,10 @@ func (q *queryExecutor) do(ctx context.Context, qry ExecutableQuery, hostIter Ne
 	}
 
 	var potentiallyExecuted bool
+	// attempts is the number of attempts made by this execution and lastAttempt
+	// is the latest of them, if it is observed.
+	var attempts int
+	var lastAttempt *observedAttempt
 
 	execute := func(qry ExecutableQuery, selectedHost SelectedHost) (iter *Iter, retry RetryType) {
 		host := selectedHost.Info()
@@ -199,7 +326,22 @@ func (q *queryExecutor) do(ctx context.Context, qry ExecutableQuery, hostIter Ne
 				},
 			}, RetryNextHost
 		}
-		iter = q.attemptQuery(ctx, qry, conn)
+		limiter, err := pool.limiter.acquire(ctx, conn.observedShard())
+		if err != nil {
+			retry = RetryNextHost
+			if ctx.Err() != nil {
+				retry = Rethrow
+			}
+			return &Iter{
+				err: &QueryError{
+					err:                 err,
+					potentiallyExecuted: potentiallyExecuted,
+				},
+			}, retry
+		}
+		iter, lastAttempt = q.attemptQuery(ctx, qry, conn, exec, attempts)
+		limiter.release(iter.err)
+		attempts++
 		iter.host = selectedHost.Info()
 		// Update host
 		if iter.err == nil {
@@ -236,8 +378,10 @@ func (q *queryExecutor) do(ctx context.Context, qry ExecutableQuery, hostIter Ne
 	var lastErr error
 	selectedHost := hostIter()
 	for selectedHost != nil {
+		lastAttempt = nil
 		iter, retryType := execute(qry, selectedHost)
 		if iter.err == nil {
+			lastAttempt.finish(retryType)
 			return iter
 		}
 		lastErr = iter.err
@@ -245,10 +389,14 @@ func (q *queryExecutor) do(ctx context.Context, qry ExecutableQuery, hostIter Ne
 		// Exit if retry policy decides to not retry anymore
 		if retryType == RetryType(255) {
 			if !getShouldRetry(qry) {
+				lastAttempt.finish(Rethrow)
+				q.metrics.retry(Rethrow)
 				return iter
 			}
 			retryType = getRetryType(iter.err)
 		}
+		lastAttempt.finish(retryType)
+		q.metrics.retry(retryType)
 
 		// If query is unsuccessful, check the error with RetryPolicy to retry
 		switch retryType {
@@ -272,9 +420,10 @@ func (q *queryExecutor) do(ctx context.Context, qry ExecutableQuery, hostIter Ne
 	return &Iter{err: ErrNoConnections}
 }
 
-func (q *queryExecutor) run(ctx context.Context, qry ExecutableQuery, hostIter NextHost, results chan<- *Iter) {
+func (q *queryExecutor) run(ctx context.Context, qry ExecutableQuery, hostIter NextHost, results chan<- *Iter,
+	exec *observedExecution) {
 	select {
-	case results <- q.do(ctx, qry, hostIter):
+	case results <- q.do(ctx, qry, hostIter, exec):
 	case <-ctx.Done():
 	}
 	qry.releaseAfterExecution()
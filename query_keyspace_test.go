@@ -0,0 +1,51 @@
+//go:build unit
+// +build unit
+
+package gocql_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+func TestQueryKeyspaceRequiresProtoV5(t *testing.T) {
+	c, err := gocqltest.NewCluster(gocqltest.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	cfg := c.ClusterConfig()
+	cfg.Timeout = time.Second
+	session, err := cfg.CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	const stmt = `INSERT INTO t (id) VALUES (?)`
+	if err := session.Query(stmt, "a").SetKeyspace("ks").Exec(); !errors.Is(err, gocql.ErrRequiresProtoV5) {
+		t.Errorf("expected %v for the keyspace of a query, got %v", gocql.ErrRequiresProtoV5, err)
+	}
+	if err := session.Query(stmt, "a").WithNowInSeconds(1700000000).Exec(); !errors.Is(err, gocql.ErrRequiresProtoV5) {
+		t.Errorf("expected %v for now_in_seconds of a query, got %v", gocql.ErrRequiresProtoV5, err)
+	}
+
+	b := session.Batch(gocql.LoggedBatch).SetKeyspace("ks")
+	b.Query(stmt, "a")
+	if err := b.Exec(); !errors.Is(err, gocql.ErrRequiresProtoV5) {
+		t.Errorf("expected %v for the keyspace of a batch, got %v", gocql.ErrRequiresProtoV5, err)
+	}
+	if b.Keyspace() != "ks" {
+		t.Errorf("expected the keyspace of the batch to be ks, got %q", b.Keyspace())
+	}
+
+	// without the options the statement is executed as usual
+	if err := session.Query(stmt, "a").Exec(); err != nil {
+		t.Fatal(err)
+	}
+}
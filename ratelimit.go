@@ -0,0 +1,359 @@
+package gocql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	frm "github.com/gocql/gocql/internal/frame"
+)
+
+// RateLimitPolicy decides what happens to requests which can't be admitted by the client side rate limiter.
+type RateLimitPolicy int
+
+const (
+	// RateLimitWait queues requests until they can be admitted. A request fails with ErrRateLimited
+	// if it waits longer than RateLimiterConfig.MaxWait, or with the error of its context.
+	RateLimitWait RateLimitPolicy = iota
+	// RateLimitFailFast fails requests which can't be admitted immediately with ErrRateLimited.
+	RateLimitFailFast
+)
+
+func (p RateLimitPolicy) String() string {
+	switch p {
+	case RateLimitWait:
+		return "wait"
+	case RateLimitFailFast:
+		return "fail fast"
+	default:
+		return fmt.Sprintf("unknown rate limit policy %d", int(p))
+	}
+}
+
+// RateLimiterConfig configures client side admission control of requests.
+//
+// Every host has a limiter per shard, or a single one if the host is not sharded. A limiter combines
+// a token bucket, which limits the rate of requests, with an adaptive limit of concurrent requests.
+// The concurrency limit grows slowly while requests succeed and is cut by BackoffFactor whenever
+// the host rejects a request with a rate limit error (RequestErrRateLimitReached) or an overloaded
+// error, which also empties the token bucket.
+//
+// Requests which are not admitted by the limiter of a host fail with ErrRateLimited and are
+// tried on the next host returned by the host selection policy.
+type RateLimiterConfig struct {
+	// Rate is the number of requests per second admitted per shard. Zero disables the rate limit.
+	Rate float64
+	// Burst is the number of requests which can be admitted at once when the shard was idle.
+	// Default: Rate rounded up
+	Burst int
+	// MaxConcurrency is the maximum number of concurrent requests per shard, the adaptive
+	// concurrency limit starts at it. Zero disables the concurrency limit.
+	MaxConcurrency int
+	// MinConcurrency is the lower bound of the adaptive concurrency limit.
+	// Default: 1
+	MinConcurrency int
+	// BackoffFactor multiplies the concurrency limit when the host rejects a request because
+	// it is rate limited or overloaded, it has to be between 0 and 1.
+	// Default: 0.5
+	BackoffFactor float64
+	// Policy decides what happens to requests which can't be admitted immediately.
+	// Default: RateLimitWait
+	Policy RateLimitPolicy
+	// MaxWait is the maximum time a request waits to be admitted with RateLimitWait.
+	// Zero means that requests wait until their context is done.
+	MaxWait time.Duration
+}
+
+// Validate checks the configuration.
+func (cfg *RateLimiterConfig) Validate() error {
+	if cfg == nil {
+		return nil
+	}
+	if cfg.Rate < 0 {
+		return errors.New("Rate should be positive number or zero")
+	}
+	if cfg.Burst < 0 {
+		return errors.New("Burst should be positive number or zero")
+	}
+	if cfg.MaxConcurrency < 0 {
+		return errors.New("MaxConcurrency should be positive number or zero")
+	}
+	if cfg.MinConcurrency < 0 || (cfg.MaxConcurrency > 0 && cfg.MinConcurrency > cfg.MaxConcurrency) {
+		return errors.New("MinConcurrency should be positive number or zero, not greater than MaxConcurrency")
+	}
+	if cfg.BackoffFactor < 0 || cfg.BackoffFactor >= 1 {
+		return errors.New("BackoffFactor should be between 0 and 1")
+	}
+	if cfg.Policy != RateLimitWait && cfg.Policy != RateLimitFailFast {
+		return fmt.Errorf("Policy is invalid: %v", cfg.Policy)
+	}
+	if cfg.MaxWait < 0 {
+		return errors.New("MaxWait should be positive time.Duration or zero")
+	}
+	return nil
+}
+
+// RateLimiterState is the state of the client side rate limiter of a shard, see HostPoolRateLimiterInfo.
+type RateLimiterState struct {
+	// Shard is the shard of the host, or -1 if the host is not sharded.
+	Shard int
+	// InFlight is the number of admitted requests which have not finished yet.
+	InFlight int
+	// Waiting is the number of requests waiting to be admitted.
+	Waiting int
+	// ConcurrencyLimit is the current adaptive concurrency limit, zero if concurrency is not limited.
+	ConcurrencyLimit int
+	// Tokens is the number of requests the token bucket would admit right now, zero if rate is not limited.
+	Tokens float64
+	// Admitted is the number of admitted requests.
+	Admitted int64
+	// Rejected is the number of requests which failed with ErrRateLimited or while waiting to be admitted.
+	Rejected int64
+	// Backoffs is the number of rate limit and overloaded errors returned by the host.
+	Backoffs int64
+}
+
+// hostRateLimiter holds the rate limiters of the shards of a host.
+// A nil *hostRateLimiter admits all requests.
+type hostRateLimiter struct {
+	cfg RateLimiterConfig
+
+	mu     sync.Mutex
+	shards map[int]*shardRateLimiter
+}
+
+func newHostRateLimiter(cfg *RateLimiterConfig) *hostRateLimiter {
+	if cfg == nil {
+		return nil
+	}
+	return &hostRateLimiter{cfg: *cfg, shards: make(map[int]*shardRateLimiter)}
+}
+
+// acquire waits until a request to the shard is admitted. The returned limiter has to be released
+// once the request is finished.
+func (l *hostRateLimiter) acquire(ctx context.Context, shard int) (*shardRateLimiter, error) {
+	if l == nil {
+		return nil, nil
+	}
+
+	l.mu.Lock()
+	s, ok := l.shards[shard]
+	if !ok {
+		s = newShardRateLimiter(&l.cfg, shard)
+		l.shards[shard] = s
+	}
+	l.mu.Unlock()
+
+	if err := s.acquire(ctx); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (l *hostRateLimiter) state() []RateLimiterState {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	states := make([]RateLimiterState, 0, len(l.shards))
+	for _, s := range l.shards {
+		states = append(states, s.state())
+	}
+	l.mu.Unlock()
+
+	sort.Slice(states, func(i, j int) bool { return states[i].Shard < states[j].Shard })
+	return states
+}
+
+type shardRateLimiter struct {
+	shard    int
+	rate     float64
+	burst    float64
+	minLimit float64
+	maxLimit float64
+	backoff  float64
+	policy   RateLimitPolicy
+	maxWait  time.Duration
+
+	mu       sync.Mutex
+	tokens   float64
+	refilled time.Time
+	limit    float64
+	inFlight int
+	waiting  int
+	// released is closed when a request finishes while other requests are waiting.
+	released chan struct{}
+	admitted int64
+	rejected int64
+	backoffs int64
+}
+
+func newShardRateLimiter(cfg *RateLimiterConfig, shard int) *shardRateLimiter {
+	s := &shardRateLimiter{
+		shard:    shard,
+		rate:     cfg.Rate,
+		burst:    float64(cfg.Burst),
+		minLimit: float64(cfg.MinConcurrency),
+		maxLimit: float64(cfg.MaxConcurrency),
+		backoff:  cfg.BackoffFactor,
+		policy:   cfg.Policy,
+		maxWait:  cfg.MaxWait,
+		refilled: time.Now(),
+		released: make(chan struct{}),
+	}
+	if s.burst == 0 {
+		s.burst = math.Ceil(s.rate)
+	}
+	if s.minLimit == 0 {
+		s.minLimit = 1
+	}
+	if s.backoff == 0 {
+		s.backoff = 0.5
+	}
+	s.tokens = s.burst
+	s.limit = s.maxLimit
+	return s
+}
+
+// tryAcquire admits a request if possible, otherwise it returns how long it takes until the token
+// bucket admits the next request, zero if the request has to wait for another one to finish.
+func (s *shardRateLimiter) tryAcquire(now time.Time) (time.Duration, bool) {
+	if s.rate > 0 {
+		s.tokens = math.Min(s.burst, s.tokens+now.Sub(s.refilled).Seconds()*s.rate)
+		s.refilled = now
+	}
+	if s.maxLimit > 0 && s.inFlight >= int(s.limit) {
+		return 0, false
+	}
+	if s.rate > 0 {
+		if s.tokens < 1 {
+			return time.Duration((1 - s.tokens) / s.rate * float64(time.Second)), false
+		}
+		s.tokens--
+	}
+	s.inFlight++
+	s.admitted++
+	return 0, true
+}
+
+func (s *shardRateLimiter) acquire(ctx context.Context) error {
+	var deadline <-chan time.Time
+	for {
+		s.mu.Lock()
+		wait, ok := s.tryAcquire(time.Now())
+		if ok {
+			s.mu.Unlock()
+			return nil
+		}
+		if s.policy == RateLimitFailFast {
+			s.rejected++
+			s.mu.Unlock()
+			return ErrRateLimited
+		}
+		released := s.released
+		s.waiting++
+		s.mu.Unlock()
+
+		if deadline == nil && s.maxWait > 0 {
+			timer := time.NewTimer(s.maxWait)
+			defer timer.Stop()
+			deadline = timer.C
+		}
+		var refilled <-chan time.Time
+		var timer *time.Timer
+		if wait > 0 {
+			timer = time.NewTimer(wait)
+			refilled = timer.C
+		}
+
+		var err error
+		select {
+		case <-released:
+		case <-refilled:
+		case <-deadline:
+			err = ErrRateLimited
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+
+		s.mu.Lock()
+		s.waiting--
+		if err != nil {
+			s.rejected++
+		}
+		s.mu.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// release finishes an admitted request, backing off if the host rejected it because it is
+// rate limited or overloaded.
+func (s *shardRateLimiter) release(err error) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.inFlight--
+	switch {
+	case isOverloadedError(err):
+		s.backoffs++
+		s.tokens = 0
+		if s.maxLimit > 0 {
+			s.limit = math.Max(s.minLimit, s.limit*s.backoff)
+		}
+	case err == nil && s.limit < s.maxLimit:
+		// additive increase, the limit grows by one once a limit worth of requests succeeds
+		s.limit = math.Min(s.maxLimit, s.limit+1/s.limit)
+	}
+
+	if s.waiting > 0 {
+		close(s.released)
+		s.released = make(chan struct{})
+	}
+}
+
+func (s *shardRateLimiter) state() RateLimiterState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state := RateLimiterState{
+		Shard:            s.shard,
+		InFlight:         s.inFlight,
+		Waiting:          s.waiting,
+		ConcurrencyLimit: int(s.limit),
+		Admitted:         s.admitted,
+		Rejected:         s.rejected,
+		Backoffs:         s.backoffs,
+	}
+	if s.rate > 0 {
+		state.Tokens = math.Min(s.burst, s.tokens+time.Since(s.refilled).Seconds()*s.rate)
+	}
+	return state
+}
+
+// isOverloadedError reports whether err means that the host rejected the request because it is
+// rate limited or overloaded.
+func isOverloadedError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var rateLimited *RequestErrRateLimitReached
+	if errors.As(err, &rateLimited) {
+		return true
+	}
+	var errFrame frm.ErrorFrame
+	return errors.As(err, &errFrame) && errFrame.Code == ErrCodeOverloaded
+}
@@ -0,0 +1,264 @@
+//go:build unit
+// +build unit
+
+package gocql
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	frm "github.com/gocql/gocql/internal/frame"
+)
+
+func TestRateLimiterConcurrencyFailFast(t *testing.T) {
+	l := newHostRateLimiter(&RateLimiterConfig{MaxConcurrency: 2, Policy: RateLimitFailFast})
+	ctx := context.Background()
+
+	first, err := l.acquire(ctx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.acquire(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.acquire(ctx, 0); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("expected ErrRateLimited, got %v", err)
+	}
+	// shards are limited independently
+	if _, err := l.acquire(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	first.release(nil)
+	if _, err := l.acquire(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	states := l.state()
+	if len(states) != 2 {
+		t.Fatalf("expected the state of 2 shards, got %d", len(states))
+	}
+	if s := states[0]; s.Shard != 0 || s.InFlight != 2 || s.Admitted != 3 || s.Rejected != 1 || s.ConcurrencyLimit != 2 {
+		t.Fatalf("unexpected state of shard 0: %+v", s)
+	}
+	if s := states[1]; s.Shard != 1 || s.InFlight != 1 || s.Admitted != 1 {
+		t.Fatalf("unexpected state of shard 1: %+v", s)
+	}
+}
+
+func TestRateLimiterWait(t *testing.T) {
+	l := newHostRateLimiter(&RateLimiterConfig{MaxConcurrency: 1})
+	ctx := context.Background()
+
+	first, err := l.acquire(ctx, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	admitted := make(chan error, 1)
+	go func() {
+		_, err := l.acquire(ctx, -1)
+		admitted <- err
+	}()
+
+	select {
+	case err := <-admitted:
+		t.Fatalf("request admitted over the concurrency limit: %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	if s := l.state()[0]; s.Waiting != 1 {
+		t.Fatalf("expected one waiting request, got %d", s.Waiting)
+	}
+
+	first.release(nil)
+	select {
+	case err := <-admitted:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("waiting request not admitted after release")
+	}
+}
+
+func TestRateLimiterWaitTimeout(t *testing.T) {
+	l := newHostRateLimiter(&RateLimiterConfig{MaxConcurrency: 1, MaxWait: 10 * time.Millisecond})
+
+	if _, err := l.acquire(context.Background(), 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.acquire(context.Background(), 0); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("expected ErrRateLimited, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := l.acquire(ctx, 0); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+
+	if s := l.state()[0]; s.Rejected != 2 || s.Waiting != 0 {
+		t.Fatalf("unexpected state: %+v", s)
+	}
+}
+
+func TestRateLimiterTokenBucket(t *testing.T) {
+	l := newHostRateLimiter(&RateLimiterConfig{Rate: 100, Burst: 2, Policy: RateLimitFailFast})
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		s, err := l.acquire(ctx, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.release(nil)
+	}
+	if _, err := l.acquire(ctx, 0); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("expected ErrRateLimited after the burst, got %v", err)
+	}
+
+	// with RateLimitWait the request waits for the bucket to be refilled
+	l = newHostRateLimiter(&RateLimiterConfig{Rate: 100, Burst: 1})
+	if _, err := l.acquire(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if _, err := l.acquire(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 5*time.Millisecond {
+		t.Fatalf("expected the request to wait for a token, waited %v", elapsed)
+	}
+}
+
+func TestRateLimiterBackoff(t *testing.T) {
+	l := newHostRateLimiter(&RateLimiterConfig{MaxConcurrency: 8, MinConcurrency: 2})
+	ctx := context.Background()
+
+	release := func(err error) {
+		t.Helper()
+		s, aerr := l.acquire(ctx, 0)
+		if aerr != nil {
+			t.Fatal(aerr)
+		}
+		s.release(err)
+	}
+	limit := func() int {
+		return l.state()[0].ConcurrencyLimit
+	}
+
+	release(&RequestErrRateLimitReached{})
+	if limit() != 4 {
+		t.Fatalf("expected the limit to be halved to 4, got %d", limit())
+	}
+	release(&QueryError{err: frm.ErrorFrame{Code: ErrCodeOverloaded}})
+	if limit() != 2 {
+		t.Fatalf("expected the limit to be halved to 2, got %d", limit())
+	}
+	release(&RequestErrRateLimitReached{})
+	if limit() != 2 {
+		t.Fatalf("expected the limit to stay at MinConcurrency, got %d", limit())
+	}
+
+	// other errors don't change the limit
+	release(frm.ErrorFrame{Code: ErrCodeServer})
+	if limit() != 2 {
+		t.Fatalf("expected the limit to stay at 2, got %d", limit())
+	}
+
+	// the limit grows by 1/limit per success
+	for i := 0; i < 3; i++ {
+		release(nil)
+	}
+	if limit() != 3 {
+		t.Fatalf("expected the limit to grow to 3, got %d", limit())
+	}
+	if s := l.state()[0]; s.Backoffs != 3 {
+		t.Fatalf("expected 3 backoffs, got %d", s.Backoffs)
+	}
+}
+
+func TestRateLimiterConfigValidate(t *testing.T) {
+	valid := []*RateLimiterConfig{
+		nil,
+		{},
+		{Rate: 1000, Burst: 10, MaxConcurrency: 100, MinConcurrency: 10, BackoffFactor: 0.7, Policy: RateLimitFailFast},
+	}
+	for _, cfg := range valid {
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("%+v: %v", cfg, err)
+		}
+	}
+
+	invalid := []*RateLimiterConfig{
+		{Rate: -1},
+		{Burst: -1},
+		{MaxConcurrency: -1},
+		{MaxConcurrency: 1, MinConcurrency: 2},
+		{BackoffFactor: 1},
+		{Policy: RateLimitPolicy(5)},
+		{MaxWait: -time.Second},
+	}
+	for _, cfg := range invalid {
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("%+v: expected an error", cfg)
+		}
+	}
+}
+
+func TestSessionRateLimiter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	srv := NewTestServer(t, protoVersion4, ctx)
+	defer srv.Stop()
+
+	cluster := testCluster(protoVersion4, srv.Address)
+	cluster.PoolConfig.RateLimiter = &RateLimiterConfig{MaxConcurrency: 1, Policy: RateLimitFailFast}
+
+	db, err := cluster.CreateSession()
+	if err != nil {
+		t.Fatalf("NewCluster: %v", err)
+	}
+	defer db.Close()
+
+	slow := make(chan error, 1)
+	go func() {
+		slow <- db.Query("slow").Exec()
+	}()
+
+	var pool HostPoolRateLimiterInfo
+	db.IterateHostPools(func(info HostPoolInfo) bool {
+		pool, _ = info.(HostPoolRateLimiterInfo)
+		return false
+	})
+	if pool == nil {
+		t.Fatal("the host pool doesn't provide the rate limiter state")
+	}
+	for deadline := time.Now().Add(time.Second); ; {
+		if states := pool.RateLimiterState(); len(states) == 1 && states[0].InFlight == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("slow query not admitted")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := db.Query("void").Exec(); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("expected ErrRateLimited, got %v", err)
+	}
+	if err := <-slow; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Query("void").Exec(); err != nil {
+		t.Fatal(err)
+	}
+
+	state := pool.RateLimiterState()[0]
+	if state.InFlight != 0 || state.Admitted != 2 || state.Rejected != 1 {
+		t.Fatalf("unexpected rate limiter state: %+v", state)
+	}
+}
@@ -298,6 +298,10 @@ func (m *mockControlConn) reconnect() error {
 	return nil
 }
 
+func (m *mockControlConn) rotate() error {
+	return nil
+}
+
 func (m *mockControlConn) getConn() *connHost {
 	return &connHost{
 		conn: &mockConnection{},
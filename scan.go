@@ -0,0 +1,266 @@
+package gocql
+
+import (
+	"fmt"
+	"iter"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+// ScanAll scans all the remaining rows of the iterator into values of type T and closes the iterator.
+//
+// If T is a struct, the columns are mapped to its fields. The cql tag can be used to specify the
+// column name mapped to a field, fields tagged with `cql:"-"` are ignored. Untagged exported fields
+// are matched to columns case-insensitively by name, fields of embedded structs are promoted like
+// in Go, so fields with the same name at the same depth are ambiguous and not matched.
+// Every column of the result has to be mapped to a field, fields without a column are left unchanged.
+//
+//	type User struct {
+//		ID    gocql.UUID `cql:"id"`
+//		Name  string
+//		Email string `cql:"email_address"`
+//	}
+//
+//	users, err := gocql.ScanAll[User](session.Query(`SELECT id, name, email_address FROM users`).Iter())
+//
+// If T is not a struct, implements Unmarshaler or is time.Time, the result has to consist of a single
+// column which is unmarshaled into T as a whole.
+//
+// Unlike Iter.Scan, tuple columns are not expanded into a value per element, a tuple is unmarshaled
+// as a whole into a struct, slice or array of its elements.
+//
+// The mapping of columns to fields is computed once per type and result columns and cached,
+// subsequent rows are unmarshaled straight into the fields without inspecting T again.
+func ScanAll[T any](iter *Iter) ([]T, error) {
+	var rows []T
+	for row, err := range scanRows[T](iter) {
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// Rows executes the query when iterated over and yields its rows scanned into values of type T,
+// see ScanAll for how the columns are mapped to T. The next pages are fetched as needed.
+//
+// If an error occurs, it is yielded with the zero value of T and the iteration stops.
+// The iterator is closed when the iteration stops, including when the loop is exited early.
+//
+//	for user, err := range gocql.Rows[User](session.Query(`SELECT id, name, email_address FROM users`)) {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(user.Name)
+//	}
+func Rows[T any](q *Query) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		scanRows[T](q.Iter())(yield)
+	}
+}
+
+// scanRows yields the remaining rows of it scanned into values of type T and closes it
+// once the iteration stops.
+func scanRows[T any](it *Iter) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var (
+			plan *scanPlan
+			cols []ColumnInfo
+		)
+		for it.nextRow() {
+			if plan == nil || !sameColumns(cols, it.meta.columns) {
+				cols = it.meta.columns
+				var err error
+				if plan, err = scanPlanFor(reflect.TypeFor[T](), cols); err != nil {
+					it.err = err
+					break
+				}
+				if err = plan.checkTuples(it.codecs, cols); err != nil {
+					it.err = err
+					break
+				}
+			}
+
+			var row T
+			if err := it.scanRow(plan, unsafe.Pointer(&row)); err != nil {
+				it.err = err
+				break
+			}
+			if !yield(row, nil) {
+				it.Close()
+				return
+			}
+		}
+
+		if err := it.Close(); err != nil {
+			var zero T
+			yield(zero, err)
+		}
+	}
+}
+
+// scanRow unmarshals the columns of the current row into the fields of the value at base.
+func (iter *Iter) scanRow(plan *scanPlan, base unsafe.Pointer) error {
+	for i, col := range iter.meta.columns {
+		p, err := iter.readColumn()
+		if err != nil {
+			return err
+		}
+		if err := unmarshalWith(iter.codecs, col.TypeInfo, p, plan.fields[i].dest(base)); err != nil {
+			return err
+		}
+	}
+	iter.pos++
+	return nil
+}
+
+func sameColumns(a, b []ColumnInfo) bool {
+	return len(a) == len(b) && (len(a) == 0 || &a[0] == &b[0])
+}
+
+// scanPlan maps the columns of a result to the fields of a type.
+type scanPlan struct {
+	// fields holds a field per column.
+	fields []scanField
+}
+
+// scanField is a field of a type a column is unmarshaled into.
+type scanField struct {
+	typ    reflect.Type
+	offset uintptr
+	// ptr returns the pointer to a value of type typ at p as an interface{}.
+	ptr func(p unsafe.Pointer) interface{}
+}
+
+// dest returns a pointer to the field of the value at base as passed to Unmarshal.
+func (f *scanField) dest(base unsafe.Pointer) interface{} {
+	return f.ptr(unsafe.Add(base, f.offset))
+}
+
+func newScanField(t reflect.Type, offset uintptr) scanField {
+	ptr, ok := scanFieldPointers[t]
+	if !ok {
+		ptr = func(p unsafe.Pointer) interface{} {
+			return reflect.NewAt(t, p).Interface()
+		}
+	}
+	return scanField{typ: t, offset: offset, ptr: ptr}
+}
+
+// scanFieldPointers convert pointers to the types columns are usually scanned into without reflection.
+var scanFieldPointers = map[reflect.Type]func(unsafe.Pointer) interface{}{
+	reflect.TypeFor[string]():            typedPointer[string],
+	reflect.TypeFor[[]byte]():            typedPointer[[]byte],
+	reflect.TypeFor[bool]():              typedPointer[bool],
+	reflect.TypeFor[int]():               typedPointer[int],
+	reflect.TypeFor[int8]():              typedPointer[int8],
+	reflect.TypeFor[int16]():             typedPointer[int16],
+	reflect.TypeFor[int32]():             typedPointer[int32],
+	reflect.TypeFor[int64]():             typedPointer[int64],
+	reflect.TypeFor[uint]():              typedPointer[uint],
+	reflect.TypeFor[uint8]():             typedPointer[uint8],
+	reflect.TypeFor[uint16]():            typedPointer[uint16],
+	reflect.TypeFor[uint32]():            typedPointer[uint32],
+	reflect.TypeFor[uint64]():            typedPointer[uint64],
+	reflect.TypeFor[float32]():           typedPointer[float32],
+	reflect.TypeFor[float64]():           typedPointer[float64],
+	reflect.TypeFor[time.Time]():         typedPointer[time.Time],
+	reflect.TypeFor[time.Duration]():     typedPointer[time.Duration],
+	reflect.TypeFor[UUID]():              typedPointer[UUID],
+	reflect.TypeFor[*string]():           typedPointer[*string],
+	reflect.TypeFor[*int]():              typedPointer[*int],
+	reflect.TypeFor[*int64]():            typedPointer[*int64],
+	reflect.TypeFor[*bool]():             typedPointer[*bool],
+	reflect.TypeFor[*float64]():          typedPointer[*float64],
+	reflect.TypeFor[*time.Time]():        typedPointer[*time.Time],
+	reflect.TypeFor[*UUID]():             typedPointer[*UUID],
+	reflect.TypeFor[[]string]():          typedPointer[[]string],
+	reflect.TypeFor[map[string]string](): typedPointer[map[string]string],
+}
+
+func typedPointer[T any](p unsafe.Pointer) interface{} {
+	return (*T)(p)
+}
+
+// checkTuples checks that the tuple columns are mapped to fields a tuple can be unmarshaled into.
+func (p *scanPlan) checkTuples(codecs *CodecRegistry, cols []ColumnInfo) error {
+	for i, col := range cols {
+		if col.TypeInfo.Type() != TypeTuple {
+			continue
+		}
+		t := p.fields[i].typ
+		if _, ok := codecs.lookup(TypeTuple, t); ok || reflect.PointerTo(t).Implements(unmarshalerType) {
+			continue
+		}
+		switch t.Kind() {
+		case reflect.Struct, reflect.Slice, reflect.Array:
+			continue
+		}
+		return fmt.Errorf("gocql: cannot scan tuple column %q into %s, it has to be a struct, slice or array of its elements", col.Name, t)
+	}
+	return nil
+}
+
+type scanPlanKey struct {
+	typ     reflect.Type
+	columns string
+}
+
+// scanPlans caches scan plans by scanPlanKey.
+var scanPlans sync.Map
+
+func scanPlanFor(t reflect.Type, cols []ColumnInfo) (*scanPlan, error) {
+	var key strings.Builder
+	for _, col := range cols {
+		key.WriteString(col.Name)
+		key.WriteByte(':')
+		key.WriteString(strconv.Itoa(int(col.TypeInfo.Type())))
+		key.WriteByte(',')
+	}
+
+	k := scanPlanKey{typ: t, columns: key.String()}
+	if plan, ok := scanPlans.Load(k); ok {
+		return plan.(*scanPlan), nil
+	}
+
+	plan, err := newScanPlan(t, cols)
+	if err != nil {
+		return nil, err
+	}
+	scanPlans.Store(k, plan)
+	return plan, nil
+}
+
+var (
+	unmarshalerType = reflect.TypeFor[Unmarshaler]()
+	timeType        = reflect.TypeFor[time.Time]()
+)
+
+func scanAsWhole(t reflect.Type) bool {
+	return t.Kind() != reflect.Struct || t == timeType || reflect.PointerTo(t).Implements(unmarshalerType)
+}
+
+func newScanPlan(t reflect.Type, cols []ColumnInfo) (*scanPlan, error) {
+	if scanAsWhole(t) {
+		if len(cols) != 1 {
+			return nil, fmt.Errorf("gocql: cannot scan %d columns into %s, it has to be a struct", len(cols), t)
+		}
+		return &scanPlan{fields: []scanField{newScanField(t, 0)}}, nil
+	}
+
+	fields := structFields(t)
+	plan := &scanPlan{fields: make([]scanField, len(cols))}
+	for i, col := range cols {
+		f, ok := fields.lookup(col.Name)
+		if !ok {
+			return nil, fmt.Errorf("gocql: no field of %s to scan column %q into", t, col.Name)
+		}
+		plan.fields[i] = newScanField(f.typ, f.offset)
+	}
+	return plan, nil
+}
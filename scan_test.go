@@ -0,0 +1,320 @@
+//go:build unit
+// +build unit
+
+package gocql
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/gocql/gocql/internal/tests/mock"
+)
+
+var scanTestMetadata = resultMetadata{
+	columns: []ColumnInfo{
+		{Keyspace: "ks", Table: "users", Name: "id", TypeInfo: NativeType{proto: protoVersion4, typ: TypeInt}},
+		{Keyspace: "ks", Table: "users", Name: "name", TypeInfo: NativeType{proto: protoVersion4, typ: TypeVarchar}},
+		{Keyspace: "ks", Table: "users", Name: "email_address", TypeInfo: NativeType{proto: protoVersion4, typ: TypeVarchar}},
+		{Keyspace: "ks", Table: "users", Name: "created", TypeInfo: NativeType{proto: protoVersion4, typ: TypeTimestamp}},
+	},
+	colCount:       4,
+	actualColCount: 4,
+}
+
+type scanTestAudit struct {
+	Created time.Time
+}
+
+type scanTestUser struct {
+	scanTestAudit
+	ID      int
+	Name    *string
+	Email   string `cql:"email_address"`
+	Ignored string `cql:"-"`
+	unused  int
+}
+
+func newScanTestIter(meta resultMetadata, rows ...[]interface{}) *Iter {
+	var data [][]byte
+	for _, row := range rows {
+		data = append(data, marshalMetadataMust(meta, row)...)
+	}
+	return &Iter{
+		meta:    meta,
+		framer:  &mock.MockFramer{Data: data},
+		numRows: len(rows),
+	}
+}
+
+func TestScanAllStruct(t *testing.T) {
+	t.Parallel()
+
+	created := time.UnixMilli(1700000000000).UTC()
+	iter := newScanTestIter(scanTestMetadata,
+		[]interface{}{1, "alice", "alice@example.com", created},
+		[]interface{}{2, nil, "bob@example.com", created.Add(time.Hour)},
+	)
+
+	users, err := ScanAll[scanTestUser](iter)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(users) != 2 {
+		t.Fatalf("expected 2 users, got %d", len(users))
+	}
+	if u := users[0]; u.ID != 1 || u.Name == nil || *u.Name != "alice" || u.Email != "alice@example.com" || !u.Created.Equal(created) {
+		t.Fatalf("unexpected first user: %+v", u)
+	}
+	if u := users[1]; u.ID != 2 || u.Name != nil || u.Email != "bob@example.com" || !u.Created.Equal(created.Add(time.Hour)) {
+		t.Fatalf("unexpected second user: %+v", u)
+	}
+}
+
+func TestScanAllSingleColumn(t *testing.T) {
+	t.Parallel()
+
+	meta := resultMetadata{
+		columns:        scanTestMetadata.columns[3:],
+		colCount:       1,
+		actualColCount: 1,
+	}
+	created := time.UnixMilli(1700000000000).UTC()
+
+	times, err := ScanAll[time.Time](newScanTestIter(meta, []interface{}{created}, []interface{}{created.Add(time.Second)}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(times) != 2 || !times[0].Equal(created) || !times[1].Equal(created.Add(time.Second)) {
+		t.Fatalf("unexpected values: %v", times)
+	}
+
+	if _, err := ScanAll[string](newScanTestIter(scanTestMetadata, []interface{}{1, "alice", "alice@example.com", created})); err == nil {
+		t.Fatal("expected an error scanning several columns into a string")
+	}
+}
+
+func TestScanAllMissingField(t *testing.T) {
+	t.Parallel()
+
+	type partialUser struct {
+		ID   int
+		Name string
+	}
+
+	iter := newScanTestIter(scanTestMetadata, []interface{}{1, "alice", "alice@example.com", time.Now()})
+	_, err := ScanAll[partialUser](iter)
+	if err == nil || !strings.Contains(err.Error(), `"email_address"`) {
+		t.Fatalf("expected an error about the unmapped column, got %v", err)
+	}
+	if iter.Close() != err {
+		t.Fatal("expected the error to be recorded on the iterator")
+	}
+}
+
+func TestScanAllTuple(t *testing.T) {
+	t.Parallel()
+
+	tuple := TupleTypeInfo{
+		NativeType: NativeType{proto: protoVersion4, typ: TypeTuple},
+		Elems: []TypeInfo{
+			NativeType{proto: protoVersion4, typ: TypeInt},
+			NativeType{proto: protoVersion4, typ: TypeVarchar},
+		},
+	}
+	meta := resultMetadata{
+		columns: []ColumnInfo{
+			{Keyspace: "ks", Table: "t", Name: "id", TypeInfo: NativeType{proto: protoVersion4, typ: TypeInt}},
+			{Keyspace: "ks", Table: "t", Name: "pair", TypeInfo: tuple},
+		},
+		colCount:       2,
+		actualColCount: 3,
+	}
+	type pair struct {
+		N int
+		S string
+	}
+	row := []interface{}{1, []interface{}{2, "b"}}
+
+	structs, err := ScanAll[struct {
+		ID   int
+		Pair pair
+	}](newScanTestIter(meta, row))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(structs) != 1 || structs[0].ID != 1 || structs[0].Pair != (pair{2, "b"}) {
+		t.Fatalf("unexpected rows %+v", structs)
+	}
+
+	slices, err := ScanAll[struct {
+		ID   int
+		Pair []interface{}
+	}](newScanTestIter(meta, row))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(slices) != 1 || !reflect.DeepEqual(slices[0].Pair, []interface{}{2, "b"}) {
+		t.Fatalf("unexpected rows %+v", slices)
+	}
+
+	_, err = ScanAll[struct {
+		ID   int
+		Pair int
+	}](newScanTestIter(meta, row))
+	if err == nil || !strings.Contains(err.Error(), `tuple column "pair"`) {
+		t.Fatalf("expected an error about the tuple column, got %v", err)
+	}
+}
+
+func TestScanRowsEarlyExit(t *testing.T) {
+	t.Parallel()
+
+	iter := newScanTestIter(scanTestMetadata,
+		[]interface{}{1, "alice", "alice@example.com", time.Now()},
+		[]interface{}{2, "bob", "bob@example.com", time.Now()},
+	)
+
+	var seen []int
+	for user, err := range scanRows[scanTestUser](iter) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen = append(seen, user.ID)
+		break
+	}
+
+	if !reflect.DeepEqual(seen, []int{1}) {
+		t.Fatalf("expected to see only the first row, got %v", seen)
+	}
+	if iter.closed != 1 {
+		t.Fatal("expected the iterator to be closed")
+	}
+}
+
+func TestScanPlanCached(t *testing.T) {
+	t.Parallel()
+
+	typ := reflect.TypeFor[scanTestUser]()
+	first, err := scanPlanFor(typ, scanTestMetadata.columns)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// an equal set of columns from another page reuses the plan
+	cols := append([]ColumnInfo(nil), scanTestMetadata.columns...)
+	second, err := scanPlanFor(typ, cols)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second {
+		t.Fatal("expected the scan plan to be cached")
+	}
+
+	cols[0].TypeInfo = NativeType{proto: protoVersion4, typ: TypeBigInt}
+	third, err := scanPlanFor(typ, cols)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if third == first {
+		t.Fatal("expected a different plan for different column types")
+	}
+}
+
+func BenchmarkScanAll(b *testing.B) {
+	created := time.UnixMilli(1700000000000).UTC()
+	rows := make([][]interface{}, 100)
+	for i := range rows {
+		rows[i] = []interface{}{i, "alice", "alice@example.com", created}
+	}
+
+	b.Run("Scan", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			iter := newScanTestIter(scanTestMetadata, rows...)
+			var users []scanTestUser
+			var u scanTestUser
+			for iter.Scan(&u.ID, &u.Name, &u.Email, &u.Created) {
+				users = append(users, u)
+			}
+			if err := iter.Close(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("ScanAll", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := ScanAll[scanTestUser](newScanTestIter(scanTestMetadata, rows...)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+type scanTestName string
+
+func TestScanFieldDest(t *testing.T) {
+	t.Parallel()
+
+	var v struct {
+		ID   int
+		Name scanTestName
+		Tags []string
+	}
+	typ := reflect.TypeOf(v)
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		field := newScanField(sf.Type, sf.Offset)
+		dest := field.dest(unsafe.Pointer(&v))
+		rv := reflect.ValueOf(dest)
+		if rv.Type() != reflect.PointerTo(sf.Type) {
+			t.Fatalf("field %s: expected %s, got %s", sf.Name, reflect.PointerTo(sf.Type), rv.Type())
+		}
+		if rv.Pointer() != reflect.ValueOf(&v).Elem().Field(i).Addr().Pointer() {
+			t.Fatalf("field %s: destination does not point to the field", sf.Name)
+		}
+	}
+}
+
+type fieldsTestInner struct {
+	Name string
+	ID   int
+}
+
+type fieldsTestA struct {
+	fieldsTestInner
+	Email string `cql:"email_address"`
+}
+
+type fieldsTestB struct {
+	ID    int
+	Email string `cql:"email_address"`
+}
+
+type fieldsTestRow struct {
+	fieldsTestA
+	fieldsTestB
+}
+
+func TestStructFieldsPromotion(t *testing.T) {
+	t.Parallel()
+
+	fields := structFields(reflect.TypeFor[fieldsTestRow]())
+
+	// a shallower field hides the deeper ones
+	if f, ok := fields.lookup("id"); !ok || !reflect.DeepEqual(f.index, []int{1, 0}) {
+		t.Fatalf("expected id to be fieldsTestB.ID, got %+v", f)
+	}
+	if f, ok := fields.lookup("name"); !ok || !reflect.DeepEqual(f.index, []int{0, 0, 0}) {
+		t.Fatalf("expected name to be promoted from fieldsTestInner, got %+v", f)
+	}
+	// fields at the same depth are ambiguous
+	if f, ok := fields.lookup("email_address"); ok {
+		t.Fatalf("expected email_address to be ambiguous, got %+v", f)
+	}
+}
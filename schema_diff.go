@@ -0,0 +1,625 @@
+package gocql
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ErrIncompatibleSchemaChange is returned by DiffKeyspaces when an object can't be altered to the
+// desired schema, e.g. the primary key of a table or the type of a column changed.
+var ErrIncompatibleSchemaChange = errors.New("gocql: incompatible schema change")
+
+// SchemaChangeKind is the kind of a SchemaChange.
+type SchemaChangeKind string
+
+const (
+	SchemaChangeCreate SchemaChangeKind = "CREATE"
+	SchemaChangeAlter  SchemaChangeKind = "ALTER"
+	SchemaChangeDrop   SchemaChangeKind = "DROP"
+)
+
+// SchemaObject is the type of the object changed by a SchemaChange.
+type SchemaObject string
+
+const (
+	SchemaObjectKeyspace  SchemaObject = "KEYSPACE"
+	SchemaObjectType      SchemaObject = "TYPE"
+	SchemaObjectTable     SchemaObject = "TABLE"
+	SchemaObjectIndex     SchemaObject = "INDEX"
+	SchemaObjectView      SchemaObject = "MATERIALIZED VIEW"
+	SchemaObjectFunction  SchemaObject = "FUNCTION"
+	SchemaObjectAggregate SchemaObject = "AGGREGATE"
+)
+
+// SchemaChange is a statement changing the schema of a keyspace, see DiffKeyspaces.
+type SchemaChange struct {
+	Kind   SchemaChangeKind
+	Object SchemaObject
+	// Name is the name of the changed object, e.g. the table name.
+	Name string
+	// Statement is the CQL statement applying the change, without the trailing semicolon.
+	Statement string
+	// Destructive is true if the change deletes data stored in the keyspace, i.e. it drops
+	// the keyspace, a table or a column.
+	Destructive bool
+}
+
+// DiffKeyspaces returns the statements changing the schema of the keyspace from to the schema
+// of the keyspace to, in the order they have to be executed:
+//
+//   - the keyspace is created or its replication altered,
+//   - views, indexes, aggregates, functions and tables missing in to are dropped, as well as
+//     the views, indexes, aggregates and functions which have to be recreated,
+//   - columns missing in to are dropped, then types missing in to,
+//   - types are created or new fields added,
+//   - tables are created, new columns added and changed options altered,
+//   - indexes, functions, aggregates and views are created, replaced or altered.
+//
+// from is nil if the keyspace doesn't exist yet and to is nil if it has to be dropped, the names
+// of the keyspaces must be the same otherwise. Schema changes which can't be applied with ALTER
+// statements without losing data, e.g. a change of the primary key of a table, the type of a column
+// or the fields of a type, fail with ErrIncompatibleSchemaChange. Custom indexes are ignored,
+// like by KeyspaceMetadata.ToCQL. Names in ALTER and DROP statements are quoted unless they are
+// lower case and not reserved keywords, CREATE statements are rendered like by KeyspaceMetadata.ToCQL.
+//
+// The statements should be executed one by one, waiting for schema agreement after each of them,
+// see Session.AwaitSchemaAgreement.
+func DiffKeyspaces(from, to *KeyspaceMetadata) ([]SchemaChange, error) {
+	switch {
+	case from == nil && to == nil:
+		return nil, nil
+	case to == nil:
+		return []SchemaChange{{
+			Kind:        SchemaChangeDrop,
+			Object:      SchemaObjectKeyspace,
+			Name:        from.Name,
+			Statement:   "DROP KEYSPACE " + quoteIdent(from.Name),
+			Destructive: true,
+		}}, nil
+	case from != nil && from.Name != to.Name:
+		return nil, fmt.Errorf("gocql: can't diff keyspace %s with keyspace %s", from.Name, to.Name)
+	}
+
+	d := &schemaDiff{from: from, to: to}
+	if from == nil {
+		d.from = &KeyspaceMetadata{Name: to.Name}
+		stmt, err := renderCQL(to.keyspaceToCQL)
+		if err != nil {
+			return nil, err
+		}
+		d.add(SchemaChangeCreate, SchemaObjectKeyspace, to.Name, stmt)
+	} else {
+		d.alterKeyspace()
+	}
+
+	for _, step := range []func() error{
+		d.dropViews,
+		d.dropIndexes,
+		d.dropAggregates,
+		d.dropFunctions,
+		d.dropTables,
+		d.dropColumns,
+		d.dropTypes,
+		d.createTypes,
+		d.createTables,
+		d.createIndexes,
+		d.createFunctions,
+		d.createAggregates,
+		d.createViews,
+	} {
+		if err := step(); err != nil {
+			return nil, err
+		}
+	}
+	return d.changes, nil
+}
+
+type schemaDiff struct {
+	from, to *KeyspaceMetadata
+	changes  []SchemaChange
+}
+
+func (d *schemaDiff) add(kind SchemaChangeKind, object SchemaObject, name, stmt string) {
+	d.changes = append(d.changes, SchemaChange{Kind: kind, Object: object, Name: name, Statement: stmt})
+}
+
+func (d *schemaDiff) addDestructive(kind SchemaChangeKind, object SchemaObject, name, stmt string) {
+	d.changes = append(d.changes, SchemaChange{Kind: kind, Object: object, Name: name, Statement: stmt, Destructive: true})
+}
+
+func (d *schemaDiff) name(object string) string {
+	return quoteIdent(d.to.Name) + "." + quoteIdent(object)
+}
+
+func (d *schemaDiff) alterKeyspace() {
+	if d.from.StrategyClass == d.to.StrategyClass &&
+		reflect.DeepEqual(d.from.StrategyOptions, d.to.StrategyOptions) &&
+		d.from.DurableWrites == d.to.DurableWrites {
+		return
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "ALTER KEYSPACE %s WITH replication = {'class': %s", quoteIdent(d.to.Name),
+		cqlHelpers.escape(cqlHelpers.fixStrategy(d.to.StrategyClass)))
+	for _, key := range sortedNames(d.to.StrategyOptions) {
+		fmt.Fprintf(&sb, ", %s: %s", cqlHelpers.escape(key), cqlHelpers.escape(d.to.StrategyOptions[key]))
+	}
+	fmt.Fprintf(&sb, "} AND durable_writes = %t", d.to.DurableWrites)
+	d.add(SchemaChangeAlter, SchemaObjectKeyspace, d.to.Name, sb.String())
+}
+
+func (d *schemaDiff) dropViews() error {
+	for _, name := range sortedNames(d.from.Views) {
+		if vm, ok := d.to.Views[name]; ok && viewDefinitionEquals(d.from.Views[name], vm) {
+			continue
+		}
+		d.add(SchemaChangeDrop, SchemaObjectView, name, "DROP MATERIALIZED VIEW "+d.name(name))
+	}
+	return nil
+}
+
+func (d *schemaDiff) dropIndexes() error {
+	for _, name := range sortedNames(d.from.Indexes) {
+		if im, ok := d.to.Indexes[name]; ok && indexEquals(d.from.Indexes[name], im) {
+			continue
+		}
+		if d.from.Indexes[name].Kind == IndexKindCustom {
+			continue
+		}
+		d.add(SchemaChangeDrop, SchemaObjectIndex, name, "DROP INDEX "+d.name(name))
+	}
+	return nil
+}
+
+func (d *schemaDiff) dropAggregates() error {
+	for _, name := range sortedNames(d.from.Aggregates) {
+		if d.keepAggregate(name) {
+			continue
+		}
+		am := d.from.Aggregates[name]
+		d.add(SchemaChangeDrop, SchemaObjectAggregate, name,
+			fmt.Sprintf("DROP AGGREGATE %s(%s)", d.name(name), argumentTypesToCQL(am.ArgumentTypes)))
+	}
+	return nil
+}
+
+func (d *schemaDiff) dropFunctions() error {
+	for _, name := range sortedNames(d.from.Functions) {
+		if d.keepFunction(name) {
+			continue
+		}
+		fm := d.from.Functions[name]
+		d.add(SchemaChangeDrop, SchemaObjectFunction, name,
+			fmt.Sprintf("DROP FUNCTION %s(%s)", d.name(name), argumentTypesToCQL(fm.ArgumentTypes)))
+	}
+	return nil
+}
+
+func (d *schemaDiff) dropTables() error {
+	for _, name := range sortedNames(d.from.Tables) {
+		if _, ok := d.to.Tables[name]; ok {
+			continue
+		}
+		d.addDestructive(SchemaChangeDrop, SchemaObjectTable, name, "DROP TABLE "+d.name(name))
+	}
+	return nil
+}
+
+// dropColumns drops the columns missing in the desired tables, before the types they may use are dropped.
+func (d *schemaDiff) dropColumns() error {
+	for _, name := range sortedNames(d.to.Tables) {
+		old, ok := d.from.Tables[name]
+		if !ok {
+			continue
+		}
+		tm := d.to.Tables[name]
+		if err := checkPrimaryKey(d.name(name), old, tm); err != nil {
+			return err
+		}
+		for _, cn := range old.OrderedColumns {
+			if _, ok := tm.Columns[cn]; ok {
+				continue
+			}
+			d.addDestructive(SchemaChangeAlter, SchemaObjectTable, name,
+				fmt.Sprintf("ALTER TABLE %s DROP %s", d.name(name), quoteIdent(cn)))
+		}
+	}
+	return nil
+}
+
+func (d *schemaDiff) dropTypes() error {
+	types := sortedTypes(d.from.Types)
+	for i := len(types) - 1; i >= 0; i-- {
+		if _, ok := d.to.Types[types[i].Name]; ok {
+			continue
+		}
+		d.add(SchemaChangeDrop, SchemaObjectType, types[i].Name, "DROP TYPE "+d.name(types[i].Name))
+	}
+	return nil
+}
+
+func (d *schemaDiff) createTypes() error {
+	for _, tm := range sortedTypes(d.to.Types) {
+		old, ok := d.from.Types[tm.Name]
+		if !ok {
+			stmt, err := renderCQL(func(w io.Writer) error { return d.to.userTypeToCQL(w, tm) })
+			if err != nil {
+				return err
+			}
+			d.add(SchemaChangeCreate, SchemaObjectType, tm.Name, stmt)
+			continue
+		}
+
+		// fields can only be added at the end of a type
+		if len(tm.FieldNames) < len(old.FieldNames) ||
+			!reflect.DeepEqual(tm.FieldNames[:len(old.FieldNames)], old.FieldNames) ||
+			!reflect.DeepEqual(tm.FieldTypes[:len(old.FieldTypes)], old.FieldTypes) {
+			return fmt.Errorf("%w: the fields of type %s changed", ErrIncompatibleSchemaChange, d.name(tm.Name))
+		}
+		for i := len(old.FieldNames); i < len(tm.FieldNames); i++ {
+			d.add(SchemaChangeAlter, SchemaObjectType, tm.Name,
+				fmt.Sprintf("ALTER TYPE %s ADD %s %s", d.name(tm.Name), quoteIdent(tm.FieldNames[i]), tm.FieldTypes[i]))
+		}
+	}
+	return nil
+}
+
+func (d *schemaDiff) createTables() error {
+	for _, name := range sortedNames(d.to.Tables) {
+		tm := d.to.Tables[name]
+		old, ok := d.from.Tables[name]
+		if !ok {
+			stmt, err := renderCQL(func(w io.Writer) error { return d.to.tableToCQL(w, d.to.Name, tm) })
+			if err != nil {
+				return err
+			}
+			d.add(SchemaChangeCreate, SchemaObjectTable, name, stmt)
+			continue
+		}
+
+		for _, cn := range tm.OrderedColumns {
+			cm := tm.Columns[cn]
+			if oc, ok := old.Columns[cn]; ok {
+				if oc.Type != cm.Type || oc.Kind != cm.Kind {
+					return fmt.Errorf("%w: column %s of table %s changed from %s %s to %s %s", ErrIncompatibleSchemaChange,
+						cn, d.name(name), oc.Kind, oc.Type, cm.Kind, cm.Type)
+				}
+				continue
+			}
+			stmt := fmt.Sprintf("ALTER TABLE %s ADD %s %s", d.name(name), quoteIdent(cn), cm.Type)
+			if cm.Kind == ColumnStatic {
+				stmt += " static"
+			}
+			d.add(SchemaChangeAlter, SchemaObjectTable, name, stmt)
+		}
+
+		options, err := alteredOptionsToCQL(old.Options, old.Extensions, tm.Options, tm.Extensions)
+		if err != nil {
+			return err
+		}
+		if options != "" {
+			d.add(SchemaChangeAlter, SchemaObjectTable, name, fmt.Sprintf("ALTER TABLE %s WITH %s", d.name(name), options))
+		}
+	}
+	return nil
+}
+
+func (d *schemaDiff) createIndexes() error {
+	for _, name := range sortedNames(d.to.Indexes) {
+		im := d.to.Indexes[name]
+		if old, ok := d.from.Indexes[name]; ok && indexEquals(old, im) {
+			continue
+		}
+		stmt, err := renderCQL(func(w io.Writer) error { return d.to.indexToCQL(w, im) })
+		if err != nil {
+			return err
+		}
+		if stmt != "" {
+			d.add(SchemaChangeCreate, SchemaObjectIndex, name, stmt)
+		}
+	}
+	return nil
+}
+
+func (d *schemaDiff) createFunctions() error {
+	for _, name := range sortedNames(d.to.Functions) {
+		fm := d.to.Functions[name]
+		old, ok := d.from.Functions[name]
+		if ok && functionEquals(old, fm) {
+			continue
+		}
+		stmt, err := renderCQL(func(w io.Writer) error { return d.to.functionToCQL(w, d.to.Name, fm) })
+		if err != nil {
+			return err
+		}
+		if ok && d.keepFunction(name) {
+			d.add(SchemaChangeAlter, SchemaObjectFunction, name,
+				strings.Replace(stmt, "CREATE FUNCTION", "CREATE OR REPLACE FUNCTION", 1))
+			continue
+		}
+		d.add(SchemaChangeCreate, SchemaObjectFunction, name, stmt)
+	}
+	return nil
+}
+
+func (d *schemaDiff) createAggregates() error {
+	for _, name := range sortedNames(d.to.Aggregates) {
+		if d.keepAggregate(name) {
+			continue
+		}
+		stmt, err := renderCQL(func(w io.Writer) error { return d.to.aggregateToCQL(w, d.to.Aggregates[name]) })
+		if err != nil {
+			return err
+		}
+		d.add(SchemaChangeCreate, SchemaObjectAggregate, name, stmt)
+	}
+	return nil
+}
+
+func (d *schemaDiff) createViews() error {
+	for _, name := range sortedNames(d.to.Views) {
+		vm := d.to.Views[name]
+		old, ok := d.from.Views[name]
+		if !ok || !viewDefinitionEquals(old, vm) {
+			stmt, err := renderCQL(func(w io.Writer) error { return d.to.viewToCQL(w, vm) })
+			if err != nil {
+				return err
+			}
+			d.add(SchemaChangeCreate, SchemaObjectView, name, stmt)
+			continue
+		}
+
+		options, err := alteredOptionsToCQL(old.Options, old.Extensions, vm.Options, vm.Extensions)
+		if err != nil {
+			return err
+		}
+		if options != "" {
+			d.add(SchemaChangeAlter, SchemaObjectView, name, fmt.Sprintf("ALTER MATERIALIZED VIEW %s WITH %s", d.name(name), options))
+		}
+	}
+	return nil
+}
+
+// keepFunction reports whether the function exists in both schemas with the same signature,
+// so it can be replaced instead of being dropped and created.
+func (d *schemaDiff) keepFunction(name string) bool {
+	old, ok := d.from.Functions[name]
+	if !ok {
+		return false
+	}
+	fm, ok := d.to.Functions[name]
+	if !ok {
+		return false
+	}
+	return reflect.DeepEqual(old.ArgumentTypes, fm.ArgumentTypes) &&
+		reflect.DeepEqual(old.ArgumentNames, fm.ArgumentNames) &&
+		old.ReturnType == fm.ReturnType
+}
+
+// keepAggregate reports whether the aggregate is the same in both schemas and its functions are not
+// recreated, aggregates are dropped and created otherwise.
+func (d *schemaDiff) keepAggregate(name string) bool {
+	old, ok := d.from.Aggregates[name]
+	if !ok {
+		return false
+	}
+	am, ok := d.to.Aggregates[name]
+	if !ok {
+		return false
+	}
+	if !reflect.DeepEqual(old.ArgumentTypes, am.ArgumentTypes) ||
+		old.StateType != am.StateType ||
+		old.ReturnType != am.ReturnType ||
+		old.InitCond != am.InitCond ||
+		old.StateFunc.Name != am.StateFunc.Name ||
+		old.FinalFunc.Name != am.FinalFunc.Name {
+		return false
+	}
+	for _, fn := range []string{am.StateFunc.Name, am.FinalFunc.Name} {
+		if _, ok := d.from.Functions[fn]; ok && !d.keepFunction(fn) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkPrimaryKey returns an error if the primary key of the table changed, it can't be altered.
+func checkPrimaryKey(name string, old, tm *TableMetadata) error {
+	same := func(a, b []*ColumnMetadata) bool {
+		if len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if a[i].Name != b[i].Name || a[i].Type != b[i].Type || a[i].ClusteringOrder != b[i].ClusteringOrder {
+				return false
+			}
+		}
+		return true
+	}
+	if !same(old.PartitionKey, tm.PartitionKey) || !same(old.ClusteringColumns, tm.ClusteringColumns) {
+		return fmt.Errorf("%w: the primary key of table %s changed", ErrIncompatibleSchemaChange, name)
+	}
+	return nil
+}
+
+func viewDefinitionEquals(a, b *ViewMetadata) bool {
+	columnNames := func(columns []*ColumnMetadata) []string {
+		names := make([]string, 0, len(columns))
+		for _, c := range columns {
+			names = append(names, c.Name+" "+c.ClusteringOrder)
+		}
+		return names
+	}
+	return a.BaseTableName == b.BaseTableName &&
+		a.WhereClause == b.WhereClause &&
+		a.IncludeAllColumns == b.IncludeAllColumns &&
+		(a.IncludeAllColumns || reflect.DeepEqual(a.OrderedColumns, b.OrderedColumns)) &&
+		reflect.DeepEqual(columnNames(a.PartitionKey), columnNames(b.PartitionKey)) &&
+		reflect.DeepEqual(columnNames(a.ClusteringColumns), columnNames(b.ClusteringColumns))
+}
+
+func indexEquals(a, b *IndexMetadata) bool {
+	return a.TableName == b.TableName && a.Kind == b.Kind && compareStringMaps(a.Options, b.Options)
+}
+
+func functionEquals(a, b *FunctionMetadata) bool {
+	return a.Body == b.Body &&
+		a.Language == b.Language &&
+		a.ReturnType == b.ReturnType &&
+		a.CalledOnNullInput == b.CalledOnNullInput &&
+		reflect.DeepEqual(a.ArgumentTypes, b.ArgumentTypes) &&
+		reflect.DeepEqual(a.ArgumentNames, b.ArgumentNames)
+}
+
+// disabledOptions are the values of the options which are omitted by tableOptionsToCQL and
+// tableExtensionsToCQL when they are disabled.
+var disabledOptions = map[string]string{
+	"in_memory":                 "in_memory = false",
+	"cdc":                       "cdc = {'enabled': 'false'}",
+	"scylla_encryption_options": "scylla_encryption_options = {'key_provider': 'none'}",
+}
+
+// alteredOptionsToCQL returns the options of a table or a view which differ, joined with AND.
+func alteredOptionsToCQL(oldOpts TableMetadataOptions, oldExts map[string]interface{},
+	opts TableMetadataOptions, exts map[string]interface{}) (string, error) {
+	properties := func(opts TableMetadataOptions, exts map[string]interface{}) (map[string]string, error) {
+		options, err := cqlHelpers.tableOptionsToCQL(opts)
+		if err != nil {
+			return nil, err
+		}
+		extensions, err := cqlHelpers.tableExtensionsToCQL(exts)
+		if err != nil {
+			return nil, err
+		}
+		m := make(map[string]string, len(options)+len(extensions))
+		for _, p := range append(options, extensions...) {
+			m[p[:strings.Index(p, " = ")]] = p
+		}
+		return m, nil
+	}
+	old, err := properties(oldOpts, oldExts)
+	if err != nil {
+		return "", err
+	}
+	desired, err := properties(opts, exts)
+	if err != nil {
+		return "", err
+	}
+
+	var altered []string
+	for key, p := range desired {
+		if old[key] != p {
+			altered = append(altered, p)
+		}
+	}
+	for key := range old {
+		if _, ok := desired[key]; !ok {
+			if p, ok := disabledOptions[key]; ok {
+				altered = append(altered, p)
+			}
+		}
+	}
+	sort.Strings(altered)
+	return strings.Join(altered, " AND "), nil
+}
+
+// argumentTypesToCQL returns the argument types of a function or an aggregate as in its signature.
+func argumentTypesToCQL(types []string) string {
+	args := make([]string, 0, len(types))
+	for _, t := range types {
+		args = append(args, cqlHelpers.stripFrozen(t))
+	}
+	return strings.Join(args, ", ")
+}
+
+// renderCQL returns the statement written by a ToCQL template, without the trailing semicolon.
+func renderCQL(render func(w io.Writer) error) (string, error) {
+	var sb strings.Builder
+	if err := render(&sb); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(sb.String()), ";")), nil
+}
+
+// sortedTypes returns the types sorted by name, the types used by the fields of a type come first.
+func sortedTypes(types map[string]*TypeMetadata) []*TypeMetadata {
+	sorted := make([]*TypeMetadata, 0, len(types))
+	visited := make(map[string]bool, len(types))
+	var visit func(name string)
+	visit = func(name string) {
+		if visited[name] {
+			return
+		}
+		visited[name] = true
+		tm := types[name]
+		for _, ft := range tm.FieldTypes {
+			for _, dep := range cqlTypeNames(ft) {
+				if _, ok := types[dep]; ok {
+					visit(dep)
+				}
+			}
+		}
+		sorted = append(sorted, tm)
+	}
+	for _, name := range sortedNames(types) {
+		visit(name)
+	}
+	return sorted
+}
+
+// cqlTypeNames returns the names used in a CQL type, e.g. frozen, map, text and address for
+// frozen<map<text, address>>.
+func cqlTypeNames(t string) []string {
+	return strings.FieldsFunc(t, func(r rune) bool {
+		return r == '<' || r == '>' || r == ',' || r == ' ' || r == '"'
+	})
+}
+
+// quoteIdent quotes a keyspace, table, column or field name unless it is a lower case identifier
+// which isn't a reserved keyword, so its case is preserved.
+func quoteIdent(name string) string {
+	if isLowerIdent(name) && !cqlReservedKeywords[name] {
+		return name
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func isLowerIdent(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r == '_', 'a' <= r && r <= 'z':
+		case '0' <= r && r <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// cqlReservedKeywords are the CQL keywords which can't be used as unquoted identifiers.
+var cqlReservedKeywords = map[string]bool{
+	"add": true, "allow": true, "alter": true, "and": true, "apply": true, "asc": true, "authorize": true,
+	"batch": true, "begin": true, "by": true, "columnfamily": true, "create": true, "delete": true, "desc": true,
+	"describe": true, "drop": true, "entries": true, "execute": true, "from": true, "full": true, "grant": true,
+	"if": true, "in": true, "index": true, "infinity": true, "insert": true, "into": true, "keyspace": true,
+	"limit": true, "modify": true, "nan": true, "norecursive": true, "not": true, "null": true, "of": true,
+	"on": true, "or": true, "order": true, "primary": true, "rename": true, "replace": true, "revoke": true,
+	"schema": true, "select": true, "set": true, "table": true, "to": true, "token": true, "truncate": true,
+	"unlogged": true, "update": true, "use": true, "using": true, "view": true, "where": true, "with": true,
+}
+
+func sortedNames[V any](m map[string]V) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,302 @@
+//go:build unit
+// +build unit
+
+package gocql
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func diffTestTable(name string, pk, ck []*ColumnMetadata, columns ...*ColumnMetadata) *TableMetadata {
+	tm := &TableMetadata{
+		Keyspace:          "ks",
+		Name:              name,
+		Columns:           map[string]*ColumnMetadata{},
+		PartitionKey:      pk,
+		ClusteringColumns: ck,
+		Options: TableMetadataOptions{
+			Caching:          map[string]string{"keys": "ALL"},
+			Compaction:       map[string]string{"class": "SizeTieredCompactionStrategy"},
+			Compression:      map[string]string{"sstable_compression": "LZ4Compressor"},
+			GcGraceSeconds:   864000,
+			SpeculativeRetry: "99.0PERCENTILE",
+		},
+	}
+	for _, cols := range [][]*ColumnMetadata{pk, ck, columns} {
+		for _, c := range cols {
+			tm.Columns[c.Name] = c
+			tm.OrderedColumns = append(tm.OrderedColumns, c.Name)
+		}
+	}
+	return tm
+}
+
+func diffTestColumn(name, typ string, kind ColumnKind) *ColumnMetadata {
+	c := &ColumnMetadata{Keyspace: "ks", Name: name, Type: typ, Kind: kind}
+	if kind == ColumnClusteringKey {
+		c.ClusteringOrder = "ASC"
+	}
+	return c
+}
+
+// diffTestKeyspace returns a keyspace with an object of each type.
+func diffTestKeyspace() *KeyspaceMetadata {
+	users := diffTestTable("users",
+		[]*ColumnMetadata{diffTestColumn("id", "uuid", ColumnPartitionKey)},
+		nil,
+		diffTestColumn("home", "frozen<address>", ColumnRegular),
+		diffTestColumn("name", "text", ColumnRegular),
+	)
+	events := diffTestTable("events",
+		[]*ColumnMetadata{diffTestColumn("id", "uuid", ColumnPartitionKey)},
+		[]*ColumnMetadata{diffTestColumn("at", "timestamp", ColumnClusteringKey)},
+		diffTestColumn("kind", "text", ColumnRegular),
+	)
+	view := &ViewMetadata{
+		KeyspaceName:      "ks",
+		ViewName:          "events_by_kind",
+		BaseTableName:     "events",
+		WhereClause:       "kind IS NOT NULL AND id IS NOT NULL AND at IS NOT NULL",
+		IncludeAllColumns: true,
+		PartitionKey:      []*ColumnMetadata{diffTestColumn("kind", "text", ColumnPartitionKey)},
+		ClusteringColumns: []*ColumnMetadata{diffTestColumn("id", "uuid", ColumnClusteringKey), diffTestColumn("at", "timestamp", ColumnClusteringKey)},
+		Options:           events.Options,
+	}
+	sum := &FunctionMetadata{
+		Keyspace:      "ks",
+		Name:          "sum_state",
+		ArgumentNames: []string{"state", "v"},
+		ArgumentTypes: []string{"int", "int"},
+		ReturnType:    "int",
+		Language:      "lua",
+		Body:          "return state + v",
+	}
+	return &KeyspaceMetadata{
+		Name:            "ks",
+		StrategyClass:   "org.apache.cassandra.locator.SimpleStrategy",
+		StrategyOptions: map[string]interface{}{"replication_factor": "1"},
+		DurableWrites:   true,
+		Tables:          map[string]*TableMetadata{"users": users, "events": events},
+		Types: map[string]*TypeMetadata{
+			// country is used by address, it has to be created first
+			"address": {Keyspace: "ks", Name: "address", FieldNames: []string{"street", "country"}, FieldTypes: []string{"text", "frozen<country>"}},
+			"country": {Keyspace: "ks", Name: "country", FieldNames: []string{"code"}, FieldTypes: []string{"text"}},
+		},
+		Indexes: map[string]*IndexMetadata{
+			"users_name": {Name: "users_name", KeyspaceName: "ks", TableName: "users", Kind: "COMPOSITES", Options: map[string]string{"target": "name"}},
+		},
+		Views:     map[string]*ViewMetadata{"events_by_kind": view},
+		Functions: map[string]*FunctionMetadata{"sum_state": sum},
+		Aggregates: map[string]*AggregateMetadata{
+			"total": {Keyspace: "ks", Name: "total", ArgumentTypes: []string{"int"}, StateType: "int", ReturnType: "int", InitCond: "0", StateFunc: *sum},
+		},
+	}
+}
+
+func diffStatements(t *testing.T, from, to *KeyspaceMetadata) []string {
+	t.Helper()
+	changes, err := DiffKeyspaces(from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stmts []string
+	for _, c := range changes {
+		if !strings.Contains(c.Statement, c.Name) || !strings.HasPrefix(c.Statement, string(c.Kind)) && c.Object != SchemaObjectFunction {
+			t.Errorf("unexpected change %+v", c)
+		}
+		stmts = append(stmts, strings.Join(strings.Fields(c.Statement), " "))
+	}
+	return stmts
+}
+
+func checkStatements(t *testing.T, stmts []string, expected ...string) {
+	t.Helper()
+	if len(stmts) != len(expected) {
+		t.Fatalf("expected %d statements, got %d:\n%s", len(expected), len(stmts), strings.Join(stmts, "\n"))
+	}
+	for i := range expected {
+		if !strings.HasPrefix(stmts[i], expected[i]) {
+			t.Errorf("expected statement %d to start with %q, got %q", i, expected[i], stmts[i])
+		}
+	}
+}
+
+func TestDiffKeyspacesCreate(t *testing.T) {
+	stmts := diffStatements(t, nil, diffTestKeyspace())
+	checkStatements(t, stmts,
+		"CREATE KEYSPACE ks WITH replication = { 'class': 'SimpleStrategy', 'replication_factor': '1' }",
+		"CREATE TYPE ks.country ( code text )",
+		"CREATE TYPE ks.address ( street text, country frozen<country> )",
+		"CREATE TABLE ks.events (",
+		"CREATE TABLE ks.users (",
+		"CREATE INDEX users_name ON ks.users (name)",
+		"CREATE FUNCTION ks.sum_state",
+		"CREATE AGGREGATE ks.total",
+		"CREATE MATERIALIZED VIEW ks.events_by_kind",
+	)
+	for _, stmt := range stmts {
+		if strings.HasSuffix(stmt, ";") {
+			t.Errorf("unexpected semicolon in %q", stmt)
+		}
+	}
+
+	if stmts := diffStatements(t, diffTestKeyspace(), diffTestKeyspace()); len(stmts) != 0 {
+		t.Fatalf("expected no changes, got %q", stmts)
+	}
+}
+
+func TestDiffKeyspacesDrop(t *testing.T) {
+	changes, err := DiffKeyspaces(diffTestKeyspace(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].Statement != "DROP KEYSPACE ks" || !changes[0].Destructive {
+		t.Fatalf("unexpected changes %+v", changes)
+	}
+
+	to := diffTestKeyspace()
+	delete(to.Tables, "users")
+	delete(to.Types, "address")
+	delete(to.Types, "country")
+	delete(to.Indexes, "users_name")
+	delete(to.Aggregates, "total")
+	delete(to.Functions, "sum_state")
+	delete(to.Views, "events_by_kind")
+	changes, err = DiffKeyspaces(diffTestKeyspace(), to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stmts []string
+	for _, c := range changes {
+		if c.Destructive != (c.Object == SchemaObjectTable) {
+			t.Errorf("unexpected destructive flag of %q", c.Statement)
+		}
+		stmts = append(stmts, c.Statement)
+	}
+	checkStatements(t, stmts,
+		"DROP MATERIALIZED VIEW ks.events_by_kind",
+		"DROP INDEX ks.users_name",
+		"DROP AGGREGATE ks.total(int)",
+		"DROP FUNCTION ks.sum_state(int, int)",
+		"DROP TABLE ks.users",
+		"DROP TYPE ks.address",
+		"DROP TYPE ks.country",
+	)
+}
+
+func TestDiffKeyspacesAlter(t *testing.T) {
+	to := diffTestKeyspace()
+	to.StrategyClass = "NetworkTopologyStrategy"
+	to.StrategyOptions = map[string]interface{}{"dc1": "3"}
+
+	users := to.Tables["users"]
+	delete(users.Columns, "home")
+	users.OrderedColumns = []string{"id", "name", "email", "version"}
+	users.Columns["email"] = diffTestColumn("email", "text", ColumnRegular)
+	users.Columns["version"] = diffTestColumn("version", "int", ColumnStatic)
+	users.Options.GcGraceSeconds = 3600
+	users.Options.CDC = map[string]string{"enabled": "true"}
+
+	to.Types["country"].FieldNames = append(to.Types["country"].FieldNames, "name")
+	to.Types["country"].FieldTypes = append(to.Types["country"].FieldTypes, "text")
+	to.Indexes["users_name"].Options = map[string]string{"target": "email"}
+	to.Functions["sum_state"].Body = "return state + v + 0"
+	to.Views["events_by_kind"].Options.Comment = "by kind"
+
+	stmts := diffStatements(t, diffTestKeyspace(), to)
+	checkStatements(t, stmts,
+		"ALTER KEYSPACE ks WITH replication = {'class': 'NetworkTopologyStrategy', 'dc1': '3'} AND durable_writes = true",
+		"DROP INDEX ks.users_name",
+		"ALTER TABLE ks.users DROP home",
+		"ALTER TYPE ks.country ADD name text",
+		"ALTER TABLE ks.users ADD email text",
+		"ALTER TABLE ks.users ADD version int static",
+		"ALTER TABLE ks.users WITH cdc = {'enabled':'true'} AND gc_grace_seconds = 3600",
+		"CREATE INDEX users_name ON ks.users (email)",
+		"CREATE OR REPLACE FUNCTION ks.sum_state",
+		"ALTER MATERIALIZED VIEW ks.events_by_kind WITH comment = 'by kind'",
+	)
+
+	// disabling CDC has to be explicit
+	back := diffTestKeyspace()
+	back.Types["country"] = to.Types["country"]
+	stmts = diffStatements(t, to, back)
+	for _, stmt := range stmts {
+		if strings.HasPrefix(stmt, "ALTER TABLE ks.users WITH") {
+			if stmt != "ALTER TABLE ks.users WITH cdc = {'enabled': 'false'} AND gc_grace_seconds = 864000" {
+				t.Fatalf("unexpected options %q", stmt)
+			}
+			return
+		}
+	}
+	t.Fatalf("expected the options to be altered: %q", stmts)
+}
+
+func TestDiffKeyspacesQuotedNames(t *testing.T) {
+	from := diffTestKeyspace()
+	from.Name = "Ks"
+	users := from.Tables["users"]
+	users.OrderedColumns = append(users.OrderedColumns, "from")
+	users.Columns["from"] = diffTestColumn("from", "text", ColumnRegular)
+
+	to := diffTestKeyspace()
+	to.Name = "Ks"
+	users = to.Tables["users"]
+	users.OrderedColumns = append(users.OrderedColumns, "Name", "email")
+	users.Columns["Name"] = diffTestColumn("Name", "text", ColumnRegular)
+	users.Columns["email"] = diffTestColumn("email", "text", ColumnRegular)
+	country := to.Types["country"]
+	country.FieldNames = append(country.FieldNames, "Code")
+	country.FieldTypes = append(country.FieldTypes, "text")
+
+	checkStatements(t, diffStatements(t, from, to),
+		`ALTER TABLE "Ks".users DROP "from"`,
+		`ALTER TYPE "Ks".country ADD "Code" text`,
+		`ALTER TABLE "Ks".users ADD "Name" text`,
+		`ALTER TABLE "Ks".users ADD email text`,
+	)
+}
+
+func TestDiffKeyspacesRecreateFunction(t *testing.T) {
+	to := diffTestKeyspace()
+	to.Functions["sum_state"].ArgumentTypes = []string{"int", "bigint"}
+
+	// the aggregate uses the function, it's recreated as well
+	stmts := diffStatements(t, diffTestKeyspace(), to)
+	checkStatements(t, stmts,
+		"DROP AGGREGATE ks.total(int)",
+		"DROP FUNCTION ks.sum_state(int, int)",
+		"CREATE FUNCTION ks.sum_state (state int, v bigint)",
+		"CREATE AGGREGATE ks.total",
+	)
+}
+
+func TestDiffKeyspacesIncompatible(t *testing.T) {
+	for name, change := range map[string]func(ks *KeyspaceMetadata){
+		"primary key": func(ks *KeyspaceMetadata) {
+			ks.Tables["events"].ClusteringColumns[0].ClusteringOrder = "DESC"
+		},
+		"column type": func(ks *KeyspaceMetadata) {
+			ks.Tables["users"].Columns["name"].Type = "int"
+		},
+		"type field": func(ks *KeyspaceMetadata) {
+			ks.Types["address"].FieldTypes[0] = "int"
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			to := diffTestKeyspace()
+			change(to)
+			if _, err := DiffKeyspaces(diffTestKeyspace(), to); !errors.Is(err, ErrIncompatibleSchemaChange) {
+				t.Fatalf("expected an incompatible change error, got %v", err)
+			}
+		})
+	}
+
+	other := diffTestKeyspace()
+	other.Name = "other"
+	if _, err := DiffKeyspaces(diffTestKeyspace(), other); err == nil {
+		t.Fatal("expected an error for different keyspaces")
+	}
+}
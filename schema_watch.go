@@ -0,0 +1,180 @@
+package gocql
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/gocql/gocql/internal/eventbus"
+)
+
+// MetadataChange is a created, altered or dropped schema object of a SchemaUpdate.
+// Old is nil if the object was created, New is nil if it was dropped.
+type MetadataChange[T any] struct {
+	Name string
+	Old  *T
+	New  *T
+}
+
+// SchemaUpdate is the change of the metadata of a keyspace delivered by WatchSchema.
+// The slices contain only the objects which changed, sorted by name.
+type SchemaUpdate struct {
+	Keyspace string
+	// Old is the metadata before the change, nil if the keyspace was created.
+	Old *KeyspaceMetadata
+	// New is the metadata after the change, nil if the keyspace was dropped.
+	New *KeyspaceMetadata
+	// KeyspaceAltered is true if the replication or durable writes of the keyspace changed.
+	KeyspaceAltered bool
+
+	Tables     []MetadataChange[TableMetadata]
+	Types      []MetadataChange[TypeMetadata]
+	Views      []MetadataChange[ViewMetadata]
+	Indexes    []MetadataChange[IndexMetadata]
+	Functions  []MetadataChange[FunctionMetadata]
+	Aggregates []MetadataChange[AggregateMetadata]
+}
+
+// empty reports whether nothing changed.
+func (u *SchemaUpdate) empty() bool {
+	return (u.Old == nil) == (u.New == nil) && !u.KeyspaceAltered && len(u.Tables) == 0 && len(u.Types) == 0 &&
+		len(u.Views) == 0 && len(u.Indexes) == 0 && len(u.Functions) == 0 && len(u.Aggregates) == 0
+}
+
+// WatchSchema subscribes to the schema changes of the keyspaces, which must not be empty.
+// After the driver receives a schema change event of a watched keyspace, it waits for schema agreement,
+// reloads the metadata of the keyspace and delivers the difference to the previous metadata as a SchemaUpdate.
+// A keyspace which doesn't exist yet can be watched, its creation is delivered with a nil Old.
+//
+// queueSize is the buffer size of the subscriber, updates are dropped when it's full.
+// The returned subscriber must be stopped when it's not needed anymore, the keyspaces are not
+// refreshed anymore once no subscriber watches them. Schema events must not be disabled with
+// ClusterConfig.Events.DisableSchemaEvents.
+func (s *Session) WatchSchema(name string, queueSize int, keyspaces ...string) (*eventbus.Subscriber[*SchemaUpdate], error) {
+	if len(keyspaces) == 0 {
+		return nil, errors.New("gocql: no keyspace to watch")
+	}
+	if s.cfg.Events.DisableSchemaEvents {
+		return nil, errors.New("gocql: can't watch the schema, schema events are disabled")
+	}
+	watched := make(map[string]bool, len(keyspaces))
+	unwatch := func() {
+		for keyspace := range watched {
+			s.metadataDescriber.unwatch(keyspace)
+		}
+	}
+	for _, keyspace := range keyspaces {
+		if watched[keyspace] {
+			continue
+		}
+		if err := s.metadataDescriber.watch(keyspace); err != nil {
+			unwatch()
+			return nil, err
+		}
+		watched[keyspace] = true
+	}
+	return s.schemaBus.SubscribeWithStop(name, queueSize, func(u *SchemaUpdate) bool {
+		return watched[u.Keyspace]
+	}, unwatch), nil
+}
+
+// watch loads the metadata of the keyspace, it's the baseline of the updates of the keyspace.
+func (s *metadataDescriber) watch(keyspaceName string) error {
+	keyspace, err := s.getSchema(keyspaceName)
+	if errors.Is(err, ErrKeyspaceDoesNotExist) {
+		keyspace = nil
+	} else if err != nil {
+		return fmt.Errorf("gocql: unable to watch keyspace %q: %w", keyspaceName, err)
+	}
+
+	s.watchMu.Lock()
+	defer s.watchMu.Unlock()
+	if s.watched == nil {
+		s.watched = make(map[string]*KeyspaceMetadata)
+		s.watchers = make(map[string]int)
+	}
+	if _, ok := s.watched[keyspaceName]; !ok {
+		s.watched[keyspaceName] = keyspace
+	}
+	s.watchers[keyspaceName]++
+	return nil
+}
+
+// unwatch stops watching the keyspace once no subscriber watches it.
+func (s *metadataDescriber) unwatch(keyspaceName string) {
+	s.watchMu.Lock()
+	defer s.watchMu.Unlock()
+	if s.watchers[keyspaceName]--; s.watchers[keyspaceName] <= 0 {
+		delete(s.watchers, keyspaceName)
+		delete(s.watched, keyspaceName)
+	}
+}
+
+// isWatched reports whether the keyspace is watched by WatchSchema.
+func (s *metadataDescriber) isWatched(keyspaceName string) bool {
+	s.watchMu.Lock()
+	defer s.watchMu.Unlock()
+	_, ok := s.watched[keyspaceName]
+	return ok
+}
+
+// notifyRefreshed is called with the reloaded metadata of the keyspace, nil if it doesn't exist.
+// It publishes the difference to the previous metadata if the keyspace is watched.
+func (s *metadataDescriber) notifyRefreshed(keyspaceName string, keyspace *KeyspaceMetadata) {
+	s.watchMu.Lock()
+	defer s.watchMu.Unlock()
+	old, ok := s.watched[keyspaceName]
+	if !ok {
+		return
+	}
+	s.watched[keyspaceName] = keyspace
+
+	update := diffKeyspaceMetadata(keyspaceName, old, keyspace)
+	if update.empty() {
+		return
+	}
+	if !s.session.schemaBus.PublishEvent(update) {
+		logEntry(s.session.logger, LogLevelWarn, "gocql: can't publish the schema update, the schema update queue is full; update is dropped",
+			"keyspace", keyspaceName)
+	}
+}
+
+func diffKeyspaceMetadata(keyspaceName string, old, new *KeyspaceMetadata) *SchemaUpdate {
+	u := &SchemaUpdate{Keyspace: keyspaceName, Old: old, New: new}
+	if old == nil {
+		old = &KeyspaceMetadata{}
+	}
+	if new == nil {
+		new = &KeyspaceMetadata{}
+	}
+	u.KeyspaceAltered = u.Old != nil && u.New != nil && (old.StrategyClass != new.StrategyClass ||
+		old.DurableWrites != new.DurableWrites || !compareInterfaceMaps(old.StrategyOptions, new.StrategyOptions))
+	u.Tables = diffMetadataMaps(old.Tables, new.Tables, (*TableMetadata).Equals)
+	u.Types = diffMetadataMaps(old.Types, new.Types, deepEqual[TypeMetadata])
+	u.Views = diffMetadataMaps(old.Views, new.Views, deepEqual[ViewMetadata])
+	u.Indexes = diffMetadataMaps(old.Indexes, new.Indexes, deepEqual[IndexMetadata])
+	u.Functions = diffMetadataMaps(old.Functions, new.Functions, deepEqual[FunctionMetadata])
+	u.Aggregates = diffMetadataMaps(old.Aggregates, new.Aggregates, deepEqual[AggregateMetadata])
+	return u
+}
+
+func diffMetadataMaps[T any](old, new map[string]*T, equal func(a, b *T) bool) []MetadataChange[T] {
+	var changes []MetadataChange[T]
+	for name, o := range old {
+		if n := new[name]; n == nil || !equal(o, n) {
+			changes = append(changes, MetadataChange[T]{Name: name, Old: o, New: n})
+		}
+	}
+	for name, n := range new {
+		if _, ok := old[name]; !ok {
+			changes = append(changes, MetadataChange[T]{Name: name, New: n})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	return changes
+}
+
+func deepEqual[T any](a, b *T) bool {
+	return reflect.DeepEqual(a, b)
+}
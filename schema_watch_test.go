@@ -0,0 +1,182 @@
+//go:build unit
+// +build unit
+
+package gocql_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+func TestWatchSchema(t *testing.T) {
+	c, err := gocqltest.NewCluster(gocqltest.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	var (
+		mu      sync.Mutex
+		exists  = true
+		columns = [][]interface{}{
+			{"users", "id", "none", "int", "partition_key", 0},
+			{"users", "name", "none", "text", "regular", -1},
+		}
+	)
+	c.When(`FROM system_schema\.keyspaces\s+WHERE keyspace_name = \?`).
+		Params(gocqltest.Col("keyspace_name", gocql.TypeText)).
+		Handle(func(req *gocqltest.Request) gocqltest.Response {
+			mu.Lock()
+			defer mu.Unlock()
+			if !exists {
+				return gocqltest.Rows(nil)
+			}
+			return gocqltest.Rows([]gocqltest.Column{
+				gocqltest.Col("durable_writes", gocql.TypeBoolean),
+				{Name: "replication", Type: gocqltest.MapOf(gocqltest.Native(gocql.TypeText), gocqltest.Native(gocql.TypeText))},
+			}, []interface{}{true, map[string]string{"class": "org.apache.cassandra.locator.SimpleStrategy", "replication_factor": "1"}})
+		})
+	c.When(`FROM system_schema\.tables WHERE keyspace_name = \?`).
+		Params(gocqltest.Col("keyspace_name", gocql.TypeText)).
+		Handle(func(req *gocqltest.Request) gocqltest.Response {
+			mu.Lock()
+			defer mu.Unlock()
+			if !exists {
+				return gocqltest.Rows(nil)
+			}
+			return gocqltest.Rows([]gocqltest.Column{gocqltest.Col("table_name", gocql.TypeText)}, []interface{}{"users"})
+		})
+	c.When(`FROM system_schema\.columns WHERE keyspace_name = \?`).
+		Params(gocqltest.Col("keyspace_name", gocql.TypeText)).
+		Handle(func(req *gocqltest.Request) gocqltest.Response {
+			mu.Lock()
+			defer mu.Unlock()
+			if !exists {
+				return gocqltest.Rows(nil)
+			}
+			return gocqltest.Rows([]gocqltest.Column{
+				gocqltest.Col("table_name", gocql.TypeText),
+				gocqltest.Col("column_name", gocql.TypeText),
+				gocqltest.Col("clustering_order", gocql.TypeText),
+				gocqltest.Col("type", gocql.TypeText),
+				gocqltest.Col("kind", gocql.TypeVarchar),
+				gocqltest.Col("position", gocql.TypeInt),
+			}, columns...)
+		})
+
+	cfg := c.ClusterConfig()
+	cfg.Timeout = time.Second
+	session, err := cfg.CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	if _, err := session.WatchSchema("test", 10); err == nil {
+		t.Fatal("expected an error without keyspaces")
+	}
+	sub, err := session.WatchSchema("test", 10, "ks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Stop()
+
+	next := func() *gocql.SchemaUpdate {
+		t.Helper()
+		select {
+		case u := <-sub.Events():
+			return u
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for a schema update")
+			return nil
+		}
+	}
+
+	mu.Lock()
+	columns = append(columns, []interface{}{"users", "email", "none", "text", "regular", -1})
+	mu.Unlock()
+	c.PushSchemaChange("UPDATED", "TABLE", "ks", "users")
+
+	u := next()
+	if u.Keyspace != "ks" || u.Old == nil || u.New == nil || u.KeyspaceAltered || len(u.Types) != 0 {
+		t.Fatalf("unexpected update %+v", u)
+	}
+	if len(u.Tables) != 1 || u.Tables[0].Name != "users" || u.Tables[0].Old == nil || u.Tables[0].New == nil {
+		t.Fatalf("expected the users table to change, got %+v", u.Tables)
+	}
+	if _, ok := u.Tables[0].Old.Columns["email"]; ok {
+		t.Fatal("the old metadata has the added column")
+	}
+	if col, ok := u.Tables[0].New.Columns["email"]; !ok || col.Type != "text" {
+		t.Fatalf("the new metadata doesn't have the added column: %+v", u.Tables[0].New.Columns)
+	}
+	if ks, err := session.KeyspaceMetadata("ks"); err != nil || ks.Tables["users"].Columns["email"] == nil {
+		t.Fatalf("expected the refreshed metadata, got %v", err)
+	}
+
+	mu.Lock()
+	exists = false
+	mu.Unlock()
+	c.PushSchemaChange("DROPPED", "KEYSPACE", "ks", "")
+
+	u = next()
+	if u.Old == nil || u.New != nil || len(u.Tables) != 1 || u.Tables[0].New != nil {
+		t.Fatalf("expected the keyspace to be dropped, got %+v", u)
+	}
+
+	// the keyspace is not refreshed anymore once the subscriber is stopped, the keyspace of another
+	// subscriber still is
+	other, err := session.WatchSchema("other", 10, "other")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Stop()
+	if err := sub.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	exists = true
+	mu.Unlock()
+	// let the refreshes of the dropped keyspace settle
+	time.Sleep(200 * time.Millisecond)
+	queries := c.Count(`FROM system_schema\.columns WHERE keyspace_name = \?`)
+	c.PushSchemaChange("UPDATED", "TABLE", "ks", "users")
+	c.PushSchemaChange("UPDATED", "TABLE", "other", "users")
+	select {
+	case u := <-other.Events():
+		if u.Keyspace != "other" || u.Old != nil || u.New == nil {
+			t.Fatalf("expected the other keyspace to be created, got %+v", u)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a schema update")
+	}
+	time.Sleep(200 * time.Millisecond)
+	if n := c.Count(`FROM system_schema\.columns WHERE keyspace_name = \?`) - queries; n != 1 {
+		t.Fatalf("expected only the watched keyspace to be refreshed, got %d refreshes", n)
+	}
+}
+
+func TestWatchSchemaEventsDisabled(t *testing.T) {
+	c, err := gocqltest.NewCluster(gocqltest.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	cfg := c.ClusterConfig()
+	cfg.Timeout = time.Second
+	cfg.Events.DisableSchemaEvents = true
+	session, err := cfg.CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	if _, err := session.WatchSchema("test", 10, "ks"); err == nil {
+		t.Fatal("expected an error watching the schema with schema events disabled")
+	}
+}
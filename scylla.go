@@ -2,7 +2,6 @@ package gocql
 
 import (
 	"context"
-	"crypto/tls"
 	"errors"
 	"fmt"
 	"math"
@@ -364,6 +363,14 @@ func (c *Conn) isScyllaConn() bool {
 	return c.getScyllaSupported().nrShards != 0
 }
 
+// observedShard returns the shard of the connection as reported to observers, -1 for non-Scylla connections.
+func (c *Conn) observedShard() int {
+	if !c.isScyllaConn() {
+		return -1
+	}
+	return c.getScyllaSupported().shard
+}
+
 // scyllaConnPicker is a specialised ConnPicker that selects connections based
 // on token trying to get connection to a shard containing the given token.
 // A list of excess connections is maintained to allow for lazy closing of
@@ -401,7 +408,7 @@ func newScyllaConnPicker(conn *Conn, logger StdLogger) *scyllaConnPicker {
 	}
 
 	if debug.Enabled {
-		logger.Printf("scylla: %s new conn picker sharding options %+v", addr, conn.scyllaSupported)
+		logEntry(logger, LogLevelDebug, "scylla: new conn picker", "addr", addr, "sharding", fmt.Sprintf("%+v", conn.scyllaSupported))
 	}
 
 	return &scyllaConnPicker{
@@ -526,8 +533,8 @@ func (p *scyllaConnPicker) Put(conn *Conn) error {
 
 	if nrShards != p.nrShards {
 		if debug.Enabled {
-			p.logger.Printf("scylla: %s shard count changed from %d to %d, rebuilding connection pool",
-				p.address, p.nrShards, nrShards)
+			logEntry(p.logger, LogLevelDebug, "scylla: shard count changed, rebuilding connection pool",
+				"addr", p.address, "from", p.nrShards, "to", nrShards)
 		}
 		p.handleShardCountChange(conn, nrShards)
 	} else if nrShards != len(p.conns) {
@@ -544,10 +551,9 @@ func (p *scyllaConnPicker) Put(conn *Conn) error {
 			// changes the source port along the way, therefore we can't trust
 			// the shard-aware port to return connection to the shard
 			// that we requested. Fall back to non-shard-aware port for some time.
-			p.logger.Printf(
-				"scylla: connection to shard-aware address %s resulted in wrong shard being assigned; please check that you are not behind a NAT or AddressTranslater which changes source ports; falling back to non-shard-aware port for %v",
-				p.address,
-				scyllaShardAwarePortFallbackDuration,
+			logEntry(p.logger, LogLevelWarn,
+				"scylla: connection to shard-aware address resulted in wrong shard being assigned; please check that you are not behind a NAT or AddressTranslater which changes source ports; falling back to non-shard-aware port",
+				"addr", p.address, "shard", shard, "fallback", scyllaShardAwarePortFallbackDuration,
 			)
 			until := time.Now().Add(scyllaShardAwarePortFallbackDuration)
 			p.disableShardAwarePortUntil.Store(until)
@@ -556,14 +562,16 @@ func (p *scyllaConnPicker) Put(conn *Conn) error {
 		} else {
 			p.excessConns = append(p.excessConns, conn)
 			if debug.Enabled {
-				p.logger.Printf("scylla: %s put shard %d excess connection total: %d missing: %d excess: %d", p.address, shard, p.nrConns, p.nrShards-p.nrConns, len(p.excessConns))
+				logEntry(p.logger, LogLevelDebug, "scylla: put excess connection",
+					"addr", p.address, "shard", shard, "total", p.nrConns, "missing", p.nrShards-p.nrConns, "excess", len(p.excessConns))
 			}
 		}
 	} else {
 		p.conns[shard] = conn
 		p.nrConns++
 		if debug.Enabled {
-			p.logger.Printf("scylla: %s put shard %d connection total: %d missing: %d", p.address, shard, p.nrConns, p.nrShards-p.nrConns)
+			logEntry(p.logger, LogLevelDebug, "scylla: put connection",
+				"addr", p.address, "shard", shard, "total", p.nrConns, "missing", p.nrShards-p.nrConns)
 		}
 	}
 
@@ -580,7 +588,7 @@ func (p *scyllaConnPicker) handleShardCountChange(newConn *Conn, newShardCount i
 	copy(oldConns, p.conns)
 
 	if debug.Enabled {
-		p.logger.Printf("scylla: %s handling shard topology change from %d to %d", p.address, oldShardCount, newShardCount)
+		logEntry(p.logger, LogLevelDebug, "scylla: handling shard topology change", "addr", p.address, "from", oldShardCount, "to", newShardCount)
 	}
 
 	newConns := make([]*Conn, newShardCount)
@@ -610,7 +618,8 @@ func (p *scyllaConnPicker) handleShardCountChange(newConn *Conn, newShardCount i
 	}
 
 	if debug.Enabled {
-		p.logger.Printf("scylla: %s migrated %d/%d connections to new shard topology, closing %d excess connections", p.address, migratedCount, len(oldConns), len(toClose))
+		logEntry(p.logger, LogLevelDebug, "scylla: migrated connections to new shard topology",
+			"addr", p.address, "migrated", migratedCount, "total", len(oldConns), "closing", len(toClose))
 	}
 }
 
@@ -640,12 +649,12 @@ func (p *scyllaConnPicker) Remove(conn *Conn) {
 		// It is possible for Remove to be called before the connection is added to the pool.
 		// Ignoring these connections here is safe.
 		if debug.Enabled {
-			p.logger.Printf("scylla: %s has unknown sharding state, ignoring it", p.address)
+			logEntry(p.logger, LogLevelDebug, "scylla: unknown sharding state, ignoring it", "addr", p.address)
 		}
 		return
 	}
 	if debug.Enabled {
-		p.logger.Printf("scylla: %s remove shard %d connection", p.address, shard)
+		logEntry(p.logger, LogLevelDebug, "scylla: remove connection", "addr", p.address, "shard", shard)
 	}
 
 	if p.conns[shard] != nil {
@@ -676,7 +685,7 @@ func (p *scyllaConnPicker) Close() {
 func (p *scyllaConnPicker) closeConns() {
 	if len(p.conns) == 0 {
 		if debug.Enabled {
-			p.logger.Printf("scylla: %s no connections to close", p.address)
+			logEntry(p.logger, LogLevelDebug, "scylla: no connections to close", "addr", p.address)
 		}
 		return
 	}
@@ -686,7 +695,7 @@ func (p *scyllaConnPicker) closeConns() {
 	p.nrConns = 0
 
 	if debug.Enabled {
-		p.logger.Printf("scylla: %s closing %d connections", p.address, len(conns))
+		logEntry(p.logger, LogLevelDebug, "scylla: closing connections", "addr", p.address, "count", len(conns))
 	}
 	go closeConns(conns...)
 }
@@ -694,7 +703,7 @@ func (p *scyllaConnPicker) closeConns() {
 func (p *scyllaConnPicker) closeExcessConns() {
 	if len(p.excessConns) == 0 {
 		if debug.Enabled {
-			p.logger.Printf("scylla: %s no excess connections to close", p.address)
+			logEntry(p.logger, LogLevelDebug, "scylla: no excess connections to close", "addr", p.address)
 		}
 		return
 	}
@@ -703,7 +712,7 @@ func (p *scyllaConnPicker) closeExcessConns() {
 	p.excessConns = nil
 
 	if debug.Enabled {
-		p.logger.Printf("scylla: %s closing %d excess connections", p.address, len(conns))
+		logEntry(p.logger, LogLevelDebug, "scylla: closing excess connections", "addr", p.address, "count", len(conns))
 	}
 	go closeConns(conns...)
 }
@@ -764,10 +773,11 @@ type ShardDialer interface {
 
 // A dialer which dials a particular shard
 type scyllaDialer struct {
-	dialer    Dialer
-	logger    StdLogger
-	tlsConfig *tls.Config
-	cfg       *ClusterConfig
+	dialer Dialer
+	logger StdLogger
+	// tls provides the TLS config of every new connection, nil if TLS is not used.
+	tls *tlsConfigSource
+	cfg *ClusterConfig
 }
 
 const scyllaShardAwarePortFallbackDuration time.Duration = 5 * time.Minute
@@ -792,7 +802,7 @@ func (sd *scyllaDialer) DialHost(ctx context.Context, host *HostInfo) (*DialedHo
 	if err != nil {
 		return nil, err
 	}
-	return WrapTLS(ctx, conn, addr, sd.tlsConfig)
+	return WrapTLS(ctx, conn, addr, sd.tls.get())
 }
 
 func (sd *scyllaDialer) DialShard(ctx context.Context, host *HostInfo, shardID, nrShards int) (*DialedHost, error) {
@@ -811,7 +821,7 @@ func (sd *scyllaDialer) DialShard(ctx context.Context, host *HostInfo, shardID,
 	translatedInfo := host.getTranslatedConnectionInfo()
 	if translatedInfo != nil {
 		addr = translatedInfo.CQL.ToNetAddr()
-		if sd.tlsConfig != nil {
+		if sd.tls != nil {
 			if translatedInfo.ShardAwareTLS.IsValid() {
 				shardAwareAddr = translatedInfo.ShardAwareTLS.ToNetAddr()
 			}
@@ -821,18 +831,18 @@ func (sd *scyllaDialer) DialShard(ctx context.Context, host *HostInfo, shardID,
 	}
 
 	if debug.Enabled {
-		sd.logger.Printf("scylla: connecting to shard %d", shardID)
+		logEntry(sd.logger, LogLevelDebug, "scylla: connecting to shard", "addr", addr, "shard", shardID)
 	}
 
-	conn, err := sd.dialShardAware(ctx, addr, shardAwareAddr, iter)
+	conn, err := sd.dialShardAware(ctx, addr, shardAwareAddr, shardID, iter)
 	if err != nil {
 		return nil, err
 	}
 
-	return WrapTLS(ctx, conn, addr, sd.tlsConfig)
+	return WrapTLS(ctx, conn, addr, sd.tls.get())
 }
 
-func (sd *scyllaDialer) dialShardAware(ctx context.Context, addr, shardAwareAddr string, iter *scyllaPortIterator) (net.Conn, error) {
+func (sd *scyllaDialer) dialShardAware(ctx context.Context, addr, shardAwareAddr string, shardID int, iter *scyllaPortIterator) (net.Conn, error) {
 	for {
 		port, ok := iter.Next()
 		if !ok {
@@ -858,11 +868,9 @@ func (sd *scyllaDialer) dialShardAware(ctx context.Context, addr, shardAwareAddr
 				// We can't avoid false positives here, so I'm putting it
 				// behind a debug flag.
 				if debug.Enabled {
-					sd.logger.Printf(
-						"scylla: %s couldn't connect to shard-aware address while the non-shard-aware address %s is available; this might be an issue with ",
-						addr,
-						shardAwareAddr,
-					)
+					logEntry(sd.logger, LogLevelDebug,
+						"scylla: couldn't connect to shard-aware address while the non-shard-aware address is available; this might be an issue with the shard-aware port",
+						"addr", addr, "shard_aware_addr", shardAwareAddr, "shard", shardID)
 				}
 			}
 			return conn, err
@@ -0,0 +1,292 @@
+package gocql
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// Protocol v5 "modern framing".
+//
+// Starting with native protocol v5 every message exchanged after STARTUP is
+// wrapped into segments. A segment carries either one or more complete frames
+// (self-contained) or a part of a single frame that is too big to fit into one
+// segment. Each segment header is protected by a CRC24 checksum and each payload
+// by a CRC32 checksum. When compression is negotiated it is applied per segment
+// instead of per frame, and only LZ4 is allowed.
+//
+// See https://github.com/apache/cassandra/blob/7337fc0/doc/native_protocol_v5.spec#L126-L200
+
+const (
+	maxSegmentPayloadSize = 128*1024 - 1
+
+	segmentHeaderSize           = 3
+	compressedSegmentHeaderSize = 5
+	segmentHeaderCRCSize        = 3
+	segmentPayloadCRCSize       = 4
+
+	segmentLengthMask = 1<<17 - 1
+
+	crc24Init = 0x875060
+	crc24Poly = 0x1974F0B
+)
+
+// segmentCRC32Initial is fed to the CRC32 digest before the payload bytes, as done by Cassandra.
+var segmentCRC32Initial = []byte{0xfa, 0x2d, 0x55, 0xca}
+
+// SegmentChecksumError is returned when a protocol v5 segment fails checksum validation.
+// Once it is returned the connection can no longer be used since the position in the byte
+// stream is lost, the connection is closed by the driver.
+type SegmentChecksumError struct {
+	// Part is either "header" or "payload".
+	Part     string
+	Expected uint32
+	Actual   uint32
+}
+
+func (e *SegmentChecksumError) Error() string {
+	return fmt.Sprintf("gocql: segment %s checksum mismatch: expected %#x got %#x", e.Part, e.Expected, e.Actual)
+}
+
+// crc24 computes the CRC24 used to protect segment headers.
+func crc24(buf []byte) uint32 {
+	crc := uint32(crc24Init)
+	for _, b := range buf {
+		crc ^= uint32(b) << 16
+		for i := 0; i < 8; i++ {
+			crc <<= 1
+			if crc&0x1000000 != 0 {
+				crc ^= crc24Poly
+			}
+		}
+	}
+	return crc & 0xFFFFFF
+}
+
+// segmentCRC32 computes the CRC32 used to protect segment payloads.
+func segmentCRC32(payload []byte) uint32 {
+	crc := crc32.Update(0, crc32.IEEETable, segmentCRC32Initial)
+	return crc32.Update(crc, crc32.IEEETable, payload)
+}
+
+func putUint24(b []byte, v uint32) {
+	b[0] = byte(v)
+	b[1] = byte(v >> 8)
+	b[2] = byte(v >> 16)
+}
+
+func getUint24(b []byte) uint32 {
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16
+}
+
+// segmentCodec encodes frames into segments.
+//
+// The compressor, if any, is expected to follow the format of the lz4 package: compressed
+// blocks are prefixed with 4 bytes of the uncompressed length in big endian order.
+// Segments carry the uncompressed length in their header, so the prefix is stripped
+// before sending and restored before decoding.
+type segmentCodec struct {
+	compressor Compressor
+}
+
+func newSegmentCodec(compressor Compressor) *segmentCodec {
+	return &segmentCodec{compressor: compressor}
+}
+
+func (s *segmentCodec) headerSize() int {
+	if s.compressor != nil {
+		return compressedSegmentHeaderSize
+	}
+	return segmentHeaderSize
+}
+
+// encode wraps an encoded frame into one self-contained segment, or into a sequence of
+// non self-contained segments if the frame does not fit into a single segment.
+func (s *segmentCodec) encode(frame []byte) ([]byte, error) {
+	selfContained := len(frame) <= maxSegmentPayloadSize
+	overhead := s.headerSize() + segmentHeaderCRCSize + segmentPayloadCRCSize
+	segments := (len(frame) + maxSegmentPayloadSize - 1) / maxSegmentPayloadSize
+	dst := make([]byte, 0, len(frame)+segments*overhead)
+
+	for len(frame) > 0 {
+		n := len(frame)
+		if n > maxSegmentPayloadSize {
+			n = maxSegmentPayloadSize
+		}
+
+		var err error
+		dst, err = s.appendSegment(dst, frame[:n], selfContained)
+		if err != nil {
+			return nil, err
+		}
+		frame = frame[n:]
+	}
+
+	return dst, nil
+}
+
+func (s *segmentCodec) appendSegment(dst, payload []byte, selfContained bool) ([]byte, error) {
+	var flag uint64
+	if selfContained {
+		flag = 1
+	}
+
+	var header [8]byte
+	hdrLen := s.headerSize()
+	if s.compressor == nil {
+		putUint24(header[:], uint32(uint64(len(payload))|flag<<17))
+	} else {
+		compressed, err := s.compressor.Encode(payload)
+		if err != nil {
+			return nil, err
+		}
+		if len(compressed) < 4 {
+			return nil, fmt.Errorf("gocql: %s compressor produced a block without length prefix", s.compressor.Name())
+		}
+
+		var uncompressedLen uint64
+		// Compression is only worth it if it saves space, otherwise the payload is sent
+		// as is and the uncompressed length is set to zero.
+		if compressed = compressed[4:]; len(compressed) < len(payload) {
+			uncompressedLen = uint64(len(payload))
+			payload = compressed
+		}
+
+		v := uint64(len(payload)) | uncompressedLen<<17 | flag<<34
+		binary.LittleEndian.PutUint32(header[:], uint32(v))
+		header[4] = byte(v >> 32)
+	}
+	putUint24(header[hdrLen:], crc24(header[:hdrLen]))
+
+	dst = append(dst, header[:hdrLen+segmentHeaderCRCSize]...)
+	dst = append(dst, payload...)
+	dst = binary.LittleEndian.AppendUint32(dst, segmentCRC32(payload))
+	return dst, nil
+}
+
+// segmentReader is an io.Reader which reads segments from the underlying reader and
+// returns their payloads, so the frames they carry can be read as a plain byte stream.
+// Frames split across multiple segments are reassembled transparently.
+//
+// segmentReader keeps the progress of a partially read segment, so a Read interrupted by
+// a temporary error can be retried. Checksum errors are permanent.
+type segmentReader struct {
+	r     io.Reader
+	codec *segmentCodec
+	err   error
+
+	header  [compressedSegmentHeaderSize + segmentHeaderCRCSize]byte
+	hdrRead int
+
+	// raw holds the segment payload and its CRC32 as read from the wire.
+	raw             []byte
+	rawRead         int
+	uncompressedLen int
+
+	payload []byte
+}
+
+func newSegmentReader(r io.Reader, codec *segmentCodec) *segmentReader {
+	return &segmentReader{r: r, codec: codec}
+}
+
+func (s *segmentReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	for len(s.payload) == 0 {
+		if s.err != nil {
+			return 0, s.err
+		}
+		if err := s.readSegment(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, s.payload)
+	s.payload = s.payload[n:]
+	return n, nil
+}
+
+func (s *segmentReader) readSegment() error {
+	hdrLen := s.codec.headerSize() + segmentHeaderCRCSize
+	for s.hdrRead < hdrLen {
+		n, err := s.r.Read(s.header[s.hdrRead:hdrLen])
+		s.hdrRead += n
+		if err != nil && s.hdrRead < hdrLen {
+			return err
+		}
+	}
+
+	if s.raw == nil {
+		payloadLen, uncompressedLen, err := s.parseHeader()
+		if err != nil {
+			s.err = err
+			return err
+		}
+		s.raw = make([]byte, payloadLen+segmentPayloadCRCSize)
+		s.rawRead = 0
+		s.uncompressedLen = uncompressedLen
+	}
+
+	for s.rawRead < len(s.raw) {
+		n, err := s.r.Read(s.raw[s.rawRead:])
+		s.rawRead += n
+		if err != nil && s.rawRead < len(s.raw) {
+			return err
+		}
+	}
+
+	uncompressedLen := s.uncompressedLen
+	raw := s.raw
+	s.raw = nil
+	s.hdrRead = 0
+
+	payload := raw[:len(raw)-segmentPayloadCRCSize]
+	expected := binary.LittleEndian.Uint32(raw[len(payload):])
+	if actual := segmentCRC32(payload); actual != expected {
+		s.err = &SegmentChecksumError{Part: "payload", Expected: expected, Actual: actual}
+		return s.err
+	}
+
+	if uncompressedLen > 0 {
+		block := make([]byte, 4+len(payload))
+		binary.BigEndian.PutUint32(block, uint32(uncompressedLen))
+		copy(block[4:], payload)
+
+		decoded, err := s.codec.compressor.Decode(block)
+		if err != nil {
+			s.err = err
+			return err
+		}
+		if len(decoded) != uncompressedLen {
+			s.err = NewErrProtocol("segment decompressed to %d bytes, expected %d", len(decoded), uncompressedLen)
+			return s.err
+		}
+		payload = decoded
+	}
+
+	s.payload = payload
+	return nil
+}
+
+func (s *segmentReader) parseHeader() (payloadLen, uncompressedLen int, err error) {
+	hdrLen := s.codec.headerSize()
+	expected := getUint24(s.header[hdrLen:])
+	if actual := crc24(s.header[:hdrLen]); actual != expected {
+		return 0, 0, &SegmentChecksumError{Part: "header", Expected: expected, Actual: actual}
+	}
+
+	var v uint64
+	if hdrLen == compressedSegmentHeaderSize {
+		v = uint64(binary.LittleEndian.Uint32(s.header[:])) | uint64(s.header[4])<<32
+		uncompressedLen = int(v >> 17 & segmentLengthMask)
+	} else {
+		v = uint64(getUint24(s.header[:]))
+	}
+	payloadLen = int(v & segmentLengthMask)
+
+	return payloadLen, uncompressedLen, nil
+}
@@ -0,0 +1,496 @@
+//go:build unit
+// +build unit
+
+package gocql
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"testing"
+	"testing/iotest"
+
+	"github.com/klauspost/compress/s2"
+
+	frm "github.com/gocql/gocql/internal/frame"
+)
+
+// testSegmentCompressor mimics the block format of lz4.LZ4Compressor, which prefixes
+// compressed blocks with the uncompressed length.
+type testSegmentCompressor struct{}
+
+func (testSegmentCompressor) Name() string {
+	return "lz4"
+}
+
+func (testSegmentCompressor) Encode(data []byte) ([]byte, error) {
+	buf := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	return append(buf, s2.Encode(nil, data)...), nil
+}
+
+func (testSegmentCompressor) Decode(data []byte) ([]byte, error) {
+	return s2.Decode(nil, data[4:])
+}
+
+// lz4SegmentCompressor writes and reads the blocks of lz4.LZ4Compressor, LZ4 blocks prefixed
+// with the uncompressed length. The lz4 package lives in a separate module so that the driver
+// doesn't depend on github.com/pierrec/lz4, hence the block format is implemented here, with a
+// greedy matcher, and checked against a block produced by lz4.LZ4Compressor in TestLZ4SegmentCompressor.
+type lz4SegmentCompressor struct{}
+
+func (lz4SegmentCompressor) Name() string {
+	return "lz4"
+}
+
+func (lz4SegmentCompressor) Encode(data []byte) ([]byte, error) {
+	buf := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+
+	// the last match has to start at least 12 bytes and end at least 5 bytes before the end of the block
+	const minMatch, lastLiterals, matchLimit = 4, 5, 12
+	table := make(map[uint32]int)
+	anchor := 0
+	for i := 0; i < len(data)-matchLimit; {
+		seq := binary.LittleEndian.Uint32(data[i:])
+		ref, ok := table[seq]
+		table[seq] = i
+		if !ok || i-ref > math.MaxUint16 {
+			i++
+			continue
+		}
+
+		n := minMatch
+		for i+n < len(data)-lastLiterals && data[ref+n] == data[i+n] {
+			n++
+		}
+		buf = appendLZ4Sequence(buf, data[anchor:i], i-ref, n-minMatch)
+		i += n
+		anchor = i
+	}
+	return appendLZ4Sequence(buf, data[anchor:], 0, 0), nil
+}
+
+// appendLZ4Sequence appends literals followed by a match, the last sequence of a block has no match
+// and is written with a zero offset.
+func appendLZ4Sequence(buf, literals []byte, offset, matchLen int) []byte {
+	token := byte(min(len(literals), 15)) << 4
+	if offset > 0 {
+		token |= byte(min(matchLen, 15))
+	}
+	buf = append(buf, token)
+	buf = appendLZ4Length(buf, len(literals))
+	buf = append(buf, literals...)
+	if offset == 0 {
+		return buf
+	}
+	buf = binary.LittleEndian.AppendUint16(buf, uint16(offset))
+	return appendLZ4Length(buf, matchLen)
+}
+
+func appendLZ4Length(buf []byte, n int) []byte {
+	if n < 15 {
+		return buf
+	}
+	for n -= 15; n >= 255; n -= 255 {
+		buf = append(buf, 255)
+	}
+	return append(buf, byte(n))
+}
+
+func (lz4SegmentCompressor) Decode(data []byte) ([]byte, error) {
+	errCorrupt := errors.New("lz4: corrupt block")
+	if len(data) < 4 {
+		return nil, errCorrupt
+	}
+	out := make([]byte, 0, binary.BigEndian.Uint32(data))
+
+	readLength := func(src []byte, n int) (int, []byte, error) {
+		if n < 15 {
+			return n, src, nil
+		}
+		for {
+			if len(src) == 0 {
+				return 0, nil, errCorrupt
+			}
+			b := src[0]
+			src = src[1:]
+			n += int(b)
+			if b != 255 {
+				return n, src, nil
+			}
+		}
+	}
+
+	src := data[4:]
+	for len(src) > 0 {
+		token := src[0]
+		literals, rest, err := readLength(src[1:], int(token>>4))
+		if err != nil || literals > len(rest) {
+			return nil, errCorrupt
+		}
+		out = append(out, rest[:literals]...)
+		src = rest[literals:]
+		if len(src) == 0 {
+			break
+		}
+
+		if len(src) < 2 {
+			return nil, errCorrupt
+		}
+		offset := int(binary.LittleEndian.Uint16(src))
+		matchLen, rest, err := readLength(src[2:], int(token&15))
+		if err != nil || offset == 0 || offset > len(out) {
+			return nil, errCorrupt
+		}
+		src = rest
+		for i := 0; i < matchLen+4; i++ {
+			out = append(out, out[len(out)-offset])
+		}
+	}
+	if len(out) != cap(out) {
+		return nil, errCorrupt
+	}
+	return out, nil
+}
+
+func TestLZ4SegmentCompressor(t *testing.T) {
+	t.Parallel()
+
+	// produced by lz4.LZ4Compressor
+	const text = "SELECT id, name FROM ks.users WHERE id = ?; SELECT id, name FROM ks.users WHERE id = ?; SELECT id, name FROM ks.users WHERE id = ?;"
+	block, err := hex.DecodeString("00000083ff1d53454c4543542069642c206e616d652046524f4d206b732e7573657273205748455245206964203d203f3b202c0031005800005800b0455245206964203d203f3b")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := lz4SegmentCompressor{}.Decode(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(decoded) != text {
+		t.Fatalf("expected %q, got %q", text, decoded)
+	}
+
+	for _, payload := range [][]byte{nil, []byte(text), segmentTestPayload(100000, true), segmentTestPayload(100000, false)} {
+		encoded, err := lz4SegmentCompressor{}.Encode(payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := lz4SegmentCompressor{}.Decode(encoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(decoded, payload) {
+			t.Fatalf("%d bytes: decoded payload does not match the original", len(payload))
+		}
+	}
+}
+
+func segmentTestPayload(n int, compressible bool) []byte {
+	p := make([]byte, n)
+	for i := range p {
+		if compressible {
+			p[i] = byte(i % 7)
+		} else {
+			p[i] = byte(i*7919 + i>>3)
+		}
+	}
+	return p
+}
+
+func TestSegmentCodecRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	sizes := []int{1, 9, 1024, maxSegmentPayloadSize, maxSegmentPayloadSize + 1, 3*maxSegmentPayloadSize + 17}
+	compressors := map[string]Compressor{
+		"uncompressed": nil,
+		"compressed":   testSegmentCompressor{},
+		"lz4":          lz4SegmentCompressor{},
+	}
+
+	for name, compressor := range compressors {
+		for _, size := range sizes {
+			for _, compressible := range []bool{true, false} {
+				codec := newSegmentCodec(compressor)
+				payload := segmentTestPayload(size, compressible)
+
+				encoded, err := codec.encode(payload)
+				if err != nil {
+					t.Fatalf("%s/%d: encode failed: %v", name, size, err)
+				}
+
+				decoded, err := io.ReadAll(newSegmentReader(bytes.NewReader(encoded), codec))
+				if err != nil {
+					t.Fatalf("%s/%d: decode failed: %v", name, size, err)
+				}
+				if !bytes.Equal(decoded, payload) {
+					t.Fatalf("%s/%d: decoded payload does not match the original", name, size)
+				}
+			}
+		}
+	}
+}
+
+func TestSegmentCodecLZ4(t *testing.T) {
+	t.Parallel()
+
+	random := make([]byte, 3*maxSegmentPayloadSize+17)
+	rand.New(rand.NewSource(1)).Read(random)
+
+	tests := []struct {
+		name       string
+		payload    []byte
+		compressed bool
+	}{
+		{name: "compressible", payload: segmentTestPayload(1024, true), compressed: true},
+		{name: "compressible multi segment", payload: segmentTestPayload(2*maxSegmentPayloadSize, true), compressed: true},
+		{name: "incompressible", payload: random[:1024]},
+		{name: "incompressible multi segment", payload: random},
+		{name: "too short", payload: []byte{1}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			codec := newSegmentCodec(lz4SegmentCompressor{})
+
+			encoded, err := codec.encode(test.payload)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// check the header of every segment, compressed segments carry the length of
+			// the uncompressed payload and uncompressed ones have it set to 0
+			var total int
+			for rest := encoded; len(rest) > 0; {
+				hdr := binary.LittleEndian.Uint64(append(rest[:compressedSegmentHeaderSize:compressedSegmentHeaderSize], 0, 0, 0))
+				payloadLen := int(hdr & segmentLengthMask)
+				uncompressedLen := int(hdr >> 17 & segmentLengthMask)
+				if test.compressed {
+					if uncompressedLen == 0 || payloadLen >= uncompressedLen {
+						t.Fatalf("expected a compressed segment, payload length %d uncompressed length %d", payloadLen, uncompressedLen)
+					}
+					total += uncompressedLen
+				} else {
+					if uncompressedLen != 0 {
+						t.Fatalf("expected an uncompressed segment, got uncompressed length %d", uncompressedLen)
+					}
+					total += payloadLen
+				}
+				rest = rest[compressedSegmentHeaderSize+segmentHeaderCRCSize+payloadLen+segmentPayloadCRCSize:]
+			}
+			if total != len(test.payload) {
+				t.Fatalf("expected segments to carry %d bytes, got %d", len(test.payload), total)
+			}
+
+			decoded, err := io.ReadAll(newSegmentReader(bytes.NewReader(encoded), codec))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(decoded, test.payload) {
+				t.Fatal("decoded payload does not match the original")
+			}
+		})
+	}
+}
+
+func TestSegmentCodecSelfContained(t *testing.T) {
+	t.Parallel()
+
+	codec := newSegmentCodec(nil)
+
+	encoded, err := codec.encode(segmentTestPayload(100, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hdr := getUint24(encoded); hdr>>17&1 != 1 {
+		t.Fatalf("expected small frame to be sent in a self-contained segment, header=%#x", hdr)
+	}
+
+	encoded, err = codec.encode(segmentTestPayload(maxSegmentPayloadSize+1, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := getUint24(encoded)
+	if first>>17&1 != 0 {
+		t.Fatalf("expected large frame to be split into non self-contained segments, header=%#x", first)
+	}
+	if l := first & segmentLengthMask; l != maxSegmentPayloadSize {
+		t.Fatalf("expected first segment to be full, got length %d", l)
+	}
+	second := getUint24(encoded[segmentHeaderSize+segmentHeaderCRCSize+maxSegmentPayloadSize+segmentPayloadCRCSize:])
+	if l := second & segmentLengthMask; l != 1 {
+		t.Fatalf("expected second segment to carry the last byte, got length %d", l)
+	}
+}
+
+func TestSegmentReaderChecksumErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		compressor Compressor
+		corrupt    func(encoded []byte)
+		part       string
+	}{
+		{
+			name:    "header",
+			corrupt: func(b []byte) { b[0] ^= 0x01 },
+			part:    "header",
+		},
+		{
+			name:    "header crc",
+			corrupt: func(b []byte) { b[segmentHeaderSize] ^= 0x01 },
+			part:    "header",
+		},
+		{
+			name:    "payload",
+			corrupt: func(b []byte) { b[segmentHeaderSize+segmentHeaderCRCSize] ^= 0x01 },
+			part:    "payload",
+		},
+		{
+			name:    "payload crc",
+			corrupt: func(b []byte) { b[len(b)-1] ^= 0x01 },
+			part:    "payload",
+		},
+		{
+			name:       "compressed header",
+			compressor: testSegmentCompressor{},
+			corrupt:    func(b []byte) { b[4] ^= 0x80 },
+			part:       "header",
+		},
+		{
+			name:       "compressed payload",
+			compressor: testSegmentCompressor{},
+			corrupt:    func(b []byte) { b[compressedSegmentHeaderSize+segmentHeaderCRCSize] ^= 0x01 },
+			part:       "payload",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			codec := newSegmentCodec(test.compressor)
+			encoded, err := codec.encode(segmentTestPayload(512, true))
+			if err != nil {
+				t.Fatal(err)
+			}
+			test.corrupt(encoded)
+
+			r := newSegmentReader(bytes.NewReader(encoded), codec)
+			_, err = io.ReadAll(r)
+
+			var checksumErr *SegmentChecksumError
+			if !errors.As(err, &checksumErr) {
+				t.Fatalf("expected SegmentChecksumError got %v", err)
+			}
+			if checksumErr.Part != test.part {
+				t.Fatalf("expected %s checksum error got %s", test.part, checksumErr.Part)
+			}
+
+			// the error is permanent
+			if _, err := r.Read(make([]byte, 1)); err != checksumErr {
+				t.Fatalf("expected the same error on subsequent reads, got %v", err)
+			}
+		})
+	}
+}
+
+func TestSegmentReaderResumesAfterTimeout(t *testing.T) {
+	t.Parallel()
+
+	codec := newSegmentCodec(testSegmentCompressor{})
+	payload := segmentTestPayload(2*maxSegmentPayloadSize+100, true)
+	encoded, err := codec.encode(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := newSegmentReader(iotest.TimeoutReader(iotest.HalfReader(bytes.NewReader(encoded))), codec)
+
+	var decoded []byte
+	buf := make([]byte, 1000)
+	timeouts := 0
+	for {
+		n, err := r.Read(buf)
+		decoded = append(decoded, buf[:n]...)
+		if err == iotest.ErrTimeout {
+			timeouts++
+			continue
+		} else if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if timeouts != 1 {
+		t.Fatalf("expected exactly one timeout, got %d", timeouts)
+	}
+	if !bytes.Equal(decoded, payload) {
+		t.Fatal("decoded payload does not match the original")
+	}
+}
+
+func TestSegmentFramerOverConn(t *testing.T) {
+	t.Parallel()
+
+	for _, compressor := range []Compressor{nil, testSegmentCompressor{}, lz4SegmentCompressor{}} {
+		codec := newSegmentCodec(compressor)
+
+		server, client := net.Pipe()
+
+		// a frame which needs to be reassembled from several segments followed by a
+		// small one which is sent in a self-contained segment
+		bodies := [][]byte{
+			segmentTestPayload(3*maxSegmentPayloadSize, false),
+			segmentTestPayload(64, true),
+		}
+
+		go func() {
+			defer server.Close()
+			for i, body := range bodies {
+				f := newFramer(nil, protoVersion5|protoDirectionMask)
+				f.writeHeader(0, frm.OpResult, i+1)
+				f.writeBytes(body)
+				if err := f.finish(); err != nil {
+					t.Error(err)
+					return
+				}
+
+				encoded, err := codec.encode(f.buf)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if _, err := server.Write(encoded); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+
+		r := bufio.NewReader(newSegmentReader(bufio.NewReader(client), codec))
+		for i, body := range bodies {
+			head, err := readHeader(r, make([]byte, headSize))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if head.Stream != i+1 {
+				t.Fatalf("expected stream %d got %d", i+1, head.Stream)
+			}
+
+			f := newFramer(nil, protoVersion5)
+			if err := f.readFrame(r, &head); err != nil {
+				t.Fatal(err)
+			}
+			if got := f.readBytes(); !bytes.Equal(got, body) {
+				t.Fatalf("frame %d: body does not match", i)
+			}
+		}
+		client.Close()
+	}
+}
@@ -62,7 +62,10 @@ type Session struct {
 	logger                    StdLogger
 	trace                     Tracer
 	policy                    HostSelectionPolicy
+	policies                  hostSelectionPolicies
+	executionProfiles         map[string]*ExecutionProfile
 	batchObserver             BatchObserver
+	executionObserver         ExecutionObserver
 	connectObserver           ConnectObserver
 	frameObserver             FrameHeaderObserver
 	streamObserver            StreamObserver
@@ -74,10 +77,13 @@ type Session struct {
 	ringRefresher             *debounce.RefreshDebouncer
 	readyCh                   chan struct{}
 	executor                  *queryExecutor
+	metrics                   *sessionMetrics
 	cancel                    context.CancelFunc
 	schemaEvents              *eventDebouncer
 	metadataDescriber         *metadataDescriber
 	eventBus                  *eventbus.EventBus[events.Event]
+	schemaBus                 *eventbus.EventBus[*SchemaUpdate]
+	warmStartSnapshot         *warmStartSnapshot
 	connCfg                   *ConnConfig
 	clientRoutesHandler       *ClientRoutesHandler
 	routingKeyInfoCache       routingKeyInfoLRU
@@ -141,6 +147,7 @@ func newSessionCommon(cfg ClusterConfig) (*Session, error) {
 		logger:            cfg.logger(),
 		addressTranslator: cfg.AddressTranslator,
 		readyCh:           make(chan struct{}, 1),
+		metrics:           newSessionMetrics(),
 	}
 
 	if cfg.ClientRoutesConfig != nil {
@@ -158,10 +165,18 @@ func newSessionCommon(cfg ClusterConfig) (*Session, error) {
 
 	s.metadataDescriber = newMetadataDescriber(s)
 
+	if cfg.WarmStart != nil {
+		s.warmStartSnapshot = loadWarmStart(cfg.WarmStart, s.logger)
+	}
+
 	s.eventBus = eventbus.New[events.Event](cfg.EventBusConfig, cfg.Logger)
 	if err = s.eventBus.Start(); err != nil {
 		return nil, fmt.Errorf("gocql: unable to create session: %v", err)
 	}
+	s.schemaBus = eventbus.New[*SchemaUpdate](cfg.EventBusConfig, cfg.Logger)
+	if err = s.schemaBus.Start(); err != nil {
+		return nil, fmt.Errorf("gocql: unable to create session: %v", err)
+	}
 
 	s.nodeEvents = newEventDebouncer("NodeEvents", s.handleNodeEvent, s.logger)
 	s.schemaEvents = newEventDebouncer("SchemaEvents", s.handleSchemaEvent, s.logger)
@@ -181,13 +196,25 @@ func newSessionCommon(cfg ClusterConfig) (*Session, error) {
 	s.policy = cfg.PoolConfig.HostSelectionPolicy
 	s.policy.Init(s)
 
+	s.executionProfiles = make(map[string]*ExecutionProfile, len(cfg.ExecutionProfiles))
+	for name, p := range cfg.ExecutionProfiles {
+		s.executionProfiles[name] = p
+	}
+	s.policies = newHostSelectionPolicies(s.policy, s.executionProfiles)
+	for _, p := range s.policies.profiles() {
+		p.Init(s)
+	}
+
 	s.executor = &queryExecutor{
-		pool:   s.pool,
-		policy: cfg.PoolConfig.HostSelectionPolicy,
+		pool:    s.pool,
+		policy:  cfg.PoolConfig.HostSelectionPolicy,
+		metrics: s.metrics,
 	}
+	s.executor.latencyTracker, _ = s.policy.(LatencyTracker)
 
 	s.queryObserver = cfg.QueryObserver
 	s.batchObserver = cfg.BatchObserver
+	s.executionObserver = cfg.ExecutionObserver
 	s.connectObserver = cfg.ConnectObserver
 	s.frameObserver = cfg.FrameHeaderObserver
 	s.streamObserver = cfg.StreamObserver
@@ -202,6 +229,13 @@ func newSessionCommon(cfg ClusterConfig) (*Session, error) {
 	if cfg.WarningsHandlerBuilder != nil {
 		s.warningHandler = cfg.WarningsHandlerBuilder(s)
 	}
+	if cfg.MetricsExporter != nil {
+		interval := cfg.MetricsExportInterval
+		if interval <= 0 {
+			interval = defaultMetricsExportInterval
+		}
+		go s.exportMetrics(ctx, cfg.MetricsExporter, interval)
+	}
 	return s, nil
 }
 
@@ -272,44 +306,54 @@ func (s *Session) init() error {
 	var partitioner string
 
 	if !s.cfg.disableControlConn {
+		// the hosts of the warm start snapshot are only tried once none of the configured hosts
+		// can be reached, a stale snapshot must not take precedence over the configuration
+		var snapshotHosts []*HostInfo
+		if s.warmStartSnapshot != nil {
+			snapshotHosts = s.warmStartSnapshot.contactPoints(hosts)
+		}
+
 		s.control = createControlConn(s)
 		reconnectionPolicy := s.cfg.InitialReconnectionPolicy
+		fromSnapshot := false
 		for i := 0; i < reconnectionPolicy.GetMaxRetries(); i++ {
 			if i != 0 {
 				time.Sleep(reconnectionPolicy.GetInterval(i))
 			}
 
-			if s.cfg.ProtoVersion == 0 {
-				var proto int
-				proto, err = s.control.discoverProtocol(hosts)
-				if err != nil {
-					err = fmt.Errorf("unable to discover protocol version: %w\n", err)
-					if debug.Enabled {
-						s.logger.Println(err.Error())
-					}
-					continue
-				} else if proto == 0 {
-					return errors.New("unable to discovery protocol version")
+			err = s.connectControl(hosts)
+			if err != nil && len(snapshotHosts) > 0 && !errors.Is(err, errNoProtocolVersion) {
+				logEntry(s.logger, LogLevelWarn, "gocql: unable to connect to the configured hosts, trying the hosts of the warm start snapshot",
+					"path", s.cfg.WarmStart.Path, "err", err)
+				if snapshotErr := s.connectControl(snapshotHosts); snapshotErr == nil {
+					err, fromSnapshot = nil, true
 				}
-
-				// TODO(zariel): we really only need this in 1 place
-				s.cfg.ProtoVersion = proto
-				s.connCfg.ProtoVersion = proto
 			}
-
-			if err = s.control.connect(hosts); err != nil {
-				err = fmt.Errorf("unable to create control connection: %w\n", err)
-				if debug.Enabled {
-					s.logger.Println(err.Error())
-				}
-				continue
+			if err == nil || errors.Is(err, errNoProtocolVersion) {
+				break
 			}
-			break
+		}
+		if errors.Is(err, errNoProtocolVersion) {
+			return err
 		}
 		if err != nil {
 			return fmt.Errorf("unable to connect to the cluster, last error: %w", err)
 		}
 
+		if snapshot := s.warmStartSnapshot; snapshot != nil {
+			name := s.control.getConn().host.ClusterName()
+			if fromSnapshot && name != snapshot.ClusterName {
+				s.control.close()
+				return fmt.Errorf("gocql: unable to connect to the cluster, the hosts of the warm start snapshot of cluster %q are part of cluster %q",
+					snapshot.ClusterName, name)
+			}
+			if name != "" && snapshot.ClusterName != "" && name != snapshot.ClusterName {
+				logEntry(s.logger, LogLevelWarn, "gocql: ignoring the warm start snapshot of another cluster",
+					"path", s.cfg.WarmStart.Path, "cluster", snapshot.ClusterName)
+				s.warmStartSnapshot = nil
+			}
+		}
+
 		conn := s.control.getConn().conn.(*Conn)
 		conn.mu.Lock()
 		s.tabletsRoutingV1 = conn.isTabletSupported()
@@ -353,7 +397,7 @@ func (s *Session) init() error {
 	}
 
 	if partitioner != "" {
-		s.policy.SetPartitioner(partitioner)
+		s.policies.SetPartitioner(partitioner)
 	}
 
 	hostMap := make(map[string]*HostInfo, len(hosts))
@@ -406,16 +450,7 @@ func (s *Session) init() error {
 
 	// before waiting for them to connect, add them all to the policy so we can
 	// utilize efficiencies by calling AddHosts if the policy supports it
-	type bulkAddHosts interface {
-		AddHosts([]*HostInfo)
-	}
-	if v, ok := s.policy.(bulkAddHosts); ok {
-		v.AddHosts(hosts)
-	} else {
-		for _, host := range hosts {
-			s.policy.AddHost(host)
-		}
-	}
+	s.policies.AddHosts(hosts)
 
 	readyPolicy, _ := s.policy.(ReadyPolicy)
 	// now loop over connectedCh until it's closed (meaning we've connected to all)
@@ -440,13 +475,21 @@ func (s *Session) init() error {
 	// Invoke KeyspaceChanged to let the policy cache the session keyspace
 	// parameters. This is used by tokenAwareHostPolicy to discover replicas.
 	if !s.cfg.disableControlConn && s.cfg.Keyspace != "" {
-		s.policy.KeyspaceChanged(KeyspaceUpdateEvent{Keyspace: s.cfg.Keyspace})
+		s.policies.KeyspaceChanged(KeyspaceUpdateEvent{Keyspace: s.cfg.Keyspace})
 	}
 
-	if err = s.policy.IsOperational(s); err != nil {
+	if err = s.policies.IsOperational(s); err != nil {
 		return fmt.Errorf("gocql: unable to create session: %v", err)
 	}
 
+	if s.cfg.WarmStart != nil {
+		if s.warmStartSnapshot != nil && s.control != nil {
+			s.warmStart(s.warmStartSnapshot)
+			s.warmStartSnapshot = nil
+		}
+		go s.writeWarmStartLoop(s.ctx)
+	}
+
 	s.sessionStateMu.Lock()
 	s.isInitialized = true
 	s.sessionStateMu.Unlock()
@@ -454,6 +497,38 @@ func (s *Session) init() error {
 	return nil
 }
 
+var errNoProtocolVersion = errors.New("unable to discovery protocol version")
+
+// connectControl discovers the protocol version, unless it's configured, and connects the control
+// connection to one of the hosts.
+func (s *Session) connectControl(hosts []*HostInfo) error {
+	if s.cfg.ProtoVersion == 0 {
+		proto, err := s.control.discoverProtocol(hosts)
+		if err != nil {
+			err = fmt.Errorf("unable to discover protocol version: %w\n", err)
+			if debug.Enabled {
+				s.logger.Println(err.Error())
+			}
+			return err
+		} else if proto == 0 {
+			return errNoProtocolVersion
+		}
+
+		// TODO(zariel): we really only need this in 1 place
+		s.cfg.ProtoVersion = proto
+		s.connCfg.ProtoVersion = proto
+	}
+
+	if err := s.control.connect(hosts); err != nil {
+		err = fmt.Errorf("unable to create control connection: %w\n", err)
+		if debug.Enabled {
+			s.logger.Println(err.Error())
+		}
+		return err
+	}
+	return nil
+}
+
 // AwaitSchemaAgreement will wait until schema versions across all nodes in the
 // cluster are the same (as seen from the point of view of the control connection).
 // The maximum amount of time this takes is governed
@@ -556,7 +631,6 @@ func (s *Session) Query(stmt string, values ...interface{}) *Query {
 	qry.hostID = ""
 	qry.defaultsFromSession()
 	qry.routingInfo.lwt = false
-	qry.SetRequestTimeout(s.cfg.Timeout)
 	return qry
 }
 
@@ -580,7 +654,6 @@ func (s *Session) Bind(stmt string, b func(q *QueryInfo) ([]interface{}, error))
 	qry.binding = b
 	qry.defaultsFromSession()
 	qry.routingInfo.lwt = false
-	qry.SetRequestTimeout(s.cfg.Timeout)
 	return qry
 }
 
@@ -594,8 +667,15 @@ func (s *Session) Close() {
 		return
 	}
 	s.isClosing = true
+	initialized := s.isInitialized
 	s.sessionStateMu.Unlock()
 
+	if initialized && s.cfg.WarmStart != nil {
+		if err := s.writeWarmStart(); err != nil {
+			logEntry(s.logger, LogLevelWarn, "gocql: unable to write the warm start snapshot", "path", s.cfg.WarmStart.Path, "err", err)
+		}
+	}
+
 	if s.pool != nil {
 		s.pool.Close()
 	}
@@ -616,6 +696,10 @@ func (s *Session) Close() {
 		_ = s.eventBus.Stop()
 	}
 
+	if s.schemaBus != nil {
+		_ = s.schemaBus.Stop()
+	}
+
 	if s.ringRefresher != nil {
 		s.ringRefresher.Stop()
 	}
@@ -633,6 +717,31 @@ func (s *Session) Close() {
 	s.sessionStateMu.Unlock()
 }
 
+// RotateCredentials replaces the connections of the session with new ones, so they use the current
+// credentials: the authenticator returned by ClusterConfig.AuthProvider and the TLS files of
+// ClusterConfig.SslOpts, which are reloaded first. The control connection is replaced, then the
+// pools of the hosts are replaced one host at a time: the new pool is filled before it replaces the old
+// one, whose connections are closed once their in-flight requests complete or ctx is done.
+//
+// A host which can't be connected to keeps its old connections and doesn't stop the rotation of the
+// other hosts. The returned error joins the errors of all the hosts which failed to rotate, each of
+// them names the address of its host.
+func (s *Session) RotateCredentials(ctx context.Context) error {
+	if s.Closed() {
+		return ErrSessionClosed
+	}
+	if err := s.cfg.getTLSConfigSource().reload(); err != nil {
+		return fmt.Errorf("gocql: unable to reload TLS configuration: %w", err)
+	}
+	var controlErr error
+	if s.control != nil {
+		if err := s.control.rotate(); err != nil {
+			controlErr = fmt.Errorf("gocql: unable to rotate the control connection: %w", err)
+		}
+	}
+	return errors.Join(controlErr, s.pool.rotate(ctx))
+}
+
 func (s *Session) Closed() bool {
 	s.sessionStateMu.RLock()
 	closed := s.isClosed
@@ -670,6 +779,9 @@ func (s *Session) executeQuery(qry *Query) (it *Iter) {
 	if err := s.Ready(); err != nil {
 		return &Iter{err: err}
 	}
+	if err := s.checkExecutionProfile(qry.executionProfile); err != nil {
+		return &Iter{err: err}
+	}
 
 	iter, err := s.executor.executeQuery(qry)
 	if err != nil {
@@ -683,10 +795,11 @@ func (s *Session) executeQuery(qry *Query) (it *Iter) {
 }
 
 func (s *Session) removeHost(h *HostInfo) {
-	s.policy.RemoveHost(h)
+	s.policies.RemoveHost(h)
 	hostID := h.HostID()
 	s.pool.removeHost(hostID)
 	s.hostSource.removeHost(hostID)
+	s.metrics.removeHost(hostID)
 }
 
 // KeyspaceMetadata returns the schema metadata for the keyspace specified. Returns an error if the keyspace does not exist.
@@ -739,11 +852,17 @@ func (s *Session) findTabletReplicasForToken(keyspace, table string, token int64
 	return s.metadataDescriber.metadata.tabletsMetadata.FindReplicasForToken(keyspace, table, token)
 }
 
-// returns routing key indexes and type info
-func (s *Session) routingKeyInfo(ctx context.Context, stmt string, requestTimeout time.Duration) (*routingKeyInfo, error) {
+// returns routing key indexes and type info, the keyspace is the keyspace set with
+// Query.SetKeyspace, empty if the statement is prepared in the keyspace of the session.
+func (s *Session) routingKeyInfo(ctx context.Context, stmt, keyspace string, requestTimeout time.Duration) (*routingKeyInfo, error) {
+	cacheKey := stmt
+	if keyspace != "" {
+		cacheKey = keyspace + "\x00" + stmt
+	}
+
 	s.routingKeyInfoCache.mu.Lock()
 
-	entry, cached := s.routingKeyInfoCache.lru.Get(stmt)
+	entry, cached := s.routingKeyInfoCache.lru.Get(cacheKey)
 	if cached {
 		// done accessing the cache
 		s.routingKeyInfoCache.mu.Unlock()
@@ -767,7 +886,7 @@ func (s *Session) routingKeyInfo(ctx context.Context, stmt string, requestTimeou
 	inflight := new(inflightCachedEntry)
 	inflight.wg.Add(1)
 	defer inflight.wg.Done()
-	s.routingKeyInfoCache.lru.Add(stmt, inflight)
+	s.routingKeyInfoCache.lru.Add(cacheKey, inflight)
 	s.routingKeyInfoCache.mu.Unlock()
 
 	var (
@@ -783,10 +902,10 @@ func (s *Session) routingKeyInfo(ctx context.Context, stmt string, requestTimeou
 	}
 
 	// get the query info for the statement
-	info, inflight.err = conn.prepareStatement(ctx, stmt, nil, requestTimeout)
+	info, inflight.err = conn.prepareStatement(ctx, stmt, keyspace, nil, requestTimeout)
 	if inflight.err != nil {
 		// don't cache this error
-		s.routingKeyInfoCache.Remove(stmt)
+		s.routingKeyInfoCache.Remove(cacheKey)
 		return nil, inflight.err
 	}
 
@@ -799,25 +918,28 @@ func (s *Session) routingKeyInfo(ctx context.Context, stmt string, requestTimeou
 	}
 
 	table := info.request.table
-	keyspace := info.request.keyspace
+	keyspace = info.request.keyspace
 
 	partitioner, err := scyllaGetTablePartitioner(s, keyspace, table)
 	if err != nil {
 		// don't cache this error
-		s.routingKeyInfoCache.Remove(stmt)
+		s.routingKeyInfoCache.Remove(cacheKey)
 		return nil, inflight.err
 	}
 
 	if len(info.request.pkeyColumns) > 0 {
 		// proto v4 dont need to calculate primary key columns
 		types := make([]TypeInfo, len(info.request.pkeyColumns))
+		names := make([]string, len(info.request.pkeyColumns))
 		for i, col := range info.request.pkeyColumns {
 			types[i] = info.request.columns[col].TypeInfo
+			names[i] = info.request.columns[col].Name
 		}
 
 		routingKeyInfo := &routingKeyInfo{
 			indexes:     info.request.pkeyColumns,
 			types:       types,
+			names:       names,
 			lwt:         info.request.lwt,
 			partitioner: partitioner,
 			keyspace:    keyspace,
@@ -834,7 +956,7 @@ func (s *Session) routingKeyInfo(ctx context.Context, stmt string, requestTimeou
 	keyspaceMetadata, inflight.err = s.KeyspaceMetadata(info.request.columns[0].Keyspace)
 	if inflight.err != nil {
 		// don't cache this error
-		s.routingKeyInfoCache.Remove(stmt)
+		s.routingKeyInfoCache.Remove(cacheKey)
 		return nil, inflight.err
 	}
 
@@ -845,7 +967,7 @@ func (s *Session) routingKeyInfo(ctx context.Context, stmt string, requestTimeou
 		// in the metadata code, or that the table was just dropped.
 		inflight.err = ErrNoMetadata
 		// don't cache this error
-		s.routingKeyInfoCache.Remove(stmt)
+		s.routingKeyInfoCache.Remove(cacheKey)
 		return nil, inflight.err
 	}
 
@@ -855,6 +977,7 @@ func (s *Session) routingKeyInfo(ctx context.Context, stmt string, requestTimeou
 	routingKeyInfo := &routingKeyInfo{
 		indexes:     make([]int, size),
 		types:       make([]TypeInfo, size),
+		names:       make([]string, size),
 		lwt:         info.request.lwt,
 		partitioner: partitioner,
 		keyspace:    keyspace,
@@ -871,6 +994,7 @@ func (s *Session) routingKeyInfo(ctx context.Context, stmt string, requestTimeou
 				// there may be many such bound columns, pick the first
 				routingKeyInfo.indexes[keyIndex] = argIndex
 				routingKeyInfo.types[keyIndex] = boundColumn.TypeInfo
+				routingKeyInfo.names[keyIndex] = boundColumn.Name
 				break
 			}
 		}
@@ -907,6 +1031,9 @@ func (s *Session) executeBatch(batch *Batch) *Iter {
 	if err := s.Ready(); err != nil {
 		return &Iter{err: err}
 	}
+	if err := s.checkExecutionProfile(batch.executionProfile); err != nil {
+		return &Iter{err: err}
+	}
 
 	// Drop metrics from prior query executions
 	batch.metrics.reset()
@@ -1132,6 +1259,12 @@ type Query struct {
 	observer QueryObserver
 	metrics  *queryMetrics
 	session  *Session
+	// executionObserver observes executions of this query, including retries and speculative executions.
+	executionObserver ExecutionObserver
+	// policy is the host selection policy of the execution profile, nil if the session's policy is used.
+	policy HostSelectionPolicy
+	// executionProfile is the name of the execution profile the defaults of the query come from.
+	executionProfile string
 	// Timeout on waiting for response from server
 	customPayload map[string][]byte
 	// getKeyspace is field so that it can be overriden in tests
@@ -1139,18 +1272,23 @@ type Query struct {
 	// routingInfo is a pointer because Query can be copied and copyable struct can't hold a mutex.
 	routingInfo *queryRoutingInfo
 	binding     func(q *QueryInfo) ([]interface{}, error)
+	namedValues namedValues
 	// hostID specifies the host on which the query should be executed.
 	// If it is empty, then the host is picked by HostSelectionPolicy
-	hostID     string
-	stmt       string
-	routingKey []byte
-	values     []interface{}
-	pageState  []byte
+	hostID string
+	// queryKeyspace is the keyspace set with SetKeyspace, the statement is prepared and executed in it.
+	queryKeyspace string
+	stmt          string
+	routingKey    []byte
+	values        []interface{}
+	pageState     []byte
 	// requestTimeout is a timeout on waiting for response from server
 	requestTimeout        time.Duration
 	defaultTimestampValue int64
 	prefetch              float64
+	nowInSecondsValue     int32
 	pageSize              int
+	pageNumber            int
 	refCount              uint32
 	cons                  Consistency
 	serialCons            Consistency
@@ -1159,6 +1297,7 @@ type Query struct {
 	skipPrepare           bool
 	disableSkipMetadata   bool
 	defaultTimestamp      bool
+	nowInSeconds          bool
 }
 
 type queryRoutingInfo struct {
@@ -1191,19 +1330,70 @@ func (q *Query) defaultsFromSession() {
 	s := q.session
 
 	s.mu.RLock()
-	q.cons = s.cons
-	q.pageSize = s.pageSize
 	q.trace = s.trace
 	q.observer = s.queryObserver
+	q.executionObserver = s.executionObserver
 	q.prefetch = s.prefetch
-	q.rt = s.cfg.RetryPolicy
-	q.serialCons = s.cfg.SerialConsistency
 	q.defaultTimestamp = s.cfg.DefaultTimestamp
 	q.idempotent = s.cfg.DefaultIdempotence
 	q.metrics = &queryMetrics{m: make(map[string]*hostMetrics)}
+	s.mu.RUnlock()
 
-	q.spec = &NonSpeculativeExecution{}
+	q.applyExecutionProfile()
+}
+
+// applyExecutionProfile sets the settings an execution profile can have to those of the profile of
+// the query, or to the defaults of the session if the profile leaves them unset.
+func (q *Query) applyExecutionProfile() {
+	s := q.session
+
+	s.mu.RLock()
+	q.cons = s.cons
+	q.pageSize = s.pageSize
+	q.rt = s.cfg.RetryPolicy
+	q.serialCons = s.cfg.SerialConsistency
+	q.requestTimeout = s.cfg.Timeout
 	s.mu.RUnlock()
+
+	q.spec = &NonSpeculativeExecution{}
+	q.policy = nil
+
+	if p, ok := s.executionProfiles[q.executionProfile]; ok {
+		if p.Consistency != Any {
+			q.cons = p.Consistency
+		}
+		if p.SerialConsistency != Any {
+			q.serialCons = p.SerialConsistency
+		}
+		if p.Timeout > 0 {
+			q.requestTimeout = p.Timeout
+		}
+		if p.RetryPolicy != nil {
+			q.rt = p.RetryPolicy
+		}
+		if p.SpeculativeExecutionPolicy != nil {
+			q.spec = p.SpeculativeExecutionPolicy
+		}
+		if p.PageSize > 0 {
+			q.pageSize = p.PageSize
+		}
+		q.policy = p.HostSelectionPolicy
+	}
+}
+
+// ExecutionProfile sets the defaults of the query to those of the named profile registered in
+// ClusterConfig.ExecutionProfiles, or to those of the session if name is empty. Only the settings
+// a profile can have are changed: the consistency, serial consistency, timeout, retry policy,
+// speculative execution policy, page size and host selection policy. They are reset to the defaults
+// of the session when the profile leaves them unset, so they should be set after selecting the profile,
+// other settings, e.g. the observer or idempotence, are kept. Executing the query fails if the profile
+// does not exist.
+func (q *Query) ExecutionProfile(name string) *Query {
+	q.executionProfile = name
+	if q.session != nil {
+		q.applyExecutionProfile()
+	}
+	return q
 }
 
 // Statement returns the statement that was used to generate this query.
@@ -1286,6 +1476,13 @@ func (q *Query) Observer(observer QueryObserver) *Query {
 	return q
 }
 
+// ExecutionObserver enables execution observer on this query.
+// The provided observer will be notified about every execution of this query and its attempts.
+func (q *Query) ExecutionObserver(observer ExecutionObserver) *Query {
+	q.executionObserver = observer
+	return q
+}
+
 // PageSize will tell the iterator to fetch the result in pages of size n.
 // This is useful for iterating over large result sets, but setting the
 // page size too low might decrease the performance. This feature is only
@@ -1318,6 +1515,26 @@ func (q *Query) WithTimestamp(timestamp int64) *Query {
 	return q
 }
 
+// SetKeyspace sets the keyspace the query is prepared and executed in, instead of the keyspace
+// of the session, so that the tables don't need to be qualified with the keyspace.
+//
+// Only available on protocol >= 5
+func (q *Query) SetKeyspace(keyspace string) *Query {
+	q.queryKeyspace = keyspace
+	return q
+}
+
+// WithNowInSeconds sets the current time in seconds since the Unix epoch the server uses
+// for the query, e.g. to decide which cells expired because of their TTL. It's meant for
+// deterministic tests.
+//
+// Only available on protocol >= 5
+func (q *Query) WithNowInSeconds(now int32) *Query {
+	q.nowInSeconds = true
+	q.nowInSecondsValue = now
+	return q
+}
+
 // RoutingKey sets the routing key to use when a token aware connection
 // pool is used to optimize the routing of this query.
 func (q *Query) RoutingKey(routingKey []byte) *Query {
@@ -1371,6 +1588,20 @@ func (q *Query) attempt(keyspace string, end, start time.Time, iter *Iter, host
 	}
 }
 
+func (q *Query) getExecutionObserver() ExecutionObserver {
+	return q.executionObserver
+}
+
+func (q *Query) observedExecution() ObservedExecution {
+	return ObservedExecution{
+		Keyspace:    q.Keyspace(),
+		Statement:   q.stmt,
+		Consistency: q.cons,
+		PageNumber:  q.pageNumber,
+		Idempotent:  q.IsIdempotent(),
+	}
+}
+
 func (q *Query) retryPolicy() RetryPolicy {
 	return q.rt
 }
@@ -1383,6 +1614,9 @@ func (q *Query) Keyspace() string {
 	if q.routingInfo.keyspace != "" {
 		return q.routingInfo.keyspace
 	}
+	if q.queryKeyspace != "" {
+		return q.queryKeyspace
+	}
 
 	if q.session == nil {
 		return ""
@@ -1410,7 +1644,7 @@ func (q *Query) GetSession() *Session {
 func (q *Query) GetRoutingKey() ([]byte, error) {
 	if q.routingKey != nil {
 		return q.routingKey, nil
-	} else if q.binding != nil && len(q.values) == 0 {
+	} else if q.binding != nil && len(q.values) == 0 && q.namedValues == nil {
 		// If this query was created using session.Bind we wont have the query
 		// values yet, so we have to pass down to the next policy.
 		// TODO: Remove this and handle this case
@@ -1418,7 +1652,7 @@ func (q *Query) GetRoutingKey() ([]byte, error) {
 	}
 
 	// try to determine the routing key
-	routingKeyInfo, err := q.session.routingKeyInfo(q.Context(), q.stmt, q.requestTimeout)
+	routingKeyInfo, err := q.session.routingKeyInfo(q.Context(), q.stmt, q.queryKeyspace, q.requestTimeout)
 	if err != nil {
 		return nil, err
 	}
@@ -1431,7 +1665,10 @@ func (q *Query) GetRoutingKey() ([]byte, error) {
 		q.routingInfo.table = routingKeyInfo.table
 		q.routingInfo.mu.Unlock()
 	}
-	return createRoutingKey(routingKeyInfo, q.values)
+	if q.namedValues != nil {
+		return createNamedRoutingKey(q.session.cfg.Codecs, routingKeyInfo, q.namedValues)
+	}
+	return createRoutingKey(q.session.cfg.Codecs, routingKeyInfo, q.values)
 }
 
 func (q *Query) shouldPrepare() bool {
@@ -1481,6 +1718,10 @@ func (q *Query) speculativeExecutionPolicy() SpeculativeExecutionPolicy {
 	return q.spec
 }
 
+func (q *Query) hostSelectionPolicy() HostSelectionPolicy {
+	return q.policy
+}
+
 // IsIdempotent returns whether the query is marked as idempotent.
 // Non-idempotent query won't be retried.
 // See "Retries and speculative execution" in package docs for more details.
@@ -1508,6 +1749,10 @@ func (q *Query) Idempotent(value bool) *Query {
 // Bind sets query arguments of query. This can also be used to rebind new query arguments
 // to an existing query instance.
 func (q *Query) Bind(v ...interface{}) *Query {
+	if q.namedValues != nil {
+		q.namedValues = nil
+		q.binding = nil
+	}
 	q.values = v
 	q.pageState = nil
 	return q
@@ -1746,6 +1991,7 @@ type Iter struct {
 	pos     int
 	numRows int
 	closed  int32
+	codecs  *CodecRegistry
 }
 
 // Host returns the host which the query was sent to.
@@ -1811,7 +2057,7 @@ func (is *iterScanner) Next() bool {
 	return true
 }
 
-func scanColumn(p []byte, col ColumnInfo, dest []interface{}) (int, error) {
+func scanColumn(codecs *CodecRegistry, p []byte, col ColumnInfo, dest []interface{}) (int, error) {
 	if dest[0] == nil {
 		return 1, nil
 	}
@@ -1823,12 +2069,12 @@ func scanColumn(p []byte, col ColumnInfo, dest []interface{}) (int, error) {
 		count := len(tuple.Elems)
 		// here we pass in a slice of the struct which has the number number of
 		// values as elements in the tuple
-		if err := Unmarshal(col.TypeInfo, p, dest[:count]); err != nil {
+		if err := unmarshalWith(codecs, col.TypeInfo, p, dest[:count]); err != nil {
 			return 0, err
 		}
 		return count, nil
 	} else {
-		if err := Unmarshal(col.TypeInfo, p, dest[0]); err != nil {
+		if err := unmarshalWith(codecs, col.TypeInfo, p, dest[0]); err != nil {
 			return 0, err
 		}
 		return 1, nil
@@ -1840,28 +2086,30 @@ func (is *iterScanner) Scan(dest ...interface{}) error {
 		return errors.New("gocql: Scan called without calling Next")
 	}
 
-	iter := is.iter
+	err := scanColumns(is.iter.codecs, &is.iter.meta, is.cols, dest)
+	is.valid = false
+	return err
+}
+
+// scanColumns unmarshals the columns of a row read ahead into dest.
+func scanColumns(codecs *CodecRegistry, meta *resultMetadata, cols [][]byte, dest []interface{}) error {
 	// currently only support scanning into an expand tuple, such that its the same
 	// as scanning in more values from a single column
-	if len(dest) != iter.meta.actualColCount {
-		return fmt.Errorf("gocql: not enough columns to scan into: have %d want %d", len(dest), iter.meta.actualColCount)
+	if len(dest) != meta.actualColCount {
+		return fmt.Errorf("gocql: not enough columns to scan into: have %d want %d", len(dest), meta.actualColCount)
 	}
 
 	// i is the current position in dest, could posible replace it and just use
 	// slices of dest
 	i := 0
-	var err error
-	for _, col := range iter.meta.columns {
-		var n int
-		n, err = scanColumn(is.cols[i], col, dest[i:])
+	for c, col := range meta.columns {
+		n, err := scanColumn(codecs, cols[c], col, dest[i:])
 		if err != nil {
-			break
+			return err
 		}
 		i += n
 	}
-
-	is.valid = false
-	return err
+	return nil
 }
 
 func (is *iterScanner) Err() error {
@@ -1886,15 +2134,9 @@ func (iter *Iter) readColumn() ([]byte, error) {
 	return iter.framer.ReadBytesInternal()
 }
 
-// Scan consumes the next row of the iterator and copies the columns of the
-// current row into the values pointed at by dest. Use nil as a dest value
-// to skip the corresponding column. Scan might send additional queries
-// to the database to retrieve the next set of rows if paging was enabled.
-//
-// Scan returns true if the row was successfully unmarshaled or false if the
-// end of the result set was reached or if an error occurred. Close should
-// be called afterwards to retrieve any potential errors.
-func (iter *Iter) Scan(dest ...interface{}) bool {
+// nextRow checks whether there is a row left to be read, fetching the next page
+// if the current one was consumed. The columns of the row are read with readColumn.
+func (iter *Iter) nextRow() bool {
 	if iter.err != nil {
 		return false
 	}
@@ -1902,7 +2144,7 @@ func (iter *Iter) Scan(dest ...interface{}) bool {
 	if iter.pos >= iter.numRows {
 		if iter.next != nil {
 			*iter = *iter.next.fetch()
-			return iter.Scan(dest...)
+			return iter.nextRow()
 		}
 		return false
 	}
@@ -1910,6 +2152,21 @@ func (iter *Iter) Scan(dest ...interface{}) bool {
 	if iter.next != nil && iter.pos >= iter.next.pos {
 		iter.next.fetchAsync()
 	}
+	return true
+}
+
+// Scan consumes the next row of the iterator and copies the columns of the
+// current row into the values pointed at by dest. Use nil as a dest value
+// to skip the corresponding column. Scan might send additional queries
+// to the database to retrieve the next set of rows if paging was enabled.
+//
+// Scan returns true if the row was successfully unmarshaled or false if the
+// end of the result set was reached or if an error occurred. Close should
+// be called afterwards to retrieve any potential errors.
+func (iter *Iter) Scan(dest ...interface{}) bool {
+	if !iter.nextRow() {
+		return false
+	}
 
 	// currently only support scanning into an expand tuple, such that its the same
 	// as scanning in more values from a single column
@@ -1928,7 +2185,7 @@ func (iter *Iter) Scan(dest ...interface{}) bool {
 			return false
 		}
 
-		n, err := scanColumn(colBytes, col, dest[i:])
+		n, err := scanColumn(iter.codecs, colBytes, col, dest[i:])
 		if err != nil {
 			iter.err = err
 			return false
@@ -2045,6 +2302,12 @@ type Batch struct {
 	spec     SpeculativeExecutionPolicy
 	trace    Tracer
 	observer BatchObserver
+	// executionObserver observes executions of this batch, including retries and speculative executions.
+	executionObserver ExecutionObserver
+	// policy is the host selection policy of the execution profile, nil if the session's policy is used.
+	policy HostSelectionPolicy
+	// executionProfile is the name of the execution profile the defaults of the batch come from.
+	executionProfile string
 	// routingInfo is a pointer because Query can be copied and copyable struct can't hold a mutex.
 	routingInfo   *queryRoutingInfo
 	metrics       *queryMetrics
@@ -2052,17 +2315,21 @@ type Batch struct {
 	CustomPayload map[string][]byte
 	session       *Session
 	keyspace      string
+	// queryKeyspace is the keyspace set with SetKeyspace, it's sent with the batch.
+	queryKeyspace string
 	// hostID specifies the host on which the query should be executed.
 	// If it is empty, then the host is picked by HostSelectionPolicy
 	hostID                string
 	routingKey            []byte
 	Entries               []BatchEntry
 	defaultTimestampValue int64
+	nowInSecondsValue     int32
 	// requestTimeout is a timeout on waiting for response from serve
 	requestTimeout   time.Duration
 	serialCons       Consistency
 	Cons             Consistency
 	defaultTimestamp bool
+	nowInSeconds     bool
 	Type             BatchType
 }
 
@@ -2082,25 +2349,78 @@ func (s *Session) BatchWithContext(ctx context.Context, typ BatchType) *Batch {
 
 // Batch creates a new batch operation using defaults defined in the cluster
 func (s *Session) Batch(typ BatchType) *Batch {
-	s.mu.RLock()
 	batch := &Batch{
-		Type:             typ,
-		rt:               s.cfg.RetryPolicy,
-		serialCons:       s.cfg.SerialConsistency,
-		trace:            s.trace,
-		observer:         s.batchObserver,
-		session:          s,
-		Cons:             s.cons,
-		defaultTimestamp: s.cfg.DefaultTimestamp,
-		keyspace:         s.cfg.Keyspace,
-		metrics:          &queryMetrics{m: make(map[string]*hostMetrics)},
-		spec:             &NonSpeculativeExecution{},
-		routingInfo:      &queryRoutingInfo{},
-		requestTimeout:   s.cfg.Timeout,
+		Type:        typ,
+		session:     s,
+		keyspace:    s.cfg.Keyspace,
+		routingInfo: &queryRoutingInfo{},
 	}
+	batch.defaultsFromSession()
+	return batch
+}
+
+func (b *Batch) defaultsFromSession() {
+	s := b.session
 
+	s.mu.RLock()
+	b.trace = s.trace
+	b.observer = s.batchObserver
+	b.executionObserver = s.executionObserver
+	b.defaultTimestamp = s.cfg.DefaultTimestamp
+	b.metrics = &queryMetrics{m: make(map[string]*hostMetrics)}
 	s.mu.RUnlock()
-	return batch
+
+	b.applyExecutionProfile()
+}
+
+// applyExecutionProfile sets the settings an execution profile can have to those of the profile of
+// the batch, or to the defaults of the session if the profile leaves them unset.
+func (b *Batch) applyExecutionProfile() {
+	s := b.session
+
+	s.mu.RLock()
+	b.Cons = s.cons
+	b.rt = s.cfg.RetryPolicy
+	b.serialCons = s.cfg.SerialConsistency
+	b.requestTimeout = s.cfg.Timeout
+	s.mu.RUnlock()
+
+	b.spec = &NonSpeculativeExecution{}
+	b.policy = nil
+
+	if p, ok := s.executionProfiles[b.executionProfile]; ok {
+		if p.Consistency != Any {
+			b.Cons = p.Consistency
+		}
+		if p.SerialConsistency != Any {
+			b.serialCons = p.SerialConsistency
+		}
+		if p.Timeout > 0 {
+			b.requestTimeout = p.Timeout
+		}
+		if p.RetryPolicy != nil {
+			b.rt = p.RetryPolicy
+		}
+		if p.SpeculativeExecutionPolicy != nil {
+			b.spec = p.SpeculativeExecutionPolicy
+		}
+		b.policy = p.HostSelectionPolicy
+	}
+}
+
+// ExecutionProfile sets the defaults of the batch to those of the named profile registered in
+// ClusterConfig.ExecutionProfiles, or to those of the session if name is empty. Only the settings
+// a profile can have are changed: the consistency, serial consistency, timeout, retry policy,
+// speculative execution policy and host selection policy. They are reset to the defaults of the
+// session when the profile leaves them unset, so they should be set after selecting the profile,
+// other settings, e.g. the observer or the default timestamp, are kept. Executing the batch fails
+// if the profile does not exist.
+func (b *Batch) ExecutionProfile(name string) *Batch {
+	b.executionProfile = name
+	if b.session != nil {
+		b.applyExecutionProfile()
+	}
+	return b
 }
 
 // Trace enables tracing of this batch. Look at the documentation of the
@@ -2117,6 +2437,13 @@ func (b *Batch) Observer(observer BatchObserver) *Batch {
 	return b
 }
 
+// ExecutionObserver enables execution observer on this batch.
+// The provided observer will be notified about every execution of this batch and its attempts.
+func (b *Batch) ExecutionObserver(observer ExecutionObserver) *Batch {
+	b.executionObserver = observer
+	return b
+}
+
 func (b *Batch) Keyspace() string {
 	return b.keyspace
 }
@@ -2188,6 +2515,10 @@ func (b *Batch) speculativeExecutionPolicy() SpeculativeExecutionPolicy {
 	return b.spec
 }
 
+func (b *Batch) hostSelectionPolicy() HostSelectionPolicy {
+	return b.policy
+}
+
 func (b *Batch) SpeculativeExecutionPolicy(sp SpeculativeExecutionPolicy) *Batch {
 	b.spec = sp
 	return b
@@ -2280,6 +2611,26 @@ func (b *Batch) WithTimestamp(timestamp int64) *Batch {
 	return b
 }
 
+// SetKeyspace sets the keyspace the statements of the batch are prepared and executed in,
+// instead of the keyspace of the session.
+//
+// Only available on protocol >= 5
+func (b *Batch) SetKeyspace(keyspace string) *Batch {
+	b.keyspace = keyspace
+	b.queryKeyspace = keyspace
+	return b
+}
+
+// WithNowInSeconds sets the current time in seconds since the Unix epoch the server uses
+// for the batch, see Query.WithNowInSeconds.
+//
+// Only available on protocol >= 5
+func (b *Batch) WithNowInSeconds(now int32) *Batch {
+	b.nowInSeconds = true
+	b.nowInSecondsValue = now
+	return b
+}
+
 func (b *Batch) attempt(keyspace string, end, start time.Time, iter *Iter, host *HostInfo) {
 	latency := end.Sub(start)
 	attempt, metricsForHost := b.metrics.attempt(1, latency, host, b.observer != nil)
@@ -2310,6 +2661,25 @@ func (b *Batch) attempt(keyspace string, end, start time.Time, iter *Iter, host
 	})
 }
 
+func (b *Batch) getExecutionObserver() ExecutionObserver {
+	return b.executionObserver
+}
+
+func (b *Batch) observedExecution() ObservedExecution {
+	statements := make([]string, len(b.Entries))
+	for i, entry := range b.Entries {
+		statements[i] = entry.Stmt
+	}
+
+	return ObservedExecution{
+		Keyspace:    b.Keyspace(),
+		Statements:  statements,
+		Consistency: b.Cons,
+		Idempotent:  b.IsIdempotent(),
+		Batch:       true,
+	}
+}
+
 func (b *Batch) GetRoutingKey() ([]byte, error) {
 	if b.routingKey != nil {
 		return b.routingKey, nil
@@ -2325,7 +2695,7 @@ func (b *Batch) GetRoutingKey() ([]byte, error) {
 		return nil, nil
 	}
 	// try to determine the routing key
-	routingKeyInfo, err := b.session.routingKeyInfo(b.Context(), entry.Stmt, b.GetRequestTimeout())
+	routingKeyInfo, err := b.session.routingKeyInfo(b.Context(), entry.Stmt, b.queryKeyspace, b.GetRequestTimeout())
 	if err != nil {
 		return nil, err
 	}
@@ -2336,7 +2706,7 @@ func (b *Batch) GetRoutingKey() ([]byte, error) {
 		b.routingInfo.mu.Unlock()
 	}
 
-	return createRoutingKey(routingKeyInfo, entry.Args)
+	return createRoutingKey(b.session.cfg.Codecs, routingKeyInfo, entry.Args)
 }
 
 // GetRequestTimeout returns time driver waits for single server response
@@ -2352,14 +2722,15 @@ func (b *Batch) SetRequestTimeout(timeout time.Duration) *Batch {
 	return b
 }
 
-func createRoutingKey(routingKeyInfo *routingKeyInfo, values []interface{}) ([]byte, error) {
+func createRoutingKey(codecs *CodecRegistry, routingKeyInfo *routingKeyInfo, values []interface{}) ([]byte, error) {
 	if routingKeyInfo == nil {
 		return nil, nil
 	}
 
 	if len(routingKeyInfo.indexes) == 1 {
 		// single column routing key
-		routingKey, err := Marshal(
+		routingKey, err := marshalWith(
+			codecs,
 			routingKeyInfo.types[0],
 			values[routingKeyInfo.indexes[0]],
 		)
@@ -2372,7 +2743,8 @@ func createRoutingKey(routingKeyInfo *routingKeyInfo, values []interface{}) ([]b
 	// composite routing key
 	buf := bytes.NewBuffer(make([]byte, 0, 256))
 	for i := range routingKeyInfo.indexes {
-		encoded, err := Marshal(
+		encoded, err := marshalWith(
+			codecs,
 			routingKeyInfo.types[i],
 			values[routingKeyInfo.indexes[i]],
 		)
@@ -2451,7 +2823,9 @@ type routingKeyInfo struct {
 	table       string
 	indexes     []int
 	types       []TypeInfo
-	lwt         bool
+	// names holds the names of the bound variables of the routing key columns.
+	names []string
+	lwt   bool
 }
 
 func (r *routingKeyInfo) String() string {
@@ -2518,6 +2892,17 @@ type HostPoolInfo interface {
 	IsClosed() bool
 }
 
+// HostPoolRateLimiterInfo is implemented by the HostPoolInfo of the session, which provides the state
+// of the client side rate limiters of the host:
+//
+//	if info, ok := pool.(gocql.HostPoolRateLimiterInfo); ok {
+//		states := info.RateLimiterState()
+//		...
+//	}
+type HostPoolRateLimiterInfo interface {
+	RateLimiterState() []RateLimiterState
+}
+
 func (s *Session) GetHostPoolByID(hostID string) HostPoolInfo {
 	hostPool, _ := s.pool.getPoolByHostID(hostID)
 	return hostPool
@@ -2612,6 +2997,114 @@ type ConnectObserver interface {
 	ObserveConnect(ObservedConnect)
 }
 
+// ObservedExecution describes a single execution of a Query or a Batch.
+// An execution spans all attempts made to run the statement, including retries and
+// speculative executions. Every page of a paged query is fetched in a separate execution.
+type ObservedExecution struct {
+	// Start is a time when the execution started
+	Start time.Time
+	// End is a time when the execution finished, it is zero when the execution is starting.
+	End time.Time
+	// Err is the error the execution finished with, if any.
+	Err      error
+	Keyspace string
+	// Statement is the statement of the executed query, it is empty for batches.
+	Statement string
+	// Statements holds the statements of the executed batch, it is nil for queries.
+	Statements  []string
+	Consistency Consistency
+	// PageNumber is the index of the fetched page, the first page is number zero.
+	// It is always zero for batches.
+	PageNumber int
+	// Attempts is the number of attempts made, it is zero when the execution is starting.
+	Attempts   int
+	Idempotent bool
+	// Batch is true when a batch is executed.
+	Batch bool
+}
+
+// ObservedAttempt describes a single attempt of an execution.
+type ObservedAttempt struct {
+	// Start is a time when the attempt was sent
+	Start time.Time
+	// End is a time when the attempt was completed, it is zero when the attempt is starting.
+	End time.Time
+	// Err is the error of the attempt, if any.
+	Err error
+	// Host is a reference to the host where the attempt is executed.
+	Host *HostInfo
+	// Shard is the ScyllaDB shard the connection is bound to, it is -1 for other databases.
+	Shard       int
+	Consistency Consistency
+	// Attempt is the index of attempt within the speculative execution that made it.
+	// The first attempt is number zero and any retries have non-zero attempt number.
+	Attempt int
+	// SpeculativeExecution is zero for attempts made by the main execution and the
+	// index of the speculative execution, starting at one, otherwise.
+	SpeculativeExecution int
+	// RetryDecision is the decision of the retry policy after the attempt failed.
+	// It is only set when the attempt is finished and Err is not nil.
+	RetryDecision RetryType
+}
+
+// ExecutionObserver is the interface implemented by observers interested in the structure
+// of Query and Batch executions, e.g. to emit tracing spans.
+type ExecutionObserver interface {
+	// ExecutionStarted gets called once per execution before any attempt is made.
+	// The returned ExecutionObserverContext is notified about the attempts and the end of
+	// the execution, ExecutionStarted can return nil to not observe the execution.
+	ExecutionStarted(ctx context.Context, execution ObservedExecution) ExecutionObserverContext
+}
+
+// ExecutionObserverContext observes a single execution.
+// AttemptStarted can be called concurrently when speculative execution is enabled.
+type ExecutionObserverContext interface {
+	// AttemptStarted gets called right before an attempt is sent to a host.
+	// It can return nil to not observe the attempt.
+	AttemptStarted(attempt ObservedAttempt) AttemptObserverContext
+	// ExecutionFinished gets called once the execution is finished, after the attempts of all its
+	// speculative executions finished.
+	ExecutionFinished(execution ObservedExecution)
+}
+
+// AttemptObserverContext observes a single attempt.
+type AttemptObserverContext interface {
+	// CustomPayload returns entries to add to the custom payload of the attempt request,
+	// e.g. to propagate trace context to the server. It can return nil.
+	// It is not called for connections using protocol older than v4.
+	CustomPayload() map[string][]byte
+	// AttemptFinished gets called once the attempt is completed and, for failed attempts,
+	// the retry policy decided what to do next.
+	AttemptFinished(attempt ObservedAttempt)
+}
+
+type attemptCustomPayloadKey struct{}
+
+// withAttemptCustomPayload returns a context carrying custom payload entries added to a single attempt.
+func withAttemptCustomPayload(ctx context.Context, payload map[string][]byte) context.Context {
+	if len(payload) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, attemptCustomPayloadKey{}, payload)
+}
+
+// attemptCustomPayload merges the custom payload of the statement with the entries added to the attempt.
+func attemptCustomPayload(ctx context.Context, customPayload map[string][]byte) map[string][]byte {
+	extra, _ := ctx.Value(attemptCustomPayloadKey{}).(map[string][]byte)
+	if len(extra) == 0 {
+		return customPayload
+	}
+
+	merged := make(map[string][]byte, len(customPayload)+len(extra))
+	for k, v := range customPayload {
+		merged[k] = v
+	}
+	for k, v := range extra {
+		merged[k] = v
+	}
+	return merged
+}
+
 type Error struct {
 	Message string
 	Code    int
@@ -2634,6 +3127,7 @@ var (
 	ErrNoMetadata           = errors.New("no metadata available")
 	ErrTabletsNotUsed       = errors.New("tablets not used")
 	ErrSessionNotReady      = errors.New("session is not ready yet")
+	ErrRequiresProtoV5      = errors.New("requires protocol version 5 or higher")
 )
 
 type ErrProtocol struct{ error }
@@ -0,0 +1,105 @@
+package gocql
+
+import (
+	"crypto/tls"
+	"os"
+	"sync"
+	"time"
+)
+
+// tlsConfigSource provides the TLS config of new connections. It reloads the files of SslOptions
+// when they change, see SslOptions.ReloadInterval.
+type tlsConfigSource struct {
+	opts   *SslOptions
+	logger StdLogger
+
+	mu      sync.Mutex
+	config  *tls.Config
+	files   []tlsFileState
+	checked time.Time
+}
+
+// tlsFileState identifies a version of a file.
+type tlsFileState struct {
+	modTime time.Time
+	size    int64
+}
+
+func newTLSConfigSource(opts *SslOptions, logger StdLogger) (*tlsConfigSource, error) {
+	s := &tlsConfigSource{opts: opts, logger: logger}
+	files := s.stat()
+	config, err := setupTLSConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	s.config, s.files, s.checked = config, files, time.Now()
+	return s, nil
+}
+
+// get returns the TLS config for a new connection, nil if TLS is not used. If the files of the options
+// were modified since they were loaded, they are reloaded; the current config is kept if they are invalid,
+// e.g. if the certificate was replaced but the key is not yet.
+func (s *tlsConfigSource) get() *tls.Config {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.opts.ReloadInterval <= 0 || time.Since(s.checked) < s.opts.ReloadInterval {
+		return s.config
+	}
+	s.checked = time.Now()
+	if err := s.reloadLocked(); err != nil {
+		logEntry(s.logger, LogLevelWarn, "gocql: unable to reload TLS configuration, using the current one", "err", err)
+	}
+	return s.config
+}
+
+// reload reloads the files of the options if they were modified since they were loaded.
+func (s *tlsConfigSource) reload() error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checked = time.Now()
+	return s.reloadLocked()
+}
+
+func (s *tlsConfigSource) reloadLocked() error {
+	files := s.stat()
+	if !s.modified(files) {
+		return nil
+	}
+	config, err := setupTLSConfig(s.opts)
+	if err != nil {
+		return err
+	}
+	s.config, s.files = config, files
+	return nil
+}
+
+// stat returns the state of the files of the options, the state of a missing file is zero.
+func (s *tlsConfigSource) stat() []tlsFileState {
+	var files []tlsFileState
+	for _, path := range []string{s.opts.CertPath, s.opts.KeyPath, s.opts.CaPath} {
+		var state tlsFileState
+		if path != "" {
+			if info, err := os.Stat(path); err == nil {
+				state = tlsFileState{modTime: info.ModTime(), size: info.Size()}
+			}
+		}
+		files = append(files, state)
+	}
+	return files
+}
+
+func (s *tlsConfigSource) modified(files []tlsFileState) bool {
+	for i := range files {
+		if !files[i].modTime.Equal(s.files[i].modTime) || files[i].size != s.files[i].size {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+//go:build unit
+// +build unit
+
+package gocql
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeTestKeyPair writes a self-signed certificate and its key to the files.
+func writeTestKeyPair(t *testing.T, certPath, keyPath, name string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// touch sets the modification time of the files, the resolution of the file system may hide a rewrite.
+func touch(t *testing.T, mtime time.Time, paths ...string) {
+	t.Helper()
+	for _, path := range paths {
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func certName(t *testing.T, source *tlsConfigSource) string {
+	t.Helper()
+	config := source.get()
+	if len(config.Certificates) != 1 {
+		t.Fatalf("expected a certificate, got %d", len(config.Certificates))
+	}
+	cert, err := x509.ParseCertificate(config.Certificates[0].Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert.Subject.CommonName
+}
+
+func TestTLSConfigSourceReload(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath := filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	writeTestKeyPair(t, certPath, keyPath, "first")
+	touch(t, time.Now().Add(-time.Minute), certPath, keyPath)
+
+	source, err := newTLSConfigSource(&SslOptions{CertPath: certPath, KeyPath: keyPath, ReloadInterval: time.Nanosecond}, &testLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := certName(t, source); name != "first" {
+		t.Fatalf("expected the first certificate, got %q", name)
+	}
+
+	writeTestKeyPair(t, certPath, keyPath, "second")
+	if name := certName(t, source); name != "second" {
+		t.Fatalf("expected the rotated certificate, got %q", name)
+	}
+
+	// the current certificate is kept while the files are invalid
+	if err := os.WriteFile(keyPath, []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if name := certName(t, source); name != "second" {
+		t.Fatalf("expected the current certificate, got %q", name)
+	}
+	if err := source.reload(); err == nil {
+		t.Fatal("expected an error for the invalid key")
+	}
+}
+
+func TestTLSConfigSourceReloadInterval(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath := filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	writeTestKeyPair(t, certPath, keyPath, "first")
+	touch(t, time.Now().Add(-time.Minute), certPath, keyPath)
+
+	source, err := newTLSConfigSource(&SslOptions{CertPath: certPath, KeyPath: keyPath}, &testLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestKeyPair(t, certPath, keyPath, "second")
+	// without ReloadInterval the files are reloaded only on request
+	if name := certName(t, source); name != "first" {
+		t.Fatalf("expected the first certificate, got %q", name)
+	}
+	if err := source.reload(); err != nil {
+		t.Fatal(err)
+	}
+	if name := certName(t, source); name != "second" {
+		t.Fatalf("expected the rotated certificate, got %q", name)
+	}
+}
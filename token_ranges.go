@@ -0,0 +1,361 @@
+package gocql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocql/gocql/tablets"
+)
+
+// TokenRange is a range of tokens of the Murmur3 partitioner, from StartToken, exclusive, to EndToken, inclusive.
+// It selects the partitions with token(pk) > StartToken AND token(pk) <= EndToken.
+type TokenRange struct {
+	StartToken int64
+	EndToken   int64
+	// Replicas are the hosts which own the data of the range, nil if they are not known.
+	Replicas []*HostInfo
+}
+
+func (r TokenRange) String() string {
+	return fmt.Sprintf("(%d, %d]", r.StartToken, r.EndToken)
+}
+
+// width returns the number of tokens in the range.
+func (r TokenRange) width() uint64 {
+	return uint64(r.EndToken) - uint64(r.StartToken)
+}
+
+// Split divides the range into n sub-ranges of the same size, which have the replicas of the range.
+// It returns fewer ranges if the range has less than n tokens, none if the range is empty.
+func (r TokenRange) Split(n int) []TokenRange {
+	width := r.width()
+	if width == 0 {
+		return nil
+	}
+	if n < 1 {
+		n = 1
+	}
+	if uint64(n) > width {
+		n = int(width)
+	}
+	step, rem := width/uint64(n), width%uint64(n)
+	ranges := make([]TokenRange, 0, n)
+	start := r.StartToken
+	for i := 0; i < n; i++ {
+		size := step
+		if uint64(i) < rem {
+			size++
+		}
+		end := int64(uint64(start) + size)
+		ranges = append(ranges, TokenRange{StartToken: start, EndToken: end, Replicas: r.Replicas})
+		start = end
+	}
+	return ranges
+}
+
+// SplitTokenRanges divides the ranges into about n sub-ranges of the same size, every range is split
+// into at least one sub-range.
+func SplitTokenRanges(ranges []TokenRange, n int) []TokenRange {
+	var total float64
+	for _, r := range ranges {
+		total += float64(r.width())
+	}
+	if total == 0 {
+		return append([]TokenRange(nil), ranges...)
+	}
+	split := make([]TokenRange, 0, n)
+	for _, r := range ranges {
+		parts := int(math.Round(float64(n) * float64(r.width()) / total))
+		split = append(split, r.Split(parts)...)
+	}
+	return split
+}
+
+// TokenRanges returns the token ranges of a table, which cover the whole token ring, in ascending order.
+//
+// For tables using vnodes the ranges and their replicas are computed from the tokens of the hosts and
+// the replication strategy of the keyspace, table may be empty. For tables using tablets, the ranges are
+// the tablets known to the driver, which learns them from the responses of the hosts; the tokens not covered
+// by known tablets are returned as ranges without replicas. Only the Murmur3 partitioner is supported.
+func (s *Session) TokenRanges(keyspace, table string) ([]TokenRange, error) {
+	// fail fast
+	if s.Closed() {
+		return nil, ErrSessionClosed
+	} else if err := s.Ready(); err != nil {
+		return nil, err
+	} else if keyspace == "" {
+		return nil, ErrNoKeyspace
+	}
+
+	if s.tabletsRoutingV1 && table != "" {
+		if ranges := s.tabletTokenRanges(keyspace, table); ranges != nil {
+			return ranges, nil
+		}
+	}
+
+	hosts := s.hostSource.getHostsList()
+	var partitioner string
+	for _, host := range hosts {
+		if partitioner = host.Partitioner(); partitioner != "" {
+			break
+		}
+	}
+	if !strings.HasSuffix(partitioner, "Murmur3Partitioner") {
+		return nil, fmt.Errorf("gocql: token ranges are not supported with partitioner %q", partitioner)
+	}
+	ring, err := newTokenRing(partitioner, hosts)
+	if err != nil {
+		return nil, err
+	}
+	if len(ring.tokens) == 0 {
+		return nil, errors.New("gocql: token ring is empty")
+	}
+
+	ks, err := s.KeyspaceMetadata(keyspace)
+	if err != nil {
+		return nil, err
+	}
+	var replicas tokenRingReplicas
+	if strategy := getStrategy(ks, s.logger); strategy != nil {
+		replicas = strategy.replicaMap(ring)
+	} else {
+		// e.g. LocalStrategy, the data of a range is on the host owning it
+		for _, ht := range ring.tokens {
+			replicas = append(replicas, hostTokens{token: ht.token, hosts: []*HostInfo{ht.host}})
+		}
+	}
+	return ringTokenRanges(replicas), nil
+}
+
+// ringTokenRanges returns the ranges of a token ring, the range wrapping around the ring is split in two.
+func ringTokenRanges(replicas tokenRingReplicas) []TokenRange {
+	ranges := make([]TokenRange, 0, len(replicas)+1)
+	first := replicas[0]
+	ranges = append(ranges, TokenRange{StartToken: math.MinInt64, EndToken: int64(first.token.(int64Token)), Replicas: first.hosts})
+	for i := 1; i < len(replicas); i++ {
+		start, end := int64(replicas[i-1].token.(int64Token)), int64(replicas[i].token.(int64Token))
+		if start == end {
+			continue
+		}
+		ranges = append(ranges, TokenRange{StartToken: start, EndToken: end, Replicas: replicas[i].hosts})
+	}
+	if last := int64(replicas[len(replicas)-1].token.(int64Token)); last != math.MaxInt64 {
+		ranges = append(ranges, TokenRange{StartToken: last, EndToken: math.MaxInt64, Replicas: first.hosts})
+	}
+	if ranges[0].EndToken == math.MinInt64 {
+		ranges = ranges[1:]
+	}
+	return ranges
+}
+
+// tabletTokenRanges returns the ranges of the known tablets of a table, nil if no tablets are known.
+func (s *Session) tabletTokenRanges(keyspace, table string) []TokenRange {
+	list := s.metadataDescriber.getTablets()
+	l, r := list.FindTablets(keyspace, table)
+	if l == -1 {
+		return nil
+	}
+
+	var ranges []TokenRange
+	prev := int64(math.MinInt64)
+	for _, tablet := range list[l : r+1] {
+		if tablet.FirstToken() > prev {
+			ranges = append(ranges, TokenRange{StartToken: prev, EndToken: tablet.FirstToken()})
+		}
+		ranges = append(ranges, TokenRange{
+			StartToken: tablet.FirstToken(),
+			EndToken:   tablet.LastToken(),
+			Replicas:   s.tabletReplicaHosts(tablet.Replicas()),
+		})
+		prev = tablet.LastToken()
+	}
+	if prev != math.MaxInt64 {
+		ranges = append(ranges, TokenRange{StartToken: prev, EndToken: math.MaxInt64})
+	}
+	return ranges
+}
+
+func (s *Session) tabletReplicaHosts(replicas []tablets.ReplicaInfo) []*HostInfo {
+	hosts := make([]*HostInfo, 0, len(replicas))
+	for _, replica := range replicas {
+		if host := s.hostSource.getHost(replica.HostID()); host != nil {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+// TokenRangeScan describes a scan of token ranges, see Session.ScanTokenRanges.
+type TokenRangeScan struct {
+	// Stmt is the statement executed for every range. The start and the end token of the range are bound
+	// to its last two bind markers, e.g. SELECT pk, v FROM ks.t WHERE token(pk) > ? AND token(pk) <= ?.
+	Stmt string
+	// Values are bound to the bind markers before the tokens.
+	Values []interface{}
+	// Ranges are the ranges to scan, usually returned by Session.TokenRanges and split with SplitTokenRanges.
+	Ranges []TokenRange
+	// Concurrency is the maximum number of ranges scanned at once.
+	// Default: 1
+	Concurrency int
+	// Consistency is the consistency of the queries, zero means the consistency of the session.
+	Consistency Consistency
+	// PageSize is the page size of the queries, zero means the page size of the session.
+	PageSize int
+}
+
+// ScanTokenRanges executes scan.Stmt for every range of the scan and calls fn with the iterator of its results,
+// the iterator is closed by ScanTokenRanges once fn returns. The queries are sent to the replicas of the ranges,
+// local ones first, and to the shards owning the end tokens of the ranges. fn is called concurrently if
+// scan.Concurrency is greater than one.
+//
+// ScanTokenRanges stops once all ranges are scanned or on the first error returned by fn or by a query.
+func (s *Session) ScanTokenRanges(ctx context.Context, scan TokenRangeScan, fn func(r TokenRange, iter *Iter) error) error {
+	concurrency := scan.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if concurrency > len(scan.Ranges) {
+		concurrency = len(scan.Ranges)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+		next     = make(chan int)
+	)
+	fail := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				if err := s.scanTokenRange(ctx, scan, i, fn); err != nil {
+					fail(err)
+				}
+			}
+		}()
+	}
+
+loop:
+	for i := range scan.Ranges {
+		select {
+		case next <- i:
+		case <-ctx.Done():
+			break loop
+		}
+	}
+	close(next)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+func (s *Session) scanTokenRange(ctx context.Context, scan TokenRangeScan, i int, fn func(r TokenRange, iter *Iter) error) error {
+	r := scan.Ranges[i]
+	values := make([]interface{}, 0, len(scan.Values)+2)
+	values = append(values, scan.Values...)
+	values = append(values, r.StartToken, r.EndToken)
+
+	qry := s.Query(scan.Stmt, values...).WithContext(ctx).Idempotent(true)
+	if scan.Consistency != 0 {
+		qry.Consistency(scan.Consistency)
+	}
+	if scan.PageSize > 0 {
+		qry.PageSize(scan.PageSize)
+	}
+	qry.policy = &tokenRangeHostPolicy{
+		HostSelectionPolicy: s.policy,
+		replicas:            s.orderReplicas(r.Replicas, i),
+		token:               int64Token(r.EndToken),
+	}
+
+	iter := qry.Iter()
+	fnErr := fn(r, iter)
+	if err := iter.Close(); err != nil {
+		return fmt.Errorf("gocql: failed to scan token range %v: %w", r, err)
+	}
+	return fnErr
+}
+
+// orderReplicas returns the replicas with the local ones first, rotated by i to spread the ranges among the replicas.
+func (s *Session) orderReplicas(replicas []*HostInfo, i int) []*HostInfo {
+	ordered := make([]*HostInfo, 0, len(replicas))
+	var remote []*HostInfo
+	for _, host := range replicas {
+		if s.policy.IsLocal(host) {
+			ordered = append(ordered, host)
+		} else {
+			remote = append(remote, host)
+		}
+	}
+	rotated := make([]*HostInfo, len(ordered), len(replicas))
+	for j := range ordered {
+		rotated[j] = ordered[(i+j)%len(ordered)]
+	}
+	return append(rotated, remote...)
+}
+
+// tokenRangeHostPolicy picks the replicas of a token range, then the hosts picked by the policy of the session.
+type tokenRangeHostPolicy struct {
+	HostSelectionPolicy
+	replicas []*HostInfo
+	token    Token
+}
+
+// TrackLatency passes the latency to the policy of the session.
+func (p *tokenRangeHostPolicy) TrackLatency(host *HostInfo, latency time.Duration, err error) {
+	if tracker, ok := p.HostSelectionPolicy.(LatencyTracker); ok {
+		tracker.TrackLatency(host, latency, err)
+	}
+}
+
+func (p *tokenRangeHostPolicy) Pick(qry ExecutableQuery) NextHost {
+	var (
+		i        int
+		fallback NextHost
+	)
+	return func() SelectedHost {
+		for i < len(p.replicas) {
+			host := p.replicas[i]
+			i++
+			if host.IsUp() {
+				return selectedHost{info: host, token: p.token}
+			}
+		}
+		if fallback == nil {
+			fallback = p.HostSelectionPolicy.Pick(qry)
+		}
+		for {
+			host := fallback()
+			if host == nil || !p.isReplica(host.Info()) {
+				return host
+			}
+		}
+	}
+}
+
+func (p *tokenRangeHostPolicy) isReplica(host *HostInfo) bool {
+	for _, replica := range p.replicas {
+		if replica == host {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,135 @@
+//go:build unit
+// +build unit
+
+package gocql_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+func TestScanTokenRanges(t *testing.T) {
+	c, err := gocqltest.NewCluster(gocqltest.Config{Nodes: []gocqltest.NodeConfig{{}, {}, {}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	c.When(`FROM system_schema\.keyspaces\s+WHERE keyspace_name = \?`).
+		Params(gocqltest.Col("keyspace_name", gocql.TypeText)).
+		Respond(gocqltest.Rows([]gocqltest.Column{
+			gocqltest.Col("durable_writes", gocql.TypeBoolean),
+			{Name: "replication", Type: gocqltest.MapOf(gocqltest.Native(gocql.TypeText), gocqltest.Native(gocql.TypeText))},
+		}, []interface{}{true, map[string]string{"class": "org.apache.cassandra.locator.SimpleStrategy", "replication_factor": "2"}}))
+
+	var (
+		mu      sync.Mutex
+		scanned = make(map[[2]int64]string)
+	)
+	c.When(`SELECT v FROM ks\.t WHERE token\(pk\) > \? AND token\(pk\) <= \?`).
+		Params(gocqltest.Col("start", gocql.TypeBigInt), gocqltest.Col("end", gocql.TypeBigInt)).
+		Handle(func(req *gocqltest.Request) gocqltest.Response {
+			var start, end int64
+			if err := req.Bind(0, &start); err != nil {
+				return gocqltest.Error(gocql.ErrCodeInvalid, err.Error())
+			}
+			if err := req.Bind(1, &end); err != nil {
+				return gocqltest.Error(gocql.ErrCodeInvalid, err.Error())
+			}
+			mu.Lock()
+			scanned[[2]int64{start, end}] = req.Node.Address()
+			mu.Unlock()
+			return gocqltest.Rows([]gocqltest.Column{gocqltest.Col("v", gocql.TypeInt)}, []interface{}{1}, []interface{}{2})
+		})
+
+	cfg := c.ClusterConfig()
+	cfg.Timeout = time.Second
+	session, err := cfg.CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	ranges, err := session.TokenRanges("ks", "t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ranges) < 3 {
+		t.Fatalf("expected the ranges of 3 hosts, got %v", ranges)
+	}
+	if ranges[0].StartToken != math.MinInt64 || ranges[len(ranges)-1].EndToken != math.MaxInt64 {
+		t.Fatalf("ranges don't cover the ring: %v", ranges)
+	}
+	for i, r := range ranges {
+		if len(r.Replicas) != 2 {
+			t.Fatalf("expected 2 replicas of range %v, got %d", r, len(r.Replicas))
+		}
+		if i > 0 && r.StartToken != ranges[i-1].EndToken {
+			t.Fatalf("ranges are not contiguous: %v", ranges)
+		}
+	}
+
+	split := gocql.SplitTokenRanges(ranges, 16)
+	var (
+		rowsMu sync.Mutex
+		rows   int
+	)
+	err = session.ScanTokenRanges(context.Background(), gocql.TokenRangeScan{
+		Stmt:        `SELECT v FROM ks.t WHERE token(pk) > ? AND token(pk) <= ?`,
+		Ranges:      split,
+		Concurrency: 4,
+	}, func(r gocql.TokenRange, iter *gocql.Iter) error {
+		var v int
+		for iter.Scan(&v) {
+			rowsMu.Lock()
+			rows++
+			rowsMu.Unlock()
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows != 2*len(split) {
+		t.Fatalf("expected %d rows, got %d", 2*len(split), rows)
+	}
+
+	mu.Lock()
+	if len(scanned) != len(split) {
+		t.Fatalf("expected %d ranges to be scanned, got %d", len(split), len(scanned))
+	}
+	for _, r := range split {
+		addr, ok := scanned[[2]int64{r.StartToken, r.EndToken}]
+		if !ok {
+			t.Fatalf("range %v was not scanned", r)
+		}
+		var replica bool
+		for _, host := range r.Replicas {
+			if net.JoinHostPort(host.ConnectAddress().String(), strconv.Itoa(host.Port())) == addr {
+				replica = true
+			}
+		}
+		if !replica {
+			t.Fatalf("range %v was scanned on %s, which is not its replica", r, addr)
+		}
+	}
+	mu.Unlock()
+
+	errStop := errors.New("stop")
+	err = session.ScanTokenRanges(context.Background(), gocql.TokenRangeScan{
+		Stmt:   `SELECT v FROM ks.t WHERE token(pk) > ? AND token(pk) <= ?`,
+		Ranges: split,
+	}, func(gocql.TokenRange, *gocql.Iter) error { return errStop })
+	if !errors.Is(err, errStop) {
+		t.Fatalf("expected the error of fn, got %v", err)
+	}
+}
@@ -0,0 +1,106 @@
+//go:build unit
+// +build unit
+
+package gocql
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTokenRangeSplit(t *testing.T) {
+	r := TokenRange{StartToken: -10, EndToken: 10}
+	ranges := r.Split(3)
+	if len(ranges) != 3 {
+		t.Fatalf("expected 3 ranges, got %v", ranges)
+	}
+	if ranges[0].StartToken != -10 || ranges[2].EndToken != 10 {
+		t.Fatalf("ranges don't cover the split range: %v", ranges)
+	}
+	for i := 1; i < len(ranges); i++ {
+		if ranges[i].StartToken != ranges[i-1].EndToken {
+			t.Fatalf("ranges are not contiguous: %v", ranges)
+		}
+	}
+	if ranges[0].width() != 7 || ranges[2].width() != 6 {
+		t.Fatalf("unexpected sizes of the ranges: %v", ranges)
+	}
+
+	if ranges := (TokenRange{StartToken: 0, EndToken: 2}).Split(5); len(ranges) != 2 {
+		t.Fatalf("expected a range per token, got %v", ranges)
+	}
+
+	if ranges := (TokenRange{StartToken: 5, EndToken: 5}).Split(3); len(ranges) != 0 {
+		t.Fatalf("expected no ranges of an empty range, got %v", ranges)
+	}
+
+	full := TokenRange{StartToken: math.MinInt64, EndToken: math.MaxInt64}
+	ranges = full.Split(4)
+	if len(ranges) != 4 || ranges[0].StartToken != math.MinInt64 || ranges[3].EndToken != math.MaxInt64 {
+		t.Fatalf("unexpected split of the whole ring: %v", ranges)
+	}
+}
+
+func TestSplitTokenRanges(t *testing.T) {
+	ranges := []TokenRange{
+		{StartToken: math.MinInt64, EndToken: 0},
+		{StartToken: 0, EndToken: math.MaxInt64 / 2},
+		{StartToken: math.MaxInt64 / 2, EndToken: math.MaxInt64},
+	}
+	split := SplitTokenRanges(ranges, 8)
+	if len(split) != 8 {
+		t.Fatalf("expected 8 ranges, got %d: %v", len(split), split)
+	}
+	if split[0].StartToken != math.MinInt64 || split[len(split)-1].EndToken != math.MaxInt64 {
+		t.Fatalf("ranges don't cover the ring: %v", split)
+	}
+
+	ranges = append([]TokenRange{{StartToken: math.MinInt64, EndToken: math.MinInt64}}, ranges...)
+	if split := SplitTokenRanges(ranges, 8); len(split) != 8 || split[0].StartToken != math.MinInt64 {
+		t.Fatalf("unexpected split of ranges with an empty range: %v", split)
+	}
+}
+
+func TestRingTokenRanges(t *testing.T) {
+	a, b := &HostInfo{hostId: "a"}, &HostInfo{hostId: "b"}
+	ranges := ringTokenRanges(tokenRingReplicas{
+		{token: int64Token(-100), hosts: []*HostInfo{a, b}},
+		{token: int64Token(100), hosts: []*HostInfo{b, a}},
+	})
+	expected := []TokenRange{
+		{StartToken: math.MinInt64, EndToken: -100, Replicas: []*HostInfo{a, b}},
+		{StartToken: -100, EndToken: 100, Replicas: []*HostInfo{b, a}},
+		{StartToken: 100, EndToken: math.MaxInt64, Replicas: []*HostInfo{a, b}},
+	}
+	if len(ranges) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, ranges)
+	}
+	for i, r := range ranges {
+		if r.StartToken != expected[i].StartToken || r.EndToken != expected[i].EndToken || r.Replicas[0] != expected[i].Replicas[0] {
+			t.Fatalf("expected %v, got %v", expected, ranges)
+		}
+	}
+}
+
+func TestTokenRangeHostPolicy(t *testing.T) {
+	a, b, c := &HostInfo{hostId: "a"}, &HostInfo{hostId: "b"}, &HostInfo{hostId: "c"}
+	b.setState(NodeDown)
+	fallback := RoundRobinHostPolicy()
+	for _, host := range []*HostInfo{a, b, c} {
+		fallback.AddHost(host)
+	}
+
+	policy := &tokenRangeHostPolicy{HostSelectionPolicy: fallback, replicas: []*HostInfo{b, a}, token: int64Token(42)}
+	next := policy.Pick(nil)
+	host := next()
+	if host.Info() != a || host.Token() != int64Token(42) {
+		t.Fatalf("expected the replica which is up, got %v", host.Info())
+	}
+	// the hosts of the fallback policy which are not replicas
+	if host := next(); host == nil || host.Info() != c {
+		t.Fatalf("expected the other host, got %v", host)
+	}
+	if host := next(); host != nil {
+		t.Fatalf("expected no more hosts, got %v", host.Info())
+	}
+}
@@ -0,0 +1,348 @@
+package gocql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gocql/gocql/tablets"
+)
+
+// WarmStartConfig enables the warm start snapshot of a session, see ClusterConfig.WarmStart.
+//
+// The snapshot contains the hosts of the cluster with their tokens, the tablets, the names of the
+// keyspaces whose metadata was loaded and the statements prepared on every host. It's written
+// periodically and when the session is closed, and loaded when the next session is created:
+//   - the hosts are used as contact points when none of ClusterConfig.Hosts can be reached,
+//   - the tablets of the hosts which are still part of the cluster are used for routing
+//     until the cluster sends newer ones,
+//   - the keyspace metadata and the prepared statements are loaded in the background,
+//     before the application needs them.
+//
+// Nothing in the snapshot is trusted without being validated against the live cluster: the ring
+// is still discovered from the system tables, a snapshot of another cluster is ignored, the session
+// fails to connect when the hosts of the snapshot are part of another cluster, and the statements
+// are prepared again, only on the hosts which are still part of the cluster.
+type WarmStartConfig struct {
+	// Path is the file the snapshot is written to and loaded from. It's written to a temporary
+	// file first, which is renamed to Path.
+	Path string
+	// Interval is how often the snapshot is written.
+	// Default: 5m
+	Interval time.Duration
+	// MaxAge is the age of the oldest snapshot which is loaded, zero means no limit.
+	// Default: 0
+	MaxAge time.Duration
+	// Concurrency is the number of statements prepared concurrently when the snapshot is loaded.
+	// Default: 8
+	Concurrency int
+}
+
+// Validate checks the configuration.
+func (cfg *WarmStartConfig) Validate() error {
+	if cfg == nil {
+		return nil
+	}
+	if cfg.Path == "" {
+		return errors.New("Path should not be empty")
+	}
+	if cfg.Interval < 0 {
+		return errors.New("Interval should be positive time.Duration or zero")
+	}
+	if cfg.MaxAge < 0 {
+		return errors.New("MaxAge should be positive time.Duration or zero")
+	}
+	if cfg.Concurrency < 0 {
+		return errors.New("Concurrency should be positive number or zero")
+	}
+	return nil
+}
+
+const warmStartFormat = 1
+
+// warmStartSnapshot is the content of the snapshot file.
+type warmStartSnapshot struct {
+	Format      int                 `json:"format"`
+	ClusterName string              `json:"cluster_name"`
+	WrittenAt   time.Time           `json:"written_at"`
+	Hosts       []warmStartHost     `json:"hosts"`
+	Tablets     []warmStartTablet   `json:"tablets,omitempty"`
+	Keyspaces   []string            `json:"keyspaces,omitempty"`
+	Prepared    []warmStartPrepared `json:"prepared,omitempty"`
+}
+
+type warmStartHost struct {
+	HostID     string   `json:"host_id"`
+	Address    string   `json:"address"`
+	Port       int      `json:"port"`
+	DataCenter string   `json:"data_center"`
+	Rack       string   `json:"rack"`
+	Tokens     []string `json:"tokens,omitempty"`
+}
+
+type warmStartTablet struct {
+	Keyspace   string             `json:"keyspace"`
+	Table      string             `json:"table"`
+	FirstToken int64              `json:"first_token"`
+	LastToken  int64              `json:"last_token"`
+	Replicas   []warmStartReplica `json:"replicas"`
+}
+
+type warmStartReplica struct {
+	HostID string `json:"host_id"`
+	Shard  int    `json:"shard"`
+}
+
+type warmStartPrepared struct {
+	HostID    string `json:"host_id"`
+	Keyspace  string `json:"keyspace"`
+	Statement string `json:"statement"`
+	ID        []byte `json:"id"`
+}
+
+// loadWarmStart reads the snapshot, it returns nil if there is no usable snapshot.
+func loadWarmStart(cfg *WarmStartConfig, logger StdLogger) *warmStartSnapshot {
+	data, err := os.ReadFile(cfg.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		logEntry(logger, LogLevelWarn, "gocql: unable to read the warm start snapshot", "path", cfg.Path, "err", err)
+		return nil
+	}
+
+	snapshot := &warmStartSnapshot{}
+	if err := json.Unmarshal(data, snapshot); err != nil {
+		logEntry(logger, LogLevelWarn, "gocql: unable to parse the warm start snapshot", "path", cfg.Path, "err", err)
+		return nil
+	}
+	if snapshot.Format != warmStartFormat {
+		logEntry(logger, LogLevelInfo, "gocql: ignoring the warm start snapshot of another format", "path", cfg.Path, "format", snapshot.Format)
+		return nil
+	}
+	if cfg.MaxAge > 0 && time.Since(snapshot.WrittenAt) > cfg.MaxAge {
+		logEntry(logger, LogLevelInfo, "gocql: ignoring an expired warm start snapshot", "path", cfg.Path, "written_at", snapshot.WrittenAt)
+		return nil
+	}
+	return snapshot
+}
+
+// contactPoints returns the hosts of the snapshot which are not in hosts.
+func (w *warmStartSnapshot) contactPoints(hosts []*HostInfo) []*HostInfo {
+	known := make(map[string]bool, len(hosts))
+	for _, h := range hosts {
+		known[h.ConnectAddressAndPort()] = true
+	}
+	var extra []*HostInfo
+	for _, h := range w.Hosts {
+		ip := net.ParseIP(h.Address)
+		if ip == nil || !validIpAddr(ip) || known[net.JoinHostPort(h.Address, fmt.Sprint(h.Port))] {
+			continue
+		}
+		hb := HostInfoBuilder{
+			// like the initial endpoints, contact points don't need the other fields
+			Hostname:       h.Address,
+			ConnectAddress: ip,
+			Port:           h.Port,
+		}
+		host := hb.Build()
+		extra = append(extra, &host)
+	}
+	return extra
+}
+
+// warmStart adds the tablets of the hosts of the snapshot which are still part of the cluster and starts
+// loading the keyspace metadata and the prepared statements. The cluster name of the snapshot is already
+// validated by Session.init.
+func (s *Session) warmStart(snapshot *warmStartSnapshot) {
+	live := s.hostSource.getHostsMap()
+
+	var tabletList []*tablets.TabletInfo
+	for _, t := range snapshot.Tablets {
+		if tablet := t.build(live); tablet != nil {
+			tabletList = append(tabletList, tablet)
+		}
+	}
+	if len(tabletList) > 0 {
+		s.metadataDescriber.metadata.tabletsMetadata.BulkAddTablets(tabletList)
+	}
+
+	go s.warmUp(snapshot, live)
+}
+
+// build returns the tablet, nil if a replica is not part of the cluster anymore.
+func (t *warmStartTablet) build(live map[string]*HostInfo) *tablets.TabletInfo {
+	b := tablets.NewTabletInfoBuilder()
+	b.KeyspaceName, b.TableName, b.FirstToken, b.LastToken = t.Keyspace, t.Table, t.FirstToken, t.LastToken
+	for _, r := range t.Replicas {
+		id, err := ParseUUID(r.HostID)
+		if err != nil || live[r.HostID] == nil {
+			return nil
+		}
+		b.Replicas = append(b.Replicas, []interface{}{id, r.Shard})
+	}
+	tablet, err := b.Build()
+	if err != nil {
+		return nil
+	}
+	return tablet
+}
+
+// warmUp loads the keyspace metadata and prepares the statements of the snapshot on the hosts which are
+// still part of the cluster, statements whose ID changed are logged.
+func (s *Session) warmUp(snapshot *warmStartSnapshot, live map[string]*HostInfo) {
+	for _, keyspace := range snapshot.Keyspaces {
+		if s.Closed() {
+			return
+		}
+		if _, err := s.metadataDescriber.getSchema(keyspace); err != nil && !errors.Is(err, ErrKeyspaceDoesNotExist) {
+			logEntry(s.logger, LogLevelDebug, "gocql: unable to load the metadata of a warm start keyspace", "keyspace", keyspace, "err", err)
+		}
+	}
+
+	concurrency := s.cfg.WarmStart.Concurrency
+	if concurrency == 0 {
+		concurrency = 8
+	}
+	var (
+		wg      sync.WaitGroup
+		sem     = make(chan struct{}, concurrency)
+		mu      sync.Mutex
+		changed int
+	)
+	for _, p := range snapshot.Prepared {
+		if live[p.HostID] == nil {
+			continue
+		}
+		pool, ok := s.pool.getPoolByHostID(p.HostID)
+		if !ok {
+			continue
+		}
+		conn := pool.Pick(nil, nil)
+		if conn == nil || (conn.currentKeyspace != p.Keyspace && conn.version < protoVersion5) {
+			continue
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			info, err := conn.prepareStatement(s.ctx, p.Statement, p.Keyspace, nil, s.cfg.Timeout)
+			if err != nil {
+				logEntry(s.logger, LogLevelDebug, "gocql: unable to prepare a warm start statement", "host_id", p.HostID, "err", err)
+				return
+			}
+			if !bytes.Equal(info.id, p.ID) {
+				mu.Lock()
+				changed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if changed > 0 {
+		logEntry(s.logger, LogLevelInfo, "gocql: prepared statements of the warm start snapshot changed", "count", changed)
+	}
+}
+
+// snapshot returns the warm start snapshot of the current state of the session.
+func (s *Session) snapshot() *warmStartSnapshot {
+	snapshot := &warmStartSnapshot{Format: warmStartFormat, WrittenAt: time.Now()}
+	for _, h := range s.hostSource.getHostsList() {
+		if snapshot.ClusterName == "" {
+			snapshot.ClusterName = h.ClusterName()
+		}
+		snapshot.Hosts = append(snapshot.Hosts, warmStartHost{
+			HostID:     h.HostID(),
+			Address:    h.ConnectAddress().String(),
+			Port:       h.Port(),
+			DataCenter: h.DataCenter(),
+			Rack:       h.Rack(),
+			Tokens:     h.Tokens(),
+		})
+	}
+	sort.Slice(snapshot.Hosts, func(i, j int) bool { return snapshot.Hosts[i].HostID < snapshot.Hosts[j].HostID })
+
+	for _, t := range s.metadataDescriber.getTablets() {
+		tablet := warmStartTablet{Keyspace: t.KeyspaceName(), Table: t.TableName(), FirstToken: t.FirstToken(), LastToken: t.LastToken()}
+		for _, r := range t.Replicas() {
+			tablet.Replicas = append(tablet.Replicas, warmStartReplica{HostID: r.HostID(), Shard: r.ShardID()})
+		}
+		snapshot.Tablets = append(snapshot.Tablets, tablet)
+	}
+
+	for keyspace, metadata := range s.metadataDescriber.metadata.keyspaceMetadata.get() {
+		if metadata != nil {
+			snapshot.Keyspaces = append(snapshot.Keyspaces, keyspace)
+		}
+	}
+	sort.Strings(snapshot.Keyspaces)
+
+	for _, p := range s.stmtsLRU.prepared() {
+		snapshot.Prepared = append(snapshot.Prepared, warmStartPrepared{
+			HostID:    p.hostID,
+			Keyspace:  p.keyspace,
+			Statement: p.statement,
+			ID:        p.preparedStatment.id,
+		})
+	}
+	return snapshot
+}
+
+// writeWarmStart writes the snapshot of the session to WarmStartConfig.Path.
+func (s *Session) writeWarmStart() error {
+	snapshot := s.snapshot()
+	if len(snapshot.Hosts) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+
+	path := s.cfg.WarmStart.Path
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// writeWarmStartLoop writes the snapshot every WarmStartConfig.Interval until ctx is done.
+func (s *Session) writeWarmStartLoop(ctx context.Context) {
+	interval := s.cfg.WarmStart.Interval
+	if interval == 0 {
+		interval = 5 * time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := s.writeWarmStart(); err != nil {
+			logEntry(s.logger, LogLevelWarn, "gocql: unable to write the warm start snapshot", "path", s.cfg.WarmStart.Path, "err", err)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+//go:build unit
+// +build unit
+
+package gocql_test
+
+import (
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/gocql/gocql/gocqltest"
+)
+
+func TestWarmStart(t *testing.T) {
+	c, err := gocqltest.NewCluster(gocqltest.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	cfg := c.ClusterConfig()
+	cfg.Timeout = time.Second
+	cfg.WarmStart = &gocql.WarmStartConfig{Path: path}
+
+	const stmt = `INSERT INTO ks.t (id) VALUES (?)`
+	session, err := cfg.CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := session.Query(stmt, "a").Exec(); err != nil {
+		t.Fatal(err)
+	}
+	session.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var snapshot struct {
+		Hosts []struct {
+			HostID string `json:"host_id"`
+		} `json:"hosts"`
+		Prepared []struct{ Statement string } `json:"prepared"`
+	}
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		t.Fatal(err)
+	}
+	node := c.Nodes()[0]
+	if len(snapshot.Hosts) != 1 || snapshot.Hosts[0].HostID != node.HostID() {
+		t.Fatalf("unexpected hosts %+v", snapshot.Hosts)
+	}
+	if !slices.ContainsFunc(snapshot.Prepared, func(p struct{ Statement string }) bool { return p.Statement == stmt }) {
+		t.Fatalf("the statement is not in the prepared statements %+v", snapshot.Prepared)
+	}
+
+	// the node forgets the prepared statements
+	node.Stop()
+	if err := node.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the configured contact point is down, the session connects to the host of the snapshot
+	// and prepares the statement before it's executed
+	cfg.Hosts = []string{"127.0.0.1:1"}
+	session, err = cfg.CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !slices.Contains(node.Prepared(), stmt) {
+		if time.Now().After(deadline) {
+			t.Fatalf("the statement was not prepared, prepared: %q", node.Prepared())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// writeSnapshot writes a warm start snapshot of the cluster of the node.
+func writeSnapshot(t *testing.T, path, clusterName string, node *gocqltest.Node) {
+	t.Helper()
+	host, port, err := net.SplitHostPort(node.Address())
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot := map[string]interface{}{
+		"format":       1,
+		"cluster_name": clusterName,
+		"written_at":   time.Now(),
+		"hosts": []map[string]interface{}{
+			{"host_id": node.HostID(), "address": host, "port": json.Number(port)},
+		},
+	}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWarmStartPrefersConfiguredHosts(t *testing.T) {
+	configured, err := gocqltest.NewCluster(gocqltest.Config{ClusterName: "configured"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer configured.Close()
+	other, err := gocqltest.NewCluster(gocqltest.Config{ClusterName: "other"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	writeSnapshot(t, path, "other", other.Nodes()[0])
+
+	cfg := configured.ClusterConfig()
+	cfg.Timeout = time.Second
+	cfg.WarmStart = &gocql.WarmStartConfig{Path: path, Interval: time.Hour}
+	for i := 0; i < 5; i++ {
+		session, err := cfg.CreateSession()
+		if err != nil {
+			t.Fatal(err)
+		}
+		session.Close()
+		// closing the session overwrites the snapshot
+		writeSnapshot(t, path, "other", other.Nodes()[0])
+	}
+	if n := len(other.Requests()); n != 0 {
+		t.Fatalf("the host of the snapshot received %d requests, expected none", n)
+	}
+}
+
+func TestWarmStartRejectsSnapshotOfAnotherCluster(t *testing.T) {
+	c, err := gocqltest.NewCluster(gocqltest.Config{ClusterName: "current"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	writeSnapshot(t, path, "stale", c.Nodes()[0])
+
+	// the configured contact point is down, the host of the snapshot is part of another cluster now
+	cfg := c.ClusterConfig()
+	cfg.Hosts = []string{"127.0.0.1:1"}
+	cfg.Timeout = time.Second
+	cfg.WarmStart = &gocql.WarmStartConfig{Path: path}
+	session, err := cfg.CreateSession()
+	if err == nil {
+		session.Close()
+		t.Fatal("expected an error connecting to the host of a snapshot of another cluster")
+	}
+}
+
+func TestWarmStartConfigValidate(t *testing.T) {
+	for _, cfg := range []gocql.WarmStartConfig{
+		{},
+		{Path: "p", Interval: -1},
+		{Path: "p", MaxAge: -1},
+		{Path: "p", Concurrency: -1},
+	} {
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("expected an error for %+v", cfg)
+		}
+	}
+	if err := (&gocql.WarmStartConfig{Path: "p"}).Validate(); err != nil {
+		t.Fatal(err)
+	}
+}